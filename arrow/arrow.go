@@ -0,0 +1,171 @@
+// Package arrow converts between Apache Arrow float16 (HalfFloat) arrays and
+// Float16 slices, and computes Parquet FLOAT16 column statistics.
+//
+// An Arrow float16 array is a value buffer of little-endian uint16s and an
+// optional validity bitmap, with one bit per element in least-significant
+// bit order, where a 0 bit marks a null. Both buffers are indexed from the
+// array's offset. This package works on the buffers themselves, so it
+// doesn't depend on an Arrow implementation: with the Go Arrow module, the
+// buffers of a float16 array are arr.Data().Buffers()[0] and [1], and its
+// offset and length are arr.Data().Offset() and arr.Len().
+//
+// Parquet stores FLOAT16 as a 2-byte little-endian FIXED_LEN_BYTE_ARRAY,
+// which is the same encoding as the Arrow value buffer.
+package arrow
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	floatx "github.com/chenxingqiang/go-floatx"
+)
+
+type arrowError string
+
+func (e arrowError) Error() string { return string(e) }
+
+const (
+	// ErrShortBuffer is returned when a buffer is too short for the offset
+	// and length of an array.
+	ErrShortBuffer = arrowError("arrow: buffer too short")
+
+	// ErrLength is returned when the validity and values have different
+	// lengths.
+	ErrLength = arrowError("arrow: validity and values have different lengths")
+)
+
+// Array is an Arrow float16 array.
+type Array struct {
+	validity []byte // nil if there are no nulls
+	values   []byte
+	offset   int
+	length   int
+	nulls    int
+}
+
+// FromBuffers returns the array of length elements starting at offset of
+// the validity bitmap and value buffer. The validity bitmap may be nil if
+// the array has no nulls. The buffers aren't copied.
+func FromBuffers(validity, values []byte, offset, length int) (*Array, error) {
+	if offset < 0 || length < 0 || len(values)/2-offset < length {
+		return nil, fmt.Errorf("%w: %d values for offset %d and length %d", ErrShortBuffer, len(values)/2, offset, length)
+	}
+	if validity != nil && len(validity)*8-offset < length {
+		return nil, fmt.Errorf("%w: %d validity bits for offset %d and length %d", ErrShortBuffer, len(validity)*8, offset, length)
+	}
+	a := &Array{validity: validity, values: values, offset: offset, length: length}
+	if validity != nil {
+		for i := 0; i < length; i++ {
+			if a.IsNull(i) {
+				a.nulls++
+			}
+		}
+	}
+	return a, nil
+}
+
+// NewArray returns an array holding a copy of values. If valid isn't nil,
+// it must have the length of values, and element i is null when valid[i]
+// is false. Null elements are zero in the value buffer, and the validity
+// bitmap is left out when there are no nulls.
+func NewArray(values []floatx.Float16, valid []bool) (*Array, error) {
+	if valid != nil && len(valid) != len(values) {
+		return nil, ErrLength
+	}
+	a := &Array{values: make([]byte, 2*len(values)), length: len(values)}
+	for i, v := range values {
+		if valid != nil && !valid[i] {
+			a.nulls++
+			continue
+		}
+		binary.LittleEndian.PutUint16(a.values[2*i:], uint16(v))
+	}
+	if a.nulls > 0 {
+		a.validity = make([]byte, (len(values)+7)/8)
+		for i, ok := range valid {
+			if ok {
+				a.validity[i/8] |= 1 << (i % 8)
+			}
+		}
+	}
+	return a, nil
+}
+
+// Buffers returns the validity bitmap, which is nil if the array has no
+// nulls, and the value buffer of a. They are indexed from a.Offset().
+func (a *Array) Buffers() (validity, values []byte) {
+	return a.validity, a.values
+}
+
+// Offset returns the offset of a in its buffers.
+func (a *Array) Offset() int {
+	return a.offset
+}
+
+// Len returns the number of elements of a, including nulls.
+func (a *Array) Len() int {
+	return a.length
+}
+
+// NullCount returns the number of null elements of a.
+func (a *Array) NullCount() int {
+	return a.nulls
+}
+
+// IsNull reports whether element i of a is null.
+func (a *Array) IsNull(i int) bool {
+	if a.validity == nil {
+		return false
+	}
+	j := a.offset + i
+	return a.validity[j/8]&(1<<(j%8)) == 0
+}
+
+// Value returns element i of a. The value of a null element is whatever
+// its buffer holds, which Arrow leaves undefined.
+func (a *Array) Value(i int) floatx.Float16 {
+	return floatx.Float16(binary.LittleEndian.Uint16(a.values[2*(a.offset+i):]))
+}
+
+// Float16s copies the elements of a to dst, with null elements set to
+// null, and returns the number of elements copied, which is the minimum of
+// len(dst) and a.Len().
+func (a *Array) Float16s(dst []floatx.Float16, null floatx.Float16) int {
+	n := a.length
+	if len(dst) < n {
+		n = len(dst)
+	}
+	for i := range dst[:n] {
+		if a.IsNull(i) {
+			dst[i] = null
+		} else {
+			dst[i] = a.Value(i)
+		}
+	}
+	return n
+}
+
+// Float32s returns the elements of a converted to float32, with null
+// elements set to null.
+func (a *Array) Float32s(null float32) []float32 {
+	dst := make([]float32, a.length)
+	for i := range dst {
+		if a.IsNull(i) {
+			dst[i] = null
+		} else {
+			dst[i] = a.Value(i).Float32()
+		}
+	}
+	return dst
+}
+
+// Statistics returns the Parquet statistics of the elements of a.
+func (a *Array) Statistics() *Statistics {
+	s := &Statistics{NullCount: int64(a.nulls)}
+	for i := 0; i < a.length; i++ {
+		if !a.IsNull(i) {
+			s.Update(a.Value(i))
+		}
+	}
+	return s
+}
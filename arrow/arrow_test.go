@@ -0,0 +1,123 @@
+package arrow_test
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	floatx "github.com/chenxingqiang/go-floatx"
+	"github.com/chenxingqiang/go-floatx/arrow"
+)
+
+func TestFromBuffers(t *testing.T) {
+	// the array [null, 1, -2, null, 65504] at offset 3 of its buffers, like
+	// a slice of a longer array
+	validity := []byte{0b1011_0111, 0b0000_0001}
+	values := []byte{
+		0, 0, 0, 0, 0, 0, // before the offset
+		0xff, 0xff, 0x00, 0x3c, 0x00, 0xc0, 0x00, 0x00, 0xff, 0x7b,
+	}
+	a, err := arrow.FromBuffers(validity, values, 3, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Len() != 5 || a.NullCount() != 2 || a.Offset() != 3 {
+		t.Errorf("Len() %d, NullCount() %d, Offset() %d", a.Len(), a.NullCount(), a.Offset())
+	}
+	nulls := []bool{true, false, false, true, false}
+	for i, want := range nulls {
+		if a.IsNull(i) != want {
+			t.Errorf("IsNull(%d) = %v", i, !want)
+		}
+	}
+	if v := a.Value(4); v != 0x7bff {
+		t.Errorf("Value(4) = %#04x", uint16(v))
+	}
+
+	nan := floatx.F16NaN()
+	dst := make([]floatx.Float16, 6)
+	if n := a.Float16s(dst, nan); n != 5 || !reflect.DeepEqual(dst[:5], []floatx.Float16{nan, 0x3c00, 0xc000, nan, 0x7bff}) {
+		t.Errorf("Float16s = %d, %v", n, dst)
+	}
+	if n := a.Float16s(dst[:2], 0); n != 2 || dst[0] != 0 {
+		t.Errorf("Float16s into 2 = %d, %v", n, dst[:2])
+	}
+	got := a.Float32s(-1)
+	if want := []float32{-1, 1, -2, -1, 65504}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Float32s = %v, wanted %v", got, want)
+	}
+
+	v, b := a.Buffers()
+	if &v[0] != &validity[0] || &b[0] != &values[0] {
+		t.Error("Buffers() copied the buffers")
+	}
+
+	// no validity bitmap
+	a, err = arrow.FromBuffers(nil, values, 0, 8)
+	if err != nil || a.NullCount() != 0 || a.IsNull(7) {
+		t.Errorf("FromBuffers without validity = %v, %v", a, err)
+	}
+}
+
+func TestFromBuffersErrors(t *testing.T) {
+	testCases := []struct {
+		validity, values []byte
+		offset, length   int
+	}{
+		{nil, make([]byte, 7), 0, 4},
+		{nil, make([]byte, 8), 1, 4},
+		{nil, make([]byte, 8), -1, 1},
+		{nil, make([]byte, 8), 0, -1},
+		{make([]byte, 1), make([]byte, 20), 2, 7},
+	}
+	for _, tc := range testCases {
+		if _, err := arrow.FromBuffers(tc.validity, tc.values, tc.offset, tc.length); !errors.Is(err, arrow.ErrShortBuffer) {
+			t.Errorf("FromBuffers(%d, %d, %d, %d) returned %v, wanted ErrShortBuffer",
+				len(tc.validity), len(tc.values), tc.offset, tc.length, err)
+		}
+	}
+}
+
+func TestNewArray(t *testing.T) {
+	values := make([]floatx.Float16, 10)
+	floatx.FromFloat32s(values, []float32{0, 1, 2, 3, 4, 5, 6, 7, 8, 9})
+	valid := []bool{true, true, false, true, true, true, true, true, true, false}
+
+	a, err := arrow.NewArray(values, valid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	validity, buf := a.Buffers()
+	if !reflect.DeepEqual(validity, []byte{0b1111_1011, 0b0000_0001}) || len(buf) != 20 {
+		t.Errorf("Buffers() = %08b, %d bytes", validity, len(buf))
+	}
+	if a.NullCount() != 2 || buf[4] != 0 || buf[5] != 0 {
+		t.Errorf("NullCount() %d, null value %v", a.NullCount(), buf[4:6])
+	}
+	got := a.Float32s(float32(math.NaN()))
+	for i, v := range got {
+		if valid[i] != (v == float32(i)) {
+			t.Errorf("Float32s()[%d] = %v", i, v)
+		}
+	}
+
+	// a round trip through the buffers
+	b, err := arrow.FromBuffers(validity, buf, 0, 10)
+	if err != nil || b.NullCount() != 2 || b.Value(9) != 0 || b.Value(8) != values[8] {
+		t.Errorf("FromBuffers(Buffers()) = %v, %v", b, err)
+	}
+
+	// the validity bitmap is left out without nulls
+	a, err = arrow.NewArray(values, nil)
+	if validity, _ := a.Buffers(); err != nil || validity != nil || a.NullCount() != 0 {
+		t.Errorf("NewArray without nulls = %v, %v", validity, err)
+	}
+	a, _ = arrow.NewArray(values, make([]bool, 10))
+	if a.NullCount() != 10 {
+		t.Errorf("NewArray with all nulls has NullCount() %d", a.NullCount())
+	}
+	if _, err := arrow.NewArray(values, valid[:3]); err != arrow.ErrLength {
+		t.Errorf("NewArray with short valid returned %v, wanted ErrLength", err)
+	}
+}
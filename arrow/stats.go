@@ -0,0 +1,114 @@
+package arrow
+
+import (
+	"encoding/binary"
+
+	floatx "github.com/chenxingqiang/go-floatx"
+)
+
+// Compare returns -1, 0 or +1 as a is before, the same as or after b in the
+// IEEE 754 totalOrder: -NaN < -Inf < finite values < +Inf < +NaN, with
+// -0 < +0 and NaNs ordered by payload. It is 0 only when a and b have the
+// same bits.
+func Compare(a, b floatx.Float16) int {
+	ka, kb := totalOrderKey(a), totalOrderKey(b)
+	switch {
+	case ka < kb:
+		return -1
+	case ka > kb:
+		return 1
+	}
+	return 0
+}
+
+// totalOrderKey returns an int16 that orders like f in the totalOrder. The
+// bits of a negative f are in reverse order, so they're flipped.
+func totalOrderKey(f floatx.Float16) int16 {
+	k := int16(f)
+	return k ^ int16(uint16(k>>15)>>1)
+}
+
+// Statistics are the statistics of a Parquet FLOAT16 column chunk or page
+// with the IEEE 754 total order column order. Min and max are ordered by
+// Compare, so -0 is less than +0. NaNs are counted in NaNCount and left out
+// of the min and max, unless all non-null values are NaN.
+//
+// The zero value is the statistics of no values.
+type Statistics struct {
+	NullCount int64
+	NaNCount  int64
+
+	count    int64 // non-null values that aren't NaN
+	min, max floatx.Float16
+	nanMin   floatx.Float16
+	nanMax   floatx.Float16
+}
+
+// Update adds the non-null value v to s.
+func (s *Statistics) Update(v floatx.Float16) {
+	if v.IsNaN() {
+		if s.NaNCount == 0 || Compare(v, s.nanMin) < 0 {
+			s.nanMin = v
+		}
+		if s.NaNCount == 0 || Compare(v, s.nanMax) > 0 {
+			s.nanMax = v
+		}
+		s.NaNCount++
+		return
+	}
+	if s.count == 0 || Compare(v, s.min) < 0 {
+		s.min = v
+	}
+	if s.count == 0 || Compare(v, s.max) > 0 {
+		s.max = v
+	}
+	s.count++
+}
+
+// Merge adds the values of t to s, such as the statistics of a page to
+// those of its column chunk.
+func (s *Statistics) Merge(t *Statistics) {
+	if t.count > 0 {
+		s.Update(t.min)
+		s.Update(t.max)
+		s.count += t.count - 2
+	}
+	if t.NaNCount > 0 {
+		s.Update(t.nanMin)
+		s.Update(t.nanMax)
+		s.NaNCount += t.NaNCount - 2
+	}
+	s.NullCount += t.NullCount
+}
+
+// MinMax returns the min and max of s, and false if s has only nulls or no
+// values, in which case Parquet omits them.
+func (s *Statistics) MinMax() (min, max floatx.Float16, ok bool) {
+	if s.count > 0 {
+		return s.min, s.max, true
+	}
+	if s.NaNCount > 0 {
+		return s.nanMin, s.nanMax, true
+	}
+	return 0, 0, false
+}
+
+// EncodeMin returns the min of s encoded for the min_value field of Parquet
+// statistics, or nil if s has no min.
+func (s *Statistics) EncodeMin() []byte {
+	min, _, ok := s.MinMax()
+	if !ok {
+		return nil
+	}
+	return binary.LittleEndian.AppendUint16(nil, uint16(min))
+}
+
+// EncodeMax returns the max of s encoded for the max_value field of Parquet
+// statistics, or nil if s has no max.
+func (s *Statistics) EncodeMax() []byte {
+	_, max, ok := s.MinMax()
+	if !ok {
+		return nil
+	}
+	return binary.LittleEndian.AppendUint16(nil, uint16(max))
+}
@@ -0,0 +1,110 @@
+package arrow_test
+
+import (
+	"reflect"
+	"testing"
+
+	floatx "github.com/chenxingqiang/go-floatx"
+	"github.com/chenxingqiang/go-floatx/arrow"
+)
+
+func TestCompare(t *testing.T) {
+	// every pair of values in totalOrder
+	order := []floatx.Float16{
+		0xffff, 0xfe00, 0xfdff, 0xfc01, // -NaNs
+		0xfc00, 0xfbff, 0xbc00, 0x8001, 0x8000, // -Inf to -0
+		0x0000, 0x0001, 0x3c00, 0x7bff, 0x7c00, // +0 to +Inf
+		0x7c01, 0x7dff, 0x7e00, 0x7fff, // +NaNs
+	}
+	for i, a := range order {
+		for j, b := range order {
+			want := 0
+			if i < j {
+				want = -1
+			} else if i > j {
+				want = 1
+			}
+			if got := arrow.Compare(a, b); got != want {
+				t.Errorf("Compare(%#04x, %#04x) = %d, wanted %d", uint16(a), uint16(b), got, want)
+			}
+		}
+	}
+}
+
+func f16s(fs ...float32) []floatx.Float16 {
+	s := make([]floatx.Float16, len(fs))
+	floatx.FromFloat32s(s, fs)
+	return s
+}
+
+func TestStatistics(t *testing.T) {
+	nan, negNaN := floatx.Float16(0x7e01), floatx.Float16(0xfe00)
+	testCases := []struct {
+		name            string
+		values          []floatx.Float16
+		valid           []bool
+		min, max        floatx.Float16
+		ok              bool
+		nulls, nanCount int64
+	}{
+		{"empty", nil, nil, 0, 0, false, 0, 0},
+		{"nulls", f16s(1, 2), []bool{false, false}, 0, 0, false, 2, 0},
+		{"values", f16s(3, -1, 2, 65504), nil, 0xbc00, 0x7bff, true, 0, 0},
+		{"nulls and values", f16s(3, -100, 2), []bool{true, false, true}, 0x4000, 0x4200, true, 1, 0},
+		{"zeros", []floatx.Float16{0x0000, 0x8000, 0x0000}, nil, 0x8000, 0x0000, true, 0, 0},
+		{"positive zero", []floatx.Float16{0x0000}, nil, 0x0000, 0x0000, true, 0, 0},
+		{"infinities", []floatx.Float16{0x3c00, 0x7c00, 0xfc00}, nil, 0xfc00, 0x7c00, true, 0, 0},
+		{"NaNs left out", []floatx.Float16{nan, 0x3c00, negNaN, 0x4000}, nil, 0x3c00, 0x4000, true, 0, 2},
+		{"only NaNs", []floatx.Float16{nan, negNaN, 0x7e00}, []bool{true, true, true}, negNaN, nan, true, 0, 3},
+	}
+	for _, tc := range testCases {
+		a, err := arrow.NewArray(tc.values, tc.valid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := a.Statistics()
+		min, max, ok := s.MinMax()
+		if min != tc.min || max != tc.max || ok != tc.ok || s.NullCount != tc.nulls || s.NaNCount != tc.nanCount {
+			t.Errorf("%s: MinMax() = %#04x, %#04x, %v, NullCount %d, NaNCount %d",
+				tc.name, uint16(min), uint16(max), ok, s.NullCount, s.NaNCount)
+		}
+		wantMin, wantMax := []byte{byte(tc.min), byte(tc.min >> 8)}, []byte{byte(tc.max), byte(tc.max >> 8)}
+		if !tc.ok {
+			wantMin, wantMax = nil, nil
+		}
+		if !reflect.DeepEqual(s.EncodeMin(), wantMin) || !reflect.DeepEqual(s.EncodeMax(), wantMax) {
+			t.Errorf("%s: EncodeMin() = %v, EncodeMax() = %v", tc.name, s.EncodeMin(), s.EncodeMax())
+		}
+	}
+}
+
+func TestStatisticsMerge(t *testing.T) {
+	pages := [][]floatx.Float16{
+		{0x7e00, 0x7e00},
+		f16s(5, 1, 3),
+		{},
+		{0xfe00},
+		f16s(-2),
+		f16s(7, 6),
+	}
+	var chunk, all arrow.Statistics
+	for i, page := range pages {
+		a, _ := arrow.NewArray(page, nil)
+		s := a.Statistics()
+		if i == 2 {
+			s.NullCount = 3
+			all.NullCount = 3
+		}
+		chunk.Merge(s)
+		for _, v := range page {
+			all.Update(v)
+		}
+		if !reflect.DeepEqual(chunk, all) {
+			t.Fatalf("after page %d, Merge() = %+v, wanted %+v", i, chunk, all)
+		}
+	}
+	min, max, _ := chunk.MinMax()
+	if min != 0xc000 || max != 0x4700 || chunk.NaNCount != 3 || chunk.NullCount != 3 {
+		t.Errorf("chunk = %+v", chunk)
+	}
+}
@@ -5,93 +5,111 @@ import (
 	"strconv"
 )
 
+// BFloat16 represents bfloat16 (brain floating-point) numbers: 1 sign bit,
+// 8 exponent bits (bias 127) and 7 significand bits. BFloat16 has the same
+// exponent range as IEEE 754 binary32 and is bit-for-bit its upper half.
 type BFloat16 uint16
 
+// Limits and properties of BFloat16 values.
+//
+// MaxExp and MinExp are the largest and smallest exponents returned by Frexp
+// for finite normal values, like C's FLT_MAX_EXP and FLT_MIN_EXP.
+const (
+	BF16Max               = 0x1.fep127 // 3.3895313892515355e+38, bits 0x7f7f
+	BF16SmallestNormal    = 0x1p-126   // 1.1754943508222875e-38, bits 0x0080
+	BF16SmallestSubnormal = 0x1p-133   // 9.183549615799121e-41, bits 0x0001
+	BF16Epsilon           = 0x1p-7     // difference between 1 and the next value
+
+	BF16MaxExactInt = 1 << 8 // every integer up to this magnitude is exact
+	BF16Digits      = 8      // significand bits, including the implicit bit
+	BF16PayloadBits = 6      // NaN payload bits, below the quiet bit
+	BF16MaxExp      = 128
+	BF16MinExp      = -125
+)
+
 type BF16Precision int
 
 const (
 
-	// PrecisionExact is for non-subnormals that don't drop bits during conversion.
-	// All of these can round-trip.  Should always convert to float16.
+	// PrecisionExact is for values that don't drop bits during conversion.
+	// All of these can round-trip.  Should always convert to bfloat16.
 	BF16PrecisionExact BF16Precision = iota
 
-	// PrecisionUnknown is for subnormals that don't drop bits during conversion but
-	// not all of these can round-trip so precision is unknown without more effort.
-	// Only 2046 of these can round-trip and the rest cannot round-trip.
+	// PrecisionUnknown is never returned by BF16PrecisionFromfloat32.
+	// bfloat16 subnormals are float32 subnormals with the low 16 bits
+	// dropped, so every subnormal that keeps all of its bits round-trips
+	// and is reported as PrecisionExact. It exists for symmetry with
+	// F16PrecisionUnknown.
 	BF16PrecisionUnknown
 
 	// PrecisionInexact is for dropped significand bits and cannot round-trip.
-	// Some of these are subnormals. Cannot round-trip float32->float16->float32.
+	// Some of these are subnormals. Cannot round-trip float32->bfloat16->float32.
 	BF16PrecisionInexact
 
-	// PrecisionUnderflow is for Underflows. Cannot round-trip float32->float16->float32.
+	// PrecisionUnderflow is for Underflows. Cannot round-trip float32->bfloat16->float32.
 	BF16PrecisionUnderflow
 
-	// PrecisionOverflow is for Overflows. Cannot round-trip float32->float16->float32.
+	// PrecisionOverflow is for Overflows. Cannot round-trip float32->bfloat16->float32.
 	BF16PrecisionOverflow
 )
 
-// BFloat16 represents IEEE 754 half-precision floating-point numbers (binary16).
-
 // Precision indicates whether the conversion to BFloat16 is
-// exact, subnormal without dropped bits, inexact, underflow, or overflow.
+// exact, inexact, underflow, or overflow.
 
 // PrecisionFromfloat32 returns Precision without performing
 // the conversion.  Conversions from both Infinity and NaN
 // values will always report PrecisionExact even if NaN payload
 // or NaN-Quiet-Bit is lost. This function is kept simple to
 // allow inlining and run < 0.5 ns/op, to serve as a fast filter.
-
 func BF16PrecisionFromfloat32(f32 float32) BF16Precision {
-	u32 := math.Float32bits(f32)
+	const ABSMASK uint32 = 0x7fffffff
+	const DROPMASK uint32 = 0x0000ffff // 16 least significant bits
+	const MINSUB uint32 = 0x00010000   // 2^-133, smallest positive subnormal
+	const OVERFLOW uint32 = 0x7f7f8000 // halfway between max finite and infinity
+	const INF uint32 = 0x7f800000
 
-	if u32 == 0 || u32 == 0x80000000 {
-		// +- zero will always be exact conversion
-		return BF16PrecisionExact
-	}
+	u32 := math.Float32bits(f32) & ABSMASK
 
-	const COEFMASK uint32 = 0x7fffff // 23 least significant bits
-	const EXPSHIFT uint32 = 23
-	const EXPBIAS uint32 = 127
-	const EXPMASK uint32 = uint32(0xff) << EXPSHIFT
-	const DROPMASK uint32 = COEFMASK >> 10
-
-	exp := int32(((u32 & EXPMASK) >> EXPSHIFT) - EXPBIAS)
-	coef := u32 & COEFMASK
-
-	if exp == 128 {
-		// +- infinity or NaN
+	if u32 == 0 || u32 >= INF {
+		// +- zero, infinity or NaN
 		// apps may want to do extra checks for NaN separately
 		return BF16PrecisionExact
 	}
-
-	// https://en.wikipedia.org/wiki/Half-precision_floating-point_format says,
-	// "Decimals between 2^−24 (minimum positive subnormal) and 2^−14 (maximum subnormal): fixed interval 2^−24"
-	if exp < -24 {
+	if u32 < MINSUB {
 		return BF16PrecisionUnderflow
 	}
-	if exp > 15 {
+	if u32 >= OVERFLOW {
+		// rounds up to infinity
 		return BF16PrecisionOverflow
 	}
-	if (coef & DROPMASK) != uint32(0) {
+	if (u32 & DROPMASK) != uint32(0) {
 		// these include subnormals and non-subnormals that dropped bits
 		return BF16PrecisionInexact
 	}
 
-	if exp < -14 {
-		// Subnormals. Caller may want to test these further.
-		// There are 2046 subnormals that can successfully round-trip f32->f16->f32
-		// and 20 of those 2046 have 32-bit input coef == 0.
-		// RFC 7049 and 7049bis Draft 12 don't precisely define "preserves value"
-		// so some protocols and libraries will choose to handle subnormals differently
-		// when deciding to encode them to CBOR float32 vs float16.
-		return BF16PrecisionUnknown
-	}
-
 	return BF16PrecisionExact
 }
 
-// Frombits returns the float16 number corresponding to the IEEE 754 binary16
+// PrecisionExactFromfloat32 returns the Precision of the actual conversion
+// of f32 to BFloat16, which it performs, see F16PrecisionExactFromfloat32.
+// PrecisionFromfloat32 already reports overflow by the rounded result, but
+// reports PrecisionUnderflow for all of (0, 2^-133) while values in
+// (2^-134, 2^-133) round to the smallest subnormal and are
+// PrecisionInexact here.
+func BF16PrecisionExactFromfloat32(f32 float32) BF16Precision {
+	bf16 := BF16Fromfloat32(f32)
+	switch back := bf16.Float32(); {
+	case f32 != f32 || back == f32:
+		return BF16PrecisionExact
+	case bf16.IsInf(0):
+		return BF16PrecisionOverflow
+	case back == 0:
+		return BF16PrecisionUnderflow
+	}
+	return BF16PrecisionInexact
+}
+
+// Frombits returns the bfloat16 number corresponding to the bfloat16
 // representation u16, with the sign bit of u16 and the result in the same bit
 // position. Frombits(Bits(x)) == x.
 func BF16Frombits(u16 uint16) BFloat16 {
@@ -111,15 +129,15 @@ type BFloat16Error string
 
 func (e BFloat16Error) Error() string { return string(e) }
 
-// FromNaN32ps converts nan to IEEE binary16 NaN while preserving both
+// FromNaN32ps converts nan to bfloat16 NaN while preserving both
 // signaling and payload. Unlike Fromfloat32(), which can only return
 // qNaN because it sets quiet bit = 1, this can return both sNaN and qNaN.
 // If the result is infinity (sNaN with empty payload), then the
 // lowest bit of payload is set to make the result a NaN.
-// Returns BF16BF16ErrInvalidNaNValue and 0x7c01 (sNaN) if nan isn't IEEE 754 NaN.
+// Returns BF16ErrInvalidNaNValue and 0x7f81 (sNaN) if nan isn't IEEE 754 NaN.
 // This function was kept simple to be able to inline.
 func BF16FromNaN32ps(nan float32) (BFloat16, error) {
-	const SNAN = BFloat16(uint16(0x7c01)) // signaling NaN
+	const SNAN = BFloat16(uint16(0x7f81)) // signaling NaN
 
 	u32 := math.Float32bits(nan)
 	sign := u32 & 0x80000000
@@ -130,9 +148,9 @@ func BF16FromNaN32ps(nan float32) (BFloat16, error) {
 		return SNAN, BF16ErrInvalidNaNValue
 	}
 
-	u16 := uint16((sign >> 16) | uint32(0x7c00) | (coef >> 13))
+	u16 := uint16((sign >> 16) | uint32(0x7f80) | (coef >> 16))
 
-	if (u16 & 0x03ff) == 0 {
+	if (u16 & 0x007f) == 0 {
 		// result became infinity, make it NaN by setting lowest bit in payload
 		u16 |= 0x0001
 	}
@@ -140,12 +158,35 @@ func BF16FromNaN32ps(nan float32) (BFloat16, error) {
 	return BFloat16(u16), nil
 }
 
-// NaN returns a BFloat16 of IEEE 754 binary16 not-a-number (NaN).
-// Returned NaN value 0x7e01 has all exponent bits = 1 with the
+// NaN returns a BFloat16 of bfloat16 not-a-number (NaN).
+// Returned NaN value 0x7fc1 has all exponent bits = 1 with the
 // first and last bits = 1 in the significand. This is consistent
-// with Go's 64-bit math.NaN(). Canonical CBOR in RFC 7049 uses 0x7e00.
+// with Go's 64-bit math.NaN().
 func BF16NaN() BFloat16 {
-	return BFloat16(0x7e01)
+	return BFloat16(0x7fc1)
+}
+
+// BF16NaNWithPayload returns a positive BFloat16 NaN with the low BF16PayloadBits
+// bits of payload, quiet or signaling. A signaling NaN with a zero payload
+// would be infinity, so it gets payload 1 like BF16FromNaN32ps.
+//
+// bfloat16 is the top half of float32, so Float32 and Fromfloat32 move the
+// payload unchanged and round-trips through float32 and float64 only set
+// the quiet bit.
+func BF16NaNWithPayload(payload uint, quiet bool) BFloat16 {
+	u := uint16(0x7f80) | uint16(payload)&0x003f
+	if quiet {
+		u |= 0x0040
+	} else if u&0x003f == 0 {
+		u |= 0x0001
+	}
+	return BFloat16(u)
+}
+
+// BF16CanonicalNaN returns the canonical BFloat16 NaN 0x7fc0, the positive quiet
+// NaN with a zero payload.
+func BF16CanonicalNaN() BFloat16 {
+	return BFloat16(0x7fc0)
 }
 
 // Inf returns a BFloat16 with an infinity value with the specified sign.
@@ -153,9 +194,9 @@ func BF16NaN() BFloat16 {
 // A sign < 0 returns negative infinity.
 func BF16Inf(sign int) BFloat16 {
 	if sign >= 0 {
-		return BFloat16(0x7c00)
+		return BFloat16(0x7f80)
 	}
-	return BFloat16(0x8000 | 0x7c00)
+	return BFloat16(0x8000 | 0x7f80)
 }
 
 // Float32 returns a float32 converted from f (BFloat16).
@@ -165,21 +206,50 @@ func (f BFloat16) Float32() float32 {
 	return math.Float32frombits(u32)
 }
 
-// Bits returns the IEEE 754 binary16 representation of f, with the sign bit
+// Bits returns the bfloat16 representation of f, with the sign bit
 // of f and the result in the same bit position. Bits(Frombits(x)) == x.
 func (f BFloat16) Bits() uint16 {
 	return uint16(f)
 }
 
-// IsNaN reports whether f is an IEEE 754 binary16 “not-a-number” value.
+// Bits16 returns the same bits as Bits, for generic code over SmallFloat.
+func (f BFloat16) Bits16() uint16 {
+	return uint16(f)
+}
+
+// IsNaN reports whether f is a bfloat16 “not-a-number” value.
 func (f BFloat16) IsNaN() bool {
-	return (f&0x7c00 == 0x7c00) && (f&0x03ff != 0)
+	return (f&0x7f80 == 0x7f80) && (f&0x007f != 0)
 }
 
-// IsQuietNaN reports whether f is a quiet (non-signaling) IEEE 754 binary16
+// IsQuietNaN reports whether f is a quiet (non-signaling) bfloat16
 // “not-a-number” value.
 func (f BFloat16) IsQuietNaN() bool {
-	return (f&0x7c00 == 0x7c00) && (f&0x03ff != 0) && (f&0x0200 != 0)
+	return (f&0x7f80 == 0x7f80) && (f&0x007f != 0) && (f&0x0040 != 0)
+}
+
+// IsSignalingNaN reports whether f is a signaling bfloat16
+// “not-a-number” value.
+func (f BFloat16) IsSignalingNaN() bool {
+	return f.IsNaN() && (f&0x0040 == 0)
+}
+
+// Payload returns the NaN payload of f, the significand bits below the
+// quiet bit, or 0 if f isn't NaN.
+func (f BFloat16) Payload() uint {
+	if !f.IsNaN() {
+		return 0
+	}
+	return uint(f & 0x003f)
+}
+
+// Quiet returns f with the quiet bit set if f is a signaling NaN, keeping
+// its sign and payload, and f otherwise.
+func (f BFloat16) Quiet() BFloat16 {
+	if f.IsNaN() {
+		return f | 0x0040
+	}
+	return f
 }
 
 // IsInf reports whether f is an infinity (inf).
@@ -187,19 +257,19 @@ func (f BFloat16) IsQuietNaN() bool {
 // A sign < 0 reports whether f is negative inf.
 // A sign == 0 reports whether f is either inf.
 func (f BFloat16) IsInf(sign int) bool {
-	return ((f == 0x7c00) && sign >= 0) ||
-		(f == 0xfc00 && sign <= 0)
+	return ((f == 0x7f80) && sign >= 0) ||
+		(f == 0xff80 && sign <= 0)
 }
 
 // IsFinite returns true if f is neither infinite nor NaN.
 func (f BFloat16) IsFinite() bool {
-	return (uint16(f) & uint16(0x7c00)) != uint16(0x7c00)
+	return (uint16(f) & uint16(0x7f80)) != uint16(0x7f80)
 }
 
 // IsNormal returns true if f is neither zero, infinite, subnormal, or NaN.
 func (f BFloat16) IsNormal() bool {
-	exp := uint16(f) & uint16(0x7c00)
-	return (exp != uint16(0x7c00)) && (exp != 0)
+	exp := uint16(f) & uint16(0x7f80)
+	return (exp != uint16(0x7f80)) && (exp != 0)
 }
 
 // Signbit reports whether f is negative or negative zero.
@@ -212,47 +282,27 @@ func (f BFloat16) String() string {
 	return strconv.FormatFloat(float64(f.Float32()), 'f', -1, 32)
 }
 
-// bf16bitsToF32bits returns uint32 (float32 bits) converted from specified uint16.
+// BF16bitsToF32bits returns uint32 (float32 bits) converted from specified uint16.
 func BF16bitsToF32bits(in uint16) uint32 {
-	// All 65536 conversions with this were confirmed to be correct
-	// by Montgomery Edwards⁴⁴⁸ (github.com/x448).
+	// Adapted from F16bitsToF32bits, whose 65536 conversions were
+	// confirmed to be correct by Montgomery Edwards⁴⁴⁸ (github.com/x448).
+	// bfloat16 is the upper half of float32, so only NaNs need work.
 
-	sign := uint32(in&0x8000) << 16 // sign for 32-bit
-	exp := uint32(in&0x7c00) >> 10  // exponenent for 16-bit
-	coef := uint32(in&0x03ff) << 13 // significand for 32-bit
+	u32 := uint32(in) << 16
 
-	if exp == 0x1f {
-		if coef == 0 {
-			// infinity
-			return sign | 0x7f800000 | coef
-		}
-		// NaN
-		return sign | 0x7fc00000 | coef
+	if (u32&0x7f800000) == 0x7f800000 && (u32&0x007fffff) != 0 {
+		// NaN, set quiet bit like F16bitsToF32bits
+		return u32 | 0x00400000
 	}
 
-	if exp == 0 {
-		if coef == 0 {
-			// zero
-			return sign
-		}
-
-		// normalize subnormal numbers
-		exp++
-		for coef&0x7f800000 == 0 {
-			coef <<= 1
-			exp--
-		}
-		coef &= 0x007fffff
-	}
-
-	return sign | ((exp + (0x7f - 0xf)) << 23) | coef
+	return u32
 }
 
 // f32bitsToBF16bits returns uint16 (BFloat16 bits) converted from the specified float32.
 // Conversion rounds to nearest integer with ties to even.
 func f32bitsToBF16bits(u32 uint32) uint16 {
-	// Translated from Rust to Go by Montgomery Edwards⁴⁴⁸ (github.com/x448).
-	// All 4294967296 conversions with this were confirmed to be correct by x448.
+	// Adapted from f32bitsToF16bits, which was translated from Rust to Go
+	// by Montgomery Edwards⁴⁴⁸ (github.com/x448).
 	// Original Rust implementation is by Kathryn Long (github.com/starkat99) with MIT license.
 
 	sign := u32 & 0x80000000
@@ -263,38 +313,17 @@ func f32bitsToBF16bits(u32 uint32) uint16 {
 		// NaN or Infinity
 		nanBit := uint32(0)
 		if coef != 0 {
-			nanBit = uint32(0x0200)
-		}
-		return uint16((sign >> 16) | uint32(0x7c00) | nanBit | (coef >> 13))
-	}
-
-	halfSign := sign >> 16
-
-	unbiasedExp := int32(exp>>23) - 127
-	halfExp := unbiasedExp + 15
-
-	if halfExp >= 0x1f {
-		return uint16(halfSign | uint32(0x7c00))
-	}
-
-	if halfExp <= 0 {
-		if 14-halfExp > 24 {
-			return uint16(halfSign)
-		}
-		c := coef | uint32(0x00800000)
-		halfCoef := c >> uint32(14-halfExp)
-		roundBit := uint32(1) << uint32(13-halfExp)
-		if (c&roundBit) != 0 && (c&(3*roundBit-1)) != 0 {
-			halfCoef++
+			nanBit = uint32(0x0040)
 		}
-		return uint16(halfSign | halfCoef)
+		return uint16((sign >> 16) | uint32(0x7f80) | nanBit | (coef >> 16))
 	}
 
-	uHalfExp := uint32(halfExp) << 10
-	halfCoef := coef >> 13
-	roundBit := uint32(0x00001000)
-	if (coef&roundBit) != 0 && (coef&(3*roundBit-1)) != 0 {
-		return uint16((halfSign | uHalfExp | halfCoef) + 1)
+	// bfloat16 has the float32 exponent range, so only the significand
+	// needs rounding. A carry out of the significand increments the
+	// exponent, which also takes care of overflow to infinity.
+	roundBit := uint32(0x00008000)
+	if (u32&roundBit) != 0 && (u32&(3*roundBit-1)) != 0 {
+		return uint16(u32>>16) + 1
 	}
-	return uint16(halfSign | uHalfExp | halfCoef)
+	return uint16(u32 >> 16)
 }
@@ -20,7 +20,7 @@ func BF16BenchmarkFloat32Pi(b *testing.B) {
 		f16 := floatx.BF16Frombits(uint16(Pi16))
 		result = f16.Float32()
 	}
-	resultF32 = result
+	BF16ResultF32 = result
 }
 
 func BF16BenchmarkFrombits(b *testing.B) {
@@ -44,13 +44,13 @@ func BF16BenchmarkFromFloat32Pi(b *testing.B) {
 }
 
 func BF16BenchmarkFromFloat32nan(b *testing.B) {
-	result := floatx.Float16(0)
+	result := floatx.BFloat16(0)
 
 	nan := float32(math.NaN())
 	for i := 0; i < b.N; i++ {
-		result = floatx.F16Fromfloat32(nan)
+		result = floatx.BF16Fromfloat32(nan)
 	}
-	resultF16 = result
+	BF16ResultBF16 = result
 }
 
 func BF16BenchmarkFromFloat32subnorm(b *testing.B) {
@@ -77,7 +77,7 @@ func BF16BenchmarkString(b *testing.B) {
 	var result string
 
 	Pi32 := float32(math.Pi)
-	Pi16 := floatx.F16Fromfloat32(Pi32)
+	Pi16 := floatx.BF16Fromfloat32(Pi32)
 	for i := 0; i < b.N; i++ {
 		result = Pi16.String()
 	}
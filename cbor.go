@@ -0,0 +1,87 @@
+package floatx
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// Preferred serialization of floats in CBOR (RFC 8949 section 4.2.2): a
+// float is encoded in the shortest of the half, single and double precision
+// forms of major type 7 that preserves its value.
+
+// CBOR initial bytes of major type 7 floats.
+const (
+	cborFloat16 = 0xf9
+	cborFloat32 = 0xfa
+	cborFloat64 = 0xfb
+)
+
+// ShortestEncoding returns the smallest IEEE 754 width in bits, 16, 32 or
+// 64, that represents f64 exactly. Subnormal results count when they are
+// exact, which resolves the F16PrecisionUnknown case of
+// F16PrecisionFromfloat32. Infinities need 16 bits, and a NaN needs the
+// smallest width that keeps its sign, quiet bit and payload, which is the
+// width whose significand holds all the nonzero bits of f64's significand.
+func ShortestEncoding(f64 float64) int {
+	u64 := math.Float64bits(f64)
+	if u64&0x7ff0000000000000 == 0x7ff0000000000000 {
+		switch coef := u64 & 0xfffffffffffff; {
+		case coef&(1<<42-1) == 0:
+			return 16
+		case coef&(1<<29-1) == 0:
+			return 32
+		}
+		return 64
+	}
+	f32 := float32(f64)
+	if float64(f32) != f64 {
+		return 64
+	}
+	if F16Fromfloat32(f32).Float32() != f32 {
+		return 32
+	}
+	return 16
+}
+
+// AppendCBORFloat appends the CBOR encoding of f64 in the shortest form that
+// ShortestEncoding reports, keeping the payload of a NaN, and returns the
+// extended buffer. Canonical encodings that map every NaN to 0xf97e00 can
+// replace NaNs before calling it.
+func AppendCBORFloat(dst []byte, f64 float64) []byte {
+	u64 := math.Float64bits(f64)
+	nonFinite := u64&0x7ff0000000000000 == 0x7ff0000000000000
+	switch ShortestEncoding(f64) {
+	case 16:
+		if nonFinite {
+			return AppendCBORFloat16(dst, Float16(u64>>48&0x8000|0x7c00|u64>>42&0x3ff))
+		}
+		return AppendCBORFloat16(dst, F16Fromfloat32(float32(f64)))
+	case 32:
+		if nonFinite {
+			return AppendCBORFloat32(dst, math.Float32frombits(uint32(u64>>32&0x80000000|0x7f800000|u64>>29&0x7fffff)))
+		}
+		return AppendCBORFloat32(dst, float32(f64))
+	}
+	return AppendCBORFloat64(dst, f64)
+}
+
+// AppendCBORFloat16 appends the CBOR half-precision encoding of f, the
+// initial byte 0xf9 and the big-endian bits of f, and returns the extended
+// buffer.
+func AppendCBORFloat16(dst []byte, f Float16) []byte {
+	return binary.BigEndian.AppendUint16(append(dst, cborFloat16), uint16(f))
+}
+
+// AppendCBORFloat32 appends the CBOR single-precision encoding of f32, the
+// initial byte 0xfa and the big-endian bits of f32, and returns the
+// extended buffer.
+func AppendCBORFloat32(dst []byte, f32 float32) []byte {
+	return binary.BigEndian.AppendUint32(append(dst, cborFloat32), math.Float32bits(f32))
+}
+
+// AppendCBORFloat64 appends the CBOR double-precision encoding of f64, the
+// initial byte 0xfb and the big-endian bits of f64, and returns the
+// extended buffer.
+func AppendCBORFloat64(dst []byte, f64 float64) []byte {
+	return binary.BigEndian.AppendUint64(append(dst, cborFloat64), math.Float64bits(f64))
+}
@@ -0,0 +1,104 @@
+package floatx_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math"
+	"testing"
+
+	floatx "github.com/chenxingqiang/go-floatx"
+)
+
+func TestAppendCBORFloat(t *testing.T) {
+	testCases := []struct {
+		f64  float64
+		want string
+	}{
+		// RFC 8949 Appendix A
+		{0.0, "f90000"},
+		{math.Copysign(0, -1), "f98000"},
+		{1.0, "f93c00"},
+		{1.1, "fb3ff199999999999a"},
+		{1.5, "f93e00"},
+		{65504.0, "f97bff"},
+		{100000.0, "fa47c35000"},
+		{3.4028234663852886e+38, "fa7f7fffff"},
+		{1.0e+300, "fb7e37e43c8800759c"},
+		{5.960464477539063e-8, "f90001"},
+		{0.00006103515625, "f90400"},
+		{-4.0, "f9c400"},
+		{-4.1, "fbc010666666666666"},
+		{math.Inf(1), "f97c00"},
+		{math.Float64frombits(0x7ff8000000000000), "f97e00"},
+		{math.Inf(-1), "f9fc00"},
+
+		{65505.0, "fa477fe100"},
+		{0x1p-25, "fa33000000"},          // normal float32, below the float16 range
+		{0x3p-24, "f90003"},              // float16 subnormal
+		{0x3p-25, "fa33c00000"},          // between float16 subnormals
+		{0x1p-149, "fa00000001"},         // float32 subnormal
+		{0x1p-150, "fb3690000000000000"}, // below the float32 range
+		{math.MaxFloat64, "fb7fefffffffffffff"},
+
+		// NaNs keep their sign, quiet bit and payload
+		{math.NaN(), "fb7ff8000000000001"},
+		{math.Float64frombits(0xfff8000000000000), "f9fe00"},
+		{math.Float64frombits(0x7ff0040000000000), "f97c01"},
+		{math.Float64frombits(0x7ff0000020000000), "fa7f800001"},
+		{math.Float64frombits(0xfffc000020000000), "faffe00001"},
+		{math.Float64frombits(0x7ff0000000000001), "fb7ff0000000000001"},
+	}
+	for _, tc := range testCases {
+		got := floatx.AppendCBORFloat([]byte{0x82}, tc.f64)
+		want, _ := hex.DecodeString("82" + tc.want)
+		if !bytes.Equal(got, want) {
+			t.Errorf("AppendCBORFloat(%g, 0x%016x) = %x, want %x", tc.f64, math.Float64bits(tc.f64), got, want)
+		}
+		if n := floatx.ShortestEncoding(tc.f64); n != 8*(len(want)-2) {
+			t.Errorf("ShortestEncoding(%g, 0x%016x) = %d, want %d", tc.f64, math.Float64bits(tc.f64), n, 8*(len(want)-2))
+		}
+	}
+}
+
+func TestShortestEncodingFloat16(t *testing.T) {
+	// every float16 value needs 16 bits
+	for i := 0; i < 0x10000; i++ {
+		f := floatx.F16Frombits(uint16(i))
+		if f.IsNaN() {
+			continue
+		}
+		if n := floatx.ShortestEncoding(float64(f.Float32())); n != 16 {
+			t.Fatalf("ShortestEncoding(0x%04x) = %d, want 16", i, n)
+		}
+	}
+
+	// float32 values with F16PrecisionUnknown need 16 bits only if they
+	// round-trip through float16
+	count := 0
+	for u32 := uint32(0); u32 < 0x38800000; u32 += 0x2000 {
+		f32 := math.Float32frombits(u32)
+		if floatx.F16PrecisionFromfloat32(f32) != floatx.F16PrecisionUnknown {
+			continue
+		}
+		want := 32
+		if floatx.F16Fromfloat32(f32).Float32() == f32 {
+			want = 16
+			count++
+		}
+		if n := floatx.ShortestEncoding(float64(f32)); n != want {
+			t.Fatalf("ShortestEncoding(%g) = %d, want %d", f32, n, want)
+		}
+	}
+	if count != 1023 {
+		t.Errorf("%d positive subnormals need 16 bits, want 1023", count)
+	}
+}
+
+func TestAppendCBORFloatWidths(t *testing.T) {
+	b := floatx.AppendCBORFloat16(nil, floatx.F16Fromfloat32(1))
+	b = floatx.AppendCBORFloat32(b, 1)
+	b = floatx.AppendCBORFloat64(b, 1)
+	if want, _ := hex.DecodeString("f93c00" + "fa3f800000" + "fb3ff0000000000000"); !bytes.Equal(b, want) {
+		t.Errorf("got %x, want %x", b, want)
+	}
+}
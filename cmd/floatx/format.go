@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	floatx "github.com/chenxingqiang/go-floatx"
+)
+
+// format describes a floating-point format the tool can decode and encode.
+type format struct {
+	name    string
+	desc    string
+	size    int // bytes
+	expBits uint
+	manBits uint
+	// ieee is false for formats without infinities, whose NaNs have all
+	// exponent and significand bits set.
+	ieee bool
+	// toFloat32 returns the value of bits u, exactly.
+	toFloat32 func(u uint32) float32
+	// fromFloat32 returns the bits of f32 rounded with c, which may raise
+	// flags in c. Formats without rounding support ignore c and round to
+	// nearest even.
+	fromFloat32 func(c *floatx.Context, f32 float32) uint32
+	rounding    bool
+}
+
+var formats = map[string]*format{
+	"f16": {
+		name: "f16", desc: "IEEE 754 binary16", size: 2, expBits: 5, manBits: 10, ieee: true,
+		toFloat32: func(u uint32) float32 { return floatx.F16Frombits(uint16(u)).Float32() },
+		fromFloat32: func(c *floatx.Context, f32 float32) uint32 {
+			return uint32(c.F16Fromfloat32(f32))
+		},
+		rounding: true,
+	},
+	"bf16": {
+		name: "bf16", desc: "bfloat16", size: 2, expBits: 8, manBits: 7, ieee: true,
+		toFloat32: func(u uint32) float32 { return floatx.BF16Frombits(uint16(u)).Float32() },
+		fromFloat32: func(c *floatx.Context, f32 float32) uint32 {
+			return uint32(c.BF16Fromfloat32(f32))
+		},
+		rounding: true,
+	},
+	"f8": {
+		name: "f8", desc: "8-bit E5M2", size: 1, expBits: 5, manBits: 2, ieee: true,
+		toFloat32: func(u uint32) float32 { return floatx.F8Frombits(uint8(u)).Float32() },
+		fromFloat32: func(c *floatx.Context, f32 float32) uint32 {
+			return uint32(c.F8Fromfloat32(f32))
+		},
+		rounding: true,
+	},
+	"f8e4m3": {
+		name: "f8e4m3", desc: "8-bit E4M3FN", size: 1, expBits: 4, manBits: 3,
+		toFloat32: func(u uint32) float32 { return floatx.F8E4M3Frombits(uint8(u)).Float32() },
+		fromFloat32: func(c *floatx.Context, f32 float32) uint32 {
+			return uint32(floatx.F8E4M3Fromfloat32(f32))
+		},
+	},
+	"f32": {
+		name: "f32", desc: "IEEE 754 binary32", size: 4, expBits: 8, manBits: 23, ieee: true,
+		toFloat32: math.Float32frombits,
+		// the values of the other formats are exact in float32
+		fromFloat32: func(c *floatx.Context, f32 float32) uint32 {
+			return math.Float32bits(f32)
+		},
+		rounding: true,
+	},
+}
+
+// aliases are other names accepted for the formats.
+var aliases = map[string]string{
+	"float16": "f16", "half": "f16", "binary16": "f16",
+	"bfloat16": "bf16",
+	"float8":   "f8", "e5m2": "f8", "f8e5m2": "f8",
+	"e4m3": "f8e4m3", "e4m3fn": "f8e4m3", "f8e4m3fn": "f8e4m3",
+	"float32": "f32", "single": "f32", "binary32": "f32",
+}
+
+// lookupFormat returns the format called name.
+func lookupFormat(name string) (*format, error) {
+	name = strings.ToLower(name)
+	if a, ok := aliases[name]; ok {
+		name = a
+	}
+	if f, ok := formats[name]; ok {
+		return f, nil
+	}
+	names := make([]string, 0, len(formats))
+	for n := range formats {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return nil, fmt.Errorf("unknown format %q, want one of %s", name, strings.Join(names, ", "))
+}
+
+// parseRounding returns the rounding mode called name, such as ToZero,
+// ignoring case.
+func parseRounding(name string) (floatx.RoundingMode, error) {
+	for m := floatx.ToNearestEven; m <= floatx.ToPositiveInf; m++ {
+		if strings.EqualFold(name, m.String()) {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown rounding mode %q, want ToNearestEven, ToNearestAway, ToZero, AwayFromZero, ToNegativeInf or ToPositiveInf", name)
+}
+
+// bits returns the bits of u, with spaces between the sign, exponent and
+// significand fields.
+func (f *format) bits(u uint32) string {
+	s := fmt.Sprintf("%0*b", 8*f.size, u)
+	return s[:1] + " " + s[1:1+f.expBits] + " " + s[1+f.expBits:]
+}
+
+// hex returns u as a hexadecimal number with all the digits of f.
+func (f *format) hex(u uint32) string {
+	return fmt.Sprintf("0x%0*x", 2*f.size, u)
+}
+
+// class returns the IEEE 754 class of the value with bits u.
+func (f *format) class(u uint32) string {
+	exp := u >> f.manBits & (1<<f.expBits - 1)
+	man := u & (1<<f.manBits - 1)
+	v := f.toFloat32(u)
+	switch {
+	case v != v && !f.ieee:
+		return "NaN"
+	case v != v && man>>(f.manBits-1) != 0:
+		return "quiet NaN"
+	case v != v:
+		return "signaling NaN"
+	case math.IsInf(float64(v), 0):
+		return "infinity"
+	case v == 0:
+		return "zero"
+	case exp == 0:
+		return "subnormal"
+	}
+	return "normal"
+}
+
+// parseValue parses s, a decimal or hexadecimal floating-point number, inf
+// or nan, and returns it rounded to float32 with round to odd, so rounding
+// the result to a narrower format in any mode gives the correctly rounded
+// value of s.
+func parseValue(s string) (float32, error) {
+	if strings.EqualFold(strings.TrimLeft(s, "+-"), "nan") {
+		return float32(math.NaN()), nil
+	}
+	x, _, err := big.ParseFloat(s, 0, 24, big.ToZero)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	sticky := x.Acc() != big.Exact
+	a := new(big.Float).Abs(x)
+	var f32 float32
+	switch {
+	case x.IsInf():
+		f32 = float32(math.Inf(1))
+	case a.Cmp(big.NewFloat(math.MaxFloat32)) > 0:
+		// bits 0x7f7fffff are odd, and the value is inexact
+		f32 = math.MaxFloat32
+	case a.Cmp(big.NewFloat(0x1p-126)) < 0:
+		// truncate to a multiple of the smallest subnormal
+		n, acc := new(big.Float).SetMantExp(a, 149).Int(nil)
+		sticky = sticky || acc != big.Exact
+		f32 = float32(n.Int64()) * 0x1p-149
+	default:
+		f32, _ = a.Float32()
+	}
+	if sticky {
+		f32 = math.Float32frombits(math.Float32bits(f32) | 1)
+	}
+	if x.Signbit() {
+		f32 = -f32
+	}
+	return f32, nil
+}
+
+// formatValue returns v formatted as the shortest decimal that parses to
+// v.
+func formatValue(v float32) string {
+	return strconv.FormatFloat(float64(v), 'g', -1, 32)
+}
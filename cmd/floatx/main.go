@@ -0,0 +1,378 @@
+// Command floatx inspects and converts values of the floating-point formats
+// of github.com/chenxingqiang/go-floatx.
+//
+// Usage:
+//
+//	floatx decode <format> <bits>
+//	floatx encode <format> <value> [-round mode]
+//	floatx table <format>
+//	floatx convert [flags] <from> <to>
+//
+// The formats are f16 (IEEE 754 binary16), bf16 (bfloat16), f8 (E5M2),
+// f8e4m3 (E4M3FN) and f32 (binary32). Names such as float16, half, e5m2
+// and e4m3fn work too.
+//
+// decode shows the sign, exponent and significand fields of bits, such as
+// 0x3c00, with the value in decimal and hexadecimal and its class.
+//
+// encode rounds a decimal or hexadecimal value, inf or nan to the format
+// and shows the bits, the rounded value, whether it was exact and the IEEE
+// 754 exception flags. The rounding mode is one of ToNearestEven (the
+// default), ToNearestAway, ToZero, AwayFromZero, ToNegativeInf and
+// ToPositiveInf. f8e4m3 and f32 only round to nearest even.
+//
+// table lists every value of an 8-bit or 16-bit format.
+//
+// convert reads binary values of one format and writes them in another:
+//
+//	-in file       read from file instead of standard input
+//	-out file      write to file instead of standard output
+//	-in-order bo   byte order of the input, le (default) or be
+//	-out-order bo  byte order of the output, le (default) or be
+//	-round mode    rounding mode, as for encode
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	floatx "github.com/chenxingqiang/go-floatx"
+)
+
+const usage = `usage:
+	floatx decode <format> <bits>
+	floatx encode <format> <value> [-round mode]
+	floatx table <format>
+	floatx convert [-in file] [-out file] [-in-order le|be] [-out-order le|be] [-round mode] <from> <to>
+formats: f16, bf16, f8 (E5M2), f8e4m3 (E4M3FN), f32
+`
+
+// errUsage is returned for invalid command lines.
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	err := run(os.Args[1:], os.Stdin, os.Stdout)
+	switch {
+	case errors.Is(err, errUsage):
+		fmt.Fprintf(os.Stderr, "floatx: %v\n%s", err, usage)
+		os.Exit(2)
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "floatx: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run runs the command line args.
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: no command", errUsage)
+	}
+	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	switch args[0] {
+	case "decode":
+		pos, err := parseArgs(fs, args[1:], 2)
+		if err != nil {
+			return err
+		}
+		return decode(stdout, pos[0], pos[1])
+	case "encode":
+		round := fs.String("round", "ToNearestEven", "rounding mode")
+		pos, err := parseArgs(fs, args[1:], 2)
+		if err != nil {
+			return err
+		}
+		return encode(stdout, pos[0], pos[1], *round)
+	case "table":
+		pos, err := parseArgs(fs, args[1:], 1)
+		if err != nil {
+			return err
+		}
+		return table(stdout, pos[0])
+	case "convert":
+		c := &converter{}
+		fs.StringVar(&c.in, "in", "", "input file")
+		fs.StringVar(&c.out, "out", "", "output file")
+		fs.StringVar(&c.inOrder, "in-order", "le", "input byte order")
+		fs.StringVar(&c.outOrder, "out-order", "le", "output byte order")
+		fs.StringVar(&c.round, "round", "ToNearestEven", "rounding mode")
+		pos, err := parseArgs(fs, args[1:], 2)
+		if err != nil {
+			return err
+		}
+		return c.run(stdin, stdout, pos[0], pos[1])
+	}
+	return fmt.Errorf("%w: unknown command %q", errUsage, args[0])
+}
+
+// parseArgs parses the flags in args, which may come after the positional
+// arguments, and returns the n positional arguments. Negative numbers are
+// positional arguments.
+func parseArgs(fs *flag.FlagSet, args []string, n int) ([]string, error) {
+	var pos []string
+	for len(args) > 0 {
+		if _, err := strconv.ParseFloat(args[0], 64); err == nil || !strings.HasPrefix(args[0], "-") {
+			pos = append(pos, args[0])
+			args = args[1:]
+			continue
+		}
+		if err := fs.Parse(args); err != nil {
+			return nil, fmt.Errorf("%w: %v", errUsage, err)
+		}
+		args = fs.Args()
+	}
+	if len(pos) != n {
+		return nil, fmt.Errorf("%w: %s wants %d arguments, got %d", errUsage, fs.Name(), n, len(pos))
+	}
+	return pos, nil
+}
+
+// decode writes the fields, value and class of the bits s of the format
+// called name.
+func decode(w io.Writer, name, s string) error {
+	f, err := lookupFormat(name)
+	if err != nil {
+		return err
+	}
+	u, err := strconv.ParseUint(s, 0, 8*f.size)
+	if err != nil {
+		return fmt.Errorf("invalid %d-bit pattern %q", 8*f.size, s)
+	}
+	bits := uint32(u)
+	exp := int(bits >> f.manBits & (1<<f.expBits - 1))
+	bias := 1<<(f.expBits-1) - 1
+	unbiased := exp - bias
+	if exp == 0 {
+		unbiased = 1 - bias // subnormals and zero have the exponent of the smallest normal
+	}
+	sign := "+"
+	if bits>>(8*f.size-1) != 0 {
+		sign = "-"
+	}
+	v := f.toFloat32(bits)
+
+	fmt.Fprintf(w, "%s (%s) %s\n", f.name, f.desc, f.hex(bits))
+	fmt.Fprintf(w, "bits:        %s\n", f.bits(bits))
+	fmt.Fprintf(w, "sign:        %s\n", sign)
+	fmt.Fprintf(w, "exponent:    %d (bias %d, unbiased %d)\n", exp, bias, unbiased)
+	fmt.Fprintf(w, "significand: 0x%x\n", bits&(1<<f.manBits-1))
+	fmt.Fprintf(w, "class:       %s\n", f.class(bits))
+	fmt.Fprintf(w, "value:       %s\n", formatValue(v))
+	fmt.Fprintf(w, "hex:         %s\n", hexValue(v))
+	return nil
+}
+
+// hexValue returns v as a hexadecimal floating-point number.
+func hexValue(v float32) string {
+	return strconv.FormatFloat(float64(v), 'x', -1, 32)
+}
+
+// encode writes the bits and precision of the value s rounded to the
+// format called name with the rounding mode called round.
+func encode(w io.Writer, name, s, round string) error {
+	f, err := lookupFormat(name)
+	if err != nil {
+		return err
+	}
+	mode, err := parseRounding(round)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+	if mode != floatx.ToNearestEven && (!f.rounding || f.size == 4) {
+		return fmt.Errorf("%s only rounds to nearest even", f.name)
+	}
+
+	var in float32
+	if f.size == 4 {
+		// parse directly to float32, since round to odd isn't a float32
+		f64, err := strconv.ParseFloat(s, 32)
+		if err != nil && !errors.Is(err, strconv.ErrRange) {
+			return fmt.Errorf("invalid value %q", s)
+		}
+		in = float32(f64)
+	} else if in, err = parseValue(s); err != nil {
+		return err
+	}
+	c := &floatx.Context{Rounding: mode}
+	bits := f.fromFloat32(c, in)
+	v := f.toFloat32(bits)
+
+	fmt.Fprintf(w, "%s (%s) %s rounded %v\n", f.name, f.desc, s, mode)
+	fmt.Fprintf(w, "bits:      %s = %s\n", f.hex(bits), f.bits(bits))
+	fmt.Fprintf(w, "value:     %s\n", formatValue(v))
+	fmt.Fprintf(w, "hex:       %s\n", hexValue(v))
+	fmt.Fprintf(w, "class:     %s\n", f.class(bits))
+	if f.size < 4 {
+		fmt.Fprintf(w, "precision: %s\n", precision(in, v, c, f))
+	}
+	if f.rounding && f.size < 4 {
+		flags := c.Flags.String()
+		if flags == "" {
+			flags = "none"
+		}
+		fmt.Fprintf(w, "flags:     %s\n", flags)
+	}
+	return nil
+}
+
+// precision returns whether rounding in to v, with flags in c, was exact,
+// inexact, underflowed or overflowed.
+func precision(in, v float32, c *floatx.Context, f *format) string {
+	if !f.rounding {
+		// no flags, so compare the values
+		switch {
+		case in != in:
+			return "exact"
+		case v != v:
+			// infinities become NaN too
+			return "overflow"
+		}
+		c.Flags = 0
+		if v != in {
+			c.Flags = floatx.FlagInexact
+		}
+	}
+	switch {
+	case c.Flags&floatx.FlagOverflow != 0:
+		return "overflow"
+	case c.Flags&floatx.FlagInexact != 0 && v == 0:
+		return "underflow"
+	case c.Flags&floatx.FlagInexact != 0:
+		return "inexact"
+	}
+	return "exact"
+}
+
+// table writes every value of the format called name.
+func table(w io.Writer, name string) error {
+	f, err := lookupFormat(name)
+	if err != nil {
+		return err
+	}
+	if f.size > 2 {
+		return fmt.Errorf("table needs an 8-bit or 16-bit format, not %s", f.name)
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "bits\tvalue\thex\tclass\n")
+	for u := uint32(0); u < 1<<(8*f.size); u++ {
+		v := f.toFloat32(u)
+		fmt.Fprintf(bw, "%s\t%s\t%s\t%s\n", f.hex(u), formatValue(v), hexValue(v), f.class(u))
+	}
+	return bw.Flush()
+}
+
+// converter converts a stream of binary values between formats.
+type converter struct {
+	in, out           string
+	inOrder, outOrder string
+	round             string
+}
+
+// byteOrder returns the byte order called name.
+func byteOrder(name string) (binary.ByteOrder, error) {
+	switch strings.ToLower(name) {
+	case "le", "little":
+		return binary.LittleEndian, nil
+	case "be", "big":
+		return binary.BigEndian, nil
+	}
+	return nil, fmt.Errorf("%w: unknown byte order %q, want le or be", errUsage, name)
+}
+
+// run converts the values of format from in r to format to in w, or the
+// files named by c.
+func (c *converter) run(r io.Reader, w io.Writer, from, to string) (err error) {
+	src, err := lookupFormat(from)
+	if err != nil {
+		return err
+	}
+	dst, err := lookupFormat(to)
+	if err != nil {
+		return err
+	}
+	mode, err := parseRounding(c.round)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+	if mode != floatx.ToNearestEven && !dst.rounding {
+		return fmt.Errorf("%s only rounds to nearest even", dst.name)
+	}
+	inOrder, err := byteOrder(c.inOrder)
+	if err != nil {
+		return err
+	}
+	outOrder, err := byteOrder(c.outOrder)
+	if err != nil {
+		return err
+	}
+	if c.in != "" {
+		file, err := os.Open(c.in)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	}
+	if c.out != "" {
+		file, err := os.Create(c.out)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			// a failed close can lose buffered data, so report it
+			if cerr := file.Close(); err == nil {
+				err = cerr
+			}
+		}()
+		w = file
+	}
+
+	ctx := &floatx.Context{Rounding: mode}
+	br := bufio.NewReader(r)
+	bw := bufio.NewWriter(w)
+	in := make([]byte, src.size)
+	out := make([]byte, dst.size)
+	for n := 0; ; n++ {
+		if _, err := io.ReadFull(br, in); err == io.EOF {
+			break
+		} else if err != nil {
+			return fmt.Errorf("reading value %d: %w", n, err)
+		}
+		bits := dst.fromFloat32(ctx, src.toFloat32(get(inOrder, in)))
+		put(outOrder, out, bits)
+		if _, err := bw.Write(out); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// get returns the value of the 1, 2 or 4 bytes in b.
+func get(bo binary.ByteOrder, b []byte) uint32 {
+	switch len(b) {
+	case 1:
+		return uint32(b[0])
+	case 2:
+		return uint32(bo.Uint16(b))
+	}
+	return bo.Uint32(b)
+}
+
+// put stores u in the 1, 2 or 4 bytes of b.
+func put(bo binary.ByteOrder, b []byte, u uint32) {
+	switch len(b) {
+	case 1:
+		b[0] = uint8(u)
+	case 2:
+		bo.PutUint16(b, uint16(u))
+	default:
+		bo.PutUint32(b, u)
+	}
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func runString(t *testing.T, stdin string, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	err := run(args, strings.NewReader(stdin), &out)
+	return out.String(), err
+}
+
+func TestDecode(t *testing.T) {
+	testCases := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"decode", "f16", "0x3c00"}, []string{
+			"f16 (IEEE 754 binary16) 0x3c00",
+			"bits:        0 01111 0000000000",
+			"sign:        +",
+			"exponent:    15 (bias 15, unbiased 0)",
+			"class:       normal",
+			"value:       1",
+			"hex:         0x1p+00",
+		}},
+		{[]string{"decode", "half", "0x8001"}, []string{"sign:        -", "exponent:    0 (bias 15, unbiased -14)", "class:       subnormal", "value:       -5.9604645e-08"}},
+		{[]string{"decode", "bf16", "0x7fc0"}, []string{"bits:        0 11111111 1000000", "class:       quiet NaN"}},
+		{[]string{"decode", "bf16", "0x7f81"}, []string{"class:       signaling NaN"}},
+		{[]string{"decode", "f8", "0xfc"}, []string{"class:       infinity", "value:       -Inf"}},
+		{[]string{"decode", "E4M3FN", "0x7e"}, []string{"bits:        0 1111 110", "class:       normal", "value:       448"}},
+		{[]string{"decode", "f8e4m3", "0xff"}, []string{"class:       NaN"}},
+		{[]string{"decode", "f32", "0x80000000"}, []string{"class:       zero", "value:       -0"}},
+		{[]string{"decode", "f8", "0b00000100"}, []string{"significand: 0x0", "value:       6.1035156e-05"}},
+	}
+	for _, tc := range testCases {
+		out, err := runString(t, "", tc.args...)
+		if err != nil {
+			t.Errorf("%v: %v", tc.args, err)
+			continue
+		}
+		for _, w := range tc.want {
+			if !strings.Contains(out, w+"\n") {
+				t.Errorf("%v: output doesn't contain %q:\n%s", tc.args, w, out)
+			}
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	testCases := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"encode", "f16", "0.1"}, []string{
+			"f16 (IEEE 754 binary16) 0.1 rounded ToNearestEven",
+			"bits:      0x2e66 = 0 01011 1001100110",
+			"value:     0.099975586",
+			"hex:       0x1.998p-04",
+			"class:     normal",
+			"precision: inexact",
+			"flags:     inexact",
+		}},
+		{[]string{"encode", "f16", "1.5"}, []string{"bits:      0x3e00 = 0 01111 1000000000", "precision: exact", "flags:     none"}},
+		{[]string{"encode", "f16", "0.1", "-round", "ToZero"}, []string{"bits:      0x2e66 = 0 01011 1001100110"}},
+		{[]string{"encode", "-round=tozero", "f16", "0.1"}, nil},
+		{[]string{"encode", "f16", "0.1", "--round", "topositiveinf"}, []string{"bits:      0x2e67 = 0 01011 1001100111"}},
+		// rounding directly from decimal: 1+2^-11+2^-40 is above the tie
+		{[]string{"encode", "f16", "1.00048828125000090949"}, []string{"bits:      0x3c01 = 0 01111 0000000001"}},
+		{[]string{"encode", "f16", "0x1.002p0"}, []string{"bits:      0x3c00 = 0 01111 0000000000"}},
+		{[]string{"encode", "f16", "-65520"}, []string{"bits:      0xfc00 = 1 11111 0000000000", "precision: overflow", "flags:     inexact|overflow"}},
+		{[]string{"encode", "f16", "1e300", "-round", "ToZero"}, []string{"value:     65504", "precision: overflow"}},
+		{[]string{"encode", "f16", "1e-300"}, []string{"class:     zero", "precision: underflow"}},
+		{[]string{"encode", "f16", "-1e-300", "-round", "ToNegativeInf"}, []string{"bits:      0x8001 = 1 00000 0000000001"}},
+		{[]string{"encode", "f16", "1e-40"}, []string{"precision: underflow"}},
+		{[]string{"encode", "f16", "-inf"}, []string{"bits:      0xfc00 = 1 11111 0000000000", "precision: exact"}},
+		{[]string{"encode", "bf16", "nan"}, []string{"class:     quiet NaN", "precision: exact"}},
+		{[]string{"encode", "bf16", "3.4e39"}, []string{"value:     +Inf", "precision: overflow"}},
+		{[]string{"encode", "f8", "1e-50", "-round", "AwayFromZero"}, []string{"bits:      0x01 = 0 00000 01"}},
+		{[]string{"encode", "e4m3", "0.3"}, []string{"bits:      0x2a = 0 0101 010", "precision: inexact"}},
+		{[]string{"encode", "e4m3", "-448"}, []string{"bits:      0xfe = 1 1111 110", "precision: exact"}},
+		{[]string{"encode", "e4m3", "500"}, []string{"class:     NaN", "precision: overflow"}},
+		{[]string{"encode", "e4m3", "inf"}, []string{"precision: overflow"}},
+		{[]string{"encode", "e4m3", "nan"}, []string{"precision: exact"}},
+		{[]string{"encode", "e4m3", "1e-10"}, []string{"class:     zero", "precision: underflow"}},
+		{[]string{"encode", "f32", "0.1"}, []string{"bits:      0x3dcccccd = 0 01111011 10011001100110011001101"}},
+		{[]string{"encode", "f32", "-1e39"}, []string{"value:     -Inf"}},
+	}
+	for _, tc := range testCases {
+		out, err := runString(t, "", tc.args...)
+		if err != nil {
+			t.Errorf("%v: %v", tc.args, err)
+			continue
+		}
+		for _, w := range tc.want {
+			if !strings.Contains(out, w+"\n") {
+				t.Errorf("%v: output doesn't contain %q:\n%s", tc.args, w, out)
+			}
+		}
+		if tc.want == nil && out == "" {
+			t.Errorf("%v: no output", tc.args)
+		}
+	}
+}
+
+func TestTable(t *testing.T) {
+	out, err := runString(t, "", "table", "f8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	if len(lines) != 257 {
+		t.Fatalf("table f8 has %d lines, want 257", len(lines))
+	}
+	for i, want := range map[int]string{
+		0:    "bits\tvalue\thex\tclass",
+		1:    "0x00\t0\t0x0p+00\tzero",
+		0x3d: "0x3c\t1\t0x1p+00\tnormal",
+		0x7d: "0x7c\t+Inf\t+Inf\tinfinity",
+		0x7e: "0x7d\tNaN\tNaN\tsignaling NaN",
+		256:  "0xff\tNaN\tNaN\tquiet NaN",
+	} {
+		if lines[i] != want {
+			t.Errorf("line %d = %q, want %q", i, lines[i], want)
+		}
+	}
+
+	out, err = runString(t, "", "table", "bf16")
+	if err != nil || strings.Count(out, "\n") != 1<<16+1 {
+		t.Errorf("table bf16 has %d lines, %v", strings.Count(out, "\n"), err)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	testCases := []struct {
+		args    []string
+		in, out string
+	}{
+		{[]string{"convert", "f16", "f32"}, "\x00\x3c\x00\xc0", "\x00\x00\x80\x3f\x00\x00\x00\xc0"},
+		{[]string{"convert", "f32", "f16"}, "\xcd\xcc\xcc\x3d", "\x66\x2e"},
+		{[]string{"convert", "-round", "ToPositiveInf", "f32", "f16"}, "\xcd\xcc\xcc\x3d", "\x67\x2e"},
+		{[]string{"convert", "f32", "f16", "-in-order", "be", "-out-order", "be"}, "\x3d\xcc\xcc\xcd", "\x2e\x66"},
+		{[]string{"convert", "bf16", "f8"}, "\x80\x3f\x80\x7f", "\x3c\x7c"},
+		{[]string{"convert", "f8", "e4m3"}, "\x3c\x7c", "\x38\x7f"},
+		{[]string{"convert", "f16", "bf16"}, "", ""},
+	}
+	for _, tc := range testCases {
+		out, err := runString(t, tc.in, tc.args...)
+		if err != nil || out != tc.out {
+			t.Errorf("%v of %x = %x, %v, want %x", tc.args, tc.in, out, err, tc.out)
+		}
+	}
+}
+
+func TestConvertFiles(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in")
+	out := filepath.Join(dir, "out")
+	if err := os.WriteFile(in, []byte{0x00, 0x3c, 0x66, 0x2e}, 0o666); err != nil {
+		t.Fatal(err)
+	}
+	if stdout, err := runString(t, "", "convert", "-in", in, "-out", out, "f16", "bf16"); err != nil || stdout != "" {
+		t.Fatalf("convert = %q, %v", stdout, err)
+	}
+	got, err := os.ReadFile(out)
+	if want := []byte{0x80, 0x3f, 0xcd, 0x3d}; err != nil || !bytes.Equal(got, want) {
+		t.Errorf("output file = %x, %v, want %x", got, err, want)
+	}
+
+	if _, err := runString(t, "", "convert", "-in", filepath.Join(dir, "missing"), "f16", "bf16"); err == nil {
+		t.Error("convert of a missing file returned nil")
+	}
+	if _, err := runString(t, "", "convert", "-out", filepath.Join(dir, "missing", "out"), "f16", "bf16"); err == nil {
+		t.Error("convert to a missing directory returned nil")
+	}
+}
+
+type errWriter struct{}
+
+func (errWriter) Write([]byte) (int, error) { return 0, errors.New("write failed") }
+
+func TestErrors(t *testing.T) {
+	testCases := []struct {
+		args  []string
+		stdin string
+		usage bool
+	}{
+		{nil, "", true},
+		{[]string{"bogus"}, "", true},
+		{[]string{"decode", "f16"}, "", true},
+		{[]string{"decode", "f16", "1", "2"}, "", true},
+		{[]string{"decode", "-x", "f16", "1"}, "", true},
+		{[]string{"decode", "f64", "0"}, "", false},
+		{[]string{"decode", "f16", "0x10000"}, "", false},
+		{[]string{"decode", "f8", "zz"}, "", false},
+		{[]string{"encode", "f16"}, "", true},
+		{[]string{"encode", "f16", "1", "-round"}, "", true},
+		{[]string{"encode", "f16", "1", "-round", "up"}, "", true},
+		{[]string{"encode", "f17", "1"}, "", false},
+		{[]string{"encode", "f16", "one"}, "", false},
+		{[]string{"encode", "f32", "one"}, "", false},
+		{[]string{"encode", "e4m3", "1", "-round", "ToZero"}, "", false},
+		{[]string{"encode", "f32", "1", "-round", "ToZero"}, "", false},
+		{[]string{"table"}, "", true},
+		{[]string{"table", "f32"}, "", false},
+		{[]string{"table", "x"}, "", false},
+		{[]string{"convert", "f16"}, "", true},
+		{[]string{"convert", "-in-order", "x", "f16", "f32"}, "", true},
+		{[]string{"convert", "-out-order", "x", "f16", "f32"}, "", true},
+		{[]string{"convert", "-round", "x", "f16", "f32"}, "", true},
+		{[]string{"convert", "-round", "ToZero", "f16", "e4m3"}, "", false},
+		{[]string{"convert", "x", "f32"}, "", false},
+		{[]string{"convert", "f16", "x"}, "", false},
+		{[]string{"convert", "f16", "f32"}, "\x00", false},
+	}
+	for _, tc := range testCases {
+		_, err := runString(t, tc.stdin, tc.args...)
+		if err == nil {
+			t.Errorf("%v returned nil", tc.args)
+		} else if errors.Is(err, errUsage) != tc.usage {
+			t.Errorf("%v returned %v, want a usage error: %v", tc.args, err, tc.usage)
+		}
+	}
+
+	if err := run([]string{"table", "f8"}, nil, errWriter{}); err == nil {
+		t.Error("table to a failing writer returned nil")
+	}
+	if err := run([]string{"convert", "f16", "f32"}, strings.NewReader("\x00\x3c"), errWriter{}); err == nil {
+		t.Error("convert to a failing writer returned nil")
+	}
+}
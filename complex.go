@@ -0,0 +1,251 @@
+package floatx
+
+import (
+	"encoding/binary"
+	"math"
+	"strconv"
+)
+
+// Complex32 represents complex numbers with Float16 real and imaginary
+// parts, like CUDA's complex<half>. In memory and in its binary encoding
+// the real part comes first, followed by the imaginary part, each as a
+// little-endian binary16, which is the layout of NumPy's proposed
+// complex32 dtype.
+type Complex32 struct {
+	re, im Float16
+}
+
+// ComplexBF16 represents complex numbers with BFloat16 real and imaginary
+// parts, laid out like Complex32.
+type ComplexBF16 struct {
+	re, im BFloat16
+}
+
+// C32ErrInvalidLength is returned by UnmarshalBinary for data that isn't
+// 4 bytes long.
+const C32ErrInvalidLength = complexError("complex32: invalid length, expected 4 bytes")
+
+// CBF16ErrInvalidLength is returned by UnmarshalBinary for data that isn't
+// 4 bytes long.
+const CBF16ErrInvalidLength = complexError("complexbf16: invalid length, expected 4 bytes")
+
+type complexError string
+
+func (e complexError) Error() string { return string(e) }
+
+// C32 returns the Complex32 re + im*i.
+func C32(re, im Float16) Complex32 {
+	return Complex32{re, im}
+}
+
+// C32Fromcomplex64 returns a Complex32 with both parts of c converted
+// using IEEE default rounding (nearest int, with ties to even).
+func C32Fromcomplex64(c complex64) Complex32 {
+	return Complex32{F16Fromfloat32(real(c)), F16Fromfloat32(imag(c))}
+}
+
+// C32FromComplex64s converts src into dst and returns the number of
+// elements converted, which is the minimum of len(dst) and len(src).
+func C32FromComplex64s(dst []Complex32, src []complex64) int {
+	n := len(src)
+	if len(dst) < n {
+		n = len(dst)
+	}
+	for i, c := range src[:n] {
+		dst[i] = C32Fromcomplex64(c)
+	}
+	return n
+}
+
+// C32ToComplex64s converts src into dst and returns the number of
+// elements converted, which is the minimum of len(dst) and len(src).
+func C32ToComplex64s(dst []complex64, src []Complex32) int {
+	n := len(src)
+	if len(dst) < n {
+		n = len(dst)
+	}
+	for i, c := range src[:n] {
+		dst[i] = c.Complex64()
+	}
+	return n
+}
+
+// Real returns the real part of c.
+func (c Complex32) Real() Float16 { return c.re }
+
+// Imag returns the imaginary part of c.
+func (c Complex32) Imag() Float16 { return c.im }
+
+// Complex64 returns a complex64 converted from c.
+// This is a lossless conversion.
+func (c Complex32) Complex64() complex64 {
+	return complex(c.re.Float32(), c.im.Float32())
+}
+
+// Abs returns the absolute value (modulus) of c as a float32, which can
+// represent it even when it exceeds the largest Float16.
+func (c Complex32) Abs() float32 {
+	return float32(math.Hypot(float64(c.re.Float32()), float64(c.im.Float32())))
+}
+
+// Conj returns the complex conjugate of c. This is exact.
+func (c Complex32) Conj() Complex32 {
+	return Complex32{c.re, c.im ^ 0x8000}
+}
+
+// Add returns c + d computed with float32 intermediates and rounded to
+// Complex32.
+func (c Complex32) Add(d Complex32) Complex32 {
+	return C32Fromcomplex64(c.Complex64() + d.Complex64())
+}
+
+// Sub returns c - d computed with float32 intermediates and rounded to
+// Complex32.
+func (c Complex32) Sub(d Complex32) Complex32 {
+	return C32Fromcomplex64(c.Complex64() - d.Complex64())
+}
+
+// Mul returns c * d computed with float32 intermediates and rounded to
+// Complex32.
+func (c Complex32) Mul(d Complex32) Complex32 {
+	return C32Fromcomplex64(c.Complex64() * d.Complex64())
+}
+
+// Div returns c / d computed with float32 intermediates and rounded to
+// Complex32. Division by zero follows Go's complex64 division.
+func (c Complex32) Div(d Complex32) Complex32 {
+	return C32Fromcomplex64(c.Complex64() / d.Complex64())
+}
+
+// String satisfies the fmt.Stringer interface, formatting c like a
+// complex64, for example (1+2i).
+func (c Complex32) String() string {
+	return strconv.FormatComplex(complex128(c.Complex64()), 'f', -1, 64)
+}
+
+// MarshalBinary returns the 4-byte little-endian encoding of c, real part
+// first.
+func (c Complex32) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint16(b, uint16(c.re))
+	binary.LittleEndian.PutUint16(b[2:], uint16(c.im))
+	return b, nil
+}
+
+// UnmarshalBinary sets c from the encoding produced by MarshalBinary.
+func (c *Complex32) UnmarshalBinary(data []byte) error {
+	if len(data) != 4 {
+		return C32ErrInvalidLength
+	}
+	c.re = Float16(binary.LittleEndian.Uint16(data))
+	c.im = Float16(binary.LittleEndian.Uint16(data[2:]))
+	return nil
+}
+
+// CBF16 returns the ComplexBF16 re + im*i.
+func CBF16(re, im BFloat16) ComplexBF16 {
+	return ComplexBF16{re, im}
+}
+
+// CBF16Fromcomplex64 returns a ComplexBF16 with both parts of c converted
+// using IEEE default rounding (nearest int, with ties to even).
+func CBF16Fromcomplex64(c complex64) ComplexBF16 {
+	return ComplexBF16{BF16Fromfloat32(real(c)), BF16Fromfloat32(imag(c))}
+}
+
+// CBF16FromComplex64s converts src into dst and returns the number of
+// elements converted, which is the minimum of len(dst) and len(src).
+func CBF16FromComplex64s(dst []ComplexBF16, src []complex64) int {
+	n := len(src)
+	if len(dst) < n {
+		n = len(dst)
+	}
+	for i, c := range src[:n] {
+		dst[i] = CBF16Fromcomplex64(c)
+	}
+	return n
+}
+
+// CBF16ToComplex64s converts src into dst and returns the number of
+// elements converted, which is the minimum of len(dst) and len(src).
+func CBF16ToComplex64s(dst []complex64, src []ComplexBF16) int {
+	n := len(src)
+	if len(dst) < n {
+		n = len(dst)
+	}
+	for i, c := range src[:n] {
+		dst[i] = c.Complex64()
+	}
+	return n
+}
+
+// Real returns the real part of c.
+func (c ComplexBF16) Real() BFloat16 { return c.re }
+
+// Imag returns the imaginary part of c.
+func (c ComplexBF16) Imag() BFloat16 { return c.im }
+
+// Complex64 returns a complex64 converted from c.
+// This is a lossless conversion.
+func (c ComplexBF16) Complex64() complex64 {
+	return complex(c.re.Float32(), c.im.Float32())
+}
+
+// Abs returns the absolute value (modulus) of c as a float32.
+func (c ComplexBF16) Abs() float32 {
+	return float32(math.Hypot(float64(c.re.Float32()), float64(c.im.Float32())))
+}
+
+// Conj returns the complex conjugate of c. This is exact.
+func (c ComplexBF16) Conj() ComplexBF16 {
+	return ComplexBF16{c.re, c.im ^ 0x8000}
+}
+
+// Add returns c + d computed with float32 intermediates and rounded to
+// ComplexBF16.
+func (c ComplexBF16) Add(d ComplexBF16) ComplexBF16 {
+	return CBF16Fromcomplex64(c.Complex64() + d.Complex64())
+}
+
+// Sub returns c - d computed with float32 intermediates and rounded to
+// ComplexBF16.
+func (c ComplexBF16) Sub(d ComplexBF16) ComplexBF16 {
+	return CBF16Fromcomplex64(c.Complex64() - d.Complex64())
+}
+
+// Mul returns c * d computed with float32 intermediates and rounded to
+// ComplexBF16.
+func (c ComplexBF16) Mul(d ComplexBF16) ComplexBF16 {
+	return CBF16Fromcomplex64(c.Complex64() * d.Complex64())
+}
+
+// Div returns c / d computed with float32 intermediates and rounded to
+// ComplexBF16. Division by zero follows Go's complex64 division.
+func (c ComplexBF16) Div(d ComplexBF16) ComplexBF16 {
+	return CBF16Fromcomplex64(c.Complex64() / d.Complex64())
+}
+
+// String satisfies the fmt.Stringer interface, formatting c like a
+// complex64, for example (1+2i).
+func (c ComplexBF16) String() string {
+	return strconv.FormatComplex(complex128(c.Complex64()), 'f', -1, 64)
+}
+
+// MarshalBinary returns the 4-byte little-endian encoding of c, real part
+// first.
+func (c ComplexBF16) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint16(b, uint16(c.re))
+	binary.LittleEndian.PutUint16(b[2:], uint16(c.im))
+	return b, nil
+}
+
+// UnmarshalBinary sets c from the encoding produced by MarshalBinary.
+func (c *ComplexBF16) UnmarshalBinary(data []byte) error {
+	if len(data) != 4 {
+		return CBF16ErrInvalidLength
+	}
+	c.re = BFloat16(binary.LittleEndian.Uint16(data))
+	c.im = BFloat16(binary.LittleEndian.Uint16(data[2:]))
+	return nil
+}
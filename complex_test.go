@@ -0,0 +1,192 @@
+package floatx_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"math/cmplx"
+	"testing"
+	"unsafe"
+
+	floatx "github.com/chenxingqiang/go-floatx"
+)
+
+var complexTestValues = []complex64{
+	0, 1, 1i, -1 - 1i, 1.5 + 0.1i, 65504 + 65520i, complex(float32(math.Inf(1)), float32(math.NaN())),
+	complex(1e-7, -3.3895314e38), complex(float32(math.Copysign(0, -1)), 2.5),
+}
+
+func TestComplex32(t *testing.T) {
+	for _, c := range complexTestValues {
+		z := floatx.C32Fromcomplex64(c)
+		re, im := floatx.F16Fromfloat32(real(c)), floatx.F16Fromfloat32(imag(c))
+		if z.Real() != re || z.Imag() != im {
+			t.Errorf("C32Fromcomplex64(%v) = %v, wanted parts 0x%04x, 0x%04x", c, z, uint16(re), uint16(im))
+		}
+		if z != floatx.C32(re, im) {
+			t.Errorf("C32(%v, %v) = %v, wanted %v", re, im, floatx.C32(re, im), z)
+		}
+		if got := z.Complex64(); !sameComplex64(got, complex(re.Float32(), im.Float32())) {
+			t.Errorf("Complex64(%v) = %v", z, got)
+		}
+		if got := z.Conj(); got.Real() != re || uint16(got.Imag()) != uint16(im)^0x8000 {
+			t.Errorf("Conj(%v) = %v", z, got)
+		}
+		if got, want := float64(z.Abs()), cmplx.Abs(complex128(z.Complex64())); float32(want) != float32(got) && !math.IsNaN(want) {
+			t.Errorf("Abs(%v) = %v, wanted %v", z, got, want)
+		}
+
+		for _, d := range complexTestValues {
+			w := floatx.C32Fromcomplex64(d)
+			zc, wc := z.Complex64(), w.Complex64()
+			checkComplex32(t, "Add", z, w, z.Add(w), zc+wc)
+			checkComplex32(t, "Sub", z, w, z.Sub(w), zc-wc)
+			checkComplex32(t, "Mul", z, w, z.Mul(w), zc*wc)
+			checkComplex32(t, "Div", z, w, z.Div(w), zc/wc)
+		}
+	}
+
+	if s := floatx.C32(floatx.F16Fromfloat32(1), floatx.F16Fromfloat32(-2.5)).String(); s != "(1-2.5i)" {
+		t.Errorf("String() = %s, wanted (1-2.5i)", s)
+	}
+	if a := floatx.C32Fromcomplex64(65504 + 65504i).Abs(); a <= 65504 {
+		t.Errorf("Abs(65504+65504i) = %v, wanted > 65504", a)
+	}
+}
+
+func checkComplex32(t *testing.T, op string, z, w, got floatx.Complex32, c complex64) {
+	t.Helper()
+	if want := floatx.C32Fromcomplex64(c); got != want {
+		t.Errorf("%v.%s(%v) = %v, wanted %v", z, op, w, got, want)
+	}
+}
+
+func TestComplexBF16(t *testing.T) {
+	for _, c := range complexTestValues {
+		z := floatx.CBF16Fromcomplex64(c)
+		re, im := floatx.BF16Fromfloat32(real(c)), floatx.BF16Fromfloat32(imag(c))
+		if z.Real() != re || z.Imag() != im {
+			t.Errorf("CBF16Fromcomplex64(%v) = %v, wanted parts 0x%04x, 0x%04x", c, z, uint16(re), uint16(im))
+		}
+		if z != floatx.CBF16(re, im) {
+			t.Errorf("CBF16(%v, %v) = %v, wanted %v", re, im, floatx.CBF16(re, im), z)
+		}
+		if got := z.Complex64(); !sameComplex64(got, complex(re.Float32(), im.Float32())) {
+			t.Errorf("Complex64(%v) = %v", z, got)
+		}
+		if got := z.Conj(); got.Real() != re || uint16(got.Imag()) != uint16(im)^0x8000 {
+			t.Errorf("Conj(%v) = %v", z, got)
+		}
+		if got, want := float64(z.Abs()), cmplx.Abs(complex128(z.Complex64())); float32(want) != float32(got) && !math.IsNaN(want) {
+			t.Errorf("Abs(%v) = %v, wanted %v", z, got, want)
+		}
+
+		for _, d := range complexTestValues {
+			w := floatx.CBF16Fromcomplex64(d)
+			zc, wc := z.Complex64(), w.Complex64()
+			checkComplexBF16(t, "Add", z, w, z.Add(w), zc+wc)
+			checkComplexBF16(t, "Sub", z, w, z.Sub(w), zc-wc)
+			checkComplexBF16(t, "Mul", z, w, z.Mul(w), zc*wc)
+			checkComplexBF16(t, "Div", z, w, z.Div(w), zc/wc)
+		}
+	}
+
+	if s := floatx.CBF16(floatx.BF16Fromfloat32(-1), floatx.BF16Fromfloat32(0.5)).String(); s != "(-1+0.5i)" {
+		t.Errorf("String() = %s, wanted (-1+0.5i)", s)
+	}
+}
+
+func checkComplexBF16(t *testing.T, op string, z, w, got floatx.ComplexBF16, c complex64) {
+	t.Helper()
+	if want := floatx.CBF16Fromcomplex64(c); got != want {
+		t.Errorf("%v.%s(%v) = %v, wanted %v", z, op, w, got, want)
+	}
+}
+
+func sameComplex64(a, b complex64) bool {
+	return math.Float32bits(real(a)) == math.Float32bits(real(b)) &&
+		math.Float32bits(imag(a)) == math.Float32bits(imag(b))
+}
+
+func TestComplexSlices(t *testing.T) {
+	c32s := make([]floatx.Complex32, len(complexTestValues))
+	cbf16s := make([]floatx.ComplexBF16, len(complexTestValues)-1) // shorter dst limits the count
+
+	if n := floatx.C32FromComplex64s(c32s, complexTestValues); n != len(c32s) {
+		t.Errorf("C32FromComplex64s returned %d, wanted %d", n, len(c32s))
+	}
+	if n := floatx.CBF16FromComplex64s(cbf16s, complexTestValues); n != len(cbf16s) {
+		t.Errorf("CBF16FromComplex64s returned %d, wanted %d", n, len(cbf16s))
+	}
+	for i, c := range complexTestValues {
+		if c32s[i] != floatx.C32Fromcomplex64(c) {
+			t.Errorf("C32FromComplex64s i=%d got %v", i, c32s[i])
+		}
+		if i < len(cbf16s) && cbf16s[i] != floatx.CBF16Fromcomplex64(c) {
+			t.Errorf("CBF16FromComplex64s i=%d got %v", i, cbf16s[i])
+		}
+	}
+
+	dst := make([]complex64, len(c32s))
+	if n := floatx.C32ToComplex64s(dst, c32s); n != len(c32s) {
+		t.Errorf("C32ToComplex64s returned %d, wanted %d", n, len(c32s))
+	}
+	for i, c := range c32s {
+		if !sameComplex64(dst[i], c.Complex64()) {
+			t.Errorf("C32ToComplex64s i=%d got %v, wanted %v", i, dst[i], c.Complex64())
+		}
+	}
+	if n := floatx.C32ToComplex64s(dst[:2], c32s); n != 2 {
+		t.Errorf("C32ToComplex64s returned %d, wanted 2", n)
+	}
+	if n := floatx.CBF16ToComplex64s(dst, cbf16s); n != len(cbf16s) {
+		t.Errorf("CBF16ToComplex64s returned %d, wanted %d", n, len(cbf16s))
+	}
+	for i, c := range cbf16s {
+		if !sameComplex64(dst[i], c.Complex64()) {
+			t.Errorf("CBF16ToComplex64s i=%d got %v, wanted %v", i, dst[i], c.Complex64())
+		}
+	}
+	if n := floatx.CBF16ToComplex64s(dst[:1], cbf16s); n != 1 {
+		t.Errorf("CBF16ToComplex64s returned %d, wanted 1", n)
+	}
+	if n := floatx.C32FromComplex64s(c32s[:1], complexTestValues); n != 1 {
+		t.Errorf("C32FromComplex64s returned %d, wanted 1", n)
+	}
+}
+
+func TestComplexBinary(t *testing.T) {
+	z := floatx.C32(floatx.F16Fromfloat32(1), floatx.F16Fromfloat32(-2))
+	b, err := z.MarshalBinary()
+	if err != nil || !bytes.Equal(b, []byte{0x00, 0x3c, 0x00, 0xc0}) {
+		t.Errorf("MarshalBinary(%v) = % x, %v", z, b, err)
+	}
+	var z2 floatx.Complex32
+	if err := z2.UnmarshalBinary(b); err != nil || z2 != z {
+		t.Errorf("UnmarshalBinary(% x) = %v, %v, wanted %v", b, z2, err, z)
+	}
+	if err := z2.UnmarshalBinary(b[:3]); err != floatx.C32ErrInvalidLength {
+		t.Errorf("UnmarshalBinary of 3 bytes returned %v, wanted C32ErrInvalidLength", err)
+	}
+
+	// the memory layout is the binary encoding on little-endian machines
+	if unsafe.Sizeof(z) != 4 {
+		t.Errorf("Sizeof(Complex32) = %d, wanted 4", unsafe.Sizeof(z))
+	}
+	if binary.LittleEndian.Uint16(b[2:]) != uint16(z.Imag()) {
+		t.Errorf("imaginary part is not second")
+	}
+
+	w := floatx.CBF16(floatx.BF16Fromfloat32(1), floatx.BF16Fromfloat32(-2))
+	b, err = w.MarshalBinary()
+	if err != nil || !bytes.Equal(b, []byte{0x80, 0x3f, 0x00, 0xc0}) {
+		t.Errorf("MarshalBinary(%v) = % x, %v", w, b, err)
+	}
+	var w2 floatx.ComplexBF16
+	if err := w2.UnmarshalBinary(b); err != nil || w2 != w {
+		t.Errorf("UnmarshalBinary(% x) = %v, %v, wanted %v", b, w2, err, w)
+	}
+	if err := w2.UnmarshalBinary(nil); err == nil || err.Error() != floatx.CBF16ErrInvalidLength.Error() {
+		t.Errorf("UnmarshalBinary(nil) returned %v, wanted CBF16ErrInvalidLength", err)
+	}
+}
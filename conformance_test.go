@@ -0,0 +1,58 @@
+package floatx_test
+
+import (
+	"testing"
+
+	floatx "github.com/chenxingqiang/go-floatx"
+	"github.com/chenxingqiang/go-floatx/floatxtest"
+)
+
+// Check the conversions of every format and rounding mode against the
+// math/big.Float reference of floatxtest. The inputs are sampled unless
+// -exhaustive is set; sweeping every input of one conversion takes a few
+// minutes on one CPU, so use floatxtest.Config with Shards to run one.
+func TestConformance(t *testing.T) {
+	stride := uint32(sweepStride())
+
+	formats := []struct {
+		f       floatxtest.Format
+		convert func(c *floatx.Context, f32 float32) uint32
+	}{
+		{floatxtest.Float16, func(c *floatx.Context, f32 float32) uint32 { return uint32(c.F16Fromfloat32(f32)) }},
+		{floatxtest.BFloat16, func(c *floatx.Context, f32 float32) uint32 { return uint32(c.BF16Fromfloat32(f32)) }},
+		{floatxtest.Float8E5M2, func(c *floatx.Context, f32 float32) uint32 { return uint32(c.F8Fromfloat32(f32)) }},
+	}
+	for _, cf := range formats {
+		for _, mode := range roundingModes {
+			// a Context per call, since conversions run concurrently
+			err := floatxtest.CheckFromFloat32(cf.f, func(f32 float32) uint32 {
+				c := floatx.Context{Rounding: mode}
+				return cf.convert(&c, f32)
+			}, floatxtest.Config{Rounding: mode, Stride: stride})
+			if err != nil {
+				t.Error(err)
+			}
+		}
+	}
+	err := floatxtest.CheckFromFloat32(floatxtest.Float8E4M3FN, func(f32 float32) uint32 {
+		return uint32(floatx.F8E4M3Fromfloat32(f32))
+	}, floatxtest.Config{Stride: stride})
+	if err != nil {
+		t.Error(err)
+	}
+
+	decoders := []struct {
+		f      floatxtest.Format
+		decode func(u uint32) float32
+	}{
+		{floatxtest.Float16, func(u uint32) float32 { return floatx.F16Frombits(uint16(u)).Float32() }},
+		{floatxtest.BFloat16, func(u uint32) float32 { return floatx.BF16Frombits(uint16(u)).Float32() }},
+		{floatxtest.Float8E5M2, func(u uint32) float32 { return floatx.F8Frombits(uint8(u)).Float32() }},
+		{floatxtest.Float8E4M3FN, func(u uint32) float32 { return floatx.F8E4M3Frombits(uint8(u)).Float32() }},
+	}
+	for _, d := range decoders {
+		if err := floatxtest.CheckToFloat32(d.f, d.decode, floatxtest.Config{}); err != nil {
+			t.Error(err)
+		}
+	}
+}
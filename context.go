@@ -0,0 +1,409 @@
+package floatx
+
+import (
+	"math"
+	"strings"
+)
+
+// RoundingMode determines how a value is rounded when it can't be
+// represented exactly in the destination format.
+// The names and meanings match math/big.RoundingMode.
+type RoundingMode uint8
+
+const (
+	ToNearestEven RoundingMode = iota // == IEEE 754-2008 roundTiesToEven
+	ToNearestAway                     // == IEEE 754-2008 roundTiesToAway
+	ToZero                            // == IEEE 754-2008 roundTowardZero
+	AwayFromZero                      // no IEEE 754-2008 equivalent
+	ToNegativeInf                     // == IEEE 754-2008 roundTowardNegative
+	ToPositiveInf                     // == IEEE 754-2008 roundTowardPositive
+)
+
+var roundingModeNames = [...]string{
+	"ToNearestEven", "ToNearestAway", "ToZero", "AwayFromZero", "ToNegativeInf", "ToPositiveInf",
+}
+
+// String satisfies the fmt.Stringer interface.
+func (m RoundingMode) String() string {
+	if int(m) < len(roundingModeNames) {
+		return roundingModeNames[m]
+	}
+	return "RoundingMode(?)"
+}
+
+// Flags is a set of IEEE 754 exception flags.
+type Flags uint8
+
+const (
+	// FlagInexact is raised when a rounded result differs from the exact result.
+	FlagInexact Flags = 1 << iota
+
+	// FlagUnderflow is raised when a nonzero result is tiny (smaller than the
+	// smallest normal number before rounding) and inexact, or is flushed to zero.
+	FlagUnderflow
+
+	// FlagOverflow is raised when a finite result is too large for the format.
+	FlagOverflow
+
+	// FlagInvalid is raised for invalid operations, such as converting a
+	// signaling NaN.
+	FlagInvalid
+)
+
+var flagNames = [...]string{"inexact", "underflow", "overflow", "invalid"}
+
+// String satisfies the fmt.Stringer interface. Set flags are joined by "|".
+func (f Flags) String() string {
+	var names []string
+	for i, name := range flagNames {
+		if f&(1<<uint(i)) != 0 {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, "|")
+}
+
+// Context holds the rounding mode and subnormal handling used for
+// conversions, and the sticky exception flags raised by them.
+//
+// The zero Context uses IEEE default rounding with subnormals, so its
+// conversions give the same results as the package-level Fromfloat32
+// functions. Flags accumulate until they are cleared by the caller, so a
+// whole batch can be converted and checked once afterwards.
+//
+// FTZ and DAZ reproduce hardware that doesn't support subnormals.
+// Converting between formats with both, such as
+// c.BF16Fromfloat32(c.F16ToFloat32(f)), rounds only once.
+// A Context must not be used concurrently.
+type Context struct {
+	// Rounding is the rounding mode used for inexact results.
+	Rounding RoundingMode
+
+	// FTZ (flush-to-zero) replaces results that are tiny before rounding
+	// with zero of the same sign and raises FlagUnderflow and FlagInexact.
+	FTZ bool
+
+	// DAZ (denormals-are-zero) treats subnormal inputs as zero of the
+	// same sign. No flags are raised for them.
+	DAZ bool
+
+	// Flags are the sticky exception flags. Set flags are never cleared
+	// by conversions.
+	Flags Flags
+}
+
+// format describes the IEEE 754-style encodings of this package.
+type format struct {
+	expBits uint32
+	manBits uint32
+}
+
+var (
+	formatF16  = format{expBits: 5, manBits: 10}
+	formatBF16 = format{expBits: 8, manBits: 7}
+	formatF8   = format{expBits: 5, manBits: 2}
+)
+
+// F16Fromfloat32 returns a Float16 value converted from f32 using the
+// rounding mode and subnormal handling of c, and raises flags in c.
+func (c *Context) F16Fromfloat32(f32 float32) Float16 {
+	return Float16(c.fromF32bits(math.Float32bits(f32), formatF16))
+}
+
+// BF16Fromfloat32 returns a BFloat16 value converted from f32 using the
+// rounding mode and subnormal handling of c, and raises flags in c.
+func (c *Context) BF16Fromfloat32(f32 float32) BFloat16 {
+	return BFloat16(c.fromF32bits(math.Float32bits(f32), formatBF16))
+}
+
+// F8Fromfloat32 returns a Float8 value converted from f32 using the
+// rounding mode and subnormal handling of c, and raises flags in c.
+func (c *Context) F8Fromfloat32(f32 float32) Float8 {
+	return Float8(c.fromF32bits(math.Float32bits(f32), formatF8))
+}
+
+// F16FromFloat32s converts src into dst like F16Fromfloat32 and returns the
+// number of elements converted, which is the minimum of len(dst) and len(src).
+func (c *Context) F16FromFloat32s(dst []Float16, src []float32) int {
+	n := len(src)
+	if len(dst) < n {
+		n = len(dst)
+	}
+	for i, f32 := range src[:n] {
+		dst[i] = Float16(c.fromF32bits(math.Float32bits(f32), formatF16))
+	}
+	return n
+}
+
+// BF16FromFloat32s converts src into dst like BF16Fromfloat32 and returns the
+// number of elements converted, which is the minimum of len(dst) and len(src).
+func (c *Context) BF16FromFloat32s(dst []BFloat16, src []float32) int {
+	n := len(src)
+	if len(dst) < n {
+		n = len(dst)
+	}
+	for i, f32 := range src[:n] {
+		dst[i] = BFloat16(c.fromF32bits(math.Float32bits(f32), formatBF16))
+	}
+	return n
+}
+
+// F8FromFloat32s converts src into dst like F8Fromfloat32 and returns the
+// number of elements converted, which is the minimum of len(dst) and len(src).
+func (c *Context) F8FromFloat32s(dst []Float8, src []float32) int {
+	n := len(src)
+	if len(dst) < n {
+		n = len(dst)
+	}
+	for i, f32 := range src[:n] {
+		dst[i] = Float8(c.fromF32bits(math.Float32bits(f32), formatF8))
+	}
+	return n
+}
+
+// F16ToFloat32 returns a float32 converted from f, treating subnormal f as
+// zero if c.DAZ is set. Decoding a signaling NaN raises FlagInvalid.
+// Otherwise this is a lossless conversion like f.Float32().
+func (c *Context) F16ToFloat32(f Float16) float32 {
+	return math.Float32frombits(F16bitsToF32bits(c.decodeBits(uint16(f), formatF16)))
+}
+
+// BF16ToFloat32 returns a float32 converted from f, treating subnormal f as
+// zero if c.DAZ is set. Decoding a signaling NaN raises FlagInvalid.
+// Otherwise this is a lossless conversion like f.Float32().
+func (c *Context) BF16ToFloat32(f BFloat16) float32 {
+	return math.Float32frombits(BF16bitsToF32bits(c.decodeBits(uint16(f), formatBF16)))
+}
+
+// F8ToFloat32 returns a float32 converted from f, treating subnormal f as
+// zero if c.DAZ is set. Decoding a signaling NaN raises FlagInvalid.
+// Otherwise this is a lossless conversion like f.Float32().
+func (c *Context) F8ToFloat32(f Float8) float32 {
+	return math.Float32frombits(F8bitsToF32bits(uint8(c.decodeBits(uint16(f), formatF8))))
+}
+
+// F16ToFloat32s converts src into dst like F16ToFloat32 and returns the
+// number of elements converted, which is the minimum of len(dst) and len(src).
+func (c *Context) F16ToFloat32s(dst []float32, src []Float16) int {
+	n := len(src)
+	if len(dst) < n {
+		n = len(dst)
+	}
+	for i, f := range src[:n] {
+		dst[i] = c.F16ToFloat32(f)
+	}
+	return n
+}
+
+// BF16ToFloat32s converts src into dst like BF16ToFloat32 and returns the
+// number of elements converted, which is the minimum of len(dst) and len(src).
+func (c *Context) BF16ToFloat32s(dst []float32, src []BFloat16) int {
+	n := len(src)
+	if len(dst) < n {
+		n = len(dst)
+	}
+	for i, f := range src[:n] {
+		dst[i] = c.BF16ToFloat32(f)
+	}
+	return n
+}
+
+// F8ToFloat32s converts src into dst like F8ToFloat32 and returns the
+// number of elements converted, which is the minimum of len(dst) and len(src).
+func (c *Context) F8ToFloat32s(dst []float32, src []Float8) int {
+	n := len(src)
+	if len(dst) < n {
+		n = len(dst)
+	}
+	for i, f := range src[:n] {
+		dst[i] = c.F8ToFloat32(f)
+	}
+	return n
+}
+
+// F16PrecisionFromfloat32 returns the Precision of converting f32 with
+// c.F16Fromfloat32, without raising flags in c. Unlike the package-level
+// F16PrecisionFromfloat32 it performs the conversion, so it follows the
+// rounding mode, reports results flushed by FTZ as F16PrecisionUnderflow,
+// and never reports F16PrecisionUnknown. Overflow means a finite value
+// became infinity or the largest finite value and underflow means a nonzero
+// value became zero. Zero, subnormals flushed by DAZ, infinity and NaN
+// always report F16PrecisionExact.
+func (c *Context) F16PrecisionFromfloat32(f32 float32) F16Precision {
+	return c.precision(math.Float32bits(f32), formatF16)
+}
+
+// BF16PrecisionFromfloat32 returns the Precision of converting f32 with
+// c.BF16Fromfloat32, without raising flags in c.
+// See Context.F16PrecisionFromfloat32 for details.
+func (c *Context) BF16PrecisionFromfloat32(f32 float32) BF16Precision {
+	return BF16Precision(c.precision(math.Float32bits(f32), formatBF16))
+}
+
+// F8PrecisionFromfloat32 returns the Precision of converting f32 with
+// c.F8Fromfloat32, without raising flags in c.
+// See Context.F16PrecisionFromfloat32 for details.
+func (c *Context) F8PrecisionFromfloat32(f32 float32) F8Precision {
+	return F8Precision(c.precision(math.Float32bits(f32), formatF8))
+}
+
+// precision converts u32 (float32 bits) to fm with a copy of c and returns
+// the precision of the result. All Precision types share the same values.
+func (c *Context) precision(u32 uint32, fm format) F16Precision {
+	tmp := Context{Rounding: c.Rounding, FTZ: c.FTZ, DAZ: c.DAZ}
+	out := tmp.fromF32bits(u32, fm)
+
+	manMask := uint16(1)<<fm.manBits - 1
+	absMask := manMask | (uint16(1)<<fm.expBits-1)<<fm.manBits
+
+	switch {
+	case tmp.Flags&FlagOverflow != 0:
+		return F16PrecisionOverflow
+	case tmp.Flags&FlagInexact == 0:
+		return F16PrecisionExact
+	case out&absMask == 0:
+		return F16PrecisionUnderflow
+	}
+	return F16PrecisionInexact
+}
+
+// decodeBits returns u16 (fm bits) with subnormals replaced by zero of the
+// same sign if c.DAZ is set, and raises FlagInvalid for signaling NaN.
+func (c *Context) decodeBits(u16 uint16, fm format) uint16 {
+	manMask := uint16(1)<<fm.manBits - 1
+	expMask := (uint16(1)<<fm.expBits - 1) << fm.manBits
+	quietBit := uint16(1) << (fm.manBits - 1)
+
+	switch u16 & expMask {
+	case expMask:
+		if u16&manMask != 0 && u16&quietBit == 0 {
+			c.Flags |= FlagInvalid
+		}
+	case 0:
+		if c.DAZ {
+			return u16 &^ (expMask | manMask)
+		}
+	}
+	return u16
+}
+
+// fromF32bits returns the bits of u32 (float32 bits) rounded to fm
+// using the settings of c, and raises flags in c.
+func (c *Context) fromF32bits(u32 uint32, fm format) uint16 {
+	bias := int32(1)<<(fm.expBits-1) - 1
+	infBits := (uint32(1)<<fm.expBits - 1) << fm.manBits
+	quietBit := uint32(1) << (fm.manBits - 1)
+
+	neg := u32&0x80000000 != 0
+	sign := (u32 & 0x80000000) >> (31 - fm.expBits - fm.manBits)
+	exp := int32(u32>>23) & 0xff
+	coef := u32 & 0x007fffff
+
+	if exp == 0xff {
+		if coef == 0 {
+			// infinity
+			return uint16(sign | infBits)
+		}
+		// NaN, converting a signaling NaN is invalid
+		if coef&0x00400000 == 0 {
+			c.Flags |= FlagInvalid
+		}
+		return uint16(sign | infBits | quietBit | (coef >> (23 - fm.manBits)))
+	}
+
+	if exp == 0 && (coef == 0 || c.DAZ) {
+		// zero, or subnormal treated as zero
+		return uint16(sign)
+	}
+
+	// value is sig * 2^(e-23)
+	sig := coef
+	e := int32(-126)
+	if exp != 0 {
+		sig |= 0x00800000
+		e = exp - 127
+	}
+
+	// smallest normal exponent of fm
+	emin := 1 - bias
+	// float32 subnormals are tiny even when fm has the float32 exponent range
+	tiny := e < emin || exp == 0
+	if tiny && c.FTZ {
+		c.Flags |= FlagUnderflow | FlagInexact
+		return uint16(sign)
+	}
+
+	// number of significand bits to drop
+	q := e
+	if tiny {
+		q = emin
+	}
+	shift := uint32(q - int32(fm.manBits) - (e - 23))
+	if shift > 26 {
+		// sig < 2^24, so dropping more bits can't change the rounding
+		shift = 26
+	}
+
+	kept := sig >> shift
+	rem := sig & (uint32(1)<<shift - 1)
+	half := uint32(1) << (shift - 1)
+
+	if rem != 0 {
+		c.Flags |= FlagInexact
+		if tiny {
+			c.Flags |= FlagUnderflow
+		}
+		if roundUp(c.Rounding, neg, kept&1 != 0, rem, half) {
+			kept++
+		}
+	}
+
+	// kept includes the implicit bit for normals, so a carry out of the
+	// significand increments the exponent
+	bits := kept
+	if !tiny {
+		bits = uint32(e+bias)<<fm.manBits + kept - uint32(1)<<fm.manBits
+	}
+
+	if bits >= infBits {
+		c.Flags |= FlagOverflow | FlagInexact
+		if overflowToInf(c.Rounding, neg) {
+			return uint16(sign | infBits)
+		}
+		return uint16(sign | (infBits - 1))
+	}
+
+	return uint16(sign | bits)
+}
+
+// roundUp reports whether a truncated magnitude with nonzero remainder rem
+// should be incremented. half is the remainder of an exact tie.
+func roundUp(mode RoundingMode, neg, odd bool, rem, half uint32) bool {
+	switch mode {
+	case ToNearestAway:
+		return rem >= half
+	case ToZero:
+		return false
+	case AwayFromZero:
+		return true
+	case ToNegativeInf:
+		return neg
+	case ToPositiveInf:
+		return !neg
+	}
+	return rem > half || (rem == half && odd)
+}
+
+// overflowToInf reports whether an overflow rounds to infinity
+// rather than to the largest finite value.
+func overflowToInf(mode RoundingMode, neg bool) bool {
+	switch mode {
+	case ToZero:
+		return false
+	case ToNegativeInf:
+		return neg
+	case ToPositiveInf:
+		return !neg
+	}
+	return true
+}
@@ -0,0 +1,574 @@
+package floatx_test
+
+import (
+	"flag"
+	"math"
+	"testing"
+
+	floatx "github.com/chenxingqiang/go-floatx"
+)
+
+var exhaustive = flag.Bool("exhaustive", false, "check every float32 input in sweep tests, which takes minutes per sweep")
+
+// sweepStride returns the distance between the float32 inputs checked by
+// sweep tests. Sampling keeps go test within its default timeout; run with
+// -exhaustive and a larger -timeout to check all 4294967296 inputs.
+func sweepStride() uint64 {
+	switch {
+	case *exhaustive:
+		return 1
+	case testing.Short():
+		return 65521
+	}
+	return 4099
+}
+
+var roundingModes = []floatx.RoundingMode{
+	floatx.ToNearestEven,
+	floatx.ToNearestAway,
+	floatx.ToZero,
+	floatx.AwayFromZero,
+	floatx.ToNegativeInf,
+	floatx.ToPositiveInf,
+}
+
+// ctxFormat describes a format for checking Context conversions against
+// the package-level conversions, which are verified for all inputs.
+type ctxFormat struct {
+	name      string
+	convert   func(c *floatx.Context, f32 float32) uint16
+	nearest   func(f32 float32) uint16
+	precision func(c *floatx.Context, f32 float32) floatx.F16Precision
+	decode    func(u16 uint16) float64
+	signBit   uint16
+	maxBits   uint16  // largest finite magnitude
+	minNorm   float64 // smallest normal
+	overflow  float64 // 2^(emax+1), the first magnitude past the largest finite
+}
+
+var ctxFormats = []ctxFormat{
+	{
+		name:    "Float16",
+		convert: func(c *floatx.Context, f32 float32) uint16 { return uint16(c.F16Fromfloat32(f32)) },
+		nearest: func(f32 float32) uint16 { return uint16(floatx.F16Fromfloat32(f32)) },
+		precision: func(c *floatx.Context, f32 float32) floatx.F16Precision {
+			return c.F16PrecisionFromfloat32(f32)
+		},
+		decode:   func(u16 uint16) float64 { return float64(floatx.Float16(u16).Float32()) },
+		signBit:  0x8000,
+		maxBits:  0x7bff,
+		minNorm:  0x1p-14,
+		overflow: 0x1p16,
+	},
+	{
+		name:    "BFloat16",
+		convert: func(c *floatx.Context, f32 float32) uint16 { return uint16(c.BF16Fromfloat32(f32)) },
+		nearest: func(f32 float32) uint16 { return uint16(floatx.BF16Fromfloat32(f32)) },
+		precision: func(c *floatx.Context, f32 float32) floatx.F16Precision {
+			return floatx.F16Precision(c.BF16PrecisionFromfloat32(f32))
+		},
+		decode:   func(u16 uint16) float64 { return float64(floatx.BFloat16(u16).Float32()) },
+		signBit:  0x8000,
+		maxBits:  0x7f7f,
+		minNorm:  0x1p-126,
+		overflow: 0x1p128,
+	},
+	{
+		name:    "Float8",
+		convert: func(c *floatx.Context, f32 float32) uint16 { return uint16(c.F8Fromfloat32(f32)) },
+		nearest: func(f32 float32) uint16 { return uint16(floatx.F8Fromfloat32(f32)) },
+		precision: func(c *floatx.Context, f32 float32) floatx.F16Precision {
+			return floatx.F16Precision(c.F8PrecisionFromfloat32(f32))
+		},
+		decode:   func(u16 uint16) float64 { return float64(floatx.Float8(uint8(u16)).Float32()) },
+		signBit:  0x80,
+		maxBits:  0x7b,
+		minNorm:  0x1p-14,
+		overflow: 0x1p16,
+	},
+}
+
+// ordered maps bits to an integer with the same ordering as the values.
+func (cf ctxFormat) ordered(u16 uint16) int32 {
+	if u16&cf.signBit != 0 {
+		return -int32(u16 &^ cf.signBit)
+	}
+	return int32(u16)
+}
+
+// fromOrdered is the inverse of ordered, with +0 for 0.
+func (cf ctxFormat) fromOrdered(o int32) uint16 {
+	if o < 0 {
+		return cf.signBit | uint16(-o)
+	}
+	return uint16(o)
+}
+
+// want returns the value expected from rounding finite x with mode
+// and whether overflow is expected.
+func (cf ctxFormat) want(x float64, mode floatx.RoundingMode) (float64, bool) {
+	neg := x < 0
+	ax := math.Abs(x)
+	largest := cf.decode(cf.maxBits)
+
+	if ax >= cf.overflow {
+		toInf := mode != floatx.ToZero &&
+			!(mode == floatx.ToNegativeInf && !neg) &&
+			!(mode == floatx.ToPositiveInf && neg)
+		if toInf {
+			return math.Copysign(math.Inf(1), x), true
+		}
+		return math.Copysign(largest, x), true
+	}
+
+	// r0 is correctly rounded, so x is between it and a neighbour
+	r0 := cf.nearest(float32(x))
+	v0 := cf.decode(r0)
+	if math.IsInf(v0, 0) {
+		v0 = math.Copysign(cf.overflow, x)
+	}
+	if v0 == x {
+		return x, false
+	}
+	o := cf.ordered(r0)
+	var lo, hi float64
+	if v0 < x {
+		lo, hi = v0, cf.decode(cf.fromOrdered(o+1))
+	} else {
+		lo, hi = cf.decode(cf.fromOrdered(o-1)), v0
+	}
+	// the neighbour past the largest finite is 2^(emax+1) when rounding
+	if math.IsInf(lo, -1) || lo < -largest {
+		lo = -cf.overflow
+	}
+	if math.IsInf(hi, 1) || hi > largest {
+		hi = cf.overflow
+	}
+
+	var got float64
+	switch mode {
+	case floatx.ToNearestEven:
+		got = cf.decode(r0)
+		if math.IsInf(got, 0) {
+			got = math.Copysign(cf.overflow, x)
+		}
+	case floatx.ToNearestAway:
+		dlo, dhi := x-lo, hi-x
+		switch {
+		case dlo < dhi:
+			got = lo
+		case dhi < dlo:
+			got = hi
+		case neg:
+			got = lo
+		default:
+			got = hi
+		}
+	case floatx.ToZero:
+		got = hi
+		if !neg {
+			got = lo
+		}
+	case floatx.AwayFromZero:
+		got = lo
+		if !neg {
+			got = hi
+		}
+	case floatx.ToNegativeInf:
+		got = lo
+	case floatx.ToPositiveInf:
+		got = hi
+	}
+
+	if math.Abs(got) == cf.overflow {
+		return math.Copysign(math.Inf(1), x), true
+	}
+	return got, false
+}
+
+// checkContext converts f32 with every rounding mode and checks the
+// result and the raised flags.
+func checkContext(t *testing.T, cf ctxFormat, f32 float32) {
+	t.Helper()
+
+	x := float64(f32)
+	for _, mode := range roundingModes {
+		c := floatx.Context{Rounding: mode}
+		u16 := cf.convert(&c, f32)
+		got := cf.decode(u16)
+
+		if math.IsNaN(x) {
+			if !math.IsNaN(got) {
+				t.Errorf("%s %v: in f32bits=0x%08x, got 0x%04x, wanted NaN", cf.name, mode, math.Float32bits(f32), u16)
+			}
+			continue
+		}
+
+		if (u16&cf.signBit != 0) != math.Signbit(x) {
+			t.Errorf("%s %v: in f32bits=0x%08x, got 0x%04x with wrong sign", cf.name, mode, math.Float32bits(f32), u16)
+		}
+
+		if math.IsInf(x, 0) {
+			if got != x || c.Flags != 0 {
+				t.Errorf("%s %v: in %v, got %v flags %v, wanted %v flags 0", cf.name, mode, x, got, c.Flags, x)
+			}
+			continue
+		}
+
+		want, overflow := cf.want(x, mode)
+		if got != want {
+			t.Errorf("%s %v: in f32bits=0x%08x, got 0x%04x (%v), wanted %v", cf.name, mode, math.Float32bits(f32), u16, got, want)
+		}
+
+		var wantFlags floatx.Flags
+		if want != x {
+			wantFlags |= floatx.FlagInexact
+			if math.Abs(x) < cf.minNorm {
+				wantFlags |= floatx.FlagUnderflow
+			}
+		}
+		if overflow {
+			wantFlags |= floatx.FlagOverflow
+		}
+		if c.Flags != wantFlags {
+			t.Errorf("%s %v: in f32bits=0x%08x, got flags %v, wanted %v", cf.name, mode, math.Float32bits(f32), c.Flags, wantFlags)
+		}
+	}
+}
+
+func contextTestInputs() []float32 {
+	var in []float32
+	for _, v := range wantF32toF16bits {
+		in = append(in, v.in)
+	}
+	for _, v := range wantF32toBF16bits {
+		in = append(in, v.in)
+	}
+	for _, v := range wantF32toF8bits {
+		in = append(in, v.in)
+	}
+	return in
+}
+
+func TestContextSomeFromFloat32(t *testing.T) {
+	for _, cf := range ctxFormats {
+		for _, f32 := range contextTestInputs() {
+			checkContext(t, cf, f32)
+		}
+
+		// sparse sweep of all float32 values
+		for i := uint64(0); i <= 0xffffffff; i += sweepStride() {
+			checkContext(t, cf, math.Float32frombits(uint32(i)))
+		}
+	}
+}
+
+// Test float32 input values with the zero Context match the package-level
+// conversions, for all 4294967296 inputs with -exhaustive.
+func TestContextAllFromFloat32(t *testing.T) {
+	var c floatx.Context
+	for _, cf := range ctxFormats {
+		for i := uint64(0); i <= 0xffffffff; i += sweepStride() {
+			f32 := math.Float32frombits(uint32(i))
+			if got, want := cf.convert(&c, f32), cf.nearest(f32); got != want {
+				t.Fatalf("%s: in f32bits=0x%08x, got 0x%04x, wanted 0x%04x", cf.name, uint32(i), got, want)
+			}
+		}
+	}
+}
+
+func TestContextFlags(t *testing.T) {
+	testCases := []struct {
+		name  string
+		ctx   floatx.Context
+		in    float32
+		out   uint16
+		flags floatx.Flags
+	}{
+		{"exact", floatx.Context{}, 1.5, 0x3e00, 0},
+		{"inexact", floatx.Context{}, math.Pi, 0x4248, floatx.FlagInexact},
+		{"overflow", floatx.Context{}, 65520, 0x7c00, floatx.FlagOverflow | floatx.FlagInexact},
+		{"overflow to max", floatx.Context{Rounding: floatx.ToZero}, 1e6, 0x7bff, floatx.FlagOverflow | floatx.FlagInexact},
+		{"no overflow", floatx.Context{Rounding: floatx.ToZero}, 65535, 0x7bff, floatx.FlagInexact},
+		{"underflow", floatx.Context{}, 1e-8, 0x0000, floatx.FlagUnderflow | floatx.FlagInexact},
+		{"exact subnormal", floatx.Context{}, 0x1p-24, 0x0001, 0},
+		{"rounds to normal", floatx.Context{}, 0x1p-14 - 0x1p-26, 0x0400, floatx.FlagUnderflow | floatx.FlagInexact},
+		{"snan", floatx.Context{}, math.Float32frombits(0x7f800001), 0x7e00, floatx.FlagInvalid},
+		{"qnan", floatx.Context{}, float32(math.NaN()), 0x7e00, 0},
+		{"inf", floatx.Context{}, float32(math.Inf(-1)), 0xfc00, 0},
+		{"sticky", floatx.Context{Flags: floatx.FlagInvalid}, 1, 0x3c00, floatx.FlagInvalid},
+		{"ftz", floatx.Context{FTZ: true}, 0x1p-24, 0x0000, floatx.FlagUnderflow | floatx.FlagInexact},
+		{"ftz negative", floatx.Context{FTZ: true}, -0x1p-20, 0x8000, floatx.FlagUnderflow | floatx.FlagInexact},
+		{"ftz normal", floatx.Context{FTZ: true}, 0x1p-14, 0x0400, 0},
+		{"daz", floatx.Context{DAZ: true, Rounding: floatx.ToPositiveInf}, math.Float32frombits(0x00000001), 0x0000, 0},
+		{"daz negative", floatx.Context{DAZ: true}, math.Float32frombits(0x80400000), 0x8000, 0},
+		{"no daz", floatx.Context{Rounding: floatx.ToPositiveInf}, math.Float32frombits(0x00000001), 0x0001, floatx.FlagUnderflow | floatx.FlagInexact},
+	}
+	for _, tc := range testCases {
+		c := tc.ctx
+		got := c.F16Fromfloat32(tc.in)
+		if uint16(got) != tc.out || c.Flags != tc.flags {
+			t.Errorf("%s: F16Fromfloat32(%v) = 0x%04x flags %v, wanted 0x%04x flags %v", tc.name, tc.in, uint16(got), c.Flags, tc.out, tc.flags)
+		}
+	}
+}
+
+func TestContextFromFloat32s(t *testing.T) {
+	src := []float32{1, 0.1, 1e10, float32(math.NaN())}
+
+	c := floatx.Context{Rounding: floatx.ToZero}
+	f16s := make([]floatx.Float16, len(src)-1)
+	if n := c.F16FromFloat32s(f16s, src); n != len(f16s) {
+		t.Errorf("F16FromFloat32s returned %d, wanted %d", n, len(f16s))
+	}
+	if f16s[2] != 0x7bff {
+		t.Errorf("F16FromFloat32s got 0x%04x, wanted 0x7bff", uint16(f16s[2]))
+	}
+	if want := floatx.FlagInexact | floatx.FlagOverflow; c.Flags != want {
+		t.Errorf("F16FromFloat32s flags %v, wanted %v", c.Flags, want)
+	}
+
+	c = floatx.Context{}
+	bf16s := make([]floatx.BFloat16, 2)
+	if n := c.BF16FromFloat32s(bf16s, src); n != 2 {
+		t.Errorf("BF16FromFloat32s returned %d, wanted 2", n)
+	}
+	if bf16s[0] != 0x3f80 || c.Flags != floatx.FlagInexact {
+		t.Errorf("BF16FromFloat32s got 0x%04x flags %v", uint16(bf16s[0]), c.Flags)
+	}
+
+	c = floatx.Context{Rounding: floatx.ToPositiveInf}
+	f8s := make([]floatx.Float8, 1)
+	if n := c.F8FromFloat32s(f8s, src); n != 1 {
+		t.Errorf("F8FromFloat32s returned %d, wanted 1", n)
+	}
+	if f8s[0] != 0x3c || c.Flags != 0 {
+		t.Errorf("F8FromFloat32s got 0x%02x flags %v", uint8(f8s[0]), c.Flags)
+	}
+}
+
+func TestRoundingModeString(t *testing.T) {
+	if s := floatx.ToNegativeInf.String(); s != "ToNegativeInf" {
+		t.Errorf("ToNegativeInf.String() = %q", s)
+	}
+	if s := floatx.RoundingMode(99).String(); s != "RoundingMode(?)" {
+		t.Errorf("RoundingMode(99).String() = %q", s)
+	}
+}
+
+func TestFlagsString(t *testing.T) {
+	if s := floatx.Flags(0).String(); s != "" {
+		t.Errorf("Flags(0).String() = %q", s)
+	}
+	if s := (floatx.FlagInexact | floatx.FlagOverflow | floatx.FlagInvalid).String(); s != "inexact|overflow|invalid" {
+		t.Errorf("Flags.String() = %q", s)
+	}
+}
+
+// checkFTZ converts f32 with FTZ and the DAZ settings and checks the result
+// against the same conversion without them.
+func checkFTZ(t *testing.T, cf ctxFormat, f32 float32, mode floatx.RoundingMode, dazs ...bool) {
+	t.Helper()
+
+	u32 := math.Float32bits(f32)
+	x := float64(f32)
+	subnormal32 := u32&0x7f800000 == 0 && u32&0x007fffff != 0
+	tiny := x != 0 && math.Abs(x) < cf.minNorm
+
+	for _, daz := range dazs {
+		plain := floatx.Context{Rounding: mode}
+		want := cf.convert(&plain, f32)
+		wantFlags := plain.Flags
+		wantPcn := floatx.F16PrecisionExact
+		if (daz && subnormal32) || tiny {
+			want = 0
+			if math.Signbit(x) {
+				want = cf.signBit
+			}
+			wantFlags = 0
+			if !(daz && subnormal32) {
+				wantFlags = floatx.FlagUnderflow | floatx.FlagInexact
+				wantPcn = floatx.F16PrecisionUnderflow
+			}
+		}
+
+		c := floatx.Context{Rounding: mode, FTZ: true, DAZ: daz}
+		got := cf.convert(&c, f32)
+		if got != want && !math.IsNaN(x) {
+			t.Errorf("%s %v FTZ DAZ=%v: in f32bits=0x%08x, got 0x%04x, wanted 0x%04x", cf.name, mode, daz, u32, got, want)
+		}
+		if c.Flags != wantFlags {
+			t.Errorf("%s %v FTZ DAZ=%v: in f32bits=0x%08x, got flags %v, wanted %v", cf.name, mode, daz, u32, c.Flags, wantFlags)
+		}
+
+		if (daz && subnormal32) || tiny {
+			if pcn := cf.precision(&c, f32); pcn != wantPcn {
+				t.Errorf("%s %v FTZ DAZ=%v: in f32bits=0x%08x, got precision %d, wanted %d", cf.name, mode, daz, u32, pcn, wantPcn)
+			}
+		}
+	}
+}
+
+func TestContextSomeFTZ(t *testing.T) {
+	for _, cf := range ctxFormats {
+		for _, mode := range roundingModes {
+			for _, f32 := range contextTestInputs() {
+				checkFTZ(t, cf, f32, mode, false, true)
+			}
+			for i := uint64(0); i <= 0xffffffff; i += 65521 {
+				checkFTZ(t, cf, math.Float32frombits(uint32(i)), mode, false, true)
+			}
+		}
+	}
+}
+
+// Test float32 input values with FTZ and DAZ, all 4294967296 of them with
+// -exhaustive. Other rounding modes, FTZ without DAZ, flags of results that
+// aren't flushed, and precision are checked by TestContextSomeFTZ.
+func TestContextAllFTZ(t *testing.T) {
+	for i := uint64(0); i <= 0xffffffff; i += sweepStride() {
+		f32 := math.Float32frombits(uint32(i))
+
+		c := floatx.Context{FTZ: true, DAZ: true}
+		got := uint16(c.F16Fromfloat32(f32))
+		checkAllFTZ(t, "Float16", f32, got, uint16(floatx.F16Fromfloat32(f32)), 0x8000, 0x1p-14, c.Flags)
+
+		c = floatx.Context{FTZ: true, DAZ: true}
+		got = uint16(c.BF16Fromfloat32(f32))
+		checkAllFTZ(t, "BFloat16", f32, got, uint16(floatx.BF16Fromfloat32(f32)), 0x8000, 0x1p-126, c.Flags)
+
+		c = floatx.Context{FTZ: true, DAZ: true}
+		got = uint16(c.F8Fromfloat32(f32))
+		checkAllFTZ(t, "Float8", f32, got, uint16(floatx.F8Fromfloat32(f32)), 0x80, 0x1p-14, c.Flags)
+
+		if t.Failed() {
+			t.FailNow()
+		}
+	}
+}
+
+// checkAllFTZ checks got (converted from f32 with FTZ and DAZ) against want
+// (converted from f32 without them).
+func checkAllFTZ(t *testing.T, name string, f32 float32, got, want, signBit uint16, minNorm float32, flags floatx.Flags) {
+	u32 := math.Float32bits(f32)
+	wantFlags := flags
+	if u32&0x7f800000 == 0 && u32&0x007fffff != 0 {
+		// subnormal input treated as zero
+		want, wantFlags = uint16(u32>>31)*signBit, 0
+	} else if f32 != 0 && f32 > -minNorm && f32 < minNorm {
+		want, wantFlags = uint16(u32>>31)*signBit, floatx.FlagUnderflow|floatx.FlagInexact
+	} else if f32 != f32 {
+		// NaN
+		return
+	}
+	if got != want || flags != wantFlags {
+		t.Errorf("%s FTZ DAZ: in f32bits=0x%08x, got 0x%04x flags %v, wanted 0x%04x flags %v", name, u32, got, flags, want, wantFlags)
+	}
+}
+
+func TestContextPrecisionFromfloat32(t *testing.T) {
+	for _, cf := range ctxFormats {
+		for _, mode := range roundingModes {
+			for i := uint64(0); i <= 0xffffffff; i += 65521 {
+				f32 := math.Float32frombits(uint32(i))
+				c := floatx.Context{Rounding: mode}
+				out := cf.convert(&c, f32)
+				pcn := cf.precision(&c, f32)
+
+				x := float64(f32)
+				got := cf.decode(out)
+				var want floatx.F16Precision
+				switch {
+				case math.IsNaN(x) || math.IsInf(x, 0) || got == x:
+					want = floatx.F16PrecisionExact
+				case c.Flags&floatx.FlagOverflow != 0:
+					want = floatx.F16PrecisionOverflow
+				case got == 0:
+					want = floatx.F16PrecisionUnderflow
+				default:
+					want = floatx.F16PrecisionInexact
+				}
+				if pcn != want {
+					t.Errorf("%s %v: in f32bits=0x%08x, got precision %d, wanted %d", cf.name, mode, uint32(i), pcn, want)
+				}
+			}
+		}
+	}
+
+	// flags are not raised
+	c := floatx.Context{}
+	if pcn := c.F16PrecisionFromfloat32(1e10); pcn != floatx.F16PrecisionOverflow || c.Flags != 0 {
+		t.Errorf("F16PrecisionFromfloat32(1e10) = %d, flags %v", pcn, c.Flags)
+	}
+}
+
+// checkDAZDecode checks decoding u16 with and without DAZ.
+func checkDAZDecode(t *testing.T, cf ctxFormat, u16 uint16, decode func(c *floatx.Context, u16 uint16) float32, subnormal, snan bool) {
+	t.Helper()
+
+	for _, daz := range []bool{false, true} {
+		c := floatx.Context{DAZ: daz}
+		got := math.Float32bits(decode(&c, u16))
+		want := math.Float32bits(float32(cf.decode(u16)))
+		if daz && subnormal {
+			want &= 0x80000000
+		}
+		if got != want {
+			t.Errorf("%s DAZ=%v: decode 0x%04x got 0x%08x, wanted 0x%08x", cf.name, daz, u16, got, want)
+		}
+		if (c.Flags == floatx.FlagInvalid) != snan || c.Flags&^floatx.FlagInvalid != 0 {
+			t.Errorf("%s DAZ=%v: decode 0x%04x got flags %v", cf.name, daz, u16, c.Flags)
+		}
+	}
+}
+
+func TestContextAllToFloat32(t *testing.T) {
+	for i := 0; i < 65536; i++ {
+		u16 := uint16(i)
+
+		f16 := floatx.Float16(u16)
+		checkDAZDecode(t, ctxFormats[0], u16,
+			func(c *floatx.Context, u16 uint16) float32 { return c.F16ToFloat32(floatx.Float16(u16)) },
+			u16&0x7c00 == 0 && u16&0x03ff != 0, f16.IsNaN() && !f16.IsQuietNaN())
+
+		bf16 := floatx.BFloat16(u16)
+		checkDAZDecode(t, ctxFormats[1], u16,
+			func(c *floatx.Context, u16 uint16) float32 { return c.BF16ToFloat32(floatx.BFloat16(u16)) },
+			u16&0x7f80 == 0 && u16&0x007f != 0, bf16.IsNaN() && !bf16.IsQuietNaN())
+	}
+	for i := 0; i < 256; i++ {
+		u16 := uint16(i)
+		f8 := floatx.Float8(u16)
+		checkDAZDecode(t, ctxFormats[2], u16,
+			func(c *floatx.Context, u16 uint16) float32 { return c.F8ToFloat32(floatx.Float8(u16)) },
+			u16&0x7c == 0 && u16&0x03 != 0, f8.IsNaN() && !f8.IsQuietNaN())
+	}
+}
+
+func TestContextToFloat32s(t *testing.T) {
+	c := floatx.Context{DAZ: true}
+	dst := make([]float32, 3)
+
+	if n := c.F16ToFloat32s(dst, []floatx.Float16{0x3c00, 0x8001, 0x7c01, 0x0000}); n != 3 {
+		t.Errorf("F16ToFloat32s returned %d, wanted 3", n)
+	}
+	if dst[0] != 1 || math.Float32bits(dst[1]) != 0x80000000 || c.Flags != floatx.FlagInvalid {
+		t.Errorf("F16ToFloat32s got %v flags %v", dst, c.Flags)
+	}
+
+	c = floatx.Context{DAZ: true}
+	if n := c.BF16ToFloat32s(dst[:2], []floatx.BFloat16{0x3f80, 0x0001, 0x3f80}); n != 2 {
+		t.Errorf("BF16ToFloat32s returned %d, wanted 2", n)
+	}
+	if dst[0] != 1 || dst[1] != 0 || c.Flags != 0 {
+		t.Errorf("BF16ToFloat32s got %v flags %v", dst, c.Flags)
+	}
+
+	c = floatx.Context{}
+	if n := c.F8ToFloat32s(dst, []floatx.Float8{0x3c, 0x01, 0x7f, 0x00}); n != 3 {
+		t.Errorf("F8ToFloat32s returned %d, wanted 3", n)
+	}
+	if dst[0] != 1 || dst[1] != 0x1p-16 || !math.IsNaN(float64(dst[2])) || c.Flags != 0 {
+		t.Errorf("F8ToFloat32s got %v flags %v", dst, c.Flags)
+	}
+}
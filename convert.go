@@ -0,0 +1,257 @@
+package floatx
+
+import "math"
+
+// Direct conversions between the formats in this package.
+//
+// Every format widens to float32 exactly, so each conversion below rounds
+// only once and gives the same result as FromFloat32[To](f.Float32()).
+// Like Fromfloat32, conversions use IEEE default rounding (nearest int,
+// with ties to even) and NaN results always have the quiet bit set.
+//
+// Rounding in steps is not the same as rounding once. Narrowing
+// float32->Float16->Float8 can round twice and differ from
+// float32->Float8. For example, float32 1.1252441 (1.125 + 2^-12) becomes
+// Float8 1.25 directly, but Float16 1.125 is a tie that rounds to Float8 1.
+// The same happens with Float8E4M3FN: float32 1.0627441 (1 + 2^-4 + 2^-12)
+// becomes Float8E4M3FN 1.125 directly, but Float16 1.0625 is a tie that
+// rounds to Float8E4M3FN 1. Float16.ToFloat8E4M3 rounds the Float16 value
+// once, so it matches float32->Float8E4M3FN only when the Float16 is exact.
+
+// ToBFloat16 returns a BFloat16 converted from f (Float16).
+// Float16 subnormals are normal in bfloat16, so results can be inexact
+// but never overflow or underflow. Use BF16PrecisionFromFloat16 to check.
+func (f Float16) ToBFloat16() BFloat16 {
+	return BFloat16(f32bitsToBF16bits(F16bitsToF32bits(uint16(f))))
+}
+
+// ToFloat8 returns a Float8 converted from f (Float16).
+// Use F8PrecisionFromFloat16 to check for dropped bits, overflow, or underflow.
+func (f Float16) ToFloat8() Float8 {
+	return Float8(f16bitsToF8bits(uint16(f)))
+}
+
+// ToFloat16 returns a Float16 converted from f (BFloat16).
+// Use F16PrecisionFromBFloat16 to check for dropped bits, overflow, or underflow.
+func (f BFloat16) ToFloat16() Float16 {
+	return Float16(f32bitsToF16bits(uint32(f) << 16))
+}
+
+// ToFloat8 returns a Float8 converted from f (BFloat16).
+// Use F8PrecisionFromBFloat16 to check for dropped bits, overflow, or underflow.
+func (f BFloat16) ToFloat8() Float8 {
+	return Float8(f32bitsToF8bits(uint32(f) << 16))
+}
+
+// ToFloat16 returns a Float16 converted from f (Float8).
+// This is a lossless conversion.
+func (f Float8) ToFloat16() Float16 {
+	u16 := uint16(f) << 8
+	if f.IsNaN() {
+		u16 |= 0x0200
+	}
+	return Float16(u16)
+}
+
+// ToBFloat16 returns a BFloat16 converted from f (Float8).
+// This is a lossless conversion.
+func (f Float8) ToBFloat16() BFloat16 {
+	return BFloat16(F8bitsToF32bits(uint8(f)) >> 16)
+}
+
+// ToFloat8E4M3 returns a Float8E4M3FN converted from f (Float16).
+// Use F8E4M3PrecisionFromFloat16 to check for dropped bits, overflow, or underflow.
+func (f Float16) ToFloat8E4M3() Float8E4M3FN {
+	return Float8E4M3FN(f32bitsToF8E4M3bits(F16bitsToF32bits(uint16(f))))
+}
+
+// ToFloat8E4M3 returns a Float8E4M3FN converted from f (BFloat16).
+// Use F8E4M3PrecisionFromBFloat16 to check for dropped bits, overflow, or underflow.
+func (f BFloat16) ToFloat8E4M3() Float8E4M3FN {
+	return Float8E4M3FN(f32bitsToF8E4M3bits(uint32(f) << 16))
+}
+
+// ToFloat8E4M3 returns a Float8E4M3FN converted from f (Float8).
+// Use F8E4M3PrecisionFromFloat8 to check for dropped bits, overflow, or underflow.
+func (f Float8) ToFloat8E4M3() Float8E4M3FN {
+	return Float8E4M3FN(f32bitsToF8E4M3bits(F8bitsToF32bits(uint8(f))))
+}
+
+// ToFloat16 returns a Float16 converted from f (Float8E4M3FN).
+// This is a lossless conversion. NaN becomes a quiet Float16 NaN.
+func (f Float8E4M3FN) ToFloat16() Float16 {
+	return Float16(f32bitsToF16bits(F8E4M3bitsToF32bits(uint8(f))))
+}
+
+// ToBFloat16 returns a BFloat16 converted from f (Float8E4M3FN).
+// This is a lossless conversion. NaN becomes a quiet BFloat16 NaN.
+func (f Float8E4M3FN) ToBFloat16() BFloat16 {
+	return BFloat16(F8E4M3bitsToF32bits(uint8(f)) >> 16)
+}
+
+// ToFloat8 returns a Float8 converted from f (Float8E4M3FN). Every
+// Float8E4M3FN value is in range for E5M2, but with one less significand
+// bit results can be inexact. Use F8PrecisionFromFloat8E4M3 to check.
+func (f Float8E4M3FN) ToFloat8() Float8 {
+	return Float8(f32bitsToF8bits(F8E4M3bitsToF32bits(uint8(f))))
+}
+
+// F8E4M3FromSmallFloats converts src into dst with the ToFloat8E4M3 methods
+// and returns the number of elements converted, which is the minimum of
+// len(dst) and len(src).
+func F8E4M3FromSmallFloats[T SmallFloat](dst []Float8E4M3FN, src []T) int {
+	n := min(len(dst), len(src))
+	switch s := any(src).(type) {
+	case []Float16:
+		for i, f := range s[:n] {
+			dst[i] = f.ToFloat8E4M3()
+		}
+	case []BFloat16:
+		for i, f := range s[:n] {
+			dst[i] = f.ToFloat8E4M3()
+		}
+	case []Float8:
+		for i, f := range s[:n] {
+			dst[i] = f.ToFloat8E4M3()
+		}
+	}
+	return n
+}
+
+// F8E4M3ToSmallFloats converts src into dst with the To methods of
+// Float8E4M3FN and returns the number of elements converted, which is the
+// minimum of len(dst) and len(src).
+func F8E4M3ToSmallFloats[T SmallFloat](dst []T, src []Float8E4M3FN) int {
+	n := min(len(dst), len(src))
+	switch d := any(dst).(type) {
+	case []Float16:
+		for i, f := range src[:n] {
+			d[i] = f.ToFloat16()
+		}
+	case []BFloat16:
+		for i, f := range src[:n] {
+			d[i] = f.ToBFloat16()
+		}
+	case []Float8:
+		for i, f := range src[:n] {
+			d[i] = f.ToFloat8()
+		}
+	}
+	return n
+}
+
+// The precision functions below perform the conversion to check it, so
+// unlike the PrecisionFromfloat32 functions they never report
+// PrecisionUnknown. Overflow means a finite value became infinity and
+// underflow means a nonzero value became zero. Conversions from zero,
+// infinity and NaN always report PrecisionExact even if NaN payload or
+// NaN-Quiet-Bit is lost.
+
+// BF16PrecisionFromFloat16 returns the Precision of converting f to BFloat16.
+// Float16 values never overflow or underflow as BFloat16.
+func BF16PrecisionFromFloat16(f Float16) BF16Precision {
+	return BF16PrecisionFromfloat32(math.Float32frombits(F16bitsToF32bits(uint16(f))))
+}
+
+// F8PrecisionFromFloat16 returns the Precision of converting f to Float8.
+func F8PrecisionFromFloat16(f Float16) F8Precision {
+	return F8Precision(directPrecision(F16bitsToF32bits(uint16(f)),
+		F8bitsToF32bits(f16bitsToF8bits(uint16(f)))))
+}
+
+// F16PrecisionFromBFloat16 returns the Precision of converting f to Float16.
+func F16PrecisionFromBFloat16(f BFloat16) F16Precision {
+	u32 := uint32(f) << 16
+	return F16Precision(directPrecision(u32, F16bitsToF32bits(f32bitsToF16bits(u32))))
+}
+
+// F8PrecisionFromBFloat16 returns the Precision of converting f to Float8.
+func F8PrecisionFromBFloat16(f BFloat16) F8Precision {
+	u32 := uint32(f) << 16
+	return F8Precision(directPrecision(u32, F8bitsToF32bits(f32bitsToF8bits(u32))))
+}
+
+// directPrecision returns the precision of a conversion from in to out
+// (both float32 bits). All Precision types share the same constant values.
+func directPrecision(in, out uint32) F16Precision {
+	const ABSMASK uint32 = 0x7fffffff
+	const INF uint32 = 0x7f800000
+
+	switch {
+	case in&ABSMASK == 0 || in&ABSMASK >= INF:
+		return F16PrecisionExact
+	case out&ABSMASK == INF:
+		return F16PrecisionOverflow
+	case out&ABSMASK == 0:
+		return F16PrecisionUnderflow
+	case in != out:
+		return F16PrecisionInexact
+	}
+	return F16PrecisionExact
+}
+
+// f16bitsToF8bits returns uint8 (Float8 bits) converted from the specified
+// Float16 bits. Conversion rounds to nearest integer with ties to even.
+func f16bitsToF8bits(u16 uint16) uint8 {
+	// E5M2 is the upper byte of binary16, so only the low byte needs
+	// rounding. A carry out of the significand increments the exponent,
+	// which also takes care of overflow to infinity.
+
+	if u16&0x7c00 == 0x7c00 {
+		// NaN or Infinity
+		if u16&0x03ff != 0 {
+			return uint8(u16>>8) | 0x02
+		}
+		return uint8(u16 >> 8)
+	}
+
+	roundBit := uint16(0x0080)
+	if (u16&roundBit) != 0 && (u16&(3*roundBit-1)) != 0 {
+		return uint8(u16>>8) + 1
+	}
+	return uint8(u16 >> 8)
+}
+
+// F8E4M3PrecisionFromFloat16 returns the Precision of converting f to
+// Float8E4M3FN. Float8E4M3FN has no infinities, so Overflow means a finite
+// value or an infinity became NaN.
+func F8E4M3PrecisionFromFloat16(f Float16) F8E4M3Precision {
+	u32 := F16bitsToF32bits(uint16(f))
+	return f8e4m3Precision(u32, f32bitsToF8E4M3bits(u32))
+}
+
+// F8E4M3PrecisionFromBFloat16 returns the Precision of converting f to
+// Float8E4M3FN, with Overflow as in F8E4M3PrecisionFromFloat16.
+func F8E4M3PrecisionFromBFloat16(f BFloat16) F8E4M3Precision {
+	u32 := uint32(f) << 16
+	return f8e4m3Precision(u32, f32bitsToF8E4M3bits(u32))
+}
+
+// F8E4M3PrecisionFromFloat8 returns the Precision of converting f to
+// Float8E4M3FN, with Overflow as in F8E4M3PrecisionFromFloat16.
+func F8E4M3PrecisionFromFloat8(f Float8) F8E4M3Precision {
+	u32 := F8bitsToF32bits(uint8(f))
+	return f8e4m3Precision(u32, f32bitsToF8E4M3bits(u32))
+}
+
+// F8PrecisionFromFloat8E4M3 returns the Precision of converting f to Float8.
+// Float8E4M3FN values never overflow or underflow as Float8.
+func F8PrecisionFromFloat8E4M3(f Float8E4M3FN) F8Precision {
+	u32 := F8E4M3bitsToF32bits(uint8(f))
+	return F8Precision(directPrecision(u32, F8bitsToF32bits(f32bitsToF8bits(u32))))
+}
+
+// f8e4m3Precision returns the precision of a conversion from in (float32
+// bits) to out (Float8E4M3FN bits), where NaN takes the place of infinity.
+func f8e4m3Precision(in uint32, out uint8) F8E4M3Precision {
+	const ABSMASK uint32 = 0x7fffffff
+	const INF uint32 = 0x7f800000
+
+	switch {
+	case in&ABSMASK > INF:
+		return F8E4M3PrecisionExact
+	case out&0x7f == 0x7f:
+		return F8E4M3PrecisionOverflow
+	}
+	return F8E4M3Precision(directPrecision(in, F8E4M3bitsToF32bits(out)))
+}
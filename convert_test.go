@@ -0,0 +1,277 @@
+package floatx_test
+
+import (
+	"math"
+	"testing"
+
+	floatx "github.com/chenxingqiang/go-floatx"
+)
+
+// checkDirectPrecision verifies the precision reported for converting in
+// (float32 bits of the source value) to out (float32 bits of the result).
+// exact, underflow and overflow are the reported precision.
+func checkDirectPrecision(t *testing.T, name string, src uint16, in, out uint32, minSub float32, exact, underflow, overflow bool) {
+	t.Helper()
+
+	f32 := math.Float32frombits(in)
+	if math.IsNaN(float64(f32)) || math.IsInf(float64(f32), 0) {
+		if !exact {
+			t.Errorf("%s 0x%04x: got not exact, wanted exact for NaN or Inf", name, src)
+		}
+		return
+	}
+
+	roundTripped := in == out
+	if exact != roundTripped {
+		t.Errorf("%s 0x%04x: got exact=%v, wanted %v (result 0x%08x)", name, src, exact, roundTripped, out)
+	}
+
+	out32 := math.Float32frombits(out)
+	if overflow && !math.IsInf(float64(out32), 0) {
+		t.Errorf("%s 0x%04x: got overflow, result 0x%08x is not Inf", name, src, out)
+	}
+	if !overflow && math.IsInf(float64(out32), 0) {
+		t.Errorf("%s 0x%04x: got no overflow, result 0x%08x is Inf", name, src, out)
+	}
+	if underflow && math.Abs(float64(f32)) >= float64(minSub) {
+		t.Errorf("%s 0x%04x: got underflow, input %v is not below %v", name, src, f32, minSub)
+	}
+	if !underflow && f32 != 0 && out32 == 0 {
+		t.Errorf("%s 0x%04x: got no underflow, result is zero", name, src)
+	}
+}
+
+func TestFloat16ToBFloat16(t *testing.T) {
+	for i := 0; i < 65536; i++ {
+		f16 := floatx.Float16(i)
+
+		got := f16.ToBFloat16()
+		want := floatx.BF16Fromfloat32(f16.Float32())
+		if got != want {
+			t.Errorf("Float16(0x%04x).ToBFloat16() = 0x%04x, wanted 0x%04x", i, uint16(got), uint16(want))
+		}
+
+		pcn := floatx.BF16PrecisionFromFloat16(f16)
+		if pcn != floatx.BF16PrecisionExact && pcn != floatx.BF16PrecisionInexact {
+			t.Errorf("BF16PrecisionFromFloat16(0x%04x) = %d, wanted exact or inexact", i, pcn)
+		}
+		checkDirectPrecision(t, "Float16->BFloat16", uint16(i),
+			math.Float32bits(f16.Float32()), math.Float32bits(got.Float32()), 0,
+			pcn == floatx.BF16PrecisionExact, false, false)
+	}
+}
+
+func TestFloat16ToFloat8(t *testing.T) {
+	for i := 0; i < 65536; i++ {
+		f16 := floatx.Float16(i)
+
+		got := f16.ToFloat8()
+		want := floatx.F8Fromfloat32(f16.Float32())
+		if got != want {
+			t.Errorf("Float16(0x%04x).ToFloat8() = 0x%02x, wanted 0x%02x", i, uint8(got), uint8(want))
+		}
+
+		pcn := floatx.F8PrecisionFromFloat16(f16)
+		if pcn == floatx.F8PrecisionUnknown {
+			t.Errorf("F8PrecisionFromFloat16(0x%04x) = PrecisionUnknown", i)
+		}
+		checkDirectPrecision(t, "Float16->Float8", uint16(i),
+			math.Float32bits(f16.Float32()), math.Float32bits(got.Float32()), 0x1p-16,
+			pcn == floatx.F8PrecisionExact, pcn == floatx.F8PrecisionUnderflow, pcn == floatx.F8PrecisionOverflow)
+	}
+}
+
+func TestBFloat16ToFloat16(t *testing.T) {
+	for i := 0; i < 65536; i++ {
+		bf16 := floatx.BFloat16(i)
+
+		got := bf16.ToFloat16()
+		want := floatx.F16Fromfloat32(bf16.Float32())
+		if got != want {
+			t.Errorf("BFloat16(0x%04x).ToFloat16() = 0x%04x, wanted 0x%04x", i, uint16(got), uint16(want))
+		}
+
+		pcn := floatx.F16PrecisionFromBFloat16(bf16)
+		if pcn == floatx.F16PrecisionUnknown {
+			t.Errorf("F16PrecisionFromBFloat16(0x%04x) = PrecisionUnknown", i)
+		}
+		checkDirectPrecision(t, "BFloat16->Float16", uint16(i),
+			math.Float32bits(bf16.Float32()), math.Float32bits(got.Float32()), 0x1p-24,
+			pcn == floatx.F16PrecisionExact, pcn == floatx.F16PrecisionUnderflow, pcn == floatx.F16PrecisionOverflow)
+	}
+}
+
+func TestBFloat16ToFloat8(t *testing.T) {
+	for i := 0; i < 65536; i++ {
+		bf16 := floatx.BFloat16(i)
+
+		got := bf16.ToFloat8()
+		want := floatx.F8Fromfloat32(bf16.Float32())
+		if got != want {
+			t.Errorf("BFloat16(0x%04x).ToFloat8() = 0x%02x, wanted 0x%02x", i, uint8(got), uint8(want))
+		}
+
+		pcn := floatx.F8PrecisionFromBFloat16(bf16)
+		if pcn == floatx.F8PrecisionUnknown {
+			t.Errorf("F8PrecisionFromBFloat16(0x%04x) = PrecisionUnknown", i)
+		}
+		checkDirectPrecision(t, "BFloat16->Float8", uint16(i),
+			math.Float32bits(bf16.Float32()), math.Float32bits(got.Float32()), 0x1p-16,
+			pcn == floatx.F8PrecisionExact, pcn == floatx.F8PrecisionUnderflow, pcn == floatx.F8PrecisionOverflow)
+	}
+}
+
+func TestFloat8ToWider(t *testing.T) {
+	for i := 0; i < 256; i++ {
+		f8 := floatx.Float8(i)
+		u32 := math.Float32bits(f8.Float32())
+
+		f16 := f8.ToFloat16()
+		if f16 != floatx.F16Fromfloat32(f8.Float32()) {
+			t.Errorf("Float8(0x%02x).ToFloat16() = 0x%04x, wanted 0x%04x", i, uint16(f16), uint16(floatx.F16Fromfloat32(f8.Float32())))
+		}
+		if got := math.Float32bits(f16.Float32()); got != u32 {
+			t.Errorf("Float8(0x%02x).ToFloat16() isn't lossless: got 0x%08x, wanted 0x%08x", i, got, u32)
+		}
+
+		bf16 := f8.ToBFloat16()
+		if bf16 != floatx.BF16Fromfloat32(f8.Float32()) {
+			t.Errorf("Float8(0x%02x).ToBFloat16() = 0x%04x, wanted 0x%04x", i, uint16(bf16), uint16(floatx.BF16Fromfloat32(f8.Float32())))
+		}
+		if got := math.Float32bits(bf16.Float32()); got != u32 {
+			t.Errorf("Float8(0x%02x).ToBFloat16() isn't lossless: got 0x%08x, wanted 0x%08x", i, got, u32)
+		}
+	}
+}
+
+func TestDoubleRounding(t *testing.T) {
+	f32 := float32(1.125 + 0x1p-12)
+
+	direct := floatx.F8Fromfloat32(f32)
+	if direct.Float32() != 1.25 {
+		t.Errorf("F8Fromfloat32(%v) = %v, wanted 1.25", f32, direct)
+	}
+
+	f16 := floatx.F16Fromfloat32(f32)
+	if f16.Float32() != 1.125 {
+		t.Errorf("F16Fromfloat32(%v) = %v, wanted 1.125", f32, f16)
+	}
+	if f8 := f16.ToFloat8(); f8.Float32() != 1 {
+		t.Errorf("Float16(%v).ToFloat8() = %v, wanted 1", f16, f8)
+	}
+
+	f32 = 1 + 0x1p-4 + 0x1p-12
+	if e4m3 := floatx.F8E4M3Fromfloat32(f32); e4m3.Float32() != 1.125 {
+		t.Errorf("F8E4M3Fromfloat32(%v) = %v, wanted 1.125", f32, e4m3)
+	}
+	f16 = floatx.F16Fromfloat32(f32)
+	if e4m3 := f16.ToFloat8E4M3(); f16.Float32() != 1.0625 || e4m3.Float32() != 1 {
+		t.Errorf("Float16(%v).ToFloat8E4M3() = %v, wanted 1", f16, e4m3)
+	}
+}
+
+// checkE4M3Precision verifies the precision reported for converting src
+// (any format) to Float8E4M3FN.
+func checkE4M3Precision(t *testing.T, name string, src uint32, in float32, out floatx.Float8E4M3FN, pcn floatx.F8E4M3Precision) {
+	t.Helper()
+
+	var want floatx.F8E4M3Precision
+	switch o := out.Float32(); {
+	case in != in || in == 0:
+		want = floatx.F8E4M3PrecisionExact
+	case o != o:
+		want = floatx.F8E4M3PrecisionOverflow
+	case o == 0:
+		want = floatx.F8E4M3PrecisionUnderflow
+	case o != in:
+		want = floatx.F8E4M3PrecisionInexact
+	}
+	if pcn != want {
+		t.Errorf("%s 0x%04x: got precision %d, wanted %d", name, src, pcn, want)
+	}
+}
+
+func TestToFloat8E4M3(t *testing.T) {
+	for i := 0; i < 65536; i++ {
+		f16 := floatx.Float16(i)
+		got := f16.ToFloat8E4M3()
+		if want := floatx.F8E4M3Fromfloat32(f16.Float32()); got != want {
+			t.Errorf("Float16(0x%04x).ToFloat8E4M3() = 0x%02x, wanted 0x%02x", i, uint8(got), uint8(want))
+		}
+		checkE4M3Precision(t, "Float16->Float8E4M3FN", uint32(i), f16.Float32(), got, floatx.F8E4M3PrecisionFromFloat16(f16))
+
+		bf16 := floatx.BFloat16(i)
+		got = bf16.ToFloat8E4M3()
+		if want := floatx.F8E4M3Fromfloat32(bf16.Float32()); got != want {
+			t.Errorf("BFloat16(0x%04x).ToFloat8E4M3() = 0x%02x, wanted 0x%02x", i, uint8(got), uint8(want))
+		}
+		checkE4M3Precision(t, "BFloat16->Float8E4M3FN", uint32(i), bf16.Float32(), got, floatx.F8E4M3PrecisionFromBFloat16(bf16))
+	}
+
+	for i := 0; i < 256; i++ {
+		f8 := floatx.Float8(i)
+		got := f8.ToFloat8E4M3()
+		if want := floatx.F8E4M3Fromfloat32(f8.Float32()); got != want {
+			t.Errorf("Float8(0x%02x).ToFloat8E4M3() = 0x%02x, wanted 0x%02x", i, uint8(got), uint8(want))
+		}
+		checkE4M3Precision(t, "Float8->Float8E4M3FN", uint32(i), f8.Float32(), got, floatx.F8E4M3PrecisionFromFloat8(f8))
+	}
+	if p := floatx.F8E4M3PrecisionFromFloat16(floatx.F16Inf(-1)); p != floatx.F8E4M3PrecisionOverflow {
+		t.Errorf("F8E4M3PrecisionFromFloat16(-Inf) = %d, wanted overflow", p)
+	}
+}
+
+func TestFloat8E4M3ToOthers(t *testing.T) {
+	for i := 0; i < 256; i++ {
+		f := floatx.F8E4M3Frombits(uint8(i))
+		u32 := math.Float32bits(f.Float32())
+
+		f16 := f.ToFloat16()
+		if got := math.Float32bits(f16.Float32()); got != u32 {
+			t.Errorf("Float8E4M3FN(0x%02x).ToFloat16() isn't lossless: got 0x%08x, wanted 0x%08x", i, got, u32)
+		}
+		bf16 := f.ToBFloat16()
+		if got := math.Float32bits(bf16.Float32()); got != u32 {
+			t.Errorf("Float8E4M3FN(0x%02x).ToBFloat16() isn't lossless: got 0x%08x, wanted 0x%08x", i, got, u32)
+		}
+
+		f8 := f.ToFloat8()
+		if want := floatx.F8Fromfloat32(f.Float32()); f8 != want {
+			t.Errorf("Float8E4M3FN(0x%02x).ToFloat8() = 0x%02x, wanted 0x%02x", i, uint8(f8), uint8(want))
+		}
+		pcn := floatx.F8PrecisionFromFloat8E4M3(f)
+		if pcn != floatx.F8PrecisionExact && pcn != floatx.F8PrecisionInexact {
+			t.Errorf("F8PrecisionFromFloat8E4M3(0x%02x) = %d, wanted exact or inexact", i, pcn)
+		}
+		checkDirectPrecision(t, "Float8E4M3FN->Float8", uint16(i), u32, math.Float32bits(f8.Float32()), 0,
+			pcn == floatx.F8PrecisionExact, false, false)
+	}
+}
+
+func TestF8E4M3SmallFloats(t *testing.T) {
+	f16s := []floatx.Float16{floatx.F16Fromfloat32(1.0625), floatx.F16Fromfloat32(-500), floatx.F16Fromfloat32(0x1p-9)}
+	dst := make([]floatx.Float8E4M3FN, 4)
+	if n := floatx.F8E4M3FromSmallFloats(dst, f16s); n != 3 || dst[0].Float32() != 1 || !dst[1].IsNaN() || dst[2].Bits() != 0x01 {
+		t.Errorf("F8E4M3FromSmallFloats(f16s) = %d, %v", n, dst)
+	}
+	bf16s := []floatx.BFloat16{floatx.BF16Fromfloat32(3)}
+	if n := floatx.F8E4M3FromSmallFloats(dst, bf16s); n != 1 || dst[0].Float32() != 3 {
+		t.Errorf("F8E4M3FromSmallFloats(bf16s) = %d, %v", n, dst)
+	}
+	f8s := []floatx.Float8{floatx.F8Fromfloat32(-0.75)}
+	if n := floatx.F8E4M3FromSmallFloats(dst, f8s); n != 1 || dst[0].Float32() != -0.75 {
+		t.Errorf("F8E4M3FromSmallFloats(f8s) = %d, %v", n, dst)
+	}
+
+	src := []floatx.Float8E4M3FN{floatx.F8E4M3Frombits(0x39), floatx.F8E4M3NaN()}
+	if got := make([]floatx.Float16, 1); floatx.F8E4M3ToSmallFloats(got, src) != 1 || got[0].Float32() != 1.125 {
+		t.Errorf("F8E4M3ToSmallFloats to Float16 = %v", got)
+	}
+	if got := make([]floatx.BFloat16, 2); floatx.F8E4M3ToSmallFloats(got, src) != 2 || got[0].Float32() != 1.125 || !got[1].IsNaN() {
+		t.Errorf("F8E4M3ToSmallFloats to BFloat16 = %v", got)
+	}
+	// 1.125 is a tie between Float8 1 and 1.25
+	if got := make([]floatx.Float8, 3); floatx.F8E4M3ToSmallFloats(got, src) != 2 || got[0].Float32() != 1 || !got[1].IsNaN() {
+		t.Errorf("F8E4M3ToSmallFloats to Float8 = %v", got)
+	}
+}
@@ -0,0 +1,166 @@
+package exr
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// decompress decompresses src, a chunk of n scanlines, to dst, which has
+// the size of the uncompressed scanlines. Like OpenEXR, a chunk that
+// compression wouldn't make smaller is stored uncompressed.
+func (img *Image) decompress(dst, src []byte, n int) error {
+	if len(src) == len(dst) {
+		copy(dst, src)
+		return nil
+	}
+	switch img.Compression {
+	case RLE:
+		tmp := make([]byte, len(dst))
+		if err := rleDecompress(tmp, src); err != nil {
+			return err
+		}
+		unpredict(dst, tmp)
+		return nil
+	case ZIPS, ZIP:
+		tmp := make([]byte, len(dst))
+		if err := zlibDecompress(tmp, src); err != nil {
+			return err
+		}
+		unpredict(dst, tmp)
+		return nil
+	case PIZ:
+		return img.pizDecompress(dst, src, n)
+	}
+	return fmt.Errorf("%d bytes for %d bytes of scanlines", len(src), len(dst))
+}
+
+// compress returns the chunk of the n scanlines in raw.
+func (img *Image) compress(raw []byte, n int) []byte {
+	var b []byte
+	switch img.Compression {
+	case RLE:
+		b = rleCompress(predict(raw))
+	case ZIPS, ZIP:
+		var buf bytes.Buffer
+		zw := zlib.NewWriter(&buf)
+		zw.Write(predict(raw))
+		zw.Close()
+		b = buf.Bytes()
+	case PIZ:
+		b = img.pizCompress(raw, n)
+	}
+	if b == nil || len(b) >= len(raw) {
+		return raw
+	}
+	return b
+}
+
+// predict returns the bytes of raw reordered, with the even bytes followed
+// by the odd ones, and delta encoded, which RLE and ZIP compress better.
+func predict(raw []byte) []byte {
+	t := make([]byte, len(raw))
+	half := (len(raw) + 1) / 2
+	for i, b := range raw {
+		if i%2 == 0 {
+			t[i/2] = b
+		} else {
+			t[half+i/2] = b
+		}
+	}
+	p := byte(0)
+	for i, b := range t {
+		if i > 0 {
+			t[i] = b - p + 128
+		}
+		p = b
+	}
+	return t
+}
+
+// unpredict reverses predict, decoding t in place to dst.
+func unpredict(dst, t []byte) {
+	for i := 1; i < len(t); i++ {
+		t[i] += t[i-1] - 128
+	}
+	half := (len(t) + 1) / 2
+	for i := range dst {
+		if i%2 == 0 {
+			dst[i] = t[i/2]
+		} else {
+			dst[i] = t[half+i/2]
+		}
+	}
+}
+
+// rleCompress returns the run-length encoding of b: a count byte n
+// followed by the byte repeated n+1 times, or -n followed by n literal
+// bytes. Like OpenEXR, only runs of 3 or more bytes are encoded as runs.
+func rleCompress(b []byte) []byte {
+	const minRun, maxRun = 3, 127
+	var out []byte
+	for start := 0; start < len(b); {
+		end := start + 1
+		for end < len(b) && b[end] == b[start] && end-start-1 < maxRun {
+			end++
+		}
+		if end-start >= minRun {
+			out = append(out, byte(end-start-1), b[start])
+			start = end
+			continue
+		}
+		for end < len(b) && (end+1 >= len(b) || b[end] != b[end+1] || end+2 >= len(b) || b[end+1] != b[end+2]) && end-start < maxRun {
+			end++
+		}
+		out = append(out, byte(start-end))
+		out = append(out, b[start:end]...)
+		start = end
+	}
+	return out
+}
+
+var errCorrupt = errors.New("corrupt data")
+
+// rleDecompress decodes the run-length encoding src to dst, which it must
+// fill exactly.
+func rleDecompress(dst, src []byte) error {
+	for len(src) > 0 {
+		if n := int(int8(src[0])); n < 0 {
+			if -n > len(src)-1 || -n > len(dst) {
+				return errCorrupt
+			}
+			copy(dst, src[1:1-n])
+			dst, src = dst[-n:], src[1-n:]
+		} else {
+			if len(src) < 2 || n+1 > len(dst) {
+				return errCorrupt
+			}
+			for i := range dst[:n+1] {
+				dst[i] = src[1]
+			}
+			dst, src = dst[n+1:], src[2:]
+		}
+	}
+	if len(dst) > 0 {
+		return errCorrupt
+	}
+	return nil
+}
+
+// zlibDecompress decompresses the zlib data src to dst, which it must fill
+// exactly.
+func zlibDecompress(dst, src []byte) error {
+	zr, err := zlib.NewReader(bytes.NewReader(src))
+	if err != nil {
+		return err
+	}
+	if _, err := io.ReadFull(zr, dst); err != nil {
+		return err
+	}
+	if n, _ := zr.Read(make([]byte, 1)); n > 0 {
+		return errCorrupt
+	}
+	return zr.Close()
+}
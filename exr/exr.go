@@ -0,0 +1,662 @@
+// Package exr reads and writes scanline OpenEXR images with HALF, FLOAT and
+// UINT channels, uncompressed or with RLE, ZIPS, ZIP or PIZ compression.
+//
+// Each channel is a plane of samples in row-major order over the data
+// window. HALF channels are []Float16 planes, so HDR images can be read
+// and written without converting through float32. An Image is also an
+// image.Image of its R, G, B and A channels, and Decode is registered with
+// the image package for the "exr" format.
+//
+// Tiled, deep and multi-part files, subsampled channels and the lossy
+// compressions (PXR24, B44, B44A, DWAA and DWAB) aren't supported.
+package exr
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"io"
+	"math"
+	"sort"
+
+	floatx "github.com/chenxingqiang/go-floatx"
+)
+
+type exrError string
+
+func (e exrError) Error() string { return string(e) }
+
+const (
+	// ErrFormat is returned for a file that isn't a valid OpenEXR file.
+	ErrFormat = exrError("exr: invalid format")
+
+	// ErrUnsupported is returned for a valid OpenEXR file that uses a
+	// feature this package doesn't support.
+	ErrUnsupported = exrError("exr: unsupported feature")
+
+	// ErrShape is returned by Encode when a channel's samples don't match
+	// its type or the data window.
+	ErrShape = exrError("exr: channel samples don't match the data window")
+)
+
+const magic = "\x76\x2f\x31\x01"
+
+// maxRatio is the largest ratio of decoded to encoded image size Decode
+// accepts.
+const maxRatio = 1100
+
+// version field flags
+const (
+	flagTiled     = 0x200
+	flagLongNames = 0x400
+	flagDeep      = 0x800
+	flagMultipart = 0x1000
+)
+
+// PixelType is the type of a channel's samples.
+type PixelType int32
+
+// Pixel types.
+const (
+	Uint  PixelType = 0 // uint32
+	Half  PixelType = 1 // Float16
+	Float PixelType = 2 // float32
+)
+
+// size returns the size in bytes of a sample of type t.
+func (t PixelType) size() int {
+	if t == Half {
+		return 2
+	}
+	return 4
+}
+
+// String satisfies the fmt.Stringer interface.
+func (t PixelType) String() string {
+	switch t {
+	case Uint:
+		return "UINT"
+	case Half:
+		return "HALF"
+	case Float:
+		return "FLOAT"
+	}
+	return fmt.Sprintf("PixelType(%d)", int32(t))
+}
+
+// Compression is the compression of the pixel data.
+type Compression uint8
+
+// Compressions. Only None, RLE, ZIPS, ZIP and PIZ can be read and written.
+const (
+	None  Compression = 0
+	RLE   Compression = 1
+	ZIPS  Compression = 2 // zlib, one scanline per chunk
+	ZIP   Compression = 3 // zlib, 16 scanlines per chunk
+	PIZ   Compression = 4 // wavelet and Huffman, 32 scanlines per chunk
+	PXR24 Compression = 5
+	B44   Compression = 6
+	B44A  Compression = 7
+	DWAA  Compression = 8
+	DWAB  Compression = 9
+)
+
+var compressionNames = [...]string{"NONE", "RLE", "ZIPS", "ZIP", "PIZ", "PXR24", "B44", "B44A", "DWAA", "DWAB"}
+
+// String satisfies the fmt.Stringer interface.
+func (c Compression) String() string {
+	if int(c) < len(compressionNames) {
+		return compressionNames[c]
+	}
+	return fmt.Sprintf("Compression(%d)", uint8(c))
+}
+
+// lines returns the number of scanlines in a chunk compressed with c, or 0
+// if c isn't supported.
+func (c Compression) lines() int {
+	switch c {
+	case None, RLE, ZIPS:
+		return 1
+	case ZIP:
+		return 16
+	case PIZ:
+		return 32
+	}
+	return 0
+}
+
+// Channel is a channel of an image and its samples. Only the field for the
+// channel's type is used.
+type Channel struct {
+	Name   string
+	Type   PixelType
+	Linear bool // perceptually linear, a hint for lossy compression
+
+	Half  []floatx.Float16
+	Float []float32
+	Uint  []uint32
+}
+
+// len returns the number of samples of c for its type.
+func (c *Channel) len() int {
+	switch c.Type {
+	case Half:
+		return len(c.Half)
+	case Float:
+		return len(c.Float)
+	}
+	return len(c.Uint)
+}
+
+// Float16s returns the samples of c as Float16s: the Half plane of a HALF
+// channel, or a converted copy of the samples of another type.
+func (c *Channel) Float16s() []floatx.Float16 {
+	switch c.Type {
+	case Half:
+		return c.Half
+	case Float:
+		s := make([]floatx.Float16, len(c.Float))
+		floatx.FromFloat32s(s, c.Float)
+		return s
+	}
+	s := make([]floatx.Float16, len(c.Uint))
+	for i, v := range c.Uint {
+		s[i] = floatx.FromUint32[floatx.Float16](v)
+	}
+	return s
+}
+
+// Float32s returns the samples of c converted to float32.
+func (c *Channel) Float32s() []float32 {
+	s := make([]float32, c.len())
+	for i := range s {
+		s[i] = c.at(i)
+	}
+	return s
+}
+
+// at returns sample i of c as a float32.
+func (c *Channel) at(i int) float32 {
+	switch c.Type {
+	case Half:
+		return c.Half[i].Float32()
+	case Float:
+		return c.Float[i]
+	}
+	return float32(c.Uint[i])
+}
+
+// Attribute is a header attribute that this package doesn't interpret,
+// with its value as stored in the file.
+type Attribute struct {
+	Name  string
+	Type  string
+	Value []byte
+}
+
+// Image is a scanline OpenEXR image.
+type Image struct {
+	// DataWindow has the pixels that have samples. Like image.Rectangle,
+	// Max is exclusive, while the file stores it inclusive.
+	DataWindow    image.Rectangle
+	DisplayWindow image.Rectangle
+
+	Compression        Compression
+	PixelAspectRatio   float32
+	ScreenWindowCenter [2]float32
+	ScreenWindowWidth  float32
+
+	Channels   []*Channel  // sorted by name
+	Attributes []Attribute // others, such as chromaticities
+}
+
+// NewImage returns an image of the given size with ZIP compression and
+// channels of type t with the given names, all set to zero.
+func NewImage(r image.Rectangle, t PixelType, names ...string) *Image {
+	img := &Image{
+		DataWindow:        r,
+		DisplayWindow:     r,
+		Compression:       ZIP,
+		PixelAspectRatio:  1,
+		ScreenWindowWidth: 1,
+	}
+	n := r.Dx() * r.Dy()
+	for _, name := range names {
+		c := &Channel{Name: name, Type: t}
+		switch t {
+		case Half:
+			c.Half = make([]floatx.Float16, n)
+		case Float:
+			c.Float = make([]float32, n)
+		default:
+			c.Uint = make([]uint32, n)
+		}
+		img.Channels = append(img.Channels, c)
+	}
+	sort.Slice(img.Channels, func(i, j int) bool { return img.Channels[i].Name < img.Channels[j].Name })
+	return img
+}
+
+// Channel returns the named channel, or nil if img doesn't have it.
+func (img *Image) Channel(name string) *Channel {
+	for _, c := range img.Channels {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// decoder reads the little-endian values of a header.
+type decoder struct {
+	b   []byte
+	off int
+	err error
+}
+
+func (d *decoder) read(n int) []byte {
+	if d.err != nil || n > len(d.b)-d.off {
+		if d.err == nil {
+			d.err = fmt.Errorf("%w: unexpected end of header", ErrFormat)
+		}
+		return make([]byte, n)
+	}
+	d.off += n
+	return d.b[d.off-n : d.off]
+}
+
+func (d *decoder) int32() int32 { return int32(binary.LittleEndian.Uint32(d.read(4))) }
+func (d *decoder) float32() float32 {
+	return math.Float32frombits(binary.LittleEndian.Uint32(d.read(4)))
+}
+
+// name reads a null-terminated name of at most maxLen bytes.
+func (d *decoder) name(maxLen int) string {
+	for i := d.off; d.err == nil && i < len(d.b) && i <= d.off+maxLen; i++ {
+		if d.b[i] == 0 {
+			s := string(d.b[d.off:i])
+			d.off = i + 1
+			return s
+		}
+	}
+	if d.err == nil {
+		d.err = fmt.Errorf("%w: name too long", ErrFormat)
+	}
+	return ""
+}
+
+// box reads a box2i as an image.Rectangle.
+func (d *decoder) box() image.Rectangle {
+	x0, y0, x1, y1 := d.int32(), d.int32(), d.int32(), d.int32()
+	// not image.Rect, which would swap the corners of an invalid box
+	return image.Rectangle{image.Pt(int(x0), int(y0)), image.Pt(int(x1)+1, int(y1)+1)}
+}
+
+// attribute types of the attributes Image has fields for
+var attributeTypes = map[string]string{
+	"channels":           "chlist",
+	"compression":        "compression",
+	"dataWindow":         "box2i",
+	"displayWindow":      "box2i",
+	"lineOrder":          "lineOrder",
+	"pixelAspectRatio":   "float",
+	"screenWindowCenter": "v2f",
+	"screenWindowWidth":  "float",
+}
+
+// readHeader reads the header of the file in b up to the offset table.
+func readHeader(d *decoder) (*Image, error) {
+	if string(d.read(4)) != magic {
+		return nil, fmt.Errorf("%w: not an OpenEXR file", ErrFormat)
+	}
+	version := d.int32()
+	if d.err != nil {
+		return nil, d.err
+	}
+	if version&0xff != 2 {
+		return nil, fmt.Errorf("%w: version %d", ErrUnsupported, version&0xff)
+	}
+	if version&(flagTiled|flagDeep|flagMultipart) != 0 {
+		return nil, fmt.Errorf("%w: tiled, deep or multi-part file", ErrUnsupported)
+	}
+	maxLen := 31
+	if version&flagLongNames != 0 {
+		maxLen = 255
+	}
+
+	img := &Image{}
+	seen := make(map[string]bool)
+	for d.err == nil {
+		name := d.name(maxLen)
+		if name == "" {
+			break
+		}
+		typ := d.name(maxLen)
+		n := d.int32()
+		if d.err == nil && (n < 0 || int(n) > len(d.b)-d.off) {
+			return nil, fmt.Errorf("%w: attribute %q has size %d", ErrFormat, name, n)
+		}
+		value := d.read(int(n))
+		if d.err != nil {
+			break
+		}
+		want, ok := attributeTypes[name]
+		if !ok {
+			img.Attributes = append(img.Attributes, Attribute{name, typ, append([]byte{}, value...)})
+			continue
+		}
+		if typ != want {
+			return nil, fmt.Errorf("%w: attribute %q has type %q", ErrFormat, name, typ)
+		}
+		seen[name] = true
+		if err := img.setAttribute(name, value, maxLen); err != nil {
+			return nil, err
+		}
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	for name := range attributeTypes {
+		if !seen[name] {
+			return nil, fmt.Errorf("%w: no %s attribute", ErrFormat, name)
+		}
+	}
+	return img, nil
+}
+
+// setAttribute sets the field of img for the named attribute.
+func (img *Image) setAttribute(name string, value []byte, maxLen int) error {
+	d := &decoder{b: value}
+	switch name {
+	case "channels":
+		for {
+			c := &Channel{Name: d.name(maxLen)}
+			if c.Name == "" {
+				break
+			}
+			c.Type = PixelType(d.int32())
+			c.Linear = d.read(4)[0] != 0
+			xSampling, ySampling := d.int32(), d.int32()
+			if d.err != nil {
+				break
+			}
+			if c.Type != Uint && c.Type != Half && c.Type != Float {
+				return fmt.Errorf("%w: channel %q has pixel type %d", ErrFormat, c.Name, c.Type)
+			}
+			if xSampling != 1 || ySampling != 1 {
+				return fmt.Errorf("%w: channel %q is subsampled", ErrUnsupported, c.Name)
+			}
+			if n := len(img.Channels); n > 0 && img.Channels[n-1].Name >= c.Name {
+				return fmt.Errorf("%w: channel %q isn't sorted", ErrFormat, c.Name)
+			}
+			img.Channels = append(img.Channels, c)
+		}
+	case "compression":
+		img.Compression = Compression(d.read(1)[0])
+		if img.Compression.lines() == 0 {
+			return fmt.Errorf("%w: %v compression", ErrUnsupported, img.Compression)
+		}
+	case "dataWindow":
+		img.DataWindow = d.box()
+	case "displayWindow":
+		img.DisplayWindow = d.box()
+	case "lineOrder":
+		// the offset table makes the order of the chunks irrelevant
+	case "pixelAspectRatio":
+		img.PixelAspectRatio = d.float32()
+	case "screenWindowCenter":
+		img.ScreenWindowCenter = [2]float32{d.float32(), d.float32()}
+	case "screenWindowWidth":
+		img.ScreenWindowWidth = d.float32()
+	}
+	if d.err != nil {
+		return fmt.Errorf("%w: attribute %q is too short", ErrFormat, name)
+	}
+	return nil
+}
+
+// lineBytes returns the size in bytes of a scanline of img.
+func (img *Image) lineBytes() int {
+	n := 0
+	for _, c := range img.Channels {
+		n += c.Type.size()
+	}
+	return n * img.DataWindow.Dx()
+}
+
+// Decode reads an OpenEXR image from r.
+func Decode(r io.Reader) (*Image, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	d := &decoder{b: b}
+	img, err := readHeader(d)
+	if err != nil {
+		return nil, err
+	}
+
+	w, h := img.DataWindow.Dx(), img.DataWindow.Dy()
+	lines := img.Compression.lines()
+	numChunks := (h + lines - 1) / lines
+	if img.DataWindow.Empty() || len(img.Channels) == 0 || numChunks > (len(b)-d.off)/8 {
+		return nil, fmt.Errorf("%w: data window %v with %d channels", ErrFormat, img.DataWindow, len(img.Channels))
+	}
+	pixelBytes := 0
+	for _, c := range img.Channels {
+		pixelBytes += c.Type.size()
+	}
+	// none of the compressions expands data more than zlib's 1032 times, so
+	// a corrupt data window can't make Decode allocate much more than that
+	limit := uint64(len(b)) * maxRatio
+	if uint64(w)*uint64(pixelBytes) > min(limit, math.MaxInt)/uint64(h) {
+		return nil, fmt.Errorf("%w: data window %v is too large for a %d byte file", ErrFormat, img.DataWindow, len(b))
+	}
+	lineBytes := w * pixelBytes
+	n := w * h
+	for _, c := range img.Channels {
+		switch c.Type {
+		case Half:
+			c.Half = make([]floatx.Float16, n)
+		case Float:
+			c.Float = make([]float32, n)
+		default:
+			c.Uint = make([]uint32, n)
+		}
+	}
+
+	offsets := d.read(8 * numChunks)
+	raw := make([]byte, lines*lineBytes)
+	for i := 0; i < numChunks; i++ {
+		off := binary.LittleEndian.Uint64(offsets[8*i:])
+		if off > uint64(len(b)-8) {
+			return nil, fmt.Errorf("%w: chunk %d offset %d is beyond the end of the file", ErrFormat, i, off)
+		}
+		y := int(int32(binary.LittleEndian.Uint32(b[off:])))
+		size := binary.LittleEndian.Uint32(b[off+4:])
+		if want := img.DataWindow.Min.Y + i*lines; y != want {
+			return nil, fmt.Errorf("%w: chunk %d starts at y %d, wanted %d", ErrFormat, i, y, want)
+		}
+		if uint64(size) > uint64(len(b))-off-8 {
+			return nil, fmt.Errorf("%w: chunk %d is beyond the end of the file", ErrFormat, i)
+		}
+		src := b[off+8 : off+8+uint64(size)]
+		numLines := lines
+		if rest := h - i*lines; rest < numLines {
+			numLines = rest
+		}
+		dst := raw[:numLines*lineBytes]
+		if err := img.decompress(dst, src, numLines); err != nil {
+			return nil, fmt.Errorf("%w: chunk %d: %v", ErrFormat, i, err)
+		}
+		img.scatter(dst, i*lines*w)
+	}
+	return img, nil
+}
+
+// scatter copies the scanlines in raw to the channels, starting at sample
+// i of each channel.
+func (img *Image) scatter(raw []byte, i int) {
+	w := img.DataWindow.Dx()
+	for len(raw) > 0 {
+		for _, c := range img.Channels {
+			for x := 0; x < w; x++ {
+				switch c.Type {
+				case Half:
+					c.Half[i+x] = floatx.Float16(binary.LittleEndian.Uint16(raw[2*x:]))
+				case Float:
+					c.Float[i+x] = math.Float32frombits(binary.LittleEndian.Uint32(raw[4*x:]))
+				default:
+					c.Uint[i+x] = binary.LittleEndian.Uint32(raw[4*x:])
+				}
+			}
+			raw = raw[w*c.Type.size():]
+		}
+		i += w
+	}
+}
+
+// gather appends n scanlines from the channels, starting at sample i of
+// each channel, to raw.
+func (img *Image) gather(raw []byte, i, n int) []byte {
+	w := img.DataWindow.Dx()
+	for ; n > 0; n-- {
+		for _, c := range img.Channels {
+			for x := 0; x < w; x++ {
+				switch c.Type {
+				case Half:
+					raw = binary.LittleEndian.AppendUint16(raw, uint16(c.Half[i+x]))
+				case Float:
+					raw = binary.LittleEndian.AppendUint32(raw, math.Float32bits(c.Float[i+x]))
+				default:
+					raw = binary.LittleEndian.AppendUint32(raw, c.Uint[i+x])
+				}
+			}
+		}
+		i += w
+	}
+	return raw
+}
+
+// DecodeConfig returns the color model and dimensions of an OpenEXR image
+// without decoding it. The dimensions are those of the data window.
+func DecodeConfig(r io.Reader) (image.Config, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return image.Config{}, err
+	}
+	img, err := readHeader(&decoder{b: b})
+	if err != nil {
+		return image.Config{}, err
+	}
+	return image.Config{ColorModel: img.ColorModel(), Width: img.DataWindow.Dx(), Height: img.DataWindow.Dy()}, nil
+}
+
+func init() {
+	image.RegisterFormat("exr", magic, func(r io.Reader) (image.Image, error) { return Decode(r) }, DecodeConfig)
+}
+
+// Encode writes img to w as a single-part scanline OpenEXR file with
+// increasing y line order. The channels are written in name order.
+func Encode(w io.Writer, img *Image) error {
+	lines := img.Compression.lines()
+	if lines == 0 {
+		return fmt.Errorf("%w: %v compression", ErrUnsupported, img.Compression)
+	}
+	if img.DataWindow.Empty() || len(img.Channels) == 0 {
+		return fmt.Errorf("%w: data window %v with %d channels", ErrShape, img.DataWindow, len(img.Channels))
+	}
+	out := *img
+	out.Channels = append([]*Channel{}, img.Channels...)
+	sort.SliceStable(out.Channels, func(i, j int) bool { return out.Channels[i].Name < out.Channels[j].Name })
+	n := img.DataWindow.Dx() * img.DataWindow.Dy()
+	for i, c := range out.Channels {
+		if c.Type != Half && c.Type != Float && c.Type != Uint {
+			return fmt.Errorf("%w: channel %q has pixel type %v", ErrUnsupported, c.Name, c.Type)
+		}
+		if c.Name == "" || len(c.Name) > 255 || i > 0 && c.Name == out.Channels[i-1].Name {
+			return fmt.Errorf("%w: channel name %q", ErrFormat, c.Name)
+		}
+		if c.len() != n {
+			return fmt.Errorf("%w: channel %q has %d samples for %d pixels", ErrShape, c.Name, c.len(), n)
+		}
+	}
+
+	b := out.appendHeader([]byte(magic))
+	h := img.DataWindow.Dy()
+	numChunks := (h + lines - 1) / lines
+	table := len(b)
+	b = append(b, make([]byte, 8*numChunks)...)
+	lineBytes := out.lineBytes()
+	raw := make([]byte, 0, lines*lineBytes)
+	for i := 0; i < numChunks; i++ {
+		binary.LittleEndian.PutUint64(b[table+8*i:], uint64(len(b)))
+		numLines := lines
+		if rest := h - i*lines; rest < numLines {
+			numLines = rest
+		}
+		raw = out.gather(raw[:0], i*lines*img.DataWindow.Dx(), numLines)
+		data := out.compress(raw, numLines)
+		b = binary.LittleEndian.AppendUint32(b, uint32(int32(img.DataWindow.Min.Y+i*lines)))
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(data)))
+		b = append(b, data...)
+	}
+	_, err := w.Write(b)
+	return err
+}
+
+// appendHeader appends the version and attributes of img, in name order
+// like OpenEXR writes them, to b.
+func (img *Image) appendHeader(b []byte) []byte {
+	version := int32(2)
+	attrs := append([]Attribute{}, img.Attributes...)
+	var chlist []byte
+	for _, c := range img.Channels {
+		if len(c.Name) > 31 {
+			version |= flagLongNames
+		}
+		chlist = append(append(chlist, c.Name...), 0)
+		chlist = binary.LittleEndian.AppendUint32(chlist, uint32(c.Type))
+		linear := byte(0)
+		if c.Linear {
+			linear = 1
+		}
+		chlist = append(chlist, linear, 0, 0, 0, 1, 0, 0, 0, 1, 0, 0, 0)
+	}
+	attrs = append(attrs,
+		Attribute{"channels", "chlist", append(chlist, 0)},
+		Attribute{"compression", "compression", []byte{byte(img.Compression)}},
+		Attribute{"dataWindow", "box2i", appendBox(nil, img.DataWindow)},
+		Attribute{"displayWindow", "box2i", appendBox(nil, img.DisplayWindow)},
+		Attribute{"lineOrder", "lineOrder", []byte{0}},
+		Attribute{"pixelAspectRatio", "float", appendFloat32(nil, img.PixelAspectRatio)},
+		Attribute{"screenWindowCenter", "v2f", appendFloat32(appendFloat32(nil, img.ScreenWindowCenter[0]), img.ScreenWindowCenter[1])},
+		Attribute{"screenWindowWidth", "float", appendFloat32(nil, img.ScreenWindowWidth)},
+	)
+	sort.SliceStable(attrs, func(i, j int) bool { return attrs[i].Name < attrs[j].Name })
+	for _, a := range attrs {
+		if len(a.Name) > 31 || len(a.Type) > 31 {
+			version |= flagLongNames
+		}
+	}
+
+	b = binary.LittleEndian.AppendUint32(b, uint32(version))
+	for _, a := range attrs {
+		b = append(append(b, a.Name...), 0)
+		b = append(append(b, a.Type...), 0)
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(a.Value)))
+		b = append(b, a.Value...)
+	}
+	return append(b, 0)
+}
+
+func appendBox(b []byte, r image.Rectangle) []byte {
+	for _, v := range []int{r.Min.X, r.Min.Y, r.Max.X - 1, r.Max.Y - 1} {
+		b = binary.LittleEndian.AppendUint32(b, uint32(int32(v)))
+	}
+	return b
+}
+
+func appendFloat32(b []byte, f float32) []byte {
+	return binary.LittleEndian.AppendUint32(b, math.Float32bits(f))
+}
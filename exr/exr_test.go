@@ -0,0 +1,398 @@
+package exr_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+	"os"
+	"reflect"
+	"testing"
+
+	floatx "github.com/chenxingqiang/go-floatx"
+	"github.com/chenxingqiang/go-floatx/exr"
+)
+
+// The fixtures in testdata are written by testdata/gen_fixtures.py.
+
+var compressions = []struct {
+	name string
+	c    exr.Compression
+}{
+	{"none", exr.None},
+	{"rle", exr.RLE},
+	{"zips", exr.ZIPS},
+	{"zip", exr.ZIP},
+	{"piz", exr.PIZ},
+}
+
+func decodeFile(t *testing.T, name string) (*exr.Image, []byte) {
+	t.Helper()
+	b, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := exr.Decode(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	return img, b
+}
+
+func TestDecode(t *testing.T) {
+	want, _ := decodeFile(t, "rgba_none.exr")
+	if want.DataWindow != image.Rect(-3, 5, 18, 45) || want.DisplayWindow != image.Rect(0, 0, 20, 50) {
+		t.Errorf("DataWindow %v, DisplayWindow %v", want.DataWindow, want.DisplayWindow)
+	}
+	if want.PixelAspectRatio != 1 || want.ScreenWindowWidth != 1 || want.ScreenWindowCenter != [2]float32{} {
+		t.Errorf("PixelAspectRatio %v, ScreenWindowWidth %v, ScreenWindowCenter %v",
+			want.PixelAspectRatio, want.ScreenWindowWidth, want.ScreenWindowCenter)
+	}
+	if !reflect.DeepEqual(want.Attributes, []exr.Attribute{{Name: "owner", Type: "string", Value: []byte("floatx")}}) {
+		t.Errorf("Attributes = %q", want.Attributes)
+	}
+	var names []string
+	var types []exr.PixelType
+	for _, c := range want.Channels {
+		names = append(names, c.Name)
+		types = append(types, c.Type)
+	}
+	if !reflect.DeepEqual(names, []string{"A", "B", "G", "R", "Z", "id"}) ||
+		!reflect.DeepEqual(types, []exr.PixelType{exr.Half, exr.Half, exr.Half, exr.Half, exr.Float, exr.Uint}) {
+		t.Errorf("channels %v of types %v", names, types)
+	}
+
+	// samples at (i, j) from the corner of the data window
+	w := want.DataWindow.Dx()
+	for _, tc := range []struct {
+		name string
+		i, j int
+		want float32
+	}{
+		{"A", 0, 0, 1},
+		{"A", 7, 39, 0.25},
+		{"G", 3, 7, 1},
+		{"R", 16, 2, 1.5},
+		{"R", 20, 39, 1.625},
+		{"Z", 20, 39, 39.5},
+		{"id", 2, 1, 4},
+	} {
+		if got := want.Channel(tc.name).Float32s()[tc.j*w+tc.i]; got != tc.want {
+			t.Errorf("%s at (%d, %d) = %v, wanted %v", tc.name, tc.i, tc.j, got, tc.want)
+		}
+	}
+	if want.Channel("Y") != nil {
+		t.Error("Channel(Y) isn't nil")
+	}
+
+	for _, tc := range compressions[1:] {
+		img, _ := decodeFile(t, "rgba_"+tc.name+".exr")
+		if img.Compression != tc.c {
+			t.Errorf("%s: Compression = %v", tc.name, img.Compression)
+		}
+		img.Compression = exr.None
+		if !reflect.DeepEqual(img, want) {
+			t.Errorf("%s: samples differ from rgba_none.exr", tc.name)
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	// RLE and PIZ encode like the ports in gen_fixtures.py, while zlib
+	// streams depend on the implementation
+	for _, tc := range compressions {
+		img, b := decodeFile(t, "rgba_"+tc.name+".exr")
+		var buf bytes.Buffer
+		if err := exr.Encode(&buf, img); err != nil {
+			t.Fatal(err)
+		}
+		if tc.c != exr.ZIPS && tc.c != exr.ZIP && !bytes.Equal(buf.Bytes(), b) {
+			t.Errorf("%s: Encode wrote %d bytes different from the fixture's %d", tc.name, buf.Len(), len(b))
+		}
+		got, err := exr.Decode(&buf)
+		if err != nil || !reflect.DeepEqual(got, img) {
+			t.Errorf("%s: decoding the encoded image returned %v", tc.name, err)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	// more than 1<<14 distinct random words in the last image make PIZ use
+	// the 16-bit wavelet, and odd sizes exercise its edges
+	rnd := rand.New(rand.NewSource(1))
+	for _, r := range []image.Rectangle{image.Rect(0, 0, 1, 1), image.Rect(-10, -10, 23, 60), image.Rect(5, 3, 70, 8), image.Rect(0, 0, 640, 32)} {
+		img := exr.NewImage(r, exr.Half, "R", "B")
+		f := exr.NewImage(r, exr.Float, "depth")
+		u := exr.NewImage(r, exr.Uint, "id")
+		img.Channels = append(img.Channels, f.Channels[0], u.Channels[0])
+		img.Channels[1].Linear = true
+		for i := range img.Channels[0].Half {
+			img.Channels[0].Half[i] = floatx.Float16(0x3800 + i%9)
+			if r.Dx() == 640 {
+				img.Channels[0].Half[i] = floatx.Float16(rnd.Intn(1 << 16))
+			}
+			img.Channels[1].Half[i] = floatx.Float16(0x3c00 + i%50)
+			f.Channels[0].Float[i] = float32(i%11) / 4
+			u.Channels[0].Uint[i] = uint32(i / 7)
+		}
+		for _, tc := range compressions {
+			img.Compression = tc.c
+			var buf bytes.Buffer
+			if err := exr.Encode(&buf, img); err != nil {
+				t.Fatal(err)
+			}
+			got, err := exr.Decode(&buf)
+			if err != nil {
+				t.Fatalf("%v %s: %v", r, tc.name, err)
+			}
+			if !reflect.DeepEqual(got, img) {
+				t.Errorf("%v %s: round trip changed the image", r, tc.name)
+			}
+		}
+	}
+
+	// long names
+	img := exr.NewImage(image.Rect(0, 0, 2, 2), exr.Half, "a.channel.name.longer.than.31.bytes")
+	img.Attributes = []exr.Attribute{{Name: "comments", Type: "string", Value: []byte("hi")}}
+	var buf bytes.Buffer
+	if err := exr.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := exr.Decode(&buf); err != nil || !reflect.DeepEqual(got, img) {
+		t.Errorf("Decode of long names = %v, %v", got, err)
+	}
+}
+
+func TestImage(t *testing.T) {
+	b, err := os.ReadFile("testdata/rgba_zip.exr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, format, err := image.Decode(bytes.NewReader(b))
+	if err != nil || format != "exr" {
+		t.Fatalf("image.Decode returned %q, %v", format, err)
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(b))
+	if err != nil || format != "exr" || cfg != (image.Config{ColorModel: color.RGBA64Model, Width: 21, Height: 40}) {
+		t.Errorf("image.DecodeConfig returned %+v, %q, %v", cfg, format, err)
+	}
+	if m.ColorModel() != color.RGBA64Model || m.Bounds() != image.Rect(-3, 5, 18, 45) {
+		t.Errorf("ColorModel() %v, Bounds() %v", m.ColorModel(), m.Bounds())
+	}
+	for _, tc := range []struct {
+		x, y int
+		want color.Color
+	}{
+		{-3, 5, color.RGBA64{0xffff, 0xffff, 0, 0xffff}}, // R and G of 1, B negative
+		{4, 44, color.RGBA64{0x4000, 0x4000, 0, 0x4000}}, // clamped to A of 0.25
+		{-3, 6, color.RGBA64{0xffff, 0xffff, 0, 0xffff}}, // G of 8/7
+		{-4, 5, color.RGBA64{}},                          // outside the data window
+		{18, 44, color.RGBA64{}},
+	} {
+		if got := m.At(tc.x, tc.y); got != tc.want {
+			t.Errorf("At(%d, %d) = %v, wanted %v", tc.x, tc.y, got, tc.want)
+		}
+	}
+
+	// luminance images are gray
+	img := exr.NewImage(image.Rect(0, 0, 3, 1), exr.Half, "Y")
+	img.Channels[0].Half[1] = floatx.F16Fromfloat32(0.5)
+	img.Channels[0].Half[2] = floatx.F16NaN()
+	if img.ColorModel() != color.Gray16Model || img.At(1, 0) != (color.Gray16{0x8000}) || img.At(2, 0) != (color.Gray16{}) {
+		t.Errorf("ColorModel() %v, At(1, 0) %v, At(2, 0) %v", img.ColorModel(), img.At(1, 0), img.At(2, 0))
+	}
+	a := exr.NewImage(image.Rect(0, 0, 3, 1), exr.Float, "A")
+	a.Channels[0].Float[1] = 0.25
+	img.Channels = append(img.Channels, a.Channels[0])
+	if got := img.At(1, 0); got != (color.RGBA64{0x4000, 0x4000, 0x4000, 0x4000}) {
+		t.Errorf("At(1, 0) with alpha = %v", got)
+	}
+}
+
+func TestChannel(t *testing.T) {
+	img := exr.NewImage(image.Rect(0, 0, 2, 1), exr.Float, "f")
+	img.Channels[0].Float[1] = 1.5
+	if got := img.Channels[0].Float16s(); !reflect.DeepEqual(got, []floatx.Float16{0, 0x3e00}) {
+		t.Errorf("Float16s() of FLOAT = %v", got)
+	}
+	img = exr.NewImage(image.Rect(0, 0, 2, 1), exr.Uint, "u")
+	img.Channels[0].Uint[1] = 70000
+	if got := img.Channels[0].Float16s(); !reflect.DeepEqual(got, []floatx.Float16{0, 0x7c00}) {
+		t.Errorf("Float16s() of UINT = %v", got)
+	}
+	if got := img.Channels[0].Float32s(); !reflect.DeepEqual(got, []float32{0, 70000}) {
+		t.Errorf("Float32s() of UINT = %v", got)
+	}
+	img = exr.NewImage(image.Rect(0, 0, 2, 1), exr.Half, "h")
+	if got := img.Channels[0].Float16s(); &got[0] != &img.Channels[0].Half[0] {
+		t.Error("Float16s() of HALF copied the samples")
+	}
+
+	if s := exr.Half.String() + exr.Float.String() + exr.Uint.String() + exr.PixelType(3).String(); s != "HALFFLOATUINTPixelType(3)" {
+		t.Errorf("PixelType strings = %s", s)
+	}
+	if s := exr.PIZ.String() + exr.DWAB.String() + exr.Compression(10).String(); s != "PIZDWABCompression(10)" {
+		t.Errorf("Compression strings = %s", s)
+	}
+}
+
+type errWriter struct{}
+
+func (errWriter) Write(p []byte) (int, error) { return 0, errors.New("write failed") }
+
+func TestEncodeErrors(t *testing.T) {
+	r := image.Rect(0, 0, 2, 2)
+	newImage := func(f func(img *exr.Image)) *exr.Image {
+		img := exr.NewImage(r, exr.Half, "R", "G")
+		f(img)
+		return img
+	}
+	testCases := []struct {
+		name string
+		img  *exr.Image
+		err  error
+	}{
+		{"compression", newImage(func(img *exr.Image) { img.Compression = exr.B44 }), exr.ErrUnsupported},
+		{"empty", newImage(func(img *exr.Image) { img.DataWindow = image.Rect(0, 0, 0, 2) }), exr.ErrShape},
+		{"no channels", newImage(func(img *exr.Image) { img.Channels = nil }), exr.ErrShape},
+		{"samples", newImage(func(img *exr.Image) { img.Channels[1].Half = img.Channels[1].Half[:3] }), exr.ErrShape},
+		{"type", newImage(func(img *exr.Image) { img.Channels[0].Type = 3 }), exr.ErrUnsupported},
+		{"duplicate", newImage(func(img *exr.Image) { img.Channels[0].Name = "R" }), exr.ErrFormat},
+		{"no name", newImage(func(img *exr.Image) { img.Channels[0].Name = "" }), exr.ErrFormat},
+	}
+	for _, tc := range testCases {
+		if err := exr.Encode(&bytes.Buffer{}, tc.img); !errors.Is(err, tc.err) {
+			t.Errorf("Encode %s returned %v, wanted %v", tc.name, err, tc.err)
+		}
+	}
+	if err := exr.Encode(errWriter{}, exr.NewImage(r, exr.Half, "R")); err == nil {
+		t.Error("Encode to a failing writer returned nil")
+	}
+}
+
+// tableOffset returns the offset of the chunk offset table of an encoded
+// image without extra attributes.
+func tableOffset(b []byte) int {
+	return bytes.Index(b, []byte("screenWindowWidth\x00float\x00")) + 18 + 6 + 4 + 4 + 1
+}
+
+func TestDecodeErrors(t *testing.T) {
+	img := exr.NewImage(image.Rect(0, 0, 3, 2), exr.Half, "G", "R")
+	img.Compression = exr.None
+	var buf bytes.Buffer
+	if err := exr.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	good := buf.Bytes()
+	table := tableOffset(good)
+
+	edit := func(f func(b []byte) []byte) []byte {
+		return f(append([]byte{}, good...))
+	}
+	replace := func(old, new string) []byte {
+		if !bytes.Contains(good, []byte(old)) {
+			t.Fatalf("no %q", old)
+		}
+		return bytes.Replace(good, []byte(old), []byte(new), 1)
+	}
+	putUint32 := func(off int, v uint32) []byte {
+		return edit(func(b []byte) []byte {
+			b[off], b[off+1], b[off+2], b[off+3] = byte(v), byte(v>>8), byte(v>>16), byte(v>>24)
+			return b
+		})
+	}
+	chunk := int(binary.LittleEndian.Uint64(good[table:]))
+	dataWindow := func(x0, y0, x1, y1 int32) string {
+		b := []byte("dataWindow\x00box2i\x00\x10\x00\x00\x00")
+		for _, v := range []int32{x0, y0, x1, y1} {
+			b = binary.LittleEndian.AppendUint32(b, uint32(v))
+		}
+		return string(b)
+	}
+
+	long := exr.NewImage(image.Rect(0, 0, 1, 1), exr.Half, "a.channel.name.longer.than.31.bytes")
+	var longBuf bytes.Buffer
+	exr.Encode(&longBuf, long)
+	longNames := longBuf.Bytes()
+	longNames[5] &^= 0x04
+
+	testCases := []struct {
+		name string
+		b    []byte
+		err  error
+	}{
+		{"empty", nil, exr.ErrFormat},
+		{"magic", []byte("\x89PNG\r\n\x1a\n"), exr.ErrFormat},
+		{"version 1", edit(func(b []byte) []byte { b[4] = 1; return b }), exr.ErrUnsupported},
+		{"tiled", edit(func(b []byte) []byte { b[5] |= 0x02; return b }), exr.ErrUnsupported},
+		{"multi-part", edit(func(b []byte) []byte { b[5] |= 0x10; return b }), exr.ErrUnsupported},
+		{"truncated header", good[:40], exr.ErrFormat},
+		{"long name", longNames, exr.ErrFormat},
+		{"attribute size", replace("compression\x00compression\x00\x01", "compression\x00compression\x00\xff"), exr.ErrFormat},
+		{"negative attribute size", replace("compression\x00compression\x00\x01\x00\x00\x00", "compression\x00compression\x00\x00\x00\x00\x80"), exr.ErrFormat},
+		{"attribute type", replace("dataWindow\x00box2i", "dataWindow\x00box2f"), exr.ErrFormat},
+		{"missing attribute", replace("lineOrder\x00lineOrder", "lineOrdeX\x00lineOrder"), exr.ErrFormat},
+		{"short attribute", replace("screenWindowCenter\x00v2f\x00\x08\x00\x00\x00\x00\x00\x00\x00", "screenWindowCenter\x00v2f\x00\x04\x00\x00\x00"), exr.ErrFormat},
+		{"compression", replace("compression\x00compression\x00\x01\x00\x00\x00\x00", "compression\x00compression\x00\x01\x00\x00\x00\x06"), exr.ErrUnsupported},
+		{"pixel type", replace("R\x00\x01\x00\x00\x00", "R\x00\x03\x00\x00\x00"), exr.ErrFormat},
+		{"subsampled", replace("R\x00\x01\x00\x00\x00\x00\x00\x00\x00\x01", "R\x00\x01\x00\x00\x00\x00\x00\x00\x00\x02"), exr.ErrUnsupported},
+		{"unsorted", replace("R\x00\x01\x00\x00\x00", "A\x00\x01\x00\x00\x00"), exr.ErrFormat},
+		{"no channels", replace("G\x00\x01\x00\x00\x00\x00\x00\x00\x00\x01\x00\x00\x00\x01\x00\x00\x00R", "\x00\x00\x01\x00\x00\x00\x00\x00\x00\x00\x01\x00\x00\x00\x01\x00\x00\x00R"), exr.ErrFormat},
+		{"empty data window", replace(dataWindow(0, 0, 2, 1), dataWindow(0, 0, -1, 1)), exr.ErrFormat},
+		{"huge data window", replace(dataWindow(0, 0, 2, 1), dataWindow(0, 0, math.MaxInt32, 1)), exr.ErrFormat},
+		{"truncated offsets", good[:table+8], exr.ErrFormat},
+		{"offset", putUint32(table, 1<<31), exr.ErrFormat},
+		{"chunk y", putUint32(chunk, 1), exr.ErrFormat},
+		{"chunk size", putUint32(chunk+4, 1<<31), exr.ErrFormat},
+		{"chunk data", putUint32(chunk+4, 2), exr.ErrFormat},
+	}
+	for _, tc := range testCases {
+		if _, err := exr.Decode(bytes.NewReader(tc.b)); !errors.Is(err, tc.err) {
+			t.Errorf("Decode %s returned %v, wanted %v", tc.name, err, tc.err)
+		}
+	}
+	if _, err := exr.DecodeConfig(bytes.NewReader(good[:20])); !errors.Is(err, exr.ErrFormat) {
+		t.Errorf("DecodeConfig of a truncated header returned %v, wanted ErrFormat", err)
+	}
+	if _, err := exr.Decode(errReader{}); err == nil {
+		t.Error("Decode from a failing reader returned nil")
+	}
+	if _, err := exr.DecodeConfig(errReader{}); err == nil {
+		t.Error("DecodeConfig from a failing reader returned nil")
+	}
+}
+
+type errReader struct{}
+
+func (errReader) Read(p []byte) (int, error) { return 0, errors.New("read failed") }
+
+func TestDecodeCorrupt(t *testing.T) {
+	// decoding corrupt chunks returns errors without panicking
+	img := exr.NewImage(image.Rect(0, 0, 20, 40), exr.Half, "Y")
+	for i := range img.Channels[0].Half {
+		img.Channels[0].Half[i] = floatx.Float16(0x3c00 + i%20*(i/20%3))
+	}
+	for _, tc := range compressions[1:] {
+		img.Compression = tc.c
+		var buf bytes.Buffer
+		if err := exr.Encode(&buf, img); err != nil {
+			t.Fatal(err)
+		}
+		b := buf.Bytes()
+		start := tableOffset(b)
+		for i := start; i < len(b); i++ {
+			for _, x := range []byte{0x01, 0x80, 0xff} {
+				b[i] ^= x
+				exr.Decode(bytes.NewReader(b))
+				b[i] ^= x
+			}
+		}
+		// and truncated chunks
+		for n := 1; n < 64; n++ {
+			exr.Decode(bytes.NewReader(b[:len(b)-n]))
+		}
+	}
+}
@@ -0,0 +1,324 @@
+package exr
+
+import (
+	"container/heap"
+	"encoding/binary"
+	"fmt"
+)
+
+// Huffman coding of 16-bit words, as in OpenEXR's ImfHuf.cpp. The code
+// table is stored as code lengths, and an extra symbol after the largest
+// word codes runs of the previous word.
+
+const (
+	hufEncSize       = 1<<16 + 1 // words and the run symbol
+	maxCodeLen       = 58
+	shortZerocodeRun = 59
+	longZerocodeRun  = 63
+	shortestLongRun  = 2 + longZerocodeRun - shortZerocodeRun
+	longestLongRun   = 255 + shortestLongRun
+)
+
+// bitWriter writes bits most significant bit first.
+type bitWriter struct {
+	out []byte
+	c   uint64
+	lc  int
+}
+
+func (w *bitWriter) write(n int, bits uint64) {
+	if n > 32 {
+		w.write(n-32, bits>>32)
+		n, bits = 32, bits&(1<<32-1)
+	}
+	w.c = w.c<<n | bits
+	for w.lc += n; w.lc >= 8; {
+		w.lc -= 8
+		w.out = append(w.out, byte(w.c>>w.lc))
+	}
+}
+
+func (w *bitWriter) flush() {
+	if w.lc > 0 {
+		w.out = append(w.out, byte(w.c<<(8-w.lc)))
+	}
+}
+
+// bitReader reads bits most significant bit first.
+type bitReader struct {
+	in []byte
+	c  uint64
+	lc int
+}
+
+func (r *bitReader) read(n int) (uint64, bool) {
+	for r.lc < n {
+		if len(r.in) == 0 {
+			return 0, false
+		}
+		r.c = r.c<<8 | uint64(r.in[0])
+		r.in = r.in[1:]
+		r.lc += 8
+	}
+	r.lc -= n
+	return r.c >> r.lc & (1<<n - 1), true
+}
+
+// canonicalCodes returns the canonical codes for the code lengths, longer
+// codes first, as OpenEXR assigns them.
+func canonicalCodes(lengths []uint8) []uint64 {
+	var n [maxCodeLen + 1]uint64
+	for _, l := range lengths {
+		n[l]++
+	}
+	c := uint64(0)
+	for i := maxCodeLen; i > 0; i-- {
+		n[i], c = c, (c+n[i])>>1
+	}
+	codes := make([]uint64, len(lengths))
+	for i, l := range lengths {
+		if l > 0 {
+			codes[i] = n[l]
+			n[l]++
+		}
+	}
+	return codes
+}
+
+// freqHeap is a min-heap of symbols by frequency, then symbol.
+type freqHeap struct {
+	s    []int
+	freq []uint64
+}
+
+func (h *freqHeap) Len() int { return len(h.s) }
+func (h *freqHeap) Less(i, j int) bool {
+	fi, fj := h.freq[h.s[i]], h.freq[h.s[j]]
+	return fi < fj || fi == fj && h.s[i] < h.s[j]
+}
+func (h *freqHeap) Swap(i, j int) { h.s[i], h.s[j] = h.s[j], h.s[i] }
+func (h *freqHeap) Push(x any)    { h.s = append(h.s, x.(int)) }
+func (h *freqHeap) Pop() any {
+	x := h.s[len(h.s)-1]
+	h.s = h.s[:len(h.s)-1]
+	return x
+}
+
+// codeLengths returns the Huffman code lengths of the words with the
+// given frequencies, the smallest and largest symbol with a code, and the
+// run symbol, which is one more than the largest word.
+func codeLengths(freq []uint64) (lengths []uint8, im, iM int) {
+	for freq[im] == 0 {
+		im++
+	}
+	h := &freqHeap{freq: freq}
+	link := make([]int, hufEncSize) // lists of the symbols of each subtree
+	for i := im; i < hufEncSize; i++ {
+		link[i] = i
+		if freq[i] != 0 {
+			h.s = append(h.s, i)
+			iM = i
+		}
+	}
+	iM++
+	freq[iM] = 1
+	h.s = append(h.s, iM)
+	heap.Init(h)
+
+	lengths = make([]uint8, hufEncSize)
+	for h.Len() > 1 {
+		m := heap.Pop(h).(int)
+		mm := heap.Pop(h).(int)
+		freq[m] += freq[mm]
+		heap.Push(h, m)
+		// the symbols of both subtrees get one bit longer, and the list of
+		// mm is appended to that of m
+		for j := m; ; j = link[j] {
+			lengths[j]++
+			if link[j] == j {
+				link[j] = mm
+				break
+			}
+		}
+		for j := mm; ; j = link[j] {
+			lengths[j]++
+			if link[j] == j {
+				break
+			}
+		}
+	}
+	return lengths, im, iM
+}
+
+// hufCompress returns the Huffman coding of data.
+func hufCompress(data []uint16) []byte {
+	if len(data) == 0 {
+		return nil
+	}
+	freq := make([]uint64, hufEncSize)
+	for _, v := range data {
+		freq[v]++
+	}
+	lengths, im, iM := codeLengths(freq)
+	codes := canonicalCodes(lengths)
+
+	// the code lengths, with runs of zeros
+	table := &bitWriter{}
+	for i := im; i <= iM; i++ {
+		if lengths[i] == 0 {
+			run := 1
+			for i < iM && run < longestLongRun && lengths[i+1] == 0 {
+				i++
+				run++
+			}
+			if run >= shortestLongRun {
+				table.write(6, longZerocodeRun)
+				table.write(8, uint64(run-shortestLongRun))
+				continue
+			}
+			if run >= 2 {
+				table.write(6, uint64(shortZerocodeRun+run-2))
+				continue
+			}
+		}
+		table.write(6, uint64(lengths[i]))
+	}
+	table.flush()
+
+	// the words, with runs of up to 255 repeats coded as the word, the run
+	// symbol and the count when that's shorter
+	w := &bitWriter{}
+	send := func(s uint16, run int) {
+		l, rl := int(lengths[s]), int(lengths[iM])
+		if l+rl+8 < l*run {
+			w.write(l, codes[s])
+			w.write(rl, codes[iM])
+			w.write(8, uint64(run))
+			return
+		}
+		for ; run >= 0; run-- {
+			w.write(l, codes[s])
+		}
+	}
+	s, run := data[0], 0
+	for _, v := range data[1:] {
+		if v == s && run < 255 {
+			run++
+		} else {
+			send(s, run)
+			run = 0
+		}
+		s = v
+	}
+	send(s, run)
+	nBits := 8*len(w.out) + w.lc
+	w.flush()
+
+	b := binary.LittleEndian.AppendUint32(nil, uint32(im))
+	b = binary.LittleEndian.AppendUint32(b, uint32(iM))
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(table.out)))
+	b = binary.LittleEndian.AppendUint32(b, uint32(nBits))
+	b = binary.LittleEndian.AppendUint32(b, 0)
+	b = append(b, table.out...)
+	return append(b, w.out...)
+}
+
+// hufDecompress decodes the Huffman coding src to dst, which it must fill
+// exactly.
+func hufDecompress(dst []uint16, src []byte) error {
+	if len(src) == 0 && len(dst) == 0 {
+		return nil
+	}
+	if len(src) < 20 {
+		return errCorrupt
+	}
+	im, iM := binary.LittleEndian.Uint32(src), binary.LittleEndian.Uint32(src[4:])
+	nBits := uint64(binary.LittleEndian.Uint32(src[12:]))
+	if im > iM || iM >= hufEncSize {
+		return fmt.Errorf("invalid Huffman table size")
+	}
+
+	r := &bitReader{in: src[20:]}
+	lengths := make([]uint8, hufEncSize)
+	for i := int(im); i <= int(iM); i++ {
+		l, ok := r.read(6)
+		if !ok {
+			return errCorrupt
+		}
+		run := 0
+		switch {
+		case l == longZerocodeRun:
+			n, ok := r.read(8)
+			if !ok {
+				return errCorrupt
+			}
+			run = int(n) + shortestLongRun
+		case l >= shortZerocodeRun:
+			run = int(l) - shortZerocodeRun + 2
+		default:
+			lengths[i] = uint8(l)
+			continue
+		}
+		if i+run > int(iM)+1 {
+			return errCorrupt
+		}
+		i += run - 1
+	}
+	codes := canonicalCodes(lengths)
+
+	// canonical decoding: the codes of each length are consecutive, in
+	// symbol order
+	var first, count [maxCodeLen + 1]uint64
+	var symbols [maxCodeLen + 1][]int
+	for i, l := range lengths {
+		if l > 0 {
+			if count[l] == 0 {
+				first[l] = codes[i]
+			}
+			count[l]++
+			symbols[l] = append(symbols[l], i)
+		}
+	}
+
+	data := r.in
+	if nBits > 8*uint64(len(data)) {
+		return errCorrupt
+	}
+	r = &bitReader{in: data}
+	out := dst[:0]
+	for read := uint64(0); read < nBits; {
+		code, l := uint64(0), 0
+		for {
+			b, _ := r.read(1)
+			code = code<<1 | b
+			l++
+			read++
+			if code-first[l] < count[l] {
+				break
+			}
+			if l == maxCodeLen || read == nBits {
+				return errCorrupt
+			}
+		}
+		s := symbols[l][code-first[l]]
+		if s != int(iM) {
+			if len(out) == len(dst) {
+				return errCorrupt
+			}
+			out = append(out, uint16(s))
+			continue
+		}
+		n, _ := r.read(8)
+		read += 8
+		if read > nBits || len(out) == 0 || int(n) > len(dst)-len(out) {
+			return errCorrupt
+		}
+		for v := out[len(out)-1]; n > 0; n-- {
+			out = append(out, v)
+		}
+	}
+	if len(out) != len(dst) {
+		return errCorrupt
+	}
+	return nil
+}
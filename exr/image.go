@@ -0,0 +1,63 @@
+package exr
+
+import (
+	"image"
+	"image/color"
+)
+
+// ColorModel returns color.RGBA64Model, or color.Gray16Model for an image
+// with a Y channel and no R, G or B channel.
+func (img *Image) ColorModel() color.Model {
+	if img.Channel("R") == nil && img.Channel("G") == nil && img.Channel("B") == nil && img.Channel("Y") != nil {
+		return color.Gray16Model
+	}
+	return color.RGBA64Model
+}
+
+// Bounds returns the data window of img.
+func (img *Image) Bounds() image.Rectangle {
+	return img.DataWindow
+}
+
+// At returns the color of the pixel at (x, y) from the R, G, B and A
+// channels, or the Y and A channels of a luminance image. Missing color
+// channels are 0 and a missing A is 1. The samples are clamped to [0, 1]
+// without tone mapping or a transfer function, since OpenEXR samples are
+// linear and premultiplied, like color.RGBA64.
+func (img *Image) At(x, y int) color.Color {
+	if !(image.Point{x, y}.In(img.DataWindow)) {
+		return color.RGBA64{}
+	}
+	i := (y-img.DataWindow.Min.Y)*img.DataWindow.Dx() + x - img.DataWindow.Min.X
+	a := sample(img.Channel("A"), i, 1)
+	if img.ColorModel() == color.Gray16Model {
+		y := sample(img.Channel("Y"), i, 0)
+		if img.Channel("A") == nil {
+			return color.Gray16{y}
+		}
+		y = min(y, a)
+		return color.RGBA64{y, y, y, a}
+	}
+	return color.RGBA64{
+		min(sample(img.Channel("R"), i, 0), a),
+		min(sample(img.Channel("G"), i, 0), a),
+		min(sample(img.Channel("B"), i, 0), a),
+		a,
+	}
+}
+
+// sample returns sample i of c clamped to [0, 1] and scaled to 16 bits, or
+// def scaled if c is nil.
+func sample(c *Channel, i int, def float32) uint16 {
+	v := def
+	if c != nil {
+		v = c.at(i)
+	}
+	switch {
+	case v >= 1:
+		return 0xffff
+	case v > 0:
+		return uint16(v*0xffff + 0.5)
+	}
+	return 0 // and NaN
+}
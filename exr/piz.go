@@ -0,0 +1,254 @@
+package exr
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// PIZ compression, as in OpenEXR's ImfPizCompressor.cpp: the 16-bit words
+// of each channel are mapped through a lookup table to a dense range,
+// transformed with a 2D Haar wavelet, and Huffman coded.
+
+const bitmapSize = 1 << 16 / 8
+
+// pizWords returns the starting index in the PIZ buffer of the words of
+// each channel for n scanlines, and the total number of words.
+func (img *Image) pizWords(n int) ([]int, int) {
+	starts := make([]int, len(img.Channels))
+	total := 0
+	for i, c := range img.Channels {
+		starts[i] = total
+		total += img.DataWindow.Dx() * n * c.Type.size() / 2
+	}
+	return starts, total
+}
+
+// pizCompress returns the PIZ compression of the n scanlines in raw.
+func (img *Image) pizCompress(raw []byte, n int) []byte {
+	w := img.DataWindow.Dx()
+	starts, total := img.pizWords(n)
+	buf := make([]uint16, total)
+	for y := 0; y < n; y++ {
+		for i, c := range img.Channels {
+			nw := w * c.Type.size() / 2
+			dst := buf[starts[i]+y*nw:]
+			for x := range dst[:nw] {
+				dst[x] = binary.LittleEndian.Uint16(raw[2*x:])
+			}
+			raw = raw[2*nw:]
+		}
+	}
+
+	var bitmap [bitmapSize]byte
+	for _, v := range buf {
+		bitmap[v>>3] |= 1 << (v & 7)
+	}
+	bitmap[0] &^= 1 // zero is always in the table
+	minNonZero, maxNonZero := bitmapSize-1, 0
+	for i, b := range bitmap {
+		if b != 0 {
+			minNonZero = min(minNonZero, i)
+			maxNonZero = i
+		}
+	}
+	var lut [1 << 16]uint16
+	maxValue := uint16(0)
+	for i := 1; i < len(lut); i++ {
+		if bitmap[i>>3]&(1<<(i&7)) != 0 {
+			maxValue++
+			lut[i] = maxValue
+		}
+	}
+	for i, v := range buf {
+		buf[i] = lut[v]
+	}
+
+	for i, c := range img.Channels {
+		size := c.Type.size() / 2
+		for j := 0; j < size; j++ {
+			wav2Encode(buf[starts[i]+j:], w, size, n, w*size, maxValue)
+		}
+	}
+
+	b := binary.LittleEndian.AppendUint16(nil, uint16(minNonZero))
+	b = binary.LittleEndian.AppendUint16(b, uint16(maxNonZero))
+	if minNonZero <= maxNonZero {
+		b = append(b, bitmap[minNonZero:maxNonZero+1]...)
+	}
+	huf := hufCompress(buf)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(huf)))
+	return append(b, huf...)
+}
+
+// pizDecompress decompresses the PIZ chunk src of n scanlines to dst.
+func (img *Image) pizDecompress(dst, src []byte, n int) error {
+	if len(src) < 4 {
+		return errCorrupt
+	}
+	var bitmap [bitmapSize]byte
+	minNonZero, maxNonZero := int(binary.LittleEndian.Uint16(src)), int(binary.LittleEndian.Uint16(src[2:]))
+	src = src[4:]
+	if maxNonZero >= bitmapSize {
+		return fmt.Errorf("bitmap index %d", maxNonZero)
+	}
+	if minNonZero <= maxNonZero {
+		if len(src) < maxNonZero-minNonZero+1 {
+			return errCorrupt
+		}
+		src = src[copy(bitmap[minNonZero:maxNonZero+1], src):]
+	}
+	var lut [1 << 16]uint16
+	maxValue := uint16(0)
+	for i := 1; i < len(lut); i++ {
+		if bitmap[i>>3]&(1<<(i&7)) != 0 {
+			maxValue++
+			lut[maxValue] = uint16(i)
+		}
+	}
+
+	if len(src) < 4 || uint64(binary.LittleEndian.Uint32(src)) > uint64(len(src)-4) {
+		return errCorrupt
+	}
+	src = src[4 : 4+binary.LittleEndian.Uint32(src)]
+	w := img.DataWindow.Dx()
+	starts, total := img.pizWords(n)
+	if 2*total != len(dst) {
+		return errCorrupt
+	}
+	buf := make([]uint16, total)
+	if err := hufDecompress(buf, src); err != nil {
+		return err
+	}
+
+	for i, c := range img.Channels {
+		size := c.Type.size() / 2
+		for j := 0; j < size; j++ {
+			wav2Decode(buf[starts[i]+j:], w, size, n, w*size, maxValue)
+		}
+	}
+	for i, v := range buf {
+		buf[i] = lut[v]
+	}
+
+	for y := 0; y < n; y++ {
+		for i, c := range img.Channels {
+			nw := w * c.Type.size() / 2
+			for _, v := range buf[starts[i]+y*nw : starts[i]+(y+1)*nw] {
+				binary.LittleEndian.PutUint16(dst, v)
+				dst = dst[2:]
+			}
+		}
+	}
+	return nil
+}
+
+// The wavelet transform of OpenEXR's ImfWav.cpp. Values below 1<<14 use
+// the 14-bit transform, whose intermediate values fit in an int16, and
+// others the 16-bit transform modulo 1<<16.
+
+const (
+	aOffset = 1 << 15
+	mOffset = 1 << 15
+	modMask = 1<<16 - 1
+)
+
+func wenc14(a, b uint16) (l, h uint16) {
+	as, bs := int(int16(a)), int(int16(b))
+	return uint16((as + bs) >> 1), uint16(as - bs)
+}
+
+func wdec14(l, h uint16) (a, b uint16) {
+	hi := int(int16(h))
+	ai := int(int16(l)) + hi&1 + hi>>1
+	return uint16(ai), uint16(ai - hi)
+}
+
+func wenc16(a, b uint16) (l, h uint16) {
+	ao := (int(a) + aOffset) & modMask
+	m := (ao + int(b)) >> 1
+	d := ao - int(b)
+	if d < 0 {
+		m = (m + mOffset) & modMask
+	}
+	return uint16(m), uint16(d & modMask)
+}
+
+func wdec16(l, h uint16) (a, b uint16) {
+	m, d := int(l), int(h)
+	bb := (m - d>>1) & modMask
+	return uint16((d + bb - aOffset) & modMask), uint16(bb)
+}
+
+// wav2Encode transforms the nx by ny words of in, with x and y strides ox
+// and oy, in place. mx is the largest word.
+func wav2Encode(in []uint16, nx, ox, ny, oy int, mx uint16) {
+	enc := wenc16
+	if mx < 1<<14 {
+		enc = wenc14
+	}
+	n := min(nx, ny)
+	for p, p2 := 1, 2; p2 <= n; p, p2 = p2, p2<<1 {
+		oy1, oy2, ox1, ox2 := oy*p, oy*p2, ox*p, ox*p2
+		py := 0
+		for ; py <= oy*(ny-p2); py += oy2 {
+			px := py
+			for ; px <= py+ox*(nx-p2); px += ox2 {
+				p01, p10 := px+ox1, px+oy1
+				p11 := p10 + ox1
+				i00, i01 := enc(in[px], in[p01])
+				i10, i11 := enc(in[p10], in[p11])
+				in[px], in[p10] = enc(i00, i10)
+				in[p01], in[p11] = enc(i01, i11)
+			}
+			if nx&p != 0 { // odd column
+				p10 := px + oy1
+				in[px], in[p10] = enc(in[px], in[p10])
+			}
+		}
+		if ny&p != 0 { // odd row
+			for px := py; px <= py+ox*(nx-p2); px += ox2 {
+				p01 := px + ox1
+				in[px], in[p01] = enc(in[px], in[p01])
+			}
+		}
+	}
+}
+
+// wav2Decode reverses wav2Encode.
+func wav2Decode(in []uint16, nx, ox, ny, oy int, mx uint16) {
+	dec := wdec16
+	if mx < 1<<14 {
+		dec = wdec14
+	}
+	n := min(nx, ny)
+	p := 1
+	for p <= n {
+		p <<= 1
+	}
+	p >>= 1
+	for p, p2 := p>>1, p; p >= 1; p, p2 = p>>1, p {
+		oy1, oy2, ox1, ox2 := oy*p, oy*p2, ox*p, ox*p2
+		py := 0
+		for ; py <= oy*(ny-p2); py += oy2 {
+			px := py
+			for ; px <= py+ox*(nx-p2); px += ox2 {
+				p01, p10 := px+ox1, px+oy1
+				p11 := p10 + ox1
+				i00, i10 := dec(in[px], in[p10])
+				i01, i11 := dec(in[p01], in[p11])
+				in[px], in[p01] = dec(i00, i01)
+				in[p10], in[p11] = dec(i10, i11)
+			}
+			if nx&p != 0 { // odd column
+				p10 := px + oy1
+				in[px], in[p10] = dec(in[px], in[p10])
+			}
+		}
+		if ny&p != 0 { // odd row
+			for px := py; px <= py+ox*(nx-p2); px += ox2 {
+				p01 := px + ox1
+				in[px], in[p01] = dec(in[px], in[p01])
+			}
+		}
+	}
+}
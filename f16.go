@@ -16,6 +16,7 @@ const (
 	// PrecisionUnknown is for subnormals that don't drop bits during conversion but
 	// not all of these can round-trip so precision is unknown without more effort.
 	// Only 2046 of these can round-trip and the rest cannot round-trip.
+	// PrecisionExactFromfloat32 and ShortestEncoding tell them apart.
 	F16PrecisionUnknown
 
 	// PrecisionInexact is for dropped significand bits and cannot round-trip.
@@ -32,6 +33,23 @@ const (
 // Float16 represents IEEE 754 half-precision floating-point numbers (binary16).
 type Float16 uint16
 
+// Limits and properties of Float16 values.
+//
+// MaxExp and MinExp are the largest and smallest exponents returned by Frexp
+// for finite normal values, like C's FLT_MAX_EXP and FLT_MIN_EXP.
+const (
+	F16Max               = 65504   // 0x1.ffcp15, bits 0x7bff
+	F16SmallestNormal    = 0x1p-14 // 6.103515625e-05, bits 0x0400
+	F16SmallestSubnormal = 0x1p-24 // 5.960464477539063e-08, bits 0x0001
+	F16Epsilon           = 0x1p-10 // difference between 1 and the next value
+
+	F16MaxExactInt = 1 << 11 // every integer up to this magnitude is exact
+	F16Digits      = 11      // significand bits, including the implicit bit
+	F16PayloadBits = 9       // NaN payload bits, below the quiet bit
+	F16MaxExp      = 16
+	F16MinExp      = -13
+)
+
 // Precision indicates whether the conversion to Float16 is
 // exact, subnormal without dropped bits, inexact, underflow, or overflow.
 
@@ -77,18 +95,45 @@ func F16PrecisionFromfloat32(f32 float32) F16Precision {
 	}
 
 	if exp < -14 {
-		// Subnormals. Caller may want to test these further.
+		// Subnormals. Caller may want to test these further,
+		// or use PrecisionExactFromfloat32.
 		// There are 2046 subnormals that can successfully round-trip f32->f16->f32
 		// and 20 of those 2046 have 32-bit input coef == 0.
 		// RFC 7049 and 7049bis Draft 12 don't precisely define "preserves value"
 		// so some protocols and libraries will choose to handle subnormals differently
 		// when deciding to encode them to CBOR float32 vs float16.
+		// ShortestEncoding treats the ones that round-trip as float16.
 		return F16PrecisionUnknown
 	}
 
 	return F16PrecisionExact
 }
 
+// PrecisionExactFromfloat32 returns the Precision of the actual conversion
+// of f32 to Float16, which it performs. It never returns PrecisionUnknown:
+// PrecisionExact if f32 round-trips float32->float16->float32,
+// PrecisionOverflow if a finite f32 becomes ±Inf, PrecisionUnderflow if a
+// nonzero f32 becomes ±0, and PrecisionInexact otherwise. Infinities and
+// NaN return PrecisionExact, like PrecisionFromfloat32.
+//
+// PrecisionFromfloat32 decides overflow and underflow by the exponent of
+// f32 alone, so it reports PrecisionInexact for 65520, which rounds to
+// +Inf, and PrecisionUnderflow for values in (2^-25, 2^-24), which round
+// to the smallest subnormal. PrecisionExactFromfloat32 reports those as
+// PrecisionOverflow and PrecisionInexact.
+func F16PrecisionExactFromfloat32(f32 float32) F16Precision {
+	f16 := F16Fromfloat32(f32)
+	switch back := f16.Float32(); {
+	case f32 != f32 || back == f32:
+		return F16PrecisionExact
+	case f16.IsInf(0):
+		return F16PrecisionOverflow
+	case back == 0:
+		return F16PrecisionUnderflow
+	}
+	return F16PrecisionInexact
+}
+
 // Frombits returns the float16 number corresponding to the IEEE 754 binary16
 // representation u16, with the sign bit of u16 and the result in the same bit
 // position. Frombits(Bits(x)) == x.
@@ -146,6 +191,30 @@ func F16NaN() Float16 {
 	return Float16(0x7e01)
 }
 
+// F16NaNWithPayload returns a positive Float16 NaN with the low F16PayloadBits
+// bits of payload, quiet or signaling. A signaling NaN with a zero payload
+// would be infinity, so it gets payload 1 like F16FromNaN32ps.
+//
+// Float32 places the payload in the top bits of the float32 payload, and
+// Fromfloat32 keeps the top F16PayloadBits bits, so payloads survive
+// round-trips through float32 and float64, which quiet signaling NaNs as
+// IEEE 754 requires.
+func F16NaNWithPayload(payload uint, quiet bool) Float16 {
+	u := uint16(0x7c00) | uint16(payload)&0x01ff
+	if quiet {
+		u |= 0x0200
+	} else if u&0x01ff == 0 {
+		u |= 0x0001
+	}
+	return Float16(u)
+}
+
+// F16CanonicalNaN returns the canonical Float16 NaN 0x7e00, the positive quiet
+// NaN with a zero payload.
+func F16CanonicalNaN() Float16 {
+	return Float16(0x7e00)
+}
+
 // Inf returns a Float16 with an infinity value with the specified sign.
 // A sign >= returns positive infinity.
 // A sign < 0 returns negative infinity.
@@ -169,6 +238,11 @@ func (f Float16) Bits() uint16 {
 	return uint16(f)
 }
 
+// Bits16 returns the same bits as Bits, for generic code over SmallFloat.
+func (f Float16) Bits16() uint16 {
+	return uint16(f)
+}
+
 // IsNaN reports whether f is an IEEE 754 binary16 “not-a-number” value.
 func (f Float16) IsNaN() bool {
 	return (f&0x7c00 == 0x7c00) && (f&0x03ff != 0)
@@ -180,6 +254,30 @@ func (f Float16) IsQuietNaN() bool {
 	return (f&0x7c00 == 0x7c00) && (f&0x03ff != 0) && (f&0x0200 != 0)
 }
 
+// IsSignalingNaN reports whether f is a signaling IEEE 754 binary16
+// “not-a-number” value.
+func (f Float16) IsSignalingNaN() bool {
+	return f.IsNaN() && (f&0x0200 == 0)
+}
+
+// Payload returns the NaN payload of f, the significand bits below the
+// quiet bit, or 0 if f isn't NaN.
+func (f Float16) Payload() uint {
+	if !f.IsNaN() {
+		return 0
+	}
+	return uint(f & 0x01ff)
+}
+
+// Quiet returns f with the quiet bit set if f is a signaling NaN, keeping
+// its sign and payload, and f otherwise.
+func (f Float16) Quiet() Float16 {
+	if f.IsNaN() {
+		return f | 0x0200
+	}
+	return f
+}
+
 // IsInf reports whether f is an infinity (inf).
 // A sign > 0 reports whether f is positive inf.
 // A sign < 0 reports whether f is negative inf.
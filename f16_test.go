@@ -3,14 +3,11 @@
 package floatx_test
 
 import (
-	"bytes"
-	"crypto/sha512"
-	"encoding/binary"
-	"encoding/hex"
-	"fmt"
-	floatx "github.com/chenxingqiang/go-floatx"
 	"math"
 	"testing"
+
+	floatx "github.com/chenxingqiang/go-floatx"
+	"github.com/chenxingqiang/go-floatx/floatxtest"
 )
 
 // wantF32toF16bits is a tiny subset of expected values
@@ -318,6 +315,72 @@ func TestF16PrecisionFromfloat32(t *testing.T) {
 
 }
 
+// Test PrecisionExactFromfloat32() on every float32 value whose result is a
+// subnormal without dropped bits, and a sparse sweep of the others.
+// TestF16AllFromFloat32 checks more.
+func TestF16PrecisionExactFromfloat32(t *testing.T) {
+	unknown := 0
+	for _, sign := range []uint32{0, 0x80000000} {
+		for u32 := uint32(0); u32 < 0x38800000; u32 += 0x2000 {
+			f32 := math.Float32frombits(sign | u32)
+			if floatx.F16PrecisionFromfloat32(f32) == floatx.F16PrecisionUnknown {
+				unknown++
+			}
+			F16CheckPrecision(t, f32, floatx.F16Fromfloat32(f32), uint64(u32))
+		}
+	}
+	if unknown != 20480 {
+		t.Errorf("got %d inputs with PrecisionUnknown, wanted 20480", unknown)
+	}
+	for i := uint64(0); i <= 0xffffffff; i += 65521 {
+		f32 := math.Float32frombits(uint32(i))
+		F16CheckPrecision(t, f32, floatx.F16Fromfloat32(f32), i)
+	}
+}
+
+// Test that PrecisionExactFromfloat32 reports overflow and underflow by the
+// rounded result, at the thresholds where PrecisionFromfloat32 doesn't.
+func TestPrecisionExactThresholds(t *testing.T) {
+	const (
+		exact     = floatx.F16PrecisionExact
+		inexact   = floatx.F16PrecisionInexact
+		underflow = floatx.F16PrecisionUnderflow
+		overflow  = floatx.F16PrecisionOverflow
+	)
+	testCases := []struct {
+		f32           float32
+		f16, bf16, f8 floatx.F16Precision
+	}{
+		{65504, exact, inexact, overflow},
+		{65519, inexact, inexact, overflow},
+		{65520, overflow, inexact, overflow},
+		{-65520, overflow, inexact, overflow},
+		{57344, exact, exact, exact},
+		{61439, inexact, inexact, inexact},
+		{61440, exact, exact, overflow},
+		{math.MaxFloat32, overflow, overflow, overflow},
+		{0x1p-24, exact, exact, underflow},
+		{0x1.8p-25, inexact, exact, underflow},
+		{0x1p-25, underflow, exact, underflow},
+		{0x1.8p-17, exact, exact, inexact},
+		{0x1p-17, exact, exact, underflow},
+		{0x1.8p-134, underflow, inexact, underflow},
+		{0x1p-134, underflow, underflow, underflow},
+		{float32(math.Inf(-1)), exact, exact, exact},
+	}
+	for _, tc := range testCases {
+		if got := floatx.F16PrecisionExactFromfloat32(tc.f32); got != tc.f16 {
+			t.Errorf("F16PrecisionExactFromfloat32(%v) = %d, wanted %d", tc.f32, got, tc.f16)
+		}
+		if got := floatx.F16Precision(floatx.BF16PrecisionExactFromfloat32(tc.f32)); got != tc.bf16 {
+			t.Errorf("BF16PrecisionExactFromfloat32(%v) = %d, wanted %d", tc.f32, got, tc.bf16)
+		}
+		if got := floatx.F16Precision(floatx.F8PrecisionExactFromfloat32(tc.f32)); got != tc.f8 {
+			t.Errorf("F8PrecisionExactFromfloat32(%v) = %d, wanted %d", tc.f32, got, tc.f8)
+		}
+	}
+}
+
 func TestF16FromNaN32ps(t *testing.T) {
 	for i, v := range wantF32toF16bits {
 		f16 := floatx.F16Fromfloat32(v.in)
@@ -345,7 +408,6 @@ func TestF16FromNaN32ps(t *testing.T) {
 }
 
 // Test a small subset of possible conversions from float32 to Float16.
-// TestSomeFromFloat32 runs in under 1 second while TestAllFromFloat32 takes about 45 seconds.
 func TestF16SomeFromFloat32(t *testing.T) {
 
 	for i, v := range wantF32toF16bits {
@@ -358,97 +420,37 @@ func TestF16SomeFromFloat32(t *testing.T) {
 	}
 }
 
-// Test all possible 4294967296 float32 input values and results for
-// Fromfloat32(), FromNaN32ps(), and PrecisionFromfloat32().
+// Test Fromfloat32() against the floatxtest reference, and
+// PrecisionFromfloat32(), PrecisionExactFromfloat32() and FromNaN32ps() on
+// the same inputs. The inputs are sampled unless -exhaustive is set, which
+// checks all 4294967296 float32 values in a few minutes.
 func TestF16AllFromFloat32(t *testing.T) {
-
-	if testing.Short() {
-		t.Skip("skipping TestAllFromFloat32 in short mode.")
-	}
-
-	fmt.Printf("WARNING: TestAllFromFloat32 should take about 1-2 minutes to run on amd64, other platforms may take longer...\n")
-
-	// Blake2b is "3f310bc5608a087462d361644fe66feeb4c68145f6f18eb6f1439cd7914888b6df9e30ae5350dce0635162cc6a2f23b31b3e4353ca132a3c552bdbd58baa54e6"
-	const wantSHA512 = "08670429a475164d6c4a080969e35231c77ef7069b430b5f38af22e013796b7818bbe8f5942a6ddf26de0e1dfc67d02243f483d85729ebc3762fc2948a5ca1f8"
-
-	const batchSize uint32 = 16384
-	results := make([]uint16, batchSize)
-	buf := new(bytes.Buffer)
-	h := sha512.New()
-
-	for i := uint64(0); i < uint64(0xFFFFFFFF); i += uint64(batchSize) {
-		// fill results
-		for j := uint32(0); j < batchSize; j++ {
-			inF32 := math.Float32frombits(uint32(i) + j)
-			f16 := floatx.F16Fromfloat32(inF32)
-			results[j] = uint16(f16)
-			F16CheckPrecision(t, inF32, f16, i)
-			F16CheckFromNaN32ps(t, inF32, f16)
-		}
-
-		// convert results to []byte
-		err := binary.Write(buf, binary.LittleEndian, results)
-		if err != nil {
-			panic(err)
-		}
-
-		// update hash with []byte of results
-		_, err = h.Write(buf.Bytes())
-		if err != nil {
-			panic(err)
-		}
-
-		buf.Reset()
-	}
-
-	// display hash digest in hex
-	digest := h.Sum(nil)
-	gotSHA512hex := hex.EncodeToString(digest)
-	if gotSHA512hex != wantSHA512 {
-		t.Errorf("gotSHA512hex = %s", gotSHA512hex)
+	err := floatxtest.CheckFromFloat32(floatxtest.Float16, func(f32 float32) uint32 {
+		f := floatx.F16Fromfloat32(f32)
+		F16CheckPrecision(t, f32, f, uint64(math.Float32bits(f32)))
+		F16CheckFromNaN32ps(t, f32, f)
+		return uint32(f)
+	}, floatxtest.Config{Stride: uint32(sweepStride())})
+	if err != nil {
+		t.Error(err)
 	}
 }
 
-// Test all 65536 conversions from float16 to float32.
-// TestAllToFloat32 runs in under 1 second.
+// Test all 65536 conversions from float16 to float32. NaNs are quieted
+// but keep their sign and payload, so FromNaN32ps() gives back the
+// quieted bits.
 func TestF16AllToFloat32(t *testing.T) {
-	// Blake2b is "078d8e3fac9480de1493f22c8f9bfc1eb2051537c536f00f621557d70eed1af057a487c3e252f6d593769f5288d5ab66d8e9cd1adba359838802944bdb731f4d"
-	const wantSHA512 = "1a4ccec9fd7b6e83310c6b4958a25778cd95f8d4f88b19950e4b8d6932a955f7fbd96b1c9bd9b2a79c3a9d34d653f55e671f8f86e6a5a876660cd38479001aa6"
-	const batchSize uint32 = 16384
-	results := make([]float32, batchSize)
-	buf := new(bytes.Buffer)
-	h := sha512.New()
-
-	for i := uint64(0); i < uint64(0xFFFF); i += uint64(batchSize) {
-		// fill results
-		for j := uint32(0); j < batchSize; j++ {
-			inU16 := uint16(i) + uint16(j)
-			f16 := floatx.Float16(inU16)
-			results[j] = f16.Float32()
-		}
-
-		// convert results to []byte
-		err := binary.Write(buf, binary.LittleEndian, results)
-		if err != nil {
-			panic(err)
+	err := floatxtest.CheckToFloat32(floatxtest.Float16, func(u uint32) float32 {
+		f := floatx.F16Frombits(uint16(u))
+		f32 := f.Float32()
+		if nan, err := floatx.F16FromNaN32ps(f32); f.IsNaN() && (err != nil || nan != f.Quiet()) {
+			t.Errorf("F16FromNaN32ps(Float32(0x%x)) = 0x%x, %v", u, uint16(nan), err)
 		}
-
-		// update hash with []byte of results
-		_, err = h.Write(buf.Bytes())
-		if err != nil {
-			panic(err)
-		}
-
-		buf.Reset()
+		return f32
+	}, floatxtest.Config{})
+	if err != nil {
+		t.Error(err)
 	}
-
-	// display hash digest in hex
-	digest := h.Sum(nil)
-	gotSHA512hex := hex.EncodeToString(digest)
-	if gotSHA512hex != wantSHA512 {
-		t.Errorf("Float16toFloat32: gotSHA512hex = %s", gotSHA512hex)
-	}
-
 }
 
 func TestF16Frombits(t *testing.T) {
@@ -489,6 +491,9 @@ func TestF16Bits(t *testing.T) {
 	if uint16(f16) != f16.Bits() || f16.Bits() != x {
 		t.Errorf("Bits() returned %04x, wanted %04x", uint16(f16), x)
 	}
+	if f16.Bits16() != uint16(x) {
+		t.Errorf("Bits16() returned %04x, wanted %04x", f16.Bits16(), x)
+	}
 }
 
 func TestF16IsFinite(t *testing.T) {
@@ -717,6 +722,20 @@ func F16CheckFromNaN32ps(t *testing.T, f32 float32, f16 floatx.Float16) {
 	}
 }
 
+// wantExactPrecision returns the Precision that PrecisionExactFromfloat32
+// reports for f32 converted to back.
+func wantExactPrecision(f32, back float32) floatx.F16Precision {
+	switch {
+	case f32 != f32 || back == f32:
+		return floatx.F16PrecisionExact
+	case math.IsInf(float64(back), 0):
+		return floatx.F16PrecisionOverflow
+	case back == 0:
+		return floatx.F16PrecisionUnderflow
+	}
+	return floatx.F16PrecisionInexact
+}
+
 func F16CheckPrecision(t *testing.T, f32 float32, f16 floatx.Float16, i uint64) {
 	// TODO: rewrite this test when time allows
 
@@ -728,6 +747,11 @@ func F16CheckPrecision(t *testing.T, f32 float32, f16 floatx.Float16, i uint64)
 	roundtripped := u32 == u32bis
 	exp32, coef32, dropped32 := float32parts(f32)
 
+	// PrecisionExactFromfloat32 reports the result of the conversion
+	if exact := floatx.F16PrecisionExactFromfloat32(f32); floatx.F16Precision(exact) != wantExactPrecision(f32, f32bis) {
+		t.Errorf("i=%d, PrecisionExactFromfloat32 in f32bits=0x%08x (%f), back=0x%08x (%f), got %v with PrecisionFromfloat32 %v", i, u32, f32, u32bis, f32bis, exact, pre)
+	}
+
 	if roundtripped {
 		F16CheckRoundTrippedPrecision(t, u32, u16, u32bis, exp32, coef32, dropped32)
 		return
@@ -5,8 +5,29 @@ import (
 	"strconv"
 )
 
+// Float8 represents 8-bit E5M2 floating-point numbers: 1 sign bit,
+// 5 exponent bits (bias 15) and 2 significand bits. E5M2 keeps the
+// IEEE 754 rules for infinities and NaNs and is bit-for-bit the
+// upper byte of binary16.
 type Float8 uint8
 
+// Limits and properties of Float8 values.
+//
+// MaxExp and MinExp are the largest and smallest exponents returned by Frexp
+// for finite normal values, like C's FLT_MAX_EXP and FLT_MIN_EXP.
+const (
+	F8Max               = 57344   // 0x1.8p15, bits 0x7b
+	F8SmallestNormal    = 0x1p-14 // 6.103515625e-05, bits 0x04
+	F8SmallestSubnormal = 0x1p-16 // 1.52587890625e-05, bits 0x01
+	F8Epsilon           = 0x1p-2  // difference between 1 and the next value
+
+	F8MaxExactInt = 1 << 3 // every integer up to this magnitude is exact
+	F8Digits      = 3      // significand bits, including the implicit bit
+	F8PayloadBits = 1      // NaN payload bits, below the quiet bit
+	F8MaxExp      = 16
+	F8MinExp      = -13
+)
+
 type F8Precision int
 
 const (
@@ -17,7 +38,8 @@ const (
 
 	// PrecisionUnknown is for subnormals that don't drop bits during conversion but
 	// not all of these can round-trip so precision is unknown without more effort.
-	// Only 2046 of these can round-trip and the rest cannot round-trip.
+	// Only 6 of these can round-trip and the rest cannot round-trip.
+	// PrecisionExactFromfloat32 tells them apart.
 	F8PrecisionUnknown
 
 	// PrecisionInexact is for dropped significand bits and cannot round-trip.
@@ -31,8 +53,6 @@ const (
 	F8PrecisionOverflow
 )
 
-// Float8 represents IEEE 754 half-precision floating-point numbers (binary8).
-
 // Precision indicates whether the conversion to Float8 is
 // exact, subnormal without dropped bits, inexact, underflow, or overflow.
 
@@ -41,11 +61,10 @@ const (
 // values will always report PrecisionExact even if NaN payload
 // or NaN-Quiet-Bit is lost. This function is kept simple to
 // allow inlining and run < 0.5 ns/op, to serve as a fast filter.
-
 func F8PrecisionFromfloat32(f32 float32) F8Precision {
 	u32 := math.Float32bits(f32)
 
-	if u32 == 0 || u32 == 0x800000 {
+	if u32 == 0 || u32 == 0x80000000 {
 		// +- zero will always be exact conversion
 		return F8PrecisionExact
 	}
@@ -54,7 +73,7 @@ func F8PrecisionFromfloat32(f32 float32) F8Precision {
 	const EXPSHIFT uint32 = 23
 	const EXPBIAS uint32 = 127
 	const EXPMASK uint32 = uint32(0xff) << EXPSHIFT
-	const DROPMASK uint32 = COEFMASK >> 10
+	const DROPMASK uint32 = COEFMASK >> 2
 
 	exp := int32(((u32 & EXPMASK) >> EXPSHIFT) - EXPBIAS)
 	coef := u32 & COEFMASK
@@ -65,9 +84,9 @@ func F8PrecisionFromfloat32(f32 float32) F8Precision {
 		return F8PrecisionExact
 	}
 
-	// https://en.wikipedia.org/wiki/Half-precision_floating-point_format says,
-	// "Decimals between 2^−24 (minimum positive subnormal) and 2^−14 (maximum subnormal): fixed interval 2^−24"
-	if exp < -24 {
+	// E5M2 subnormals are between 2^-16 (minimum positive subnormal)
+	// and 2^-14 (minimum positive normal) with a fixed interval of 2^-16.
+	if exp < -16 {
 		return F8PrecisionUnderflow
 	}
 	if exp > 15 {
@@ -79,19 +98,35 @@ func F8PrecisionFromfloat32(f32 float32) F8Precision {
 	}
 
 	if exp < -14 {
-		// Subnormals. Caller may want to test these further.
-		// There are 2046 subnormals that can successfully round-trip f32->f8->f32
-		// and 20 of those 2046 have 32-bit input coef == 0.
-		// RFC 7049 and 7049bis Draft 12 don't precisely define "preserves value"
-		// so some protocols and libraries will choose to handle subnormals differently
-		// when deciding to encode them to CBOR float32 vs float8.
+		// Subnormals. Caller may want to test these further,
+		// or use PrecisionExactFromfloat32.
+		// There are 6 subnormals that can successfully round-trip f32->f8->f32
+		// and 4 of those 6 have 32-bit input coef == 0.
 		return F8PrecisionUnknown
 	}
 
 	return F8PrecisionExact
 }
 
-// Frombits returns the float8 number corresponding to the IEEE 754 binary8
+// PrecisionExactFromfloat32 returns the Precision of the actual conversion
+// of f32 to Float8, which it performs, see F16PrecisionExactFromfloat32.
+// Unlike PrecisionFromfloat32, it reports PrecisionOverflow for values in
+// [61440, 65536), which round to +Inf, and PrecisionInexact for values in
+// (2^-17, 2^-16), which round to the smallest subnormal.
+func F8PrecisionExactFromfloat32(f32 float32) F8Precision {
+	f8 := F8Fromfloat32(f32)
+	switch back := f8.Float32(); {
+	case f32 != f32 || back == f32:
+		return F8PrecisionExact
+	case f8.IsInf(0):
+		return F8PrecisionOverflow
+	case back == 0:
+		return F8PrecisionUnderflow
+	}
+	return F8PrecisionInexact
+}
+
+// Frombits returns the float8 number corresponding to the E5M2
 // representation u8, with the sign bit of u8 and the result in the same bit
 // position. Frombits(Bits(x)) == x.
 func F8Frombits(u8 uint8) Float8 {
@@ -111,18 +146,18 @@ type Float8Error string
 
 func (e Float8Error) Error() string { return string(e) }
 
-// FromNaN32ps converts nan to IEEE binary8 NaN while preserving both
+// FromNaN32ps converts nan to E5M2 NaN while preserving both
 // signaling and payload. Unlike Fromfloat32(), which can only return
 // qNaN because it sets quiet bit = 1, this can return both sNaN and qNaN.
 // If the result is infinity (sNaN with empty payload), then the
 // lowest bit of payload is set to make the result a NaN.
-// Returns F8F8ErrInvalidNaNValue and 0x7c (sNaN) if nan isn't IEEE 754 NaN.
+// Returns F8ErrInvalidNaNValue and 0x7d (sNaN) if nan isn't IEEE 754 NaN.
 // This function was kept simple to be able to inline.
 func F8FromNaN32ps(nan float32) (Float8, error) {
-	const SNAN = Float8(uint8(0x7c)) // signaling NaN
+	const SNAN = Float8(uint8(0x7d)) // signaling NaN
 
 	u32 := math.Float32bits(nan)
-	sign := u32 & 0x800000
+	sign := u32 & 0x80000000
 	exp := u32 & 0x7f800000
 	coef := u32 & 0x007fffff
 
@@ -130,21 +165,44 @@ func F8FromNaN32ps(nan float32) (Float8, error) {
 		return SNAN, F8ErrInvalidNaNValue
 	}
 
-	u8 := uint8((sign >> 8) | uint32(0x7c) | (coef >> 13))
+	u8 := uint8((sign >> 24) | uint32(0x7c) | (coef >> 21))
 
 	if (u8 & 0x03) == 0 {
 		// result became infinity, make it NaN by setting lowest bit in payload
-		u8 |= 0x0001
+		u8 |= 0x01
 	}
 
 	return Float8(u8), nil
 }
 
-// NaN returns a Float8 of IEEE 754 binary8 not-a-number (NaN).
-// Returned NaN value 0x7e01 has all exponent bits = 1 with the
+// NaN returns a Float8 of E5M2 not-a-number (NaN).
+// Returned NaN value 0x7f has all exponent bits = 1 with the
 // first and last bits = 1 in the significand. This is consistent
-// with Go's 64-bit math.NaN(). Canonical CBOR in RFC 7049 uses 0x7e00.
+// with Go's 64-bit math.NaN().
 func F8NaN() Float8 {
+	return Float8(0x7f)
+}
+
+// F8NaNWithPayload returns a positive Float8 NaN with the low F8PayloadBits
+// bits of payload, quiet or signaling. A signaling NaN with a zero payload
+// would be infinity, so it gets payload 1 like F8FromNaN32ps.
+//
+// The single payload bit is the top float32 payload bit below the quiet
+// bit after Float32, and Fromfloat32 keeps it, so round-trips through
+// float32 and float64 only set the quiet bit.
+func F8NaNWithPayload(payload uint, quiet bool) Float8 {
+	u := uint8(0x7c) | uint8(payload)&0x01
+	if quiet {
+		u |= 0x02
+	} else if u&0x01 == 0 {
+		u |= 0x01
+	}
+	return Float8(u)
+}
+
+// F8CanonicalNaN returns the canonical Float8 NaN 0x7e, the positive quiet
+// NaN with a zero payload.
+func F8CanonicalNaN() Float8 {
 	return Float8(0x7e)
 }
 
@@ -165,23 +223,53 @@ func (f Float8) Float32() float32 {
 	return math.Float32frombits(u32)
 }
 
-// Bits returns the IEEE 754 binary8 representation of f, with the sign bit
+// Bits returns the E5M2 representation of f, with the sign bit
 // of f and the result in the same bit position. Bits(Frombits(x)) == x.
 func (f Float8) Bits() uint8 {
 	return uint8(f)
 }
 
-// IsNaN reports whether f is an IEEE 754 binary8 “not-a-number” value.
+// Bits16 returns the E5M2 bits of f in the low byte of a uint16, for
+// generic code over SmallFloat. Bits returns them as a uint8.
+func (f Float8) Bits16() uint16 {
+	return uint16(f)
+}
+
+// IsNaN reports whether f is an E5M2 “not-a-number” value.
 func (f Float8) IsNaN() bool {
 	return (f&0x7c == 0x7c) && (f&0x03 != 0)
 }
 
-// IsQuietNaN reports whether f is a quiet (non-signaling) IEEE 754 binary8
+// IsQuietNaN reports whether f is a quiet (non-signaling) E5M2
 // “not-a-number” value.
 func (f Float8) IsQuietNaN() bool {
 	return (f&0x7c == 0x7c) && (f&0x03 != 0) && (f&0x02 != 0)
 }
 
+// IsSignalingNaN reports whether f is a signaling E5M2
+// “not-a-number” value.
+func (f Float8) IsSignalingNaN() bool {
+	return f.IsNaN() && (f&0x02 == 0)
+}
+
+// Payload returns the NaN payload of f, the significand bits below the
+// quiet bit, or 0 if f isn't NaN.
+func (f Float8) Payload() uint {
+	if !f.IsNaN() {
+		return 0
+	}
+	return uint(f & 0x01)
+}
+
+// Quiet returns f with the quiet bit set if f is a signaling NaN, keeping
+// its sign and payload, and f otherwise.
+func (f Float8) Quiet() Float8 {
+	if f.IsNaN() {
+		return f | 0x02
+	}
+	return f
+}
+
 // IsInf reports whether f is an infinity (inf).
 // A sign > 0 reports whether f is positive inf.
 // A sign < 0 reports whether f is negative inf.
@@ -212,46 +300,24 @@ func (f Float8) String() string {
 	return strconv.FormatFloat(float64(f.Float32()), 'f', -1, 32)
 }
 
-// bf8bitsToF32bits returns uint32 (float32 bits) converted from specified uint8.
+// F8bitsToF32bits returns uint32 (float32 bits) converted from specified uint8.
 func F8bitsToF32bits(in uint8) uint32 {
-	// All 65536 conversions with this were confirmed to be correct
-	// by Montgomery Edwards⁴⁴⁸ (github.com/x448).
-
-	sign := uint32(in&0x80) << 8  // sign for 32-bit
-	exp := uint32(in&0x7c) >> 10  // exponenent for 8-bit
-	coef := uint32(in&0x03) << 13 // significand for 32-bit
-
-	if exp == 0x1f {
-		if coef == 0 {
-			// infinity
-			return sign | 0x7f800000 | coef
-		}
-		// NaN
-		return sign | 0x7fc00000 | coef
-	}
-
-	if exp == 0 {
-		if coef == 0 {
-			// zero
-			return sign
-		}
-
-		// normalize subnormal numbers
-		exp++
-		for coef&0x7f800000 == 0 {
-			coef <<= 1
-			exp--
-		}
-		coef &= 0x007fffff
-	}
-
-	return sign | ((exp + (0x7f - 0xf)) << 23) | coef
+	// E5M2 has the same exponent field as binary16, so widening the
+	// bits and reusing the binary16 conversion is lossless. All 65536
+	// binary16 conversions were confirmed to be correct by
+	// Montgomery Edwards⁴⁴⁸ (github.com/x448).
+	return F16bitsToF32bits(uint16(in) << 8)
 }
 
 // f32bitsToF8bits returns uint8 (Float8 bits) converted from the specified float32.
 // Conversion rounds to nearest integer with ties to even.
 func f32bitsToF8bits(u32 uint32) uint8 {
-	sign := u32 & 0x800000
+	// Same algorithm as f32bitsToF16bits with the result
+	// narrowed to 2 significand bits. f32bitsToF16bits was translated
+	// from Rust to Go by Montgomery Edwards⁴⁴⁸ (github.com/x448).
+	// Original Rust implementation is by Kathryn Long (github.com/starkat99) with MIT license.
+
+	sign := u32 & 0x80000000
 	exp := u32 & 0x7f800000
 	coef := u32 & 0x007fffff
 
@@ -259,38 +325,38 @@ func f32bitsToF8bits(u32 uint32) uint8 {
 		// NaN or Infinity
 		nanBit := uint32(0)
 		if coef != 0 {
-			nanBit = uint32(0x0200)
+			nanBit = uint32(0x02)
 		}
-		return uint8((sign >> 8) | uint32(0x7c) | nanBit | (coef >> 13))
+		return uint8((sign >> 24) | uint32(0x7c) | nanBit | (coef >> 21))
 	}
 
-	halfSign := sign >> 8
+	f8Sign := sign >> 24
 
 	unbiasedExp := int32(exp>>23) - 127
-	halfExp := unbiasedExp + 15
+	f8Exp := unbiasedExp + 15
 
-	if halfExp >= 0x1f {
-		return uint8(halfSign | uint32(0x7c))
+	if f8Exp >= 0x1f {
+		return uint8(f8Sign | uint32(0x7c))
 	}
 
-	if halfExp <= 0 {
-		if 14-halfExp > 24 {
-			return uint8(halfSign)
+	if f8Exp <= 0 {
+		if 22-f8Exp > 24 {
+			return uint8(f8Sign)
 		}
 		c := coef | uint32(0x00800000)
-		halfCoef := c >> uint32(14-halfExp)
-		roundBit := uint32(1) << uint32(13-halfExp)
+		f8Coef := c >> uint32(22-f8Exp)
+		roundBit := uint32(1) << uint32(21-f8Exp)
 		if (c&roundBit) != 0 && (c&(3*roundBit-1)) != 0 {
-			halfCoef++
+			f8Coef++
 		}
-		return uint8(halfSign | halfCoef)
+		return uint8(f8Sign | f8Coef)
 	}
 
-	uHalfExp := uint32(halfExp) << 10
-	halfCoef := coef >> 13
-	roundBit := uint32(0x00001000)
+	uF8Exp := uint32(f8Exp) << 2
+	f8Coef := coef >> 21
+	roundBit := uint32(0x00100000)
 	if (coef&roundBit) != 0 && (coef&(3*roundBit-1)) != 0 {
-		return uint8((halfSign | uHalfExp | halfCoef) + 1)
+		return uint8((f8Sign | uF8Exp | f8Coef) + 1)
 	}
-	return uint8(halfSign | uHalfExp | halfCoef)
+	return uint8(f8Sign | uF8Exp | f8Coef)
 }
@@ -0,0 +1,261 @@
+package floatx
+
+import (
+	"math"
+	"strconv"
+)
+
+// Float8E4M3FN represents 8-bit E4M3FN floating-point numbers from the OCP
+// 8-bit floating point specification: 1 sign bit, 4 exponent bits (bias 7)
+// and 3 significand bits. The FN suffix means the format is finite with a
+// single NaN encoding: there are no infinities, and only 0x7f and 0xff
+// (all exponent and significand bits set) are NaN. This gains a binade of
+// range over IEEE rules, so the largest value is 448.
+//
+// Float8E4M3FN isn't part of the SmallFloat constraint, because the
+// generic functions rely on IEEE infinities.
+type Float8E4M3FN uint8
+
+// Limits and properties of Float8E4M3FN values.
+//
+// MaxExp and MinExp are the largest and smallest exponents of finite normal
+// values in the form frac × 2**exp with frac in [½, 1), like C's FLT_MAX_EXP
+// and FLT_MIN_EXP.
+const (
+	F8E4M3Max               = 448    // 0x1.cp8, bits 0x7e
+	F8E4M3SmallestNormal    = 0x1p-6 // 0.015625, bits 0x08
+	F8E4M3SmallestSubnormal = 0x1p-9 // 0.001953125, bits 0x01
+	F8E4M3Epsilon           = 0x1p-3 // difference between 1 and the next value
+
+	F8E4M3MaxExactInt = 1 << 4 // every integer up to this magnitude is exact
+	F8E4M3Digits      = 4      // significand bits, including the implicit bit
+	F8E4M3PayloadBits = 0      // NaN payload bits: E4M3FN has one NaN per sign
+	F8E4M3MaxExp      = 9
+	F8E4M3MinExp      = -5
+)
+
+// F8E4M3Precision indicates whether a conversion to Float8E4M3FN is exact,
+// inexact, underflow, or overflow. It has its own type because
+// Float8E4M3FN has no infinities: PrecisionOverflow means a finite value or
+// an infinity became NaN. The values match the other Precision types, but
+// there is no PrecisionUnknown because every E4M3 precision function
+// performs the conversion.
+type F8E4M3Precision int
+
+const (
+
+	// PrecisionExact is for conversions that round-trip, and for NaNs.
+	F8E4M3PrecisionExact F8E4M3Precision = iota
+
+	_ // PrecisionUnknown in the other formats
+
+	// PrecisionInexact is for dropped significand bits and cannot round-trip.
+	F8E4M3PrecisionInexact
+
+	// PrecisionUnderflow is for nonzero values that round to zero.
+	F8E4M3PrecisionUnderflow
+
+	// PrecisionOverflow is for values that become NaN, including infinities.
+	F8E4M3PrecisionOverflow
+)
+
+// F8E4M3PrecisionExactFromfloat32 returns the Precision of the conversion of
+// f32 to Float8E4M3FN, which it performs.
+func F8E4M3PrecisionExactFromfloat32(f32 float32) F8E4M3Precision {
+	u32 := math.Float32bits(f32)
+	return f8e4m3Precision(u32, f32bitsToF8E4M3bits(u32))
+}
+
+// F8E4M3Frombits returns the Float8E4M3FN number corresponding to the
+// E4M3FN representation u8, with the sign bit of u8 and the result in the
+// same bit position. F8E4M3Frombits(Bits(x)) == x.
+func F8E4M3Frombits(u8 uint8) Float8E4M3FN {
+	return Float8E4M3FN(u8)
+}
+
+// F8E4M3Fromfloat32 returns a Float8E4M3FN value converted from f32.
+// Conversion uses IEEE default rounding (nearest int, with ties to even).
+// Like the reference implementations, values that round to a magnitude
+// larger than F8E4M3Max, infinities and NaNs all become NaN with the sign
+// of f32. Values from F8E4M3Max up to the halfway point 464 round to
+// F8E4M3Max.
+func F8E4M3Fromfloat32(f32 float32) Float8E4M3FN {
+	return Float8E4M3FN(f32bitsToF8E4M3bits(math.Float32bits(f32)))
+}
+
+// F8E4M3FromFloat32s converts src into dst using F8E4M3Fromfloat32 and
+// returns the number of elements converted, which is the minimum of len(dst)
+// and len(src).
+func F8E4M3FromFloat32s(dst []Float8E4M3FN, src []float32) int {
+	n := len(src)
+	if len(dst) < n {
+		n = len(dst)
+	}
+
+	for i, f32 := range src[:n] {
+		dst[i] = Float8E4M3FN(f32bitsToF8E4M3bits(math.Float32bits(f32)))
+	}
+	return n
+}
+
+// F8E4M3ToFloat32s converts src into dst and returns the number of elements
+// converted, which is the minimum of len(dst) and len(src).
+// This is a lossless conversion.
+func F8E4M3ToFloat32s(dst []float32, src []Float8E4M3FN) int {
+	n := len(src)
+	if len(dst) < n {
+		n = len(dst)
+	}
+
+	for i, f := range src[:n] {
+		dst[i] = f.Float32()
+	}
+	return n
+}
+
+// F8E4M3NaN returns the positive Float8E4M3FN NaN, 0x7f.
+func F8E4M3NaN() Float8E4M3FN {
+	return Float8E4M3FN(0x7f)
+}
+
+// F8E4M3NaNWithPayload returns the positive Float8E4M3FN NaN, 0x7f. E4M3FN
+// has no NaN payload or signaling NaN, so payload and quiet are ignored.
+func F8E4M3NaNWithPayload(payload uint, quiet bool) Float8E4M3FN {
+	return Float8E4M3FN(0x7f)
+}
+
+// F8E4M3CanonicalNaN returns the canonical Float8E4M3FN NaN, 0x7f.
+func F8E4M3CanonicalNaN() Float8E4M3FN {
+	return Float8E4M3FN(0x7f)
+}
+
+// Float32 returns a float32 converted from f (Float8E4M3FN).
+// This is a lossless conversion. NaN becomes a quiet float32 NaN with the
+// sign of f.
+func (f Float8E4M3FN) Float32() float32 {
+	return math.Float32frombits(F8E4M3bitsToF32bits(uint8(f)))
+}
+
+// Bits returns the E4M3FN representation of f, with the sign bit of f and
+// the result in the same bit position. Bits(F8E4M3Frombits(x)) == x.
+func (f Float8E4M3FN) Bits() uint8 {
+	return uint8(f)
+}
+
+// Bits16 returns the E4M3FN bits of f in the low byte of a uint16, like
+// the Bits16 method of the other formats. Bits returns them as a uint8.
+func (f Float8E4M3FN) Bits16() uint16 {
+	return uint16(f)
+}
+
+// IsNaN reports whether f is the E4M3FN “not-a-number” value.
+func (f Float8E4M3FN) IsNaN() bool {
+	return f&0x7f == 0x7f
+}
+
+// IsQuietNaN reports whether f is a quiet “not-a-number” value.
+// E4M3FN has no signaling NaN, so this is the same as IsNaN.
+func (f Float8E4M3FN) IsQuietNaN() bool {
+	return f&0x7f == 0x7f
+}
+
+// IsSignalingNaN reports whether f is a signaling “not-a-number” value.
+// E4M3FN has no signaling NaN, so it always returns false.
+func (f Float8E4M3FN) IsSignalingNaN() bool {
+	return false
+}
+
+// Payload returns the NaN payload of f, which is always 0 because E4M3FN
+// NaNs have no payload bits.
+func (f Float8E4M3FN) Payload() uint {
+	return 0
+}
+
+// Quiet returns f, since every E4M3FN NaN is quiet.
+func (f Float8E4M3FN) Quiet() Float8E4M3FN {
+	return f
+}
+
+// IsInf reports whether f is an infinity. E4M3FN has no infinities,
+// so it always returns false.
+func (f Float8E4M3FN) IsInf(sign int) bool {
+	return false
+}
+
+// IsFinite returns true if f is not NaN.
+func (f Float8E4M3FN) IsFinite() bool {
+	return f&0x7f != 0x7f
+}
+
+// IsNormal returns true if f is neither zero, subnormal, or NaN.
+func (f Float8E4M3FN) IsNormal() bool {
+	return f&0x78 != 0 && f&0x7f != 0x7f
+}
+
+// Signbit reports whether f is negative or negative zero.
+func (f Float8E4M3FN) Signbit() bool {
+	return f&0x80 != 0
+}
+
+// String satisfies the fmt.Stringer interface.
+func (f Float8E4M3FN) String() string {
+	return strconv.FormatFloat(float64(f.Float32()), 'f', -1, 32)
+}
+
+// F8E4M3bitsToF32bits returns uint32 (float32 bits) converted from the
+// specified E4M3FN bits.
+func F8E4M3bitsToF32bits(in uint8) uint32 {
+	sign := uint32(in&0x80) << 24
+	exp := uint32(in>>3) & 0x0f
+	coef := uint32(in) & 0x07
+
+	switch {
+	case exp == 0x0f && coef == 0x07:
+		return sign | 0x7fc00000
+	case exp == 0:
+		// subnormal, coef × 2**-9 is exact in float32
+		return sign | math.Float32bits(float32(coef)*0x1p-9)
+	}
+	return sign | (exp+127-7)<<23 | coef<<20
+}
+
+// f32bitsToF8E4M3bits returns uint8 (Float8E4M3FN bits) converted from the
+// specified float32. Conversion rounds to nearest integer with ties to even.
+func f32bitsToF8E4M3bits(u32 uint32) uint8 {
+	sign := uint8(u32 >> 24 & 0x80)
+	exp := int32(u32>>23&0xff) - 127 + 7
+	coef := u32 & 0x007fffff
+
+	if exp > 0x0f {
+		// NaN, Inf, and values too large to round to F8E4M3Max
+		return sign | 0x7f
+	}
+
+	if exp <= 0 {
+		// subnormal, the implicit bit is shifted into the significand
+		shift := uint32(21 - exp)
+		if shift > 25 {
+			return sign
+		}
+		c := coef | 0x00800000
+		f8 := c >> shift
+		rem := c & (1<<shift - 1)
+		half := uint32(1) << (shift - 1)
+		if rem > half || rem == half && f8&1 != 0 {
+			// may carry into the exponent, giving the smallest normal
+			f8++
+		}
+		return sign | uint8(f8)
+	}
+
+	// values above F8E4M3Max give 0x7f (NaN) or, rounding up from it, 0x80
+	f8 := uint32(exp)<<3 | coef>>20
+	rem := coef & 0x000fffff
+	if rem > 0x00080000 || rem == 0x00080000 && f8&1 != 0 {
+		f8++
+	}
+	if f8 > 0x7f {
+		f8 = 0x7f
+	}
+	return sign | uint8(f8)
+}
@@ -0,0 +1,202 @@
+package floatx_test
+
+import (
+	"math"
+	"sort"
+	"testing"
+
+	floatx "github.com/chenxingqiang/go-floatx"
+)
+
+// e4m3Values are the non-negative finite E4M3FN values, indexed by bits.
+var e4m3Values = func() []float64 {
+	v := make([]float64, 0x7f)
+	for b := range v {
+		e, m := b>>3, float64(b&7)
+		if e == 0 {
+			v[b] = math.Ldexp(m, -9)
+		} else {
+			v[b] = math.Ldexp(8+m, e-10)
+		}
+	}
+	return v
+}()
+
+// wantF8E4M3 returns the E4M3FN bits nearest to f32, with ties to even.
+func wantF8E4M3(f32 float32) uint8 {
+	sign := uint8(0)
+	if math.Signbit(float64(f32)) {
+		sign = 0x80
+	}
+	a := math.Abs(float64(f32))
+	if math.IsNaN(a) || a > 464 {
+		return sign | 0x7f
+	}
+	if a >= 448 {
+		return sign | 0x7e
+	}
+	i := sort.SearchFloat64s(e4m3Values, a) // e4m3Values[i-1] < a <= e4m3Values[i]
+	if e4m3Values[i] == a {
+		return sign | uint8(i)
+	}
+	lo, hi := e4m3Values[i-1], e4m3Values[i]
+	if a-lo < hi-a || a-lo == hi-a && (i-1)&1 == 0 {
+		return sign | uint8(i-1)
+	}
+	return sign | uint8(i)
+}
+
+func TestF8E4M3ToFloat32(t *testing.T) {
+	for b := 0; b < 256; b++ {
+		f := floatx.F8E4M3Frombits(uint8(b))
+		if f.Bits() != uint8(b) {
+			t.Errorf("F8E4M3Frombits(0x%02x).Bits() = 0x%02x", b, f.Bits())
+		}
+		got := f.Float32()
+		if b&0x7f == 0x7f {
+			if !math.IsNaN(float64(got)) || math.Signbit(float64(got)) != (b&0x80 != 0) {
+				t.Errorf("0x%02x.Float32() = %v, wanted NaN with sign", b, got)
+			}
+			continue
+		}
+		want := e4m3Values[b&0x7f]
+		if b&0x80 != 0 {
+			want = -want
+		}
+		if float64(got) != want || math.Signbit(float64(got)) != (b&0x80 != 0) {
+			t.Errorf("0x%02x.Float32() = %v, wanted %v", b, got, want)
+		}
+		if r := floatx.F8E4M3Fromfloat32(got); r != f {
+			t.Errorf("F8E4M3Fromfloat32(%v) = 0x%02x, wanted 0x%02x", got, uint8(r), b)
+		}
+	}
+}
+
+func TestF8E4M3FromFloat32(t *testing.T) {
+	inputs := []float32{
+		0, 1, -1, 448, 449, 463.99997, 464, 464.00003, 480, 512, 1e10,
+		float32(math.Inf(1)), float32(math.Inf(-1)), float32(math.NaN()),
+		0x1p-6, 0x1p-9, 0x1p-10, 0x1.000002p-10, 0x1.8p-9, 0x1p-126, 0x1p-149,
+		0x1.ep-7, 0x1.fp-7, // rounds up to the smallest normal
+	}
+	// every midpoint between adjacent values, and its neighbours
+	for i := 1; i < len(e4m3Values); i++ {
+		m := float32((e4m3Values[i-1] + e4m3Values[i]) / 2)
+		inputs = append(inputs, m, math.Nextafter32(m, 0), math.Nextafter32(m, 1000))
+	}
+	for _, f32 := range inputs {
+		checkF8E4M3FromFloat32(t, f32)
+		checkF8E4M3FromFloat32(t, -f32)
+	}
+
+	for u := uint64(0); u <= math.MaxUint32; u += sweepStride() {
+		f32 := math.Float32frombits(uint32(u))
+		if floatx.F8E4M3Fromfloat32(f32).Bits() != wantF8E4M3(f32) {
+			checkF8E4M3FromFloat32(t, f32)
+		}
+	}
+}
+
+func checkF8E4M3FromFloat32(t *testing.T, f32 float32) {
+	t.Helper()
+	if got, want := floatx.F8E4M3Fromfloat32(f32).Bits(), wantF8E4M3(f32); got != want {
+		t.Fatalf("F8E4M3Fromfloat32(%v) (0x%08x) = 0x%02x, wanted 0x%02x", f32, math.Float32bits(f32), got, want)
+	}
+	checkE4M3Precision(t, "float32->Float8E4M3FN", math.Float32bits(f32), f32, floatx.F8E4M3Fromfloat32(f32), floatx.F8E4M3PrecisionExactFromfloat32(f32))
+}
+
+func TestF8E4M3Slices(t *testing.T) {
+	src := []float32{1, -2.5, 448, 1000}
+	dst := make([]floatx.Float8E4M3FN, 3) // shorter dst limits the count
+	if n := floatx.F8E4M3FromFloat32s(dst, src); n != 3 {
+		t.Errorf("F8E4M3FromFloat32s returned %d, wanted 3", n)
+	}
+	for i, f := range dst {
+		if f != floatx.F8E4M3Fromfloat32(src[i]) {
+			t.Errorf("F8E4M3FromFloat32s i=%d got 0x%02x", i, uint8(f))
+		}
+	}
+
+	f32s := make([]float32, 2)
+	if n := floatx.F8E4M3ToFloat32s(f32s, dst); n != 2 || f32s[0] != 1 || f32s[1] != -2.5 {
+		t.Errorf("F8E4M3ToFloat32s = %d, %v", n, f32s)
+	}
+	if n := floatx.F8E4M3ToFloat32s(make([]float32, 4), dst); n != 3 {
+		t.Errorf("F8E4M3ToFloat32s returned %d, wanted 3", n)
+	}
+}
+
+func TestF8E4M3Classify(t *testing.T) {
+	testCases := []struct {
+		bits                uint8
+		nan, finite, normal bool
+		signbit             bool
+		str                 string
+	}{
+		{0x00, false, true, false, false, "0"},
+		{0x80, false, true, false, true, "-0"},
+		{0x01, false, true, false, false, "0.001953125"},
+		{0x08, false, true, true, false, "0.015625"},
+		{0x38, false, true, true, false, "1"},
+		{0x7e, false, true, true, false, "448"},
+		{0xfe, false, true, true, true, "-448"},
+		{0x7f, true, false, false, false, "NaN"},
+		{0xff, true, false, false, true, "NaN"},
+	}
+	for _, tc := range testCases {
+		f := floatx.F8E4M3Frombits(tc.bits)
+		if f.IsNaN() != tc.nan || f.IsQuietNaN() != tc.nan || f.IsFinite() != tc.finite ||
+			f.IsNormal() != tc.normal || f.Signbit() != tc.signbit || f.String() != tc.str {
+			t.Errorf("0x%02x: IsNaN %v, IsQuietNaN %v, IsFinite %v, IsNormal %v, Signbit %v, String %s",
+				tc.bits, f.IsNaN(), f.IsQuietNaN(), f.IsFinite(), f.IsNormal(), f.Signbit(), f)
+		}
+		if f.IsInf(0) || f.IsInf(1) || f.IsInf(-1) {
+			t.Errorf("0x%02x.IsInf() = true", tc.bits)
+		}
+	}
+	if f := floatx.F8E4M3Frombits(0xb9); f.Bits16() != 0xb9 {
+		t.Errorf("Bits16() returned %04x, wanted 00b9", f.Bits16())
+	}
+	if nan := floatx.F8E4M3NaN(); nan.Bits() != 0x7f {
+		t.Errorf("F8E4M3NaN() = 0x%02x, wanted 0x7f", nan.Bits())
+	}
+}
+
+func TestF8E4M3NaNPayload(t *testing.T) {
+	for _, quiet := range []bool{true, false} {
+		if nan := floatx.F8E4M3NaNWithPayload(5, quiet); nan.Bits() != 0x7f {
+			t.Errorf("F8E4M3NaNWithPayload(5, %v) = 0x%02x, wanted 0x7f", quiet, nan.Bits())
+		}
+	}
+	if nan := floatx.F8E4M3CanonicalNaN(); nan.Bits() != 0x7f {
+		t.Errorf("F8E4M3CanonicalNaN() = 0x%02x, wanted 0x7f", nan.Bits())
+	}
+	for _, u := range []uint8{0x7f, 0xff, 0x38} {
+		f := floatx.F8E4M3Frombits(u)
+		if f.Payload() != 0 || f.IsSignalingNaN() || f.Quiet() != f {
+			t.Errorf("0x%02x: Payload %d, IsSignalingNaN %v, Quiet 0x%02x", u, f.Payload(), f.IsSignalingNaN(), f.Quiet().Bits())
+		}
+		if got := floatx.F8E4M3Fromfloat32(float32(float64(f.Float32()))); got != f {
+			t.Errorf("0x%02x round-trips to 0x%02x", u, got.Bits())
+		}
+	}
+}
+
+func TestF8E4M3Constants(t *testing.T) {
+	max := floatx.F8E4M3Frombits(0x7e).Float32()
+	if max != floatx.F8E4M3Max ||
+		floatx.F8E4M3Frombits(0x08).Float32() != floatx.F8E4M3SmallestNormal ||
+		floatx.F8E4M3Frombits(0x01).Float32() != floatx.F8E4M3SmallestSubnormal ||
+		floatx.F8E4M3Frombits(0x39).Float32()-1 != floatx.F8E4M3Epsilon {
+		t.Error("F8E4M3 limits don't match the bits")
+	}
+	if _, exp := math.Frexp(float64(max)); exp != floatx.F8E4M3MaxExp {
+		t.Errorf("F8E4M3MaxExp = %d, wanted %d", floatx.F8E4M3MaxExp, exp)
+	}
+	if _, exp := math.Frexp(floatx.F8E4M3SmallestNormal); exp != floatx.F8E4M3MinExp {
+		t.Errorf("F8E4M3MinExp = %d, wanted %d", floatx.F8E4M3MinExp, exp)
+	}
+	if floatx.F8E4M3Digits != 4 || float32(floatx.F8E4M3MaxExactInt+1) == floatx.F8E4M3Fromfloat32(floatx.F8E4M3MaxExactInt+1).Float32() {
+		t.Error("F8E4M3Digits or F8E4M3MaxExactInt is wrong")
+	}
+}
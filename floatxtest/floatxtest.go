@@ -0,0 +1,397 @@
+// Package floatxtest checks conversions between float32 and narrower
+// binary floating-point formats against a math/big.Float reference, for
+// every input or a sample of them.
+//
+// A Format describes the layout of a format. Round is the reference for
+// rounding to it and Value for decoding it. CheckFromFloat32 and
+// CheckToFloat32 compare a conversion function with them, so any
+// implementation, such as this module's or an assembly kernel, can be
+// tested the same way:
+//
+//	err := floatxtest.CheckFromFloat32(floatxtest.Float16, func(f32 float32) uint32 {
+//		return uint32(floatx.F16Fromfloat32(f32))
+//	}, floatxtest.Config{})
+//
+// CheckFromFloat32 spreads the 2**32 float32 inputs over all CPUs, and
+// Config.Shard and Config.Shards split them further between processes or
+// machines.
+package floatxtest
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	floatx "github.com/chenxingqiang/go-floatx"
+)
+
+// Format describes a binary floating-point format with a sign bit, ExpBits
+// exponent bits with the IEEE 754 bias and ManBits trailing significand
+// bits, stored in the low bits of a uint32.
+type Format struct {
+	Name    string
+	ExpBits int
+	ManBits int
+
+	// Finite is set for formats without infinities whose only NaNs have
+	// all exponent and significand bits set, like E4M3FN. The other
+	// encodings with all exponent bits set are normal values. Values that
+	// overflow and infinities become NaN.
+	Finite bool
+}
+
+// The formats of github.com/chenxingqiang/go-floatx.
+var (
+	Float16      = Format{Name: "binary16", ExpBits: 5, ManBits: 10}
+	BFloat16     = Format{Name: "bfloat16", ExpBits: 8, ManBits: 7}
+	Float8E5M2   = Format{Name: "E5M2", ExpBits: 5, ManBits: 2}
+	Float8E4M3FN = Format{Name: "E4M3FN", ExpBits: 4, ManBits: 3, Finite: true}
+)
+
+// Width returns the number of bits of f.
+func (f Format) Width() int {
+	return 1 + f.ExpBits + f.ManBits
+}
+
+func (f Format) bias() int {
+	return 1<<(f.ExpBits-1) - 1
+}
+
+func (f Format) signBit() uint32 {
+	return 1 << (f.Width() - 1)
+}
+
+// inf returns the bits of +Inf, or of the positive NaN for finite formats.
+func (f Format) inf() uint32 {
+	if f.Finite {
+		return 1<<(f.Width()-1) - 1
+	}
+	return (1<<f.ExpBits - 1) << f.ManBits
+}
+
+// maxBits returns the bits of the largest finite value.
+func (f Format) maxBits() uint32 {
+	return f.inf() - 1
+}
+
+// IsNaN reports whether bits u of f are a NaN.
+func (f Format) IsNaN(u uint32) bool {
+	u &^= f.signBit()
+	if f.Finite {
+		return u == f.inf()
+	}
+	return u > f.inf()
+}
+
+// Value returns the value of bits u of f, which is exact in float64.
+func (f Format) Value(u uint32) float64 {
+	if f.IsNaN(u) {
+		return math.NaN()
+	}
+	sign := 1.0
+	if u&f.signBit() != 0 {
+		sign = -1
+	}
+	exp := int(u>>f.ManBits) & (1<<f.ExpBits - 1)
+	man := int64(u & (1<<f.ManBits - 1))
+	switch {
+	case !f.Finite && exp == 1<<f.ExpBits-1:
+		return math.Inf(int(sign))
+	case exp == 0:
+		exp = 1 // subnormals have the exponent of the smallest normal
+	default:
+		man |= 1 << f.ManBits
+	}
+	x := new(big.Float).SetMantExp(big.NewFloat(float64(man)), exp-f.bias()-f.ManBits)
+	v, _ := x.Float64()
+	return math.Copysign(v, sign)
+}
+
+// Round returns the bits of x rounded to f with mode, as IEEE 754 specifies
+// for a result with unbounded precision: the value is rounded to f's
+// precision as if its exponent range were unbounded, and if that exceeds the
+// largest finite value the result is infinity, or the largest finite value
+// when rounding toward zero. For finite formats, infinity is replaced by
+// NaN. Results below the normal range are rounded to a multiple of the
+// smallest subnormal. Zeros keep their sign.
+func (f Format) Round(x *big.Float, mode floatx.RoundingMode) uint32 {
+	var sign uint32
+	if x.Signbit() {
+		sign = f.signBit()
+	}
+	if x.IsInf() {
+		return sign | f.inf()
+	}
+	if x.Sign() == 0 {
+		return sign
+	}
+
+	// round the magnitude, with the directed modes adjusted for the sign
+	m := big.RoundingMode(mode)
+	switch {
+	case mode == floatx.ToNegativeInf && sign == 0, mode == floatx.ToPositiveInf && sign != 0:
+		m = big.ToZero
+	case mode >= floatx.ToNegativeInf:
+		m = big.AwayFromZero
+	}
+	a := new(big.Float).SetPrec(x.Prec()).Abs(x)
+	emin := 1 - f.bias()
+	if a.MantExp(nil) < emin-f.ManBits-2 {
+		// all values below an eighth of the smallest subnormal round alike,
+		// so use one that keeps the sum below exact
+		a.SetMantExp(big.NewFloat(0.5), emin-f.ManBits-2)
+	}
+
+	// subnormals: add the smallest normal value so the last significand bit
+	// of the sum is the smallest subnormal
+	if a.MantExp(nil)-1 < emin {
+		offset := new(big.Float).SetMantExp(big.NewFloat(1), emin)
+		sum := new(big.Float).SetPrec(a.Prec()+uint(f.ManBits)+8).Add(a, offset)
+		sum.SetMode(m).SetPrec(uint(f.ManBits) + 1)
+		sum.Sub(sum, offset)
+		n, _ := sum.SetMantExp(sum, f.ManBits-emin).Int64()
+		return sign | uint32(n) // the smallest normal value has bits 1 << ManBits
+	}
+
+	r := new(big.Float).SetMode(m).SetPrec(uint(f.ManBits) + 1).Set(a)
+	if max := big.NewFloat(f.Value(f.maxBits())); r.Cmp(max) > 0 {
+		if m == big.ToZero {
+			return sign | f.maxBits()
+		}
+		return sign | f.inf()
+	}
+	mant := new(big.Float)
+	exp := r.MantExp(mant)
+	n, _ := mant.SetMantExp(mant, f.ManBits+1).Int64()
+	return sign | uint32(exp-1+f.bias())<<f.ManBits | uint32(n)&(1<<f.ManBits-1)
+}
+
+// Config selects the inputs and rounding mode of a check. The zero Config
+// checks every input with IEEE default rounding on all CPUs.
+type Config struct {
+	// Rounding is the rounding mode conversions are expected to use.
+	Rounding floatx.RoundingMode
+
+	// Stride checks only every Stride-th input, counting by bits from 0, for
+	// quick runs. A prime such as 4099 samples every exponent. 0 and 1 check
+	// every input.
+	Stride uint32
+
+	// Shard and Shards split the inputs into Shards contiguous ranges of
+	// bits and check only range Shard, from 0 to Shards-1, so a sweep can be
+	// run in parts. 0 Shards means 1.
+	Shards int
+	Shard  int
+
+	// Workers is the number of goroutines, or GOMAXPROCS if 0.
+	Workers int
+
+	// MaxErrors is the number of mismatches kept in an Error, 10 if 0.
+	MaxErrors int
+}
+
+// Mismatch is a conversion result that differs from the reference.
+type Mismatch struct {
+	Input, Got, Want uint32 // bits
+}
+
+// Error is returned by the Check functions for results that differ from
+// the reference.
+type Error struct {
+	Check      string
+	Count      uint64     // mismatches found
+	Mismatches []Mismatch // the first mismatches by input bits, at most Config.MaxErrors
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "floatxtest: %s: %d mismatches", e.Check, e.Count)
+	for _, m := range e.Mismatches {
+		fmt.Fprintf(&b, "\n\tinput 0x%x: got 0x%x, want 0x%x", m.Input, m.Got, m.Want)
+	}
+	return b.String()
+}
+
+// gaps is the reference for rounding float32 values of one sign to a
+// format. The results are the same for all inputs strictly between two
+// adjacent values and their midpoint, so Round is evaluated once per gap.
+type gaps struct {
+	vals  []float64 // magnitudes of the finite values and one past the largest
+	mids  []float64 // midpoint of vals[i] and vals[i+1]
+	exact []uint32  // results for vals[i]
+	below []uint32  // results for (vals[i], mids[i])
+	at    []uint32  // results for mids[i]
+	above []uint32  // results for (mids[i], vals[i+1])
+}
+
+func newGaps(f Format, mode floatx.RoundingMode, sign float64) *gaps {
+	var g gaps
+	for u := uint32(0); u <= f.maxBits(); u++ {
+		g.vals = append(g.vals, f.Value(u))
+	}
+	top := g.vals[len(g.vals)-1]
+	g.vals = append(g.vals, top+(top-g.vals[len(g.vals)-2]))
+
+	round := func(v float64) uint32 {
+		return f.Round(big.NewFloat(math.Copysign(v, sign)), mode)
+	}
+	for i, v := range g.vals {
+		g.exact = append(g.exact, round(v))
+		if i == len(g.vals)-1 {
+			break
+		}
+		next := g.vals[i+1]
+		mid := (v + next) / 2 // exact, the values have few significant bits
+		g.mids = append(g.mids, mid)
+		g.below = append(g.below, round((v+mid)/2))
+		g.at = append(g.at, round(mid))
+		g.above = append(g.above, round((mid+next)/2))
+	}
+	return &g
+}
+
+// want returns the result for a, a non-negative finite value, and the index
+// of its gap. Inputs are mostly checked in increasing order, so the search
+// starts at the previous index i.
+func (g *gaps) want(a float64, i int) (uint32, int) {
+	last := len(g.vals) - 1
+	if i < last && g.vals[i+1] <= a && (i+1 == last || g.vals[i+2] > a) {
+		i++
+	} else if i > last || g.vals[i] > a || i < last && g.vals[i+1] <= a {
+		i = sort.SearchFloat64s(g.vals, a)
+		if i > last || g.vals[i] > a {
+			i--
+		}
+	}
+	switch {
+	case g.vals[i] == a:
+		return g.exact[i], i
+	case i == last:
+		return g.exact[last], i // overflow
+	case a < g.mids[i]:
+		return g.below[i], i
+	case a == g.mids[i]:
+		return g.at[i], i
+	}
+	return g.above[i], i
+}
+
+// chunk is the number of inputs given to a worker at a time.
+const chunk = 1 << 16
+
+// CheckFromFloat32 checks that conv returns the bits of every float32 input,
+// or those selected by c, rounded to f with c.Rounding like Round. NaN
+// inputs must give a NaN, whose sign and payload aren't checked. It returns
+// an *Error if any results differ.
+func CheckFromFloat32(f Format, conv func(float32) uint32, c Config) error {
+	pos := newGaps(f, c.Rounding, 1)
+	neg := newGaps(f, c.Rounding, -1)
+	nan := f.inf() + 1
+	if f.Finite {
+		nan = f.inf()
+	}
+	return check(fmt.Sprintf("%s %v", f.Name, c.Rounding), 1<<32, c, func(in uint32, hint *int) (got, want uint32) {
+		f32 := math.Float32frombits(in)
+		got = conv(f32)
+		a := math.Abs(float64(f32))
+		switch {
+		case f32 != f32:
+			want = nan
+		case math.IsInf(a, 0):
+			want = f.Round(big.NewFloat(float64(f32)), c.Rounding)
+		case in&(1<<31) != 0:
+			want, *hint = neg.want(a, *hint)
+		default:
+			want, *hint = pos.want(a, *hint)
+		}
+		if f.IsNaN(want) && f.IsNaN(got) {
+			return got, got
+		}
+		return got, want
+	})
+}
+
+// CheckToFloat32 checks that conv returns the float32 bits of the value of
+// every bit pattern of f, or those selected by c, like Value. NaNs must give
+// a NaN. c.Rounding is ignored, since the conversion is exact.
+func CheckToFloat32(f Format, conv func(uint32) float32, c Config) error {
+	return check(f.Name+" to float32", 1<<f.Width(), c, func(in uint32, _ *int) (got, want uint32) {
+		f32 := conv(in)
+		v := float32(f.Value(in))
+		if v != v && f32 != f32 {
+			return 0, 0
+		}
+		return math.Float32bits(f32), math.Float32bits(v)
+	})
+}
+
+// check runs test for the inputs from 0 to n-1 selected by c, and returns
+// an *Error for those with got != want. hint is kept by each worker
+// between calls.
+func check(name string, n uint64, c Config, test func(in uint32, hint *int) (got, want uint32)) error {
+	stride := uint64(max(c.Stride, 1))
+	shards := uint64(max(c.Shards, 1))
+	lo := n * uint64(c.Shard) / shards
+	hi := n * uint64(c.Shard+1) / shards
+	workers := c.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	maxErrors := c.MaxErrors
+	if maxErrors <= 0 {
+		maxErrors = 10
+	}
+
+	var (
+		next  atomic.Uint64 // start of the next chunk, relative to lo
+		mu    sync.Mutex
+		count uint64
+		found []Mismatch
+		wg    sync.WaitGroup
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var mismatches []Mismatch
+			var total uint64
+			hint := 0
+			for {
+				start := lo + next.Add(chunk*stride) - chunk*stride
+				if start >= hi {
+					break
+				}
+				end := min(start+chunk*stride, hi)
+				// the first input of the chunk that is a multiple of stride
+				for in := (start + stride - 1) / stride * stride; in < end; in += stride {
+					got, want := test(uint32(in), &hint)
+					if got != want {
+						total++
+						if len(mismatches) < maxErrors {
+							mismatches = append(mismatches, Mismatch{uint32(in), got, want})
+						}
+					}
+				}
+			}
+			mu.Lock()
+			count += total
+			found = append(found, mismatches...)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if count == 0 {
+		return nil
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].Input < found[j].Input })
+	if len(found) > maxErrors {
+		found = found[:maxErrors]
+	}
+	return &Error{Check: name, Count: count, Mismatches: found}
+}
@@ -0,0 +1,222 @@
+package floatxtest_test
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	floatx "github.com/chenxingqiang/go-floatx"
+	"github.com/chenxingqiang/go-floatx/floatxtest"
+)
+
+func TestRound(t *testing.T) {
+	const (
+		rne  = floatx.ToNearestEven
+		rna  = floatx.ToNearestAway
+		rz   = floatx.ToZero
+		ra   = floatx.AwayFromZero
+		rdn  = floatx.ToNegativeInf
+		rup  = floatx.ToPositiveInf
+		tiny = 0x1p-1074
+	)
+	testCases := []struct {
+		f    floatxtest.Format
+		x    float64
+		mode floatx.RoundingMode
+		want uint32
+	}{
+		{floatxtest.Float16, 1, rne, 0x3c00},
+		{floatxtest.Float16, 0.1, rne, 0x2e66},
+		{floatxtest.Float16, 0.1, rup, 0x2e67},
+		{floatxtest.Float16, -0.1, rup, 0xae66},
+		{floatxtest.Float16, -0.1, rdn, 0xae67},
+		{floatxtest.Float16, 1 + 0x1p-11, rne, 0x3c00},
+		{floatxtest.Float16, 1 + 0x1p-11, rna, 0x3c01},
+		{floatxtest.Float16, 1 + 0x3p-11, rne, 0x3c02},
+		{floatxtest.Float16, 65504, rne, 0x7bff},
+		{floatxtest.Float16, 65519.99, rne, 0x7bff},
+		{floatxtest.Float16, 65520, rne, 0x7c00},
+		{floatxtest.Float16, 1e10, rz, 0x7bff},
+		{floatxtest.Float16, -1e10, rup, 0xfbff},
+		{floatxtest.Float16, -1e10, rdn, 0xfc00},
+		{floatxtest.Float16, 1e10, ra, 0x7c00},
+		{floatxtest.Float16, math.Inf(-1), rz, 0xfc00},
+		{floatxtest.Float16, math.Copysign(0, -1), rne, 0x8000},
+		{floatxtest.Float16, 0x1p-24, rne, 0x0001},
+		{floatxtest.Float16, 0x1p-25, rne, 0x0000},
+		{floatxtest.Float16, 0x1p-25, rna, 0x0001},
+		{floatxtest.Float16, 0x3p-25, rne, 0x0002},
+		{floatxtest.Float16, 0x1p-14 - 0x1p-30, rne, 0x0400},
+		{floatxtest.Float16, 0x1p-14 - 0x1p-30, rz, 0x03ff},
+		{floatxtest.Float16, tiny, rne, 0x0000},
+		{floatxtest.Float16, tiny, ra, 0x0001},
+		{floatxtest.Float16, -tiny, rdn, 0x8001},
+		{floatxtest.Float16, -tiny, rup, 0x8000},
+		{floatxtest.BFloat16, 1, rne, 0x3f80},
+		{floatxtest.BFloat16, math.MaxFloat32, rne, 0x7f80},
+		{floatxtest.BFloat16, 0x1p-133, rne, 0x0001},
+		{floatxtest.Float8E5M2, 1.125, rne, 0x3c},
+		{floatxtest.Float8E5M2, 1.375, rne, 0x3e},
+		{floatxtest.Float8E5M2, 57344, rne, 0x7b},
+		{floatxtest.Float8E5M2, 61440, rne, 0x7c},
+		{floatxtest.Float8E4M3FN, 448, rne, 0x7e},
+		{floatxtest.Float8E4M3FN, 464, rne, 0x7e},
+		{floatxtest.Float8E4M3FN, 465, rne, 0x7f},
+		{floatxtest.Float8E4M3FN, -1000, rz, 0xfe},
+		{floatxtest.Float8E4M3FN, math.Inf(1), rne, 0x7f},
+		{floatxtest.Float8E4M3FN, 0x1p-9, rne, 0x01},
+		{floatxtest.Float8E4M3FN, 0x1p-10, rne, 0x00},
+	}
+	for _, tc := range testCases {
+		if got := tc.f.Round(big.NewFloat(tc.x), tc.mode); got != tc.want {
+			t.Errorf("%s.Round(%g, %v) = 0x%x, want 0x%x", tc.f.Name, tc.x, tc.mode, got, tc.want)
+		}
+	}
+
+	// more precision than float64
+	x, _, _ := big.ParseFloat("1.00048828125000000000000000001", 10, 200, big.ToNearestEven)
+	if got := floatxtest.Float16.Round(x, rne); got != 0x3c01 {
+		t.Errorf("Round(1+2**-11+ε) = 0x%x, want 0x3c01", got)
+	}
+	x.SetMantExp(big.NewFloat(1), -100000)
+	if got := floatxtest.Float16.Round(x, ra); got != 0x0001 {
+		t.Errorf("Round(2**-100000, AwayFromZero) = 0x%x, want 0x0001", got)
+	}
+}
+
+func TestValue(t *testing.T) {
+	testCases := []struct {
+		f    floatxtest.Format
+		u    uint32
+		want float64
+	}{
+		{floatxtest.Float16, 0x3c00, 1},
+		{floatxtest.Float16, 0x8001, -0x1p-24},
+		{floatxtest.Float16, 0x7bff, 65504},
+		{floatxtest.Float16, 0xfc00, math.Inf(-1)},
+		{floatxtest.Float16, 0x7e00, math.NaN()},
+		{floatxtest.BFloat16, 0x7f7f, 0x1.fep127},
+		{floatxtest.Float8E5M2, 0x03, 0x3p-16},
+		{floatxtest.Float8E4M3FN, 0x7e, 448},
+		{floatxtest.Float8E4M3FN, 0x78, 256},
+		{floatxtest.Float8E4M3FN, 0xff, math.NaN()},
+	}
+	for _, tc := range testCases {
+		got := tc.f.Value(tc.u)
+		if got != tc.want && !(got != got && tc.want != tc.want) || math.Signbit(got) != math.Signbit(tc.want) {
+			t.Errorf("%s.Value(0x%x) = %g, want %g", tc.f.Name, tc.u, got, tc.want)
+		}
+	}
+	if w := floatxtest.Float8E4M3FN.Width(); w != 8 {
+		t.Errorf("Float8E4M3FN.Width() = %d, want 8", w)
+	}
+}
+
+func TestCheckFromFloat32(t *testing.T) {
+	testCases := []struct {
+		f    floatxtest.Format
+		conv func(float32) uint32
+	}{
+		{floatxtest.Float16, func(f32 float32) uint32 { return uint32(floatx.F16Fromfloat32(f32)) }},
+		{floatxtest.BFloat16, func(f32 float32) uint32 { return uint32(floatx.BF16Fromfloat32(f32)) }},
+		{floatxtest.Float8E5M2, func(f32 float32) uint32 { return uint32(floatx.F8Fromfloat32(f32)) }},
+		{floatxtest.Float8E4M3FN, func(f32 float32) uint32 { return uint32(floatx.F8E4M3Fromfloat32(f32)) }},
+	}
+	for _, tc := range testCases {
+		if err := floatxtest.CheckFromFloat32(tc.f, tc.conv, floatxtest.Config{Stride: 65537}); err != nil {
+			t.Error(err)
+		}
+	}
+
+	// truncating bfloat16 is wrong for about half the inexact inputs
+	truncate := func(f32 float32) uint32 { return math.Float32bits(f32) >> 16 }
+	err := floatxtest.CheckFromFloat32(floatxtest.BFloat16, truncate, floatxtest.Config{Stride: 101, Workers: 4, MaxErrors: 3})
+	var e *floatxtest.Error
+	if !errors.As(err, &e) || len(e.Mismatches) != 3 || e.Count < 1<<20 || e.Check != "bfloat16 ToNearestEven" {
+		t.Fatalf("CheckFromFloat32 of truncation returned %v", err)
+	}
+	for i, m := range e.Mismatches {
+		if m.Got != truncate(math.Float32frombits(m.Input)) || m.Got == m.Want || i > 0 && m.Input <= e.Mismatches[i-1].Input {
+			t.Errorf("mismatch %d = %+v", i, m)
+		}
+	}
+	if s := err.Error(); !strings.HasPrefix(s, "floatxtest: bfloat16 ToNearestEven: ") || strings.Count(s, "\n\tinput 0x") != 3 {
+		t.Errorf("Error() = %q", s)
+	}
+	// but right for rounding toward zero, except that NaNs must stay NaN
+	nans := uint64(0)
+	for u := uint32(0x7f800001); u <= 0x7f80ffff; u++ {
+		if u%101 == 0 {
+			nans++
+		}
+		if (u|1<<31)%101 == 0 {
+			nans++
+		}
+	}
+	err = floatxtest.CheckFromFloat32(floatxtest.BFloat16, truncate, floatxtest.Config{Rounding: floatx.ToZero, Stride: 101})
+	if !errors.As(err, &e) || e.Count != nans {
+		t.Errorf("CheckFromFloat32 of truncation toward zero returned %v", err)
+	}
+
+	// a NaN result is only right for the 7 NaN inputs of each sign
+	err = floatxtest.CheckFromFloat32(floatxtest.Float16, func(f32 float32) uint32 { return 0x7e00 }, floatxtest.Config{Stride: 1 << 20})
+	if !errors.As(err, &e) || e.Count != 1<<12-14 {
+		t.Errorf("CheckFromFloat32 returning NaN returned %v", err)
+	}
+}
+
+func TestCheckToFloat32(t *testing.T) {
+	testCases := []struct {
+		f    floatxtest.Format
+		conv func(uint32) float32
+	}{
+		{floatxtest.Float16, func(u uint32) float32 { return floatx.F16Frombits(uint16(u)).Float32() }},
+		{floatxtest.BFloat16, func(u uint32) float32 { return floatx.BF16Frombits(uint16(u)).Float32() }},
+		{floatxtest.Float8E5M2, func(u uint32) float32 { return floatx.F8Frombits(uint8(u)).Float32() }},
+		{floatxtest.Float8E4M3FN, func(u uint32) float32 { return floatx.F8E4M3Frombits(uint8(u)).Float32() }},
+	}
+	for _, tc := range testCases {
+		if err := floatxtest.CheckToFloat32(tc.f, tc.conv, floatxtest.Config{}); err != nil {
+			t.Error(err)
+		}
+	}
+
+	// E5M2 is not E4M3FN
+	err := floatxtest.CheckToFloat32(floatxtest.Float8E4M3FN, testCases[2].conv, floatxtest.Config{MaxErrors: 1})
+	var e *floatxtest.Error
+	if !errors.As(err, &e) || len(e.Mismatches) != 1 || e.Mismatches[0] != (floatxtest.Mismatch{Input: 0x01, Got: 0x37800000, Want: 0x3b000000}) {
+		t.Errorf("CheckToFloat32 of the wrong format returned %v", err)
+	}
+}
+
+func TestShards(t *testing.T) {
+	// the shards cover every input once
+	var count atomic.Uint64
+	var sum atomic.Uint64
+	for shard := 0; shard < 7; shard++ {
+		floatxtest.CheckToFloat32(floatxtest.Float16, func(u uint32) float32 {
+			count.Add(1)
+			sum.Add(uint64(u))
+			return 0
+		}, floatxtest.Config{Shards: 7, Shard: shard, Workers: 3})
+	}
+	if n, s := count.Load(), sum.Load(); n != 1<<16 || s != (1<<16)*(1<<16-1)/2 {
+		t.Errorf("shards checked %d inputs with sum %d", n, s)
+	}
+
+	// with a stride, inputs are multiples of the stride
+	count.Store(0)
+	floatxtest.CheckFromFloat32(floatxtest.Float8E5M2, func(f32 float32) uint32 {
+		if math.Float32bits(f32)%1000003 != 0 {
+			t.Errorf("input 0x%x isn't a multiple of the stride", math.Float32bits(f32))
+		}
+		count.Add(1)
+		return 0
+	}, floatxtest.Config{Stride: 1000003, Shards: 3, Shard: 2})
+	if n := count.Load(); n != (1<<32-1)/1000003-(1<<33-1)/3/1000003 {
+		t.Errorf("the last of 3 shards checked %d inputs", n)
+	}
+}
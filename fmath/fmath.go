@@ -0,0 +1,154 @@
+// Package fmath provides elementary functions for the floating-point types
+// of github.com/chenxingqiang/go-floatx.
+//
+// Each function evaluates the float64 function from package math and rounds
+// the result once to the argument's type with IEEE default rounding (nearest
+// int, with ties to even).
+//
+// Tests check every Float16, BFloat16 and Float8 argument of the functions
+// other than Pow against math/big references when run with -exhaustive, so
+// these are correctly rounded for all arguments. Pow is checked for all
+// Float8 pairs and, with -exhaustive, all Float16 and BFloat16 pairs, but
+// only against math.Pow, with a sample of pairs checked against math/big.
+// Since the result is rounded once, Pow is off by less than one ULP of the
+// argument's type, returning one of the two values around x**y, whenever
+// math.Pow is accurate to half an ULP of that type.
+//
+// Special cases follow package math. NaN arguments return a quiet NaN.
+package fmath
+
+import (
+	"math"
+
+	floatx "github.com/chenxingqiang/go-floatx"
+)
+
+// round returns f64 rounded to T with a single rounding.
+func round[T floatx.SmallFloat](f64 float64) T {
+	return floatx.FromFloat64[T](f64)
+}
+
+func f64[T floatx.SmallFloat](x T) float64 {
+	return float64(x.Float32())
+}
+
+// Exp returns e**x, the base-e exponential of x.
+func Exp[T floatx.SmallFloat](x T) T {
+	return round[T](math.Exp(f64(x)))
+}
+
+// Exp2 returns 2**x, the base-2 exponential of x.
+func Exp2[T floatx.SmallFloat](x T) T {
+	return round[T](math.Exp2(f64(x)))
+}
+
+// Log returns the natural logarithm of x.
+func Log[T floatx.SmallFloat](x T) T {
+	return round[T](math.Log(f64(x)))
+}
+
+// Log2 returns the binary logarithm of x.
+func Log2[T floatx.SmallFloat](x T) T {
+	return round[T](math.Log2(f64(x)))
+}
+
+// Pow returns x**y, the base-x exponential of y.
+func Pow[T floatx.SmallFloat](x, y T) T {
+	return round[T](pow(f64(x), f64(y)))
+}
+
+// pow returns x**y like math.Pow, but exactly whenever the result of
+// narrow arguments can be exactly halfway between two narrow values.
+//
+// Halfway values have at most 25 significant bits, so x**y = m needs
+// m**(2**k) = x**n for y = n/2**k. With at most 11 significant bits in x
+// that is only possible for k <= 2, or for any k if x is a power of 2.
+// math.Pow uses Exp and Log for the fraction of y, which is inexact.
+func pow(x, y float64) float64 {
+	if x <= 0 || math.IsInf(x, 1) || math.IsInf(y, 0) || y != y {
+		return math.Pow(x, y)
+	}
+
+	if frac, exp := math.Frexp(x); frac == 0.5 {
+		// exact for integer exponents
+		return math.Exp2(float64(exp-1) * y)
+	}
+
+	for k := 0; k <= 2; k++ {
+		n := math.Ldexp(y, k)
+		if n != math.Trunc(n) {
+			continue
+		}
+		// x**n is exact if it has at most 53 significant bits
+		r := math.Pow(x, n)
+		if r == 0 || math.IsInf(r, 0) {
+			break
+		}
+		for ; k > 0; k-- {
+			r = math.Sqrt(r)
+		}
+		return r
+	}
+	return math.Pow(x, y)
+}
+
+// Sqrt returns the square root of x.
+func Sqrt[T floatx.SmallFloat](x T) T {
+	return round[T](math.Sqrt(f64(x)))
+}
+
+// Rsqrt returns the reciprocal square root of x, 1/Sqrt(x).
+// Rsqrt(±0) = ±Inf, Rsqrt(+Inf) = +0, and Rsqrt(x < 0) = NaN.
+func Rsqrt[T floatx.SmallFloat](x T) T {
+	return round[T](1 / math.Sqrt(f64(x)))
+}
+
+// Sin returns the sine of the radian argument x.
+func Sin[T floatx.SmallFloat](x T) T {
+	return round[T](math.Sin(f64(x)))
+}
+
+// Cos returns the cosine of the radian argument x.
+func Cos[T floatx.SmallFloat](x T) T {
+	return round[T](math.Cos(f64(x)))
+}
+
+// Tanh returns the hyperbolic tangent of x.
+func Tanh[T floatx.SmallFloat](x T) T {
+	return round[T](math.Tanh(f64(x)))
+}
+
+// Sigmoid returns the logistic function 1/(1+e**-x).
+func Sigmoid[T floatx.SmallFloat](x T) T {
+	return round[T](sigmoid(f64(x)))
+}
+
+func sigmoid(x float64) float64 {
+	if x < 0 {
+		// avoid overflow of Exp(-x) and keep precision for small results
+		e := math.Exp(x)
+		return e / (1 + e)
+	}
+	return 1 / (1 + math.Exp(-x))
+}
+
+// Erf returns the error function of x.
+func Erf[T floatx.SmallFloat](x T) T {
+	return round[T](math.Erf(f64(x)))
+}
+
+// GELU returns the Gaussian error linear unit x*Φ(x) using the exact
+// definition 0.5*x*(1+erf(x/√2)), not the tanh approximation.
+// GELU(+Inf) = +Inf and GELU(-Inf) = -0.
+func GELU[T floatx.SmallFloat](x T) T {
+	return round[T](gelu(f64(x)))
+}
+
+func gelu(x float64) float64 {
+	if math.IsInf(x, -1) {
+		// the limit, not -Inf*0
+		return math.Copysign(0, -1)
+	}
+	// erfc(-x/√2) == 1+erf(x/√2) without cancellation for negative x
+	return 0.5 * x * math.Erfc(-x/math.Sqrt2)
+}
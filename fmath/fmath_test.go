@@ -0,0 +1,709 @@
+package fmath_test
+
+import (
+	"flag"
+	"math"
+	"math/big"
+	"testing"
+
+	floatx "github.com/chenxingqiang/go-floatx"
+	"github.com/chenxingqiang/go-floatx/fmath"
+)
+
+// margin is the smallest distance, in float64 ULPs, that the float64 result
+// may have from a rounding boundary. Results at least this far from a
+// boundary are correctly rounded if the float64 function is accurate to
+// margin ULPs, which TestReference checks without package math.
+const margin = 256
+
+// layout returns the sign bit and the number of values of T.
+func layout[T floatx.SmallFloat]() (signBit uint16, count int) {
+	var f T
+	if _, ok := any(f).(floatx.Float8); ok {
+		return 0x80, 256
+	}
+	return 0x8000, 65536
+}
+
+func ordered[T floatx.SmallFloat](x T) int {
+	signBit, _ := layout[T]()
+	if uint16(x)&signBit != 0 {
+		return -int(uint16(x) &^ signBit)
+	}
+	return int(uint16(x))
+}
+
+func fromOrdered[T floatx.SmallFloat](o int) T {
+	signBit, _ := layout[T]()
+	if o < 0 {
+		return T(signBit | uint16(-o))
+	}
+	return T(uint16(o))
+}
+
+func value[T floatx.SmallFloat](x T) float64 {
+	return float64(x.Float32())
+}
+
+// checkRounded checks got is v rounded to nearest with ties to even and that
+// v isn't too close to a rounding boundary.
+func checkRounded[T floatx.SmallFloat](t *testing.T, name string, in []T, got T, v float64) {
+	if math.IsNaN(v) {
+		if !got.IsQuietNaN() {
+			t.Errorf("%s%v = 0x%04x, wanted quiet NaN", name, in, uint16(got))
+		}
+		return
+	}
+	if got.IsNaN() {
+		t.Errorf("%s%v = NaN, wanted %v", name, in, v)
+		return
+	}
+	if v != 0 && got.Signbit() != math.Signbit(v) {
+		t.Errorf("%s%v = %v, wanted sign of %v", name, in, got, v)
+	}
+
+	g := value(got)
+	if math.IsInf(v, 0) || (g == v) {
+		if g != v {
+			t.Errorf("%s%v = %v, wanted %v", name, in, got, v)
+		}
+		return
+	}
+
+	// neighbours of got, with one step past the largest finite value
+	o := ordered(got)
+	var lo, hi float64
+	if math.IsInf(g, 0) {
+		maxv := value(fromOrdered[T](o - sign(o)))
+		prev := value(fromOrdered[T](o - 2*sign(o)))
+		g = 2*maxv - prev
+		lo, hi = maxv, math.Inf(1)
+		if o < 0 {
+			lo, hi = math.Inf(-1), maxv
+		}
+	} else {
+		lo = value(fromOrdered[T](o - 1))
+		hi = value(fromOrdered[T](o + 1))
+		if math.IsInf(hi, 1) {
+			hi = 2*g - lo
+		}
+		if math.IsInf(lo, -1) {
+			lo = 2*g - hi
+		}
+	}
+	mlo, mhi := (lo+g)/2, (g+hi)/2
+
+	even := uint16(got)&1 == 0
+	switch {
+	case v < mlo || v > mhi,
+		v == mlo && !even,
+		v == mhi && !even:
+		t.Errorf("%s%v = %v, wanted %v rounded to nearest even", name, in, got, v)
+		return
+	}
+
+	dist := math.Min(math.Abs(v-mlo), math.Abs(v-mhi))
+	ulp := math.Nextafter(math.Abs(v), math.Inf(1)) - math.Abs(v)
+	if dist != 0 && dist < margin*ulp {
+		t.Errorf("%s%v: %v is too close to a rounding boundary to be sure of %v", name, in, v, got)
+	}
+}
+
+func sign(o int) int {
+	if o < 0 {
+		return -1
+	}
+	return 1
+}
+
+var unaryFuncs = []struct {
+	name string
+	f16  func(floatx.Float16) floatx.Float16
+	bf16 func(floatx.BFloat16) floatx.BFloat16
+	f8   func(floatx.Float8) floatx.Float8
+	f64  func(float64) float64
+}{
+	{"Exp", fmath.Exp[floatx.Float16], fmath.Exp[floatx.BFloat16], fmath.Exp[floatx.Float8], math.Exp},
+	{"Exp2", fmath.Exp2[floatx.Float16], fmath.Exp2[floatx.BFloat16], fmath.Exp2[floatx.Float8], math.Exp2},
+	{"Log", fmath.Log[floatx.Float16], fmath.Log[floatx.BFloat16], fmath.Log[floatx.Float8], math.Log},
+	{"Log2", fmath.Log2[floatx.Float16], fmath.Log2[floatx.BFloat16], fmath.Log2[floatx.Float8], math.Log2},
+	{"Sqrt", fmath.Sqrt[floatx.Float16], fmath.Sqrt[floatx.BFloat16], fmath.Sqrt[floatx.Float8], math.Sqrt},
+	{"Rsqrt", fmath.Rsqrt[floatx.Float16], fmath.Rsqrt[floatx.BFloat16], fmath.Rsqrt[floatx.Float8], func(x float64) float64 { return 1 / math.Sqrt(x) }},
+	{"Sin", fmath.Sin[floatx.Float16], fmath.Sin[floatx.BFloat16], fmath.Sin[floatx.Float8], math.Sin},
+	{"Cos", fmath.Cos[floatx.Float16], fmath.Cos[floatx.BFloat16], fmath.Cos[floatx.Float8], math.Cos},
+	{"Tanh", fmath.Tanh[floatx.Float16], fmath.Tanh[floatx.BFloat16], fmath.Tanh[floatx.Float8], math.Tanh},
+	{"Sigmoid", fmath.Sigmoid[floatx.Float16], fmath.Sigmoid[floatx.BFloat16], fmath.Sigmoid[floatx.Float8], func(x float64) float64 { return 1 / (1 + math.Exp(-x)) }},
+	{"Erf", fmath.Erf[floatx.Float16], fmath.Erf[floatx.BFloat16], fmath.Erf[floatx.Float8], math.Erf},
+	{"GELU", fmath.GELU[floatx.Float16], fmath.GELU[floatx.BFloat16], fmath.GELU[floatx.Float8], wantGELU},
+}
+
+func wantGELU(x float64) float64 {
+	if math.IsInf(x, -1) {
+		return math.Copysign(0, -1)
+	}
+	return x / 2 * math.Erfc(-x/math.Sqrt2)
+}
+
+// Test all 65536 Float16 and BFloat16 and all 256 Float8 arguments.
+func TestAllUnary(t *testing.T) {
+	for _, fn := range unaryFuncs {
+		for i := 0; i < 65536; i++ {
+			f16 := floatx.Float16(i)
+			checkRounded(t, fn.name, []floatx.Float16{f16}, fn.f16(f16), fn.f64(value(f16)))
+
+			bf16 := floatx.BFloat16(i)
+			checkRounded(t, fn.name, []floatx.BFloat16{bf16}, fn.bf16(bf16), fn.f64(value(bf16)))
+		}
+		for i := 0; i < 256; i++ {
+			f8 := floatx.Float8(i)
+			checkRounded(t, fn.name, []floatx.Float8{f8}, fn.f8(f8), fn.f64(value(f8)))
+		}
+		t.Logf("%s done", fn.name)
+		if t.Failed() {
+			t.FailNow()
+		}
+	}
+}
+
+func checkPow[T floatx.SmallFloat](t *testing.T, x, y T) {
+	checkRounded(t, "Pow", []T{x, y}, fmath.Pow(x, y), wantPow(value(x), value(y)))
+}
+
+// wantPow returns x**y. math.Pow isn't exact when x**y is exactly halfway
+// between two narrow values, so those cases are computed by exactPow instead.
+func wantPow(x, y float64) float64 {
+	if v, ok := exactPow(x, y); ok {
+		return v
+	}
+	return math.Pow(x, y)
+}
+
+// exactPow returns x**y with math/big for positive finite x and finite y
+// when x**y may be exactly halfway between two narrow values, which needs
+// y*4 to be an integer or x to be a power of 2.
+func exactPow(x, y float64) (float64, bool) {
+	if !(x > 0) || math.IsInf(x, 0) || math.IsInf(y, 0) || math.IsNaN(y) {
+		return 0, false
+	}
+	if frac, exp := math.Frexp(x); frac == 0.5 {
+		if e := float64(exp-1) * y; e == math.Trunc(e) {
+			return math.Ldexp(1, int(math.Max(-2000, math.Min(e, 2000)))), true
+		}
+	}
+	n := y * 4
+	if n != math.Trunc(n) || math.Abs(n) > 256 {
+		return 0, false
+	}
+
+	// x**|n| is exact with 256 * 24 bits
+	r := new(big.Float).SetPrec(256 * 24).SetInt64(1)
+	bx := new(big.Float).SetFloat64(x)
+	for i := 0; i < int(math.Abs(n)); i++ {
+		r.Mul(r, bx)
+	}
+	r.SetPrec(200)
+	if n < 0 {
+		r.Quo(new(big.Float).SetInt64(1), r)
+	}
+	r.Sqrt(r)
+	r.Sqrt(r)
+	f, _ := r.Float64()
+	return f, true
+}
+
+// powExponents are the exponents tested with every base in short mode.
+var powExponents = []float32{0, 1, -1, 2, -2, 3, 0.5, -0.5, 1.5, 1.0 / 3, 7, 10.5, -13, 100, 0x1p-10, -0x1p-10,
+	float32(math.Inf(1)), float32(math.Inf(-1)), float32(math.NaN())}
+
+func TestPow(t *testing.T) {
+	for i := 0; i < 256; i++ {
+		for j := 0; j < 256; j++ {
+			checkPow(t, floatx.Float8(i), floatx.Float8(j))
+		}
+	}
+
+	for i := 0; i < 65536; i++ {
+		for _, y := range powExponents {
+			checkPow(t, floatx.Float16(i), floatx.F16Fromfloat32(y))
+			checkPow(t, floatx.BFloat16(i), floatx.BF16Fromfloat32(y))
+		}
+	}
+}
+
+var exhaustive = flag.Bool("exhaustive", false, "check every argument and every pair of Float16 and BFloat16 arguments of Pow, which takes about 65 minutes on one CPU")
+
+// checkAllPow checks every base of T with every stride-th exponent.
+func checkAllPow[T floatx.SmallFloat](t *testing.T, stride int) {
+	_, count := layout[T]()
+	for i := 0; i < count; i++ {
+		for j := i % stride; j < count; j += stride {
+			x, y := T(i), T(j)
+			v := wantPow(value(x), value(y))
+			got := fmath.Pow(x, y)
+			if g := value(got); g == v || (got.IsNaN() && math.IsNaN(v)) {
+				// exact, skip the slower check
+				continue
+			}
+			checkPow(t, x, y)
+		}
+		if t.Failed() {
+			t.FailNow()
+		}
+	}
+}
+
+// Test every Float16 base with every 61st Float16 exponent, or all
+// 4294967296 pairs of arguments with -exhaustive.
+func TestAllPowFloat16(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping TestAllPowFloat16 in short mode.")
+	}
+	stride := 61
+	if *exhaustive {
+		stride = 1
+	}
+	checkAllPow[floatx.Float16](t, stride)
+}
+
+// Test every BFloat16 base with every 509th BFloat16 exponent, or all
+// 4294967296 pairs of arguments with -exhaustive.
+func TestAllPowBFloat16(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping TestAllPowBFloat16 in short mode.")
+	}
+	stride := 509
+	if *exhaustive {
+		stride = 1
+	}
+	checkAllPow[floatx.BFloat16](t, stride)
+}
+
+func TestSpecialCases(t *testing.T) {
+	testCases := []struct {
+		name string
+		got  floatx.Float16
+		want float32
+	}{
+		{"Exp(0)", fmath.Exp(floatx.F16Fromfloat32(0)), 1},
+		{"Exp(12)", fmath.Exp(floatx.F16Fromfloat32(12)), float32(math.Inf(1))},
+		{"Exp(-18)", fmath.Exp(floatx.F16Fromfloat32(-18)), 0},
+		{"Log(0)", fmath.Log(floatx.F16Fromfloat32(0)), float32(math.Inf(-1))},
+		{"Log2(1024)", fmath.Log2(floatx.F16Fromfloat32(1024)), 10},
+		{"Sqrt(-0)", fmath.Sqrt(floatx.Float16(0x8000)), float32(math.Copysign(0, -1))},
+		{"Rsqrt(0)", fmath.Rsqrt(floatx.F16Fromfloat32(0)), float32(math.Inf(1))},
+		{"Rsqrt(Inf)", fmath.Rsqrt(floatx.F16Inf(1)), 0},
+		{"Pow(63, 2)", fmath.Pow(floatx.F16Fromfloat32(63), floatx.F16Fromfloat32(2)), 3968},
+		{"Sigmoid(-Inf)", fmath.Sigmoid(floatx.F16Inf(-1)), 0},
+		{"Sigmoid(0)", fmath.Sigmoid(floatx.F16Fromfloat32(0)), 0.5},
+		{"GELU(1)", fmath.GELU(floatx.F16Fromfloat32(1)), 0.8413},
+		{"GELU(-Inf)", fmath.GELU(floatx.F16Inf(-1)), float32(math.Copysign(0, -1))},
+	}
+	for _, tc := range testCases {
+		if uint16(tc.got) != uint16(floatx.F16Fromfloat32(tc.want)) {
+			t.Errorf("%s = %v (0x%04x), wanted %v", tc.name, tc.got, uint16(tc.got), tc.want)
+		}
+	}
+
+	if r := fmath.Log(floatx.F16Fromfloat32(-1)); !r.IsQuietNaN() {
+		t.Errorf("Log(-1) = 0x%04x, wanted quiet NaN", uint16(r))
+	}
+	if r := fmath.Sin(floatx.Float16(0x7c01)); !r.IsQuietNaN() {
+		t.Errorf("Sin(sNaN) = 0x%04x, wanted quiet NaN", uint16(r))
+	}
+}
+
+// prec is the precision of the reference computations, in bits.
+const prec = 320
+
+var (
+	bigPi  = machinPi(800)
+	bigLn2 = ln2(prec + 64)
+)
+
+// machinPi returns π = 16*atan(1/5) - 4*atan(1/239) with p bits.
+func machinPi(p uint) *big.Float {
+	a := new(big.Float).Mul(atanInv(5, p), big.NewFloat(16))
+	b := new(big.Float).Mul(atanInv(239, p), big.NewFloat(4))
+	return a.Sub(a, b)
+}
+
+// atanInv returns atan(1/m) = Σ (-1)**k / ((2k+1) m**(2k+1)) with p bits.
+func atanInv(m int64, p uint) *big.Float {
+	sum := new(big.Float).SetPrec(p)
+	pow := new(big.Float).SetPrec(p).Quo(big.NewFloat(1), new(big.Float).SetInt64(m)) // 1/m**(2k+1)
+	m2 := new(big.Float).SetPrec(p).SetInt64(m * m)
+	for k := int64(0); pow.MantExp(nil) > -int(p)-8; k++ {
+		term := new(big.Float).SetPrec(p).Quo(pow, new(big.Float).SetInt64(2*k+1))
+		if k%2 == 0 {
+			sum.Add(sum, term)
+		} else {
+			sum.Sub(sum, term)
+		}
+		pow.Quo(pow, m2)
+	}
+	return sum
+}
+
+// ln2 returns log(2) = Σ 1/(k 2**k) with p bits.
+func ln2(p uint) *big.Float {
+	sum := new(big.Float).SetPrec(p)
+	for k := 1; k < int(p)+8; k++ {
+		term := new(big.Float).SetPrec(p).SetMantExp(big.NewFloat(1), -k)
+		sum.Add(sum, term.Quo(term, new(big.Float).SetInt64(int64(k))))
+	}
+	return sum
+}
+
+// series returns Σ terms, with term(k, prev) returning the k-th term from
+// the previous one, until the terms are below 2**-p relative to the sum.
+func series(p uint, first *big.Float, term func(k int, prev *big.Float) *big.Float) *big.Float {
+	sum := new(big.Float).SetPrec(p).Set(first)
+	t := new(big.Float).SetPrec(p).Set(first)
+	for k := 1; ; k++ {
+		t = term(k, t)
+		sum.Add(sum, t)
+		if t.Sign() == 0 || t.MantExp(nil) < sum.MantExp(nil)-int(p)-8 {
+			return sum
+		}
+	}
+}
+
+// bigExp returns e**x for |x| <= 1000.
+func bigExp(x *big.Float) *big.Float {
+	// x = k*log(2) + r with 0 <= r < log(2)
+	q := new(big.Float).SetPrec(prec+64).Quo(x, bigLn2)
+	k, _ := q.Int64()
+	if q.Sign() < 0 && !q.IsInt() {
+		k--
+	}
+	r := new(big.Float).SetPrec(prec+64).Mul(bigLn2, new(big.Float).SetInt64(k))
+	r.Sub(x, r)
+	e := series(prec+64, big.NewFloat(1), func(k int, prev *big.Float) *big.Float {
+		t := new(big.Float).SetPrec(prec+64).Mul(prev, r)
+		return t.Quo(t, new(big.Float).SetInt64(int64(k)))
+	})
+	return e.SetMantExp(e, int(k))
+}
+
+// bigExpm1 returns e**x - 1 for |x| < 1, without cancellation.
+func bigExpm1(x *big.Float) *big.Float {
+	return series(prec, x, func(k int, prev *big.Float) *big.Float {
+		t := new(big.Float).SetPrec(prec).Mul(prev, x)
+		return t.Quo(t, new(big.Float).SetInt64(int64(k+1)))
+	})
+}
+
+// bigLog returns log(x) for x > 0.
+func bigLog(x *big.Float) *big.Float {
+	// x = m * 2**e with m in [1, 2), log(m) = 2*atanh((m-1)/(m+1))
+	m := new(big.Float).SetPrec(prec + 64)
+	e := x.MantExp(m) - 1
+	m.SetMantExp(m, 1)
+	z := new(big.Float).SetPrec(prec+64).Sub(m, big.NewFloat(1))
+	z.Quo(z, new(big.Float).SetPrec(prec+64).Add(m, big.NewFloat(1)))
+	z2 := new(big.Float).SetPrec(prec+64).Mul(z, z)
+	pow := new(big.Float).SetPrec(prec + 64).Set(z) // z**(2k+1)
+	l := series(prec+64, z, func(k int, _ *big.Float) *big.Float {
+		pow.Mul(pow, z2)
+		return new(big.Float).SetPrec(prec+64).Quo(pow, new(big.Float).SetInt64(int64(2*k+1)))
+	})
+	l.Mul(l, big.NewFloat(2))
+	return l.Add(l, new(big.Float).SetPrec(prec+64).Mul(bigLn2, new(big.Float).SetInt64(int64(e))))
+}
+
+// bigSinCos returns sin(x) and cos(x) for finite x.
+func bigSinCos(x float64) (sin, cos *big.Float) {
+	// x = k*π/2 + r with |r| <= π/4, which needs π with the bits of x
+	halfPi := new(big.Float).SetPrec(800).Quo(bigPi, big.NewFloat(2))
+	q := new(big.Float).SetPrec(800).Quo(big.NewFloat(x), halfPi)
+	q.Add(q, big.NewFloat(0.5))
+	k, _ := q.Int(nil)
+	if q.Sign() < 0 && !q.IsInt() {
+		k.Sub(k, big.NewInt(1))
+	}
+	r := new(big.Float).SetPrec(800).Mul(halfPi, new(big.Float).SetInt(k))
+	r.Sub(big.NewFloat(x), r)
+	r.SetPrec(prec)
+
+	r2 := new(big.Float).SetPrec(prec).Mul(r, r)
+	s := series(prec, r, func(k int, prev *big.Float) *big.Float {
+		t := new(big.Float).SetPrec(prec).Mul(prev, r2)
+		t.Neg(t)
+		return t.Quo(t, new(big.Float).SetInt64(int64(2*k*(2*k+1))))
+	})
+	c := series(prec, big.NewFloat(1), func(k int, prev *big.Float) *big.Float {
+		t := new(big.Float).SetPrec(prec).Mul(prev, r2)
+		t.Neg(t)
+		return t.Quo(t, new(big.Float).SetInt64(int64((2*k-1)*(2*k))))
+	})
+
+	switch new(big.Int).And(k, big.NewInt(3)).Int64() {
+	case 1:
+		return c, s.Neg(s)
+	case 2:
+		return s.Neg(s), c.Neg(c)
+	case 3:
+		return c.Neg(c), s
+	}
+	return s, c
+}
+
+// bigErf returns erf(z) for 0 <= z <= 10 with p bits, from the Taylor
+// series 2/√π Σ (-1)**k z**(2k+1) / (k! (2k+1)). The terms grow to about
+// e**(z*z) before they shrink, so p must cover that cancellation.
+func bigErf(z *big.Float, p uint) *big.Float {
+	z2 := new(big.Float).SetPrec(p).Mul(z, z)
+	pow := new(big.Float).SetPrec(p).Set(z) // (-1)**k z**(2k+1) / k!
+	sum := new(big.Float).SetPrec(p).Set(z)
+	for k := int64(1); ; k++ {
+		pow.Mul(pow, z2)
+		pow.Quo(pow, new(big.Float).SetInt64(-k))
+		t := new(big.Float).SetPrec(p).Quo(pow, new(big.Float).SetInt64(2*k+1))
+		sum.Add(sum, t)
+		// the terms shrink for good once k > z*z
+		if t.Sign() == 0 || k > 2*int64(f64Of(z2)) && t.MantExp(nil) < sum.MantExp(nil)-int(p)-8 {
+			break
+		}
+	}
+	sqrtPi := new(big.Float).SetPrec(p).Sqrt(new(big.Float).SetPrec(p).Set(bigPi))
+	sum.Mul(sum, big.NewFloat(2))
+	return sum.Quo(sum, sqrtPi)
+}
+
+// bigErfc returns erfc(z) for 10 < z <= 28 from the asymptotic series
+// e**(-z*z)/(z√π) Σ (-1)**k (2k-1)!! / (2z*z)**k, stopped at its smallest
+// term, which is below e**(-z*z) relative to the sum.
+func bigErfc(z *big.Float) *big.Float {
+	z2 := new(big.Float).SetPrec(prec).Mul(z, z)
+	sum := new(big.Float).SetPrec(prec).SetInt64(1)
+	t := new(big.Float).SetPrec(prec).SetInt64(1)
+	for k := int64(1); ; k++ {
+		next := new(big.Float).SetPrec(prec).Mul(t, new(big.Float).SetInt64(-(2*k - 1)))
+		next.Quo(next, new(big.Float).SetPrec(prec).Mul(z2, big.NewFloat(2)))
+		if next.MantExp(nil) >= t.MantExp(nil) {
+			break
+		}
+		t = next
+		sum.Add(sum, t)
+	}
+	e := bigExp(new(big.Float).SetPrec(prec).Neg(z2))
+	sqrtPi := new(big.Float).SetPrec(prec).Sqrt(new(big.Float).SetPrec(prec).Set(bigPi))
+	e.Quo(e, sqrtPi.Mul(sqrtPi, z))
+	return e.Mul(e, sum)
+}
+
+func f64Of(f *big.Float) float64 {
+	v, _ := f.Float64()
+	return v
+}
+
+// refFuncs are the math/big references of unaryFuncs for finite arguments,
+// rounded to float64. Domain errors and poles are left to package math.
+var refFuncs = map[string]func(x float64) float64{
+	"Exp": func(x float64) float64 {
+		if math.Abs(x) > 1000 {
+			return math.Exp(math.Copysign(math.Inf(1), x))
+		}
+		return f64Of(bigExp(big.NewFloat(x)))
+	},
+	"Exp2": func(x float64) float64 {
+		if math.Abs(x) > 1100 {
+			return math.Exp2(math.Copysign(math.Inf(1), x))
+		}
+		return f64Of(bigExp(new(big.Float).SetPrec(prec+64).Mul(big.NewFloat(x), bigLn2)))
+	},
+	"Log": func(x float64) float64 {
+		if x <= 0 {
+			return math.Log(x)
+		}
+		return f64Of(bigLog(big.NewFloat(x)))
+	},
+	"Log2": func(x float64) float64 {
+		if x <= 0 {
+			return math.Log2(x)
+		}
+		l := bigLog(big.NewFloat(x))
+		return f64Of(l.Quo(l, bigLn2))
+	},
+	"Sqrt": func(x float64) float64 {
+		if x <= 0 {
+			return math.Sqrt(x)
+		}
+		return f64Of(new(big.Float).SetPrec(prec).Sqrt(big.NewFloat(x)))
+	},
+	"Rsqrt": func(x float64) float64 {
+		if x <= 0 {
+			return 1 / math.Sqrt(x)
+		}
+		s := new(big.Float).SetPrec(prec).Sqrt(big.NewFloat(x))
+		return f64Of(s.Quo(big.NewFloat(1), s))
+	},
+	"Sin": func(x float64) float64 {
+		s, _ := bigSinCos(x)
+		return f64Of(s)
+	},
+	"Cos": func(x float64) float64 {
+		_, c := bigSinCos(x)
+		return f64Of(c)
+	},
+	"Tanh": func(x float64) float64 {
+		a := math.Abs(x)
+		switch {
+		case a > 64:
+			// 1 - tanh(a) < 2**-184
+			return math.Copysign(1, x)
+		case a < 1:
+			// e/(e+2) with e = e**2x - 1
+			e := bigExpm1(big.NewFloat(2 * x))
+			d := new(big.Float).SetPrec(prec).Add(e, big.NewFloat(2))
+			return f64Of(e.Quo(e, d))
+		}
+		// 1 - 2/(e**2a + 1)
+		e := bigExp(big.NewFloat(2 * a))
+		e.Add(e, big.NewFloat(1))
+		e.Quo(big.NewFloat(2), e)
+		e.Sub(big.NewFloat(1), e)
+		return math.Copysign(f64Of(e), x)
+	},
+	"Sigmoid": func(x float64) float64 {
+		if x < -800 {
+			// e**x < 2**-1154
+			return 0
+		}
+		if x > 800 {
+			return 1
+		}
+		e := bigExp(big.NewFloat(-x))
+		e.Add(e, big.NewFloat(1))
+		return f64Of(e.Quo(big.NewFloat(1), e))
+	},
+	"Erf": func(x float64) float64 {
+		if math.Abs(x) > 6 {
+			// 1 - erf(6) < 2**-55, less than half a float64 ULP
+			return math.Copysign(1, x)
+		}
+		return math.Copysign(f64Of(bigErf(big.NewFloat(math.Abs(x)), 512)), x)
+	},
+	"GELU": func(x float64) float64 {
+		// x/2 * erfc(-x/√2)
+		sqrt2 := new(big.Float).SetPrec(prec + 64).Sqrt(big.NewFloat(2))
+		z := new(big.Float).SetPrec(prec+64).Quo(big.NewFloat(math.Abs(x)), sqrt2)
+		zf := f64Of(z)
+		var erfc *big.Float
+		switch {
+		case x >= 0 && zf > 6:
+			return x
+		case x >= 0:
+			erfc = bigErf(z, 512)
+			erfc.Add(erfc, big.NewFloat(1))
+		case zf > 28:
+			// |x|/2 * erfc(z) < 2**-1075
+			return math.Copysign(0, -1)
+		case zf > 10:
+			erfc = bigErfc(z)
+		default:
+			// 1 - erf(z) >= erfc(10) > 2**-150
+			erfc = bigErf(z, 800)
+			erfc.Sub(big.NewFloat(1), erfc)
+		}
+		erfc.Mul(erfc, big.NewFloat(x/2))
+		return f64Of(erfc)
+	},
+}
+
+// refPow returns x**y with math/big, or with exactPow when x**y may be
+// exactly halfway between two narrow values. Special cases are left to
+// math.Pow.
+func refPow(x, y float64) float64 {
+	if v, ok := exactPow(x, y); ok {
+		return v
+	}
+	switch {
+	case x < 0 && y == math.Trunc(y) && !math.IsInf(y, 0):
+		v := refPow(-x, y)
+		if math.Mod(y, 2) != 0 {
+			return -v
+		}
+		return v
+	case !(x > 0) || math.IsInf(x, 0) || math.IsInf(y, 0) || math.IsNaN(y) || y == 0:
+		return math.Pow(x, y)
+	}
+
+	l := bigLog(big.NewFloat(x))
+	l.Mul(l, big.NewFloat(y))
+	if f := f64Of(l); math.Abs(f) > 1000 {
+		return math.Exp(f)
+	}
+	return f64Of(bigExp(l))
+}
+
+func checkReference[T floatx.SmallFloat](t *testing.T, name string, f func(T) T, ref func(float64) float64, x T) {
+	v := value(x)
+	if x.IsNaN() || x.IsInf(0) {
+		// checked by TestSpecialCases
+		return
+	}
+	want := ref(v)
+	if math.IsNaN(want) {
+		// domain errors
+		return
+	}
+	checkRounded(t, name, []T{x}, f(x), want)
+}
+
+// The float64 functions of package math don't document their error, and
+// some lose tens of ULPs, like math.Log2 near 1 and math.Sin for large
+// arguments, so TestAllUnary alone doesn't prove correct rounding.
+// TestReference checks every 61st Float16 and BFloat16 and all 256 Float8
+// arguments against math/big references accurate to half a float64 ULP, or
+// every argument with -exhaustive.
+func TestReference(t *testing.T) {
+	stride := 61
+	switch {
+	case *exhaustive:
+		stride = 1
+	case testing.Short():
+		stride = 4099
+	}
+
+	for _, fn := range unaryFuncs {
+		ref := refFuncs[fn.name]
+		for i := 0; i < 65536; i += stride {
+			checkReference(t, fn.name, fn.f16, ref, floatx.Float16(i))
+			checkReference(t, fn.name, fn.bf16, ref, floatx.BFloat16(i))
+		}
+		for i := 0; i < 256; i++ {
+			checkReference(t, fn.name, fn.f8, ref, floatx.Float8(i))
+		}
+		t.Logf("%s done", fn.name)
+		if t.Failed() {
+			t.FailNow()
+		}
+	}
+}
+
+func checkPowReference[T floatx.SmallFloat](t *testing.T, x, y T) {
+	checkRounded(t, "Pow", []T{x, y}, fmath.Pow(x, y), refPow(value(x), value(y)))
+}
+
+// Test all Float8 pairs and every 1048573rd pair of Float16 and BFloat16
+// arguments of Pow against math/big, or every 4099th pair with -exhaustive.
+func TestPowReference(t *testing.T) {
+	stride := 1048573
+	switch {
+	case *exhaustive:
+		stride = 4099
+	case testing.Short():
+		stride = 16777213
+	}
+
+	for i := 0; i < 256; i++ {
+		for j := 0; j < 256; j++ {
+			checkPowReference(t, floatx.Float8(i), floatx.Float8(j))
+		}
+	}
+	for p := uint64(0); p < 1<<32; p += uint64(stride) {
+		checkPowReference(t, floatx.Float16(p>>16), floatx.Float16(p))
+		checkPowReference(t, floatx.BFloat16(p>>16), floatx.BFloat16(p))
+	}
+}
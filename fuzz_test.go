@@ -0,0 +1,546 @@
+package floatx_test
+
+import (
+	"encoding/binary"
+	"math"
+	"math/big"
+	"strconv"
+	"testing"
+
+	floatx "github.com/chenxingqiang/go-floatx"
+	"github.com/chenxingqiang/go-floatx/floatxtest"
+)
+
+// Fuzz targets cross-check the conversions with the math/big.Float
+// reference of floatxtest and with the generic and Context code paths. Run
+// one with, for example, go test -fuzz=FuzzFromfloat32.
+
+// fuzzFloat32s are float32 bits at subnormal, normal and overflow
+// boundaries, ties and NaN payloads of the formats.
+var fuzzFloat32s = []uint32{
+	0x00000000, 0x80000000, 0x00000001, 0x007fffff, 0x00800000, 0x3f800000, 0xbf800000,
+	0x33000000, 0x33000001, 0x337fffff, 0x387fe000, 0x387ff000, 0x38800000, // float16 subnormals
+	0x3f801000, 0x3f803000, 0x3f801001, 0x3f800fff, // float16 ties
+	0x3f808000, 0x3f818000, 0x3f808001, // bfloat16 ties
+	0x3f900000, 0x3fb00000, 0x37000000, 0x37400000, // float8 ties and subnormals
+	0x477fe000, 0x477fefff, 0x477ff000, 0x47800000, 0x7f7fffff, 0x7f7f8000, // overflow
+	0x43e00000, 0x43e80000, 0x43e80001, 0x3a800000, 0x3a000000, // E4M3FN
+	0x7f800000, 0xff800000, 0x7fc00000, 0xffc00001, 0x7f800001, 0x7f802000, 0x7fa00000, 0xff9fffff, // infinities and NaNs
+}
+
+// fuzzFormat describes a format for the fuzz targets, with bits as uint32.
+type fuzzFormat struct {
+	ref         floatxtest.Format
+	fromFloat32 func(f32 float32) uint32
+	generic     func(f32 float32) uint32
+	slice       func(f32 float32) uint32
+	context     func(c *floatx.Context, f32 float32) uint32 // nil without Context support
+	precision   func(f32 float32) floatx.F16Precision
+	exact       func(f32 float32) floatx.F16Precision
+	fromNaN     func(f32 float32) (uint32, error)
+	toFloat32   func(u uint32) float32
+	toFloat32s  func(u uint32) float32
+	bitsToF32   func(u uint32) uint32
+	str         func(u uint32) string
+}
+
+var fuzzFormats = []fuzzFormat{
+	{
+		ref:         floatxtest.Float16,
+		fromFloat32: func(f32 float32) uint32 { return uint32(floatx.F16Fromfloat32(f32)) },
+		generic:     func(f32 float32) uint32 { return uint32(floatx.FromFloat32[floatx.Float16](f32)) },
+		slice: func(f32 float32) uint32 {
+			dst := make([]floatx.Float16, 1)
+			floatx.FromFloat32s(dst, []float32{f32})
+			return uint32(dst[0])
+		},
+		context:   func(c *floatx.Context, f32 float32) uint32 { return uint32(c.F16Fromfloat32(f32)) },
+		precision: floatx.F16PrecisionFromfloat32,
+		exact:     floatx.F16PrecisionExactFromfloat32,
+		fromNaN: func(f32 float32) (uint32, error) {
+			f, err := floatx.F16FromNaN32ps(f32)
+			return uint32(f), err
+		},
+		toFloat32: func(u uint32) float32 { return floatx.F16Frombits(uint16(u)).Float32() },
+		toFloat32s: func(u uint32) float32 {
+			dst := make([]float32, 1)
+			floatx.ToFloat32s(dst, []floatx.Float16{floatx.F16Frombits(uint16(u))})
+			return dst[0]
+		},
+		bitsToF32: func(u uint32) uint32 { return floatx.F16bitsToF32bits(uint16(u)) },
+		str:       func(u uint32) string { return floatx.F16Frombits(uint16(u)).String() },
+	},
+	{
+		ref:         floatxtest.BFloat16,
+		fromFloat32: func(f32 float32) uint32 { return uint32(floatx.BF16Fromfloat32(f32)) },
+		generic:     func(f32 float32) uint32 { return uint32(floatx.FromFloat32[floatx.BFloat16](f32)) },
+		slice: func(f32 float32) uint32 {
+			dst := make([]floatx.BFloat16, 1)
+			floatx.FromFloat32s(dst, []float32{f32})
+			return uint32(dst[0])
+		},
+		context: func(c *floatx.Context, f32 float32) uint32 { return uint32(c.BF16Fromfloat32(f32)) },
+		precision: func(f32 float32) floatx.F16Precision {
+			return floatx.F16Precision(floatx.BF16PrecisionFromfloat32(f32))
+		},
+		exact: func(f32 float32) floatx.F16Precision {
+			return floatx.F16Precision(floatx.BF16PrecisionExactFromfloat32(f32))
+		},
+		fromNaN: func(f32 float32) (uint32, error) {
+			f, err := floatx.BF16FromNaN32ps(f32)
+			return uint32(f), err
+		},
+		toFloat32: func(u uint32) float32 { return floatx.BF16Frombits(uint16(u)).Float32() },
+		toFloat32s: func(u uint32) float32 {
+			dst := make([]float32, 1)
+			floatx.ToFloat32s(dst, []floatx.BFloat16{floatx.BF16Frombits(uint16(u))})
+			return dst[0]
+		},
+		bitsToF32: func(u uint32) uint32 { return floatx.BF16bitsToF32bits(uint16(u)) },
+		str:       func(u uint32) string { return floatx.BF16Frombits(uint16(u)).String() },
+	},
+	{
+		ref:         floatxtest.Float8E5M2,
+		fromFloat32: func(f32 float32) uint32 { return uint32(floatx.F8Fromfloat32(f32)) },
+		generic:     func(f32 float32) uint32 { return uint32(floatx.FromFloat32[floatx.Float8](f32)) },
+		slice: func(f32 float32) uint32 {
+			dst := make([]floatx.Float8, 1)
+			floatx.FromFloat32s(dst, []float32{f32})
+			return uint32(dst[0])
+		},
+		context: func(c *floatx.Context, f32 float32) uint32 { return uint32(c.F8Fromfloat32(f32)) },
+		precision: func(f32 float32) floatx.F16Precision {
+			return floatx.F16Precision(floatx.F8PrecisionFromfloat32(f32))
+		},
+		exact: func(f32 float32) floatx.F16Precision {
+			return floatx.F16Precision(floatx.F8PrecisionExactFromfloat32(f32))
+		},
+		fromNaN: func(f32 float32) (uint32, error) {
+			f, err := floatx.F8FromNaN32ps(f32)
+			return uint32(f), err
+		},
+		toFloat32: func(u uint32) float32 { return floatx.F8Frombits(uint8(u)).Float32() },
+		toFloat32s: func(u uint32) float32 {
+			dst := make([]float32, 1)
+			floatx.ToFloat32s(dst, []floatx.Float8{floatx.F8Frombits(uint8(u))})
+			return dst[0]
+		},
+		bitsToF32: func(u uint32) uint32 { return floatx.F8bitsToF32bits(uint8(u)) },
+		str:       func(u uint32) string { return floatx.F8Frombits(uint8(u)).String() },
+	},
+	{
+		ref:         floatxtest.Float8E4M3FN,
+		fromFloat32: func(f32 float32) uint32 { return uint32(floatx.F8E4M3Fromfloat32(f32)) },
+		generic:     func(f32 float32) uint32 { return uint32(floatx.F8E4M3Fromfloat32(f32)) },
+		slice: func(f32 float32) uint32 {
+			dst := make([]floatx.Float8E4M3FN, 1)
+			floatx.F8E4M3FromFloat32s(dst, []float32{f32})
+			return uint32(dst[0])
+		},
+		toFloat32: func(u uint32) float32 { return floatx.F8E4M3Frombits(uint8(u)).Float32() },
+		toFloat32s: func(u uint32) float32 {
+			dst := make([]float32, 1)
+			floatx.F8E4M3ToFloat32s(dst, []floatx.Float8E4M3FN{floatx.F8E4M3Frombits(uint8(u))})
+			return dst[0]
+		},
+		bitsToF32: func(u uint32) uint32 { return floatx.F8E4M3bitsToF32bits(uint8(u)) },
+		str:       func(u uint32) string { return floatx.F8E4M3Frombits(uint8(u)).String() },
+	},
+}
+
+// sameResult reports whether got is want, or both are NaNs of f.
+func sameResult(f floatxtest.Format, got, want uint32) bool {
+	return got == want || f.IsNaN(got) && f.IsNaN(want)
+}
+
+func FuzzFromfloat32(f *testing.F) {
+	for _, u32 := range fuzzFloat32s {
+		f.Add(u32)
+	}
+	f.Fuzz(func(t *testing.T, u32 uint32) {
+		f32 := math.Float32frombits(u32)
+		x := big.NewFloat(0)
+		if f32 == f32 {
+			x.SetFloat64(float64(f32))
+		}
+		for _, ff := range fuzzFormats {
+			name := ff.ref.Name
+			want := ff.ref.Round(x, floatx.ToNearestEven)
+			got := ff.fromFloat32(f32)
+			if f32 == f32 && got != want || f32 != f32 && !ff.ref.IsNaN(got) {
+				t.Errorf("%s: Fromfloat32(0x%08x) = 0x%x, want 0x%x", name, u32, got, want)
+			}
+			if g := ff.generic(f32); g != got {
+				t.Errorf("%s: FromFloat32(0x%08x) = 0x%x, Fromfloat32 returned 0x%x", name, u32, g, got)
+			}
+			if g := ff.slice(f32); g != got {
+				t.Errorf("%s: FromFloat32s(0x%08x) = 0x%x, Fromfloat32 returned 0x%x", name, u32, g, got)
+			}
+			if ff.context == nil {
+				continue
+			}
+			for _, mode := range roundingModes {
+				c := floatx.Context{Rounding: mode}
+				g := ff.context(&c, f32)
+				if w := ff.ref.Round(x, mode); f32 == f32 && g != w || f32 != f32 && !ff.ref.IsNaN(g) {
+					t.Errorf("%s: Context{%v}.Fromfloat32(0x%08x) = 0x%x, want 0x%x", name, mode, u32, g, w)
+				}
+				if mode == floatx.ToNearestEven && g != got {
+					t.Errorf("%s: zero Context.Fromfloat32(0x%08x) = 0x%x, Fromfloat32 returned 0x%x", name, u32, g, got)
+				}
+			}
+
+			// PrecisionExactFromfloat32 reports the result of the conversion,
+			// and PrecisionFromfloat32 is Exact only when it is
+			exact := ff.exact(f32)
+			if w := wantExactPrecision(f32, ff.toFloat32(got)); exact != w {
+				t.Errorf("%s: PrecisionExactFromfloat32(0x%08x) = %d, wanted %d", name, u32, exact, w)
+			}
+			if p := ff.precision(f32); p == floatx.F16PrecisionExact && exact != p {
+				t.Errorf("%s: PrecisionFromfloat32(0x%08x) = %d, PrecisionExactFromfloat32 returned %d", name, u32, p, exact)
+			}
+		}
+	})
+}
+
+func FuzzFromNaN32ps(f *testing.F) {
+	for _, u32 := range fuzzFloat32s {
+		f.Add(u32)
+	}
+	f.Fuzz(func(t *testing.T, u32 uint32) {
+		f32 := math.Float32frombits(u32)
+		for _, ff := range fuzzFormats {
+			if ff.fromNaN == nil {
+				continue
+			}
+			name := ff.ref.Name
+			got, err := ff.fromNaN(f32)
+			if f32 == f32 {
+				if err == nil {
+					t.Errorf("%s: FromNaN32ps(0x%08x) returned nil error for a number", name, u32)
+				}
+				continue
+			}
+
+			// sign, quiet bit and the top payload bits are kept
+			manBits := uint32(ff.ref.ManBits)
+			signBit := uint32(1) << (ff.ref.Width() - 1)
+			payload := u32 & 0x7fffff >> (23 - manBits)
+			want := (u32>>31)*signBit | (signBit-1)&^(1<<manBits-1) | payload
+			if payload == 0 {
+				want |= 1
+			}
+			if err != nil || got != want {
+				t.Errorf("%s: FromNaN32ps(0x%08x) = 0x%x, %v, want 0x%x", name, u32, got, err, want)
+			}
+			// Fromfloat32 keeps them too, but quiets the NaN
+			quiet := uint32(1) << (manBits - 1)
+			want = want&^1 | payload&1 | quiet
+			if g := ff.fromFloat32(f32); g != want {
+				t.Errorf("%s: Fromfloat32(0x%08x) = 0x%x, want 0x%x", name, u32, g, want)
+			}
+		}
+	})
+}
+
+func FuzzFloat32(f *testing.F) {
+	for _, u := range []uint16{0x0000, 0x8000, 0x0001, 0x03ff, 0x0400, 0x3c00, 0x7bff, 0x7c00, 0x7c01, 0x7e00, 0xfc00, 0xffff,
+		0x007f, 0x0080, 0x7f7f, 0x7f80, 0x7f81, 0x7fc0, 0x03, 0x04, 0x7b, 0x7c, 0x7d, 0x7e, 0x7f, 0xfe} {
+		f.Add(u)
+	}
+	f.Fuzz(func(t *testing.T, u16 uint16) {
+		for _, ff := range fuzzFormats {
+			name := ff.ref.Name
+			u := uint32(u16) & (1<<ff.ref.Width() - 1)
+			want := float32(ff.ref.Value(u))
+			got := ff.toFloat32(u)
+			if math.Float32bits(got) != math.Float32bits(want) && !(got != got && want != want) {
+				t.Errorf("%s: Float32(0x%x) = %g, want %g", name, u, got, want)
+			}
+			if g := ff.toFloat32s(u); math.Float32bits(g) != math.Float32bits(got) {
+				t.Errorf("%s: ToFloat32s(0x%x) = %g, Float32 returned %g", name, u, g, got)
+			}
+			if g := ff.bitsToF32(u); g != math.Float32bits(got) {
+				t.Errorf("%s: bitsToF32bits(0x%x) = 0x%08x, Float32 returned 0x%08x", name, u, g, math.Float32bits(got))
+			}
+			if back := ff.fromFloat32(got); !sameResult(ff.ref, back, u) {
+				t.Errorf("%s: Fromfloat32(Float32(0x%x)) = 0x%x", name, u, back)
+			}
+			if ff.context != nil {
+				// converting back through a Context is exact in every mode
+				for _, mode := range roundingModes {
+					c := floatx.Context{Rounding: mode}
+					if back := ff.context(&c, got); !sameResult(ff.ref, back, u) || c.Flags != 0 {
+						t.Errorf("%s: Context{%v}.Fromfloat32(Float32(0x%x)) = 0x%x, flags %v", name, mode, u, back, c.Flags)
+					}
+				}
+			}
+		}
+	})
+}
+
+func FuzzString(f *testing.F) {
+	for _, u := range []uint16{0x0000, 0x8000, 0x0001, 0x2e66, 0x3c00, 0x7bff, 0x7c00, 0x7e00, 0xfc00, 0x3dcd, 0x7f7f} {
+		f.Add(u)
+	}
+	f.Fuzz(func(t *testing.T, u16 uint16) {
+		for _, ff := range fuzzFormats {
+			name := ff.ref.Name
+			u := uint32(u16) & (1<<ff.ref.Width() - 1)
+			s := ff.str(u)
+			f64, err := strconv.ParseFloat(s, 32)
+			if err != nil {
+				t.Errorf("%s: String(0x%x) = %q doesn't parse: %v", name, u, s, err)
+				continue
+			}
+			if back := ff.fromFloat32(float32(f64)); !sameResult(ff.ref, back, u) {
+				t.Errorf("%s: String(0x%x) = %q parses to 0x%x", name, u, s, back)
+			}
+		}
+
+		// database/sql and halfvec text round-trips
+		f16 := floatx.F16Frombits(u16)
+		v, _ := f16.Value()
+		var scanned floatx.Float16
+		if err := scanned.Scan(v); err != nil || scanned != f16 && !(f16.IsNaN() && scanned.IsNaN()) {
+			t.Errorf("Float16(0x%04x).Value() = %v scans to 0x%04x, %v", u16, v, uint16(scanned), err)
+		}
+		bf16 := floatx.BF16Frombits(u16)
+		v, _ = bf16.Value()
+		var scannedBF16 floatx.BFloat16
+		if err := scannedBF16.Scan(v); err != nil || scannedBF16 != bf16 && !(bf16.IsNaN() && scannedBF16.IsNaN()) {
+			t.Errorf("BFloat16(0x%04x).Value() = %v scans to 0x%04x, %v", u16, v, uint16(scannedBF16), err)
+		}
+		if f16.IsFinite() {
+			vec := floatx.Float16Vector{f16, floatx.Neg(f16)}
+			text, err := vec.Value()
+			var got floatx.Float16Vector
+			if err != nil || got.Scan(text) != nil || len(got) != 2 || got[0] != vec[0] || got[1] != vec[1] {
+				t.Errorf("Float16Vector%v.Value() = %v scans to %v", vec, text, got)
+			}
+		}
+	})
+}
+
+func FuzzParse(f *testing.F) {
+	for _, s := range []string{"0", "-0", "1", "0.1", "65504", "65519.99", "65520", "1e-8", "2.98023223876953125e-8",
+		"0x1.002p0", "1.00048828125000000001", "Inf", "-infinity", "NaN", "1e400", "1_000", "", "[1,2]", " 1", "0x"} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		f64, err := strconv.ParseFloat(s, 64)
+		parsed := err == nil
+
+		var f16 floatx.Float16
+		err16 := f16.Scan(s)
+		var bf16 floatx.BFloat16
+		errBF16 := bf16.Scan([]byte(s))
+		if (err16 == nil) != parsed || (errBF16 == nil) != parsed {
+			t.Fatalf("Scan(%q) returned %v and %v, ParseFloat returned %v", s, err16, errBF16, err)
+		}
+		if parsed && f64 == f64 {
+			// Scan rounds the float64 once
+			x := big.NewFloat(f64)
+			if want := floatxtest.Float16.Round(x, floatx.ToNearestEven); uint32(f16) != want {
+				t.Errorf("Float16.Scan(%q) = 0x%04x, want 0x%04x", s, uint16(f16), want)
+			}
+			if want := floatxtest.BFloat16.Round(x, floatx.ToNearestEven); uint32(bf16) != want {
+				t.Errorf("BFloat16.Scan(%q) = 0x%04x, want 0x%04x", s, uint16(bf16), want)
+			}
+		}
+
+		// vectors that scan format and scan again to the same elements
+		var vec floatx.Float16Vector
+		if vec.Scan(s) != nil {
+			return
+		}
+		text, err := vec.Value()
+		if err != nil {
+			return // NaN or infinity
+		}
+		var again floatx.Float16Vector
+		if err := again.Scan(text); err != nil || len(again) != len(vec) {
+			t.Fatalf("Float16Vector.Scan(%q) = %v formats to %v, which scans to %v, %v", s, vec, text, again, err)
+		}
+		for i := range vec {
+			if again[i] != vec[i] {
+				t.Errorf("Float16Vector.Scan(%q) element %d = 0x%04x, rescanned 0x%04x", s, i, uint16(vec[i]), uint16(again[i]))
+			}
+		}
+	})
+}
+
+func FuzzMarshalBinary(f *testing.F) {
+	for _, b := range [][]byte{nil, {0x00, 0x3c, 0x00, 0xc0}, {0x80, 0x3f, 0xc1, 0x7f}, {1, 2, 3}, {1, 2, 3, 4, 5},
+		{0x00, 0x02, 0x00, 0x00, 0x3c, 0x00, 0x7c, 0x00}, []byte("[1,2]")} {
+		f.Add(b)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var c floatx.Complex32
+		err := c.UnmarshalBinary(data)
+		if (err == nil) != (len(data) == 4) {
+			t.Fatalf("Complex32.UnmarshalBinary(% x) returned %v", data, err)
+		}
+		if err == nil {
+			if b, _ := c.MarshalBinary(); string(b) != string(data) {
+				t.Errorf("Complex32.MarshalBinary() = % x, want % x", b, data)
+			}
+			if re, im := binary.LittleEndian.Uint16(data), binary.LittleEndian.Uint16(data[2:]); uint16(c.Real()) != re || uint16(c.Imag()) != im {
+				t.Errorf("Complex32.UnmarshalBinary(% x) = %v", data, c)
+			}
+		}
+		var cb floatx.ComplexBF16
+		if err := cb.UnmarshalBinary(data); (err == nil) != (len(data) == 4) {
+			t.Fatalf("ComplexBF16.UnmarshalBinary(% x) returned %v", data, err)
+		} else if b, _ := cb.MarshalBinary(); err == nil && string(b) != string(data) {
+			t.Errorf("ComplexBF16.MarshalBinary() = % x, want % x", b, data)
+		}
+
+		// a blob that doesn't look like a halfvec scans to its little-endian
+		// values and back
+		var vec floatx.Float16Vector
+		err = vec.Scan(data)
+		if len(data) > 0 && data[0] == '[' || len(data) >= 4 && int(binary.BigEndian.Uint16(data)) == (len(data)-4)/2 && data[2] == 0 && data[3] == 0 {
+			return
+		}
+		if (err == nil) != (len(data)%2 == 0) {
+			t.Fatalf("Float16Vector.Scan(% x) returned %v", data, err)
+		}
+		if b := vec.Blob(); err == nil && string(b) != string(data) {
+			t.Errorf("Float16Vector.Scan(% x).Blob() = % x", data, b)
+		}
+	})
+}
+
+func FuzzAppendCBORFloat(f *testing.F) {
+	for _, u := range []uint64{0, 1 << 63, 0x3ff0000000000000, 0x3ff199999999999a, 0x40effc0000000000, 0x40effc0000000001,
+		0x3e70000000000000, 0x36a0000000000000, 0x7ff0000000000000, 0x7ff8000000000001, 0x7ff0040000000000, 0xfffc000020000000} {
+		f.Add(u)
+	}
+	f.Fuzz(func(t *testing.T, u64 uint64) {
+		f64 := math.Float64frombits(u64)
+		b := floatx.AppendCBORFloat(nil, f64)
+		if len(b) != 1+floatx.ShortestEncoding(f64)/8 {
+			t.Fatalf("AppendCBORFloat(0x%016x) = %x, ShortestEncoding returned %d", u64, b, floatx.ShortestEncoding(f64))
+		}
+
+		// decode the head and widen the value back to float64 bits, with
+		// NaN payloads in the top significand bits
+		var got uint64
+		switch b[0] {
+		case 0xf9:
+			u16 := uint64(binary.BigEndian.Uint16(b[1:]))
+			got = math.Float64bits(float64(floatx.F16Frombits(uint16(u16)).Float32()))
+			if u16&0x7c00 == 0x7c00 && u16&0x3ff != 0 {
+				got = u16>>15<<63 | 0x7ff<<52 | (u16&0x3ff)<<42
+			}
+		case 0xfa:
+			u32 := uint64(binary.BigEndian.Uint32(b[1:]))
+			got = math.Float64bits(float64(math.Float32frombits(uint32(u32))))
+			if u32&0x7f800000 == 0x7f800000 && u32&0x7fffff != 0 {
+				got = u32>>31<<63 | 0x7ff<<52 | (u32&0x7fffff)<<29
+			}
+		case 0xfb:
+			got = binary.BigEndian.Uint64(b[1:])
+		default:
+			t.Fatalf("AppendCBORFloat(0x%016x) = %x", u64, b)
+		}
+		if got != u64 {
+			t.Errorf("AppendCBORFloat(0x%016x) = %x, which decodes to 0x%016x", u64, b, got)
+		}
+
+		// no shorter encoding exists
+		if f64 == f64 {
+			f32 := float32(f64)
+			switch {
+			case len(b) > 3 && float64(f32) == f64 && floatx.F16Fromfloat32(f32).Float32() == f32:
+				t.Errorf("AppendCBORFloat(%g) = %x, want 16 bits", f64, b)
+			case len(b) > 5 && float64(f32) == f64:
+				t.Errorf("AppendCBORFloat(%g) = %x, want 32 bits", f64, b)
+			}
+		}
+	})
+}
+
+// fuzzMath checks the generic math functions of T for the bits u, v and
+// the exponent exp against the reference f.
+func fuzzMath[T floatx.SmallFloat](t *testing.T, f floatxtest.Format, u, v uint32, exp int) {
+	x, y := T(u), T(v)
+	fx := f.Value(u)
+	bits := func(r T) uint32 { return uint32(r) }
+	round := func(v float64, mode floatx.RoundingMode) uint32 { return f.Round(big.NewFloat(v), mode) }
+	check := func(op string, got T, want uint32) {
+		t.Helper()
+		if !sameResult(f, bits(got), want) {
+			t.Errorf("%s: %s(0x%x, 0x%x, %d) = 0x%x, want 0x%x", f.Name, op, u, v, exp, bits(got), want)
+		}
+	}
+	if x.IsNaN() {
+		check("Nextafter", floatx.Nextafter(x, y), u)
+		check("Ldexp", floatx.Ldexp(x, exp), u)
+		check("Trunc", floatx.Trunc(x), u)
+		return
+	}
+
+	check("Abs", floatx.Abs(x), round(math.Abs(fx), 0))
+	check("Neg", floatx.Neg(x), round(-fx, 0))
+	if !y.IsNaN() {
+		check("Copysign", floatx.Copysign(x, y), round(math.Copysign(fx, f.Value(v)), 0))
+	}
+
+	// the neighbours of x are the results of rounding it plus or minus
+	// much less than the smallest subnormal
+	next := func(dir float64) uint32 {
+		if math.IsInf(fx, 0) {
+			if math.Signbit(fx) == (dir > 0) {
+				return u - 1 // the largest finite value of the same sign
+			}
+			return u
+		}
+		z := new(big.Float).SetPrec(400).SetFloat64(fx)
+		z.Add(z, new(big.Float).SetMantExp(big.NewFloat(dir), -150))
+		mode := floatx.ToPositiveInf
+		if dir < 0 {
+			mode = floatx.ToNegativeInf
+		}
+		return f.Round(z, mode)
+	}
+	check("NextUp", floatx.NextUp(x), next(1))
+	check("NextDown", floatx.NextDown(x), next(-1))
+	switch fy := f.Value(v); {
+	case fy != fy:
+		check("Nextafter", floatx.Nextafter(x, y), v)
+	case fy > fx:
+		check("Nextafter", floatx.Nextafter(x, y), next(1))
+	case fy < fx:
+		check("Nextafter", floatx.Nextafter(x, y), next(-1))
+	default:
+		check("Nextafter", floatx.Nextafter(x, y), u)
+	}
+
+	if !math.IsInf(fx, 0) && fx != 0 {
+		z := new(big.Float).SetMantExp(big.NewFloat(fx), exp)
+		check("Ldexp", floatx.Ldexp(x, exp), f.Round(z, floatx.ToNearestEven))
+		frac, e := floatx.Frexp(x)
+		if ff := f.Value(bits(frac)); math.Abs(ff) < 0.5 || math.Abs(ff) >= 1 || math.Ldexp(ff, e) != fx {
+			t.Errorf("%s: Frexp(0x%x) = %g, %d", f.Name, u, ff, e)
+		}
+	}
+	check("Trunc", floatx.Trunc(x), round(math.Trunc(fx), 0))
+	check("Floor", floatx.Floor(x), round(math.Floor(fx), 0))
+	check("Ceil", floatx.Ceil(x), round(math.Ceil(fx), 0))
+	check("Round", floatx.Round(x), round(math.Round(fx), 0))
+	check("RoundToEven", floatx.RoundToEven(x), round(math.RoundToEven(fx), 0))
+}
+
+func FuzzMath(f *testing.F) {
+	for _, s := range [][3]int{{0x0000, 0x8000, 0}, {0x0001, 0x3c00, -1}, {0x3c00, 0x0000, 5}, {0x7bff, 0x7c00, 1},
+		{0x3e00, 0xbe00, -20}, {0x3800, 0x7e00, 15}, {0xfc00, 0x0000, 3}, {0x7f7f, 0x0080, -140}, {0x7b, 0x04, 30}} {
+		f.Add(uint16(s[0]), uint16(s[1]), s[2])
+	}
+	f.Fuzz(func(t *testing.T, u, v uint16, exp int) {
+		fuzzMath[floatx.Float16](t, floatxtest.Float16, uint32(u), uint32(v), exp)
+		fuzzMath[floatx.BFloat16](t, floatxtest.BFloat16, uint32(u), uint32(v), exp)
+		fuzzMath[floatx.Float8](t, floatxtest.Float8E5M2, uint32(uint8(u)), uint32(uint8(v)), exp)
+	})
+}
@@ -0,0 +1,384 @@
+package floatx
+
+import "math"
+
+// SmallFloat is a constraint that permits the floating-point types in this
+// package that have infinities, so code operating on them can be written
+// once.
+//
+// Bits() is not part of the constraint because each format returns its own
+// width. Bits16() returns the raw bits of any SmallFloat as a uint16.
+type SmallFloat interface {
+	Float16 | BFloat16 | Float8
+
+	Float32() float32
+	Bits16() uint16
+	IsNaN() bool
+	IsQuietNaN() bool
+	IsInf(sign int) bool
+	IsFinite() bool
+	IsNormal() bool
+	Signbit() bool
+	String() string
+}
+
+// AnyFloat is a constraint that permits every floating-point type in this
+// package: the SmallFloat types and Float8E4M3FN, which has no infinities
+// and is therefore not a SmallFloat. It has the same methods as SmallFloat.
+type AnyFloat interface {
+	Float16 | BFloat16 | Float8 | Float8E4M3FN
+
+	Float32() float32
+	Bits16() uint16
+	IsNaN() bool
+	IsQuietNaN() bool
+	IsInf(sign int) bool
+	IsFinite() bool
+	IsNormal() bool
+	Signbit() bool
+	String() string
+}
+
+// FromFloat32 returns a T converted from f32. Conversion uses IEEE default
+// rounding (nearest int, with ties to even), like the format's own
+// Fromfloat32 function.
+func FromFloat32[T SmallFloat](f32 float32) T {
+	return fromFloat32[T](f32)
+}
+
+// fromFloat32 is FromFloat32 for any AnyFloat, with F8E4M3Fromfloat32 for
+// Float8E4M3FN.
+func fromFloat32[T AnyFloat](f32 float32) T {
+	var f T
+	switch p := any(&f).(type) {
+	case *Float16:
+		*p = F16Fromfloat32(f32)
+	case *BFloat16:
+		*p = BF16Fromfloat32(f32)
+	case *Float8:
+		*p = F8Fromfloat32(f32)
+	case *Float8E4M3FN:
+		*p = F8E4M3Fromfloat32(f32)
+	}
+	return f
+}
+
+// FromFloat64 returns a T converted from f64 with a single rounding, using
+// IEEE default rounding (nearest int, with ties to even).
+//
+// Rounding f64 to float32 and then to T can round twice. FromFloat64 rounds
+// to float32 with round to odd instead, which keeps a sticky bit, and
+// float32 has at least 2 more significand bits than T, so the second
+// rounding gives the same result as rounding f64 directly to T.
+func FromFloat64[T SmallFloat](f64 float64) T {
+	return FromFloat32[T](roundToOdd32(f64))
+}
+
+// roundToOdd32 returns f64 rounded to float32 toward zero, with the lowest
+// significand bit set if the result is inexact.
+func roundToOdd32(f64 float64) float32 {
+	f32 := float32(f64)
+	if f64 != f64 || float64(f32) == f64 {
+		// NaN or exact
+		return f32
+	}
+
+	u32 := math.Float32bits(f32)
+	if math.Abs(float64(f32)) > math.Abs(f64) {
+		// rounded away from zero (or to infinity), step back toward zero
+		u32--
+	}
+	return math.Float32frombits(u32 | 1)
+}
+
+// FromFloat32s converts src into dst using IEEE default rounding and returns
+// the number of elements converted, which is the minimum of len(dst) and
+// len(src).
+func FromFloat32s[T SmallFloat](dst []T, src []float32) int {
+	n := len(src)
+	if len(dst) < n {
+		n = len(dst)
+	}
+
+	switch d := any(dst).(type) {
+	case []Float16:
+		for i, f32 := range src[:n] {
+			d[i] = F16Fromfloat32(f32)
+		}
+	case []BFloat16:
+		for i, f32 := range src[:n] {
+			d[i] = BF16Fromfloat32(f32)
+		}
+	case []Float8:
+		for i, f32 := range src[:n] {
+			d[i] = F8Fromfloat32(f32)
+		}
+	}
+	return n
+}
+
+// ToFloat32s converts src into dst and returns the number of elements
+// converted, which is the minimum of len(dst) and len(src).
+// This is a lossless conversion.
+func ToFloat32s[T SmallFloat](dst []float32, src []T) int {
+	n := len(src)
+	if len(dst) < n {
+		n = len(dst)
+	}
+
+	for i, f := range src[:n] {
+		dst[i] = f.Float32()
+	}
+	return n
+}
+
+// Convert converts src from one format into dst of another and returns the
+// number of elements converted, which is the minimum of len(dst) and len(src).
+// Every format in this package widens to float32 exactly, so the result is
+// rounded only once, the same as converting the exact source value.
+// Conversions between different formats use the direct To* methods.
+// Conversions to Float8E4M3FN turn infinities and values that overflow into
+// NaN, like F8E4M3Fromfloat32.
+func Convert[From, To AnyFloat](dst []To, src []From) int {
+	n := len(src)
+	if len(dst) < n {
+		n = len(dst)
+	}
+
+	switch d := any(dst).(type) {
+	case []Float16:
+		switch s := any(src).(type) {
+		case []BFloat16:
+			for i, f := range s[:n] {
+				d[i] = f.ToFloat16()
+			}
+			return n
+		case []Float8:
+			for i, f := range s[:n] {
+				d[i] = f.ToFloat16()
+			}
+			return n
+		case []Float8E4M3FN:
+			for i, f := range s[:n] {
+				d[i] = f.ToFloat16()
+			}
+			return n
+		}
+	case []BFloat16:
+		switch s := any(src).(type) {
+		case []Float16:
+			for i, f := range s[:n] {
+				d[i] = f.ToBFloat16()
+			}
+			return n
+		case []Float8:
+			for i, f := range s[:n] {
+				d[i] = f.ToBFloat16()
+			}
+			return n
+		case []Float8E4M3FN:
+			for i, f := range s[:n] {
+				d[i] = f.ToBFloat16()
+			}
+			return n
+		}
+	case []Float8:
+		switch s := any(src).(type) {
+		case []Float16:
+			for i, f := range s[:n] {
+				d[i] = f.ToFloat8()
+			}
+			return n
+		case []BFloat16:
+			for i, f := range s[:n] {
+				d[i] = f.ToFloat8()
+			}
+			return n
+		case []Float8E4M3FN:
+			for i, f := range s[:n] {
+				d[i] = f.ToFloat8()
+			}
+			return n
+		}
+	case []Float8E4M3FN:
+		switch s := any(src).(type) {
+		case []Float16:
+			for i, f := range s[:n] {
+				d[i] = f.ToFloat8E4M3()
+			}
+			return n
+		case []BFloat16:
+			for i, f := range s[:n] {
+				d[i] = f.ToFloat8E4M3()
+			}
+			return n
+		case []Float8:
+			for i, f := range s[:n] {
+				d[i] = f.ToFloat8E4M3()
+			}
+			return n
+		}
+	}
+
+	// same format, NaNs are quieted like the other conversions
+	for i, f := range src[:n] {
+		dst[i] = fromFloat32[To](f.Float32())
+	}
+	return n
+}
+
+// compensatedSum is a float64 sum with Neumaier's compensation, which keeps
+// the error of long sums near one rounding instead of growing with len(s).
+type compensatedSum struct {
+	sum, c float64
+}
+
+func (k *compensatedSum) add(x float64) {
+	t := k.sum + x
+	if math.Abs(k.sum) >= math.Abs(x) {
+		k.c += (k.sum - t) + x
+	} else {
+		k.c += (x - t) + k.sum
+	}
+	k.sum = t
+}
+
+func (k *compensatedSum) result() float64 {
+	if math.IsInf(k.sum, 0) || math.IsNaN(k.sum) {
+		// the compensation is NaN once the sum isn't finite
+		return k.sum
+	}
+	return k.sum + k.c
+}
+
+// Sum returns the sum of s accumulated in float64 with compensation and
+// rounded once to float32, so long slices don't lose accuracy.
+// The result is NaN if any element is NaN or if infinities of both signs are
+// added, as with ordinary float addition. Sum of an empty slice is 0.
+//
+// Sum and the other reductions accept Float8E4M3FN, whose only non-finite
+// value is NaN, so its NaNs propagate like those of the other formats and
+// it never contributes an infinity. The reductions are portable Go loops
+// over Float32(). There are no SIMD conversion backends in this module yet,
+// so a SIMD path for the reductions is deferred until there are.
+func Sum[T AnyFloat](s []T) float32 {
+	var k compensatedSum
+	for _, f := range s {
+		k.add(float64(f.Float32()))
+	}
+	return float32(k.result())
+}
+
+// Mean returns the arithmetic mean of s, computed like Sum and rounded once
+// to float32. Mean of an empty slice is NaN.
+func Mean[T AnyFloat](s []T) float32 {
+	var k compensatedSum
+	for _, f := range s {
+		k.add(float64(f.Float32()))
+	}
+	return float32(k.result() / float64(len(s)))
+}
+
+// Variance returns the population variance of s, the mean squared deviation
+// from Mean as used by layer normalization, rounded once to float32. It
+// makes two passes in float64 so it doesn't suffer the cancellation of the
+// sum of squares formula. The result is NaN if s is empty or any element is
+// NaN or infinite.
+func Variance[T AnyFloat](s []T) float32 {
+	var k compensatedSum
+	for _, f := range s {
+		k.add(float64(f.Float32()))
+	}
+	n := float64(len(s))
+	mean := k.result() / n
+
+	// the sum of deviations corrects the rounding error of mean
+	var sq, dev compensatedSum
+	for _, f := range s {
+		d := float64(f.Float32()) - mean
+		sq.add(d * d)
+		dev.add(d)
+	}
+	d := dev.result()
+	return float32((sq.result() - d*d/n) / n)
+}
+
+// L2Norm returns the Euclidean norm of s, the square root of the sum of
+// squares, accumulated in float64 and rounded once to float32. Squares of
+// every format are exact in float64 and can't overflow it. The result is NaN
+// if any element is NaN, otherwise +Inf if any element is infinite.
+// L2Norm of an empty slice is 0.
+func L2Norm[T AnyFloat](s []T) float32 {
+	var k compensatedSum
+	for _, f := range s {
+		x := float64(f.Float32())
+		k.add(x * x)
+	}
+	return float32(math.Sqrt(k.result()))
+}
+
+// CountNonFinite returns the number of NaNs and infinities in s, for
+// example to detect overflow in mixed-precision training.
+func CountNonFinite[T AnyFloat](s []T) int {
+	n := 0
+	for _, f := range s {
+		if !f.IsFinite() {
+			n++
+		}
+	}
+	return n
+}
+
+// ArgMax returns the index of the largest value in s, or of the first NaN if
+// any element is NaN, like MaxAbs. Ties, including -0 and +0, return the
+// first index. ArgMax of an empty slice is -1.
+func ArgMax[T AnyFloat](s []T) int {
+	i := -1
+	best := float32(0)
+	for j, f := range s {
+		if f.IsNaN() {
+			return j
+		}
+		if x := f.Float32(); i < 0 || x > best {
+			i, best = j, x
+		}
+	}
+	return i
+}
+
+// MaxAbs returns the largest absolute value in s as a T, for example to
+// compute a scaling factor before quantization. If any element is NaN, the
+// first NaN is returned. MaxAbs of an empty slice is positive zero.
+func MaxAbs[T AnyFloat](s []T) T {
+	maxAbs := float32(0)
+	for _, f := range s {
+		if f.IsNaN() {
+			return f
+		}
+		abs := float32(math.Abs(float64(f.Float32())))
+		if abs > maxAbs {
+			maxAbs = abs
+		}
+	}
+	// maxAbs came from a T, so converting it back is exact
+	return fromFloat32[T](maxAbs)
+}
+
+// MinAbs returns the smallest absolute value in s as a T. If any element is
+// NaN, the first NaN is returned like MaxAbs. MinAbs of an empty slice is
+// positive infinity, or NaN for Float8E4M3FN, which has no infinity.
+func MinAbs[T AnyFloat](s []T) T {
+	minAbs := float32(math.Inf(1))
+	for _, f := range s {
+		if f.IsNaN() {
+			return f
+		}
+		abs := float32(math.Abs(float64(f.Float32())))
+		if abs < minAbs {
+			minAbs = abs
+		}
+	}
+	// minAbs came from a T or is infinity, so converting it back is exact
+	// except for Float8E4M3FN infinity, which becomes NaN
+	return fromFloat32[T](minAbs)
+}
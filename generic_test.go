@@ -0,0 +1,449 @@
+package floatx_test
+
+import (
+	"math"
+	"testing"
+
+	floatx "github.com/chenxingqiang/go-floatx"
+	"github.com/chenxingqiang/go-floatx/floatxtest"
+)
+
+var genericF32s = []float32{
+	0, float32(math.Copysign(0, -1)), 1, -1, 0.1, -2.5, 1.5, float32(math.Pi),
+	65504, 65520, 57344, 61440, 1e-7, 1e-40, math.MaxFloat32,
+	float32(math.Inf(1)), float32(math.Inf(-1)), float32(math.NaN()),
+	math.Float32frombits(0x7f800001), // sNaN
+}
+
+func TestFromFloat32(t *testing.T) {
+	for _, f32 := range genericF32s {
+		if got, want := floatx.FromFloat32[floatx.Float16](f32), floatx.F16Fromfloat32(f32); got != want {
+			t.Errorf("FromFloat32[Float16](%v) = 0x%04x, wanted 0x%04x", f32, uint16(got), uint16(want))
+		}
+		if got, want := floatx.FromFloat32[floatx.BFloat16](f32), floatx.BF16Fromfloat32(f32); got != want {
+			t.Errorf("FromFloat32[BFloat16](%v) = 0x%04x, wanted 0x%04x", f32, uint16(got), uint16(want))
+		}
+		if got, want := floatx.FromFloat32[floatx.Float8](f32), floatx.F8Fromfloat32(f32); got != want {
+			t.Errorf("FromFloat32[Float8](%v) = 0x%02x, wanted 0x%02x", f32, uint8(got), uint8(want))
+		}
+	}
+}
+
+func TestFromFloat64(t *testing.T) {
+	for _, f32 := range genericF32s {
+		if got, want := floatx.FromFloat64[floatx.Float16](float64(f32)), floatx.F16Fromfloat32(f32); got != want {
+			t.Errorf("FromFloat64[Float16](%v) = 0x%04x, wanted 0x%04x", f32, uint16(got), uint16(want))
+		}
+	}
+
+	// float32 would round these to a tie, and then to even
+	tiny := math.Ldexp(1, -40)
+	for _, test := range []struct {
+		f64       float64
+		f16, bf16 uint16
+		f8        uint8
+	}{
+		{1 + math.Ldexp(1, -11) + tiny, 0x3c01, 0x3f80, 0x3c},
+		{1 + math.Ldexp(1, -8) + tiny, 0x3c04, 0x3f81, 0x3c},
+		{1 + math.Ldexp(1, -3) + tiny, 0x3c80, 0x3f90, 0x3d},
+		{-(1 + math.Ldexp(1, -11) + tiny), 0xbc01, 0xbf80, 0xbc},
+		{65519.99, 0x7bff, 0x4780, 0x7c},
+		{math.MaxFloat64, 0x7c00, 0x7f80, 0x7c},
+	} {
+		if got := floatx.FromFloat64[floatx.Float16](test.f64); uint16(got) != test.f16 {
+			t.Errorf("FromFloat64[Float16](%v) = 0x%04x, wanted 0x%04x", test.f64, uint16(got), test.f16)
+		}
+		if got := floatx.FromFloat64[floatx.BFloat16](test.f64); uint16(got) != test.bf16 {
+			t.Errorf("FromFloat64[BFloat16](%v) = 0x%04x, wanted 0x%04x", test.f64, uint16(got), test.bf16)
+		}
+		if got := floatx.FromFloat64[floatx.Float8](test.f64); uint8(got) != test.f8 {
+			t.Errorf("FromFloat64[Float8](%v) = 0x%02x, wanted 0x%02x", test.f64, uint8(got), test.f8)
+		}
+	}
+
+	if got := floatx.FromFloat64[floatx.Float16](math.NaN()); !got.IsNaN() {
+		t.Errorf("FromFloat64[Float16](NaN) = %v, wanted NaN", got)
+	}
+}
+
+func TestFromFloat32s(t *testing.T) {
+	f16s := make([]floatx.Float16, len(genericF32s))
+	bf16s := make([]floatx.BFloat16, len(genericF32s))
+	f8s := make([]floatx.Float8, len(genericF32s)-1) // shorter dst limits the count
+
+	if n := floatx.FromFloat32s(f16s, genericF32s); n != len(genericF32s) {
+		t.Errorf("FromFloat32s returned %d, wanted %d", n, len(genericF32s))
+	}
+	if n := floatx.FromFloat32s(bf16s, genericF32s); n != len(genericF32s) {
+		t.Errorf("FromFloat32s returned %d, wanted %d", n, len(genericF32s))
+	}
+	if n := floatx.FromFloat32s(f8s, genericF32s); n != len(f8s) {
+		t.Errorf("FromFloat32s returned %d, wanted %d", n, len(f8s))
+	}
+
+	for i, f32 := range genericF32s {
+		if f16s[i] != floatx.F16Fromfloat32(f32) {
+			t.Errorf("FromFloat32s []Float16 i=%d got 0x%04x", i, uint16(f16s[i]))
+		}
+		if bf16s[i] != floatx.BF16Fromfloat32(f32) {
+			t.Errorf("FromFloat32s []BFloat16 i=%d got 0x%04x", i, uint16(bf16s[i]))
+		}
+		if i < len(f8s) && f8s[i] != floatx.F8Fromfloat32(f32) {
+			t.Errorf("FromFloat32s []Float8 i=%d got 0x%02x", i, uint8(f8s[i]))
+		}
+	}
+}
+
+func TestToFloat32s(t *testing.T) {
+	src := make([]floatx.Float16, 65536)
+	for i := range src {
+		src[i] = floatx.Float16(i)
+	}
+
+	dst := make([]float32, len(src))
+	if n := floatx.ToFloat32s(dst, src); n != len(src) {
+		t.Errorf("ToFloat32s returned %d, wanted %d", n, len(src))
+	}
+	for i, f := range src {
+		if math.Float32bits(dst[i]) != math.Float32bits(f.Float32()) {
+			t.Errorf("ToFloat32s i=%d got 0x%08x, wanted 0x%08x", i, math.Float32bits(dst[i]), math.Float32bits(f.Float32()))
+		}
+	}
+
+	if n := floatx.ToFloat32s(dst[:3], src); n != 3 {
+		t.Errorf("ToFloat32s returned %d, wanted 3", n)
+	}
+}
+
+// checkConvert verifies all values of From convert the same as going
+// through float32 and the destination's own Fromfloat32.
+func checkConvert[From, To floatx.AnyFloat](t *testing.T, name string, src []From, want func(float32) To) {
+	dst := make([]To, len(src))
+	if n := floatx.Convert(dst, src); n != len(src) {
+		t.Errorf("Convert %s returned %d, wanted %d", name, n, len(src))
+	}
+	for i, f := range src {
+		w := want(f.Float32())
+		if dst[i] != w {
+			t.Errorf("Convert %s 0x%04x got 0x%04x, wanted 0x%04x", name, uint16(f), uint16(dst[i]), uint16(w))
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	f16s := make([]floatx.Float16, 65536)
+	bf16s := make([]floatx.BFloat16, 65536)
+	for i := range f16s {
+		f16s[i] = floatx.Float16(i)
+		bf16s[i] = floatx.BFloat16(i)
+	}
+	f8s := make([]floatx.Float8, 256)
+	for i := range f8s {
+		f8s[i] = floatx.Float8(i)
+	}
+
+	checkConvert(t, "Float16->BFloat16", f16s, floatx.BF16Fromfloat32)
+	checkConvert(t, "Float16->Float8", f16s, floatx.F8Fromfloat32)
+	checkConvert(t, "BFloat16->Float16", bf16s, floatx.F16Fromfloat32)
+	checkConvert(t, "BFloat16->Float8", bf16s, floatx.F8Fromfloat32)
+	checkConvert(t, "Float8->Float16", f8s, floatx.F16Fromfloat32)
+	checkConvert(t, "Float8->BFloat16", f8s, floatx.BF16Fromfloat32)
+	checkConvert(t, "Float16->Float16", f16s, floatx.F16Fromfloat32)
+
+	e4m3s := make([]floatx.Float8E4M3FN, 256)
+	for i := range e4m3s {
+		e4m3s[i] = floatx.Float8E4M3FN(i)
+	}
+	checkConvert(t, "Float16->Float8E4M3FN", f16s, floatx.F8E4M3Fromfloat32)
+	checkConvert(t, "BFloat16->Float8E4M3FN", bf16s, floatx.F8E4M3Fromfloat32)
+	checkConvert(t, "Float8->Float8E4M3FN", f8s, floatx.F8E4M3Fromfloat32)
+	checkConvert(t, "Float8E4M3FN->Float16", e4m3s, floatx.F16Fromfloat32)
+	checkConvert(t, "Float8E4M3FN->BFloat16", e4m3s, floatx.BF16Fromfloat32)
+	checkConvert(t, "Float8E4M3FN->Float8", e4m3s, floatx.F8Fromfloat32)
+	checkConvert(t, "Float8E4M3FN->Float8E4M3FN", e4m3s, floatx.F8E4M3Fromfloat32)
+
+	if n := floatx.Convert(make([]floatx.Float8, 3), f16s); n != 3 {
+		t.Errorf("Convert returned %d, wanted 3", n)
+	}
+}
+
+func TestSum(t *testing.T) {
+	if s := floatx.Sum([]floatx.Float16{}); s != 0 {
+		t.Errorf("Sum(empty) returned %v, wanted 0", s)
+	}
+
+	// 4096 ones would stop growing at 2048 if accumulated in float16
+	ones := make([]floatx.Float16, 4096)
+	for i := range ones {
+		ones[i] = floatx.F16Fromfloat32(1)
+	}
+	if s := floatx.Sum(ones); s != 4096 {
+		t.Errorf("Sum(4096 ones) returned %v, wanted 4096", s)
+	}
+
+	bf16s := []floatx.BFloat16{floatx.BF16Fromfloat32(1.5), floatx.BF16Fromfloat32(-0.5), floatx.BF16Fromfloat32(3)}
+	if s := floatx.Sum(bf16s); s != 4 {
+		t.Errorf("Sum(bf16s) returned %v, wanted 4", s)
+	}
+
+	f8s := []floatx.Float8{floatx.F8Inf(1), floatx.F8Inf(-1)}
+	if s := floatx.Sum(f8s); !math.IsNaN(float64(s)) {
+		t.Errorf("Sum(+Inf, -Inf) returned %v, wanted NaN", s)
+	}
+	if s := floatx.Sum(append(f8s[:1:1], floatx.F8Fromfloat32(1))); !math.IsInf(float64(s), 1) {
+		t.Errorf("Sum(+Inf, 1) returned %v, wanted +Inf", s)
+	}
+
+	// 1 is lost between 2**100 and -2**100 in a plain float64 sum
+	huge := []floatx.BFloat16{floatx.BF16Fromfloat32(0x1p100), floatx.BF16Fromfloat32(1), floatx.BF16Fromfloat32(-0x1p100)}
+	if s := floatx.Sum(huge); s != 1 {
+		t.Errorf("Sum(2**100, 1, -2**100) returned %v, wanted 1", s)
+	}
+	if s := floatx.Sum(huge[1:]); s != -0x1p100 {
+		t.Errorf("Sum(1, -2**100) returned %v, wanted -2**100", s)
+	}
+}
+
+func TestMean(t *testing.T) {
+	if m := floatx.Mean([]floatx.Float16{}); !math.IsNaN(float64(m)) {
+		t.Errorf("Mean(empty) returned %v, wanted NaN", m)
+	}
+	f16s := []floatx.Float16{floatx.F16Fromfloat32(1), floatx.F16Fromfloat32(2), floatx.F16Fromfloat32(4)}
+	if m := floatx.Mean(f16s); m != float32(7.0/3) {
+		t.Errorf("Mean(1, 2, 4) returned %v, wanted %v", m, float32(7.0/3))
+	}
+}
+
+func TestVariance(t *testing.T) {
+	if v := floatx.Variance([]floatx.Float16{}); !math.IsNaN(float64(v)) {
+		t.Errorf("Variance(empty) returned %v, wanted NaN", v)
+	}
+	f16s := []floatx.Float16{floatx.F16Fromfloat32(1), floatx.F16Fromfloat32(2), floatx.F16Fromfloat32(3), floatx.F16Fromfloat32(4)}
+	if v := floatx.Variance(f16s); v != 1.25 {
+		t.Errorf("Variance(1, 2, 3, 4) returned %v, wanted 1.25", v)
+	}
+
+	// a large offset doesn't cancel the small deviations
+	bf16s := make([]floatx.BFloat16, 1001)
+	for i := range bf16s {
+		bf16s[i] = floatx.BF16Fromfloat32(0x1p60 + float32(i%2)*0x1p53)
+	}
+	if v, want := floatx.Variance(bf16s), float32(0x1p106*500*501/1001/1001); v != want {
+		t.Errorf("Variance(2**60 ± 2**53) returned %v, wanted %v", v, want)
+	}
+	if v := floatx.Variance([]floatx.Float8{floatx.F8Inf(1), floatx.F8Fromfloat32(1)}); !math.IsNaN(float64(v)) {
+		t.Errorf("Variance(+Inf, 1) returned %v, wanted NaN", v)
+	}
+}
+
+func TestL2Norm(t *testing.T) {
+	if n := floatx.L2Norm([]floatx.Float16{}); n != 0 {
+		t.Errorf("L2Norm(empty) returned %v, wanted 0", n)
+	}
+	f16s := []floatx.Float16{floatx.F16Fromfloat32(3), floatx.F16Fromfloat32(-4)}
+	if n := floatx.L2Norm(f16s); n != 5 {
+		t.Errorf("L2Norm(3, -4) returned %v, wanted 5", n)
+	}
+
+	// squares of float16 max would overflow float32
+	maxes := []floatx.Float16{floatx.F16Fromfloat32(floatx.F16Max), floatx.F16Fromfloat32(-floatx.F16Max)}
+	if n, want := floatx.L2Norm(maxes), float32(floatx.F16Max*math.Sqrt2); n != want {
+		t.Errorf("L2Norm(F16Max, -F16Max) returned %v, wanted %v", n, want)
+	}
+	if n := floatx.L2Norm([]floatx.BFloat16{floatx.BF16Inf(-1), floatx.BF16Fromfloat32(1)}); !math.IsInf(float64(n), 1) {
+		t.Errorf("L2Norm(-Inf, 1) returned %v, wanted +Inf", n)
+	}
+	if n := floatx.L2Norm([]floatx.Float8{floatx.F8Inf(1), floatx.F8NaN()}); !math.IsNaN(float64(n)) {
+		t.Errorf("L2Norm(+Inf, NaN) returned %v, wanted NaN", n)
+	}
+}
+
+func TestCountNonFinite(t *testing.T) {
+	f16s := []floatx.Float16{floatx.F16Fromfloat32(1), floatx.F16NaN(), floatx.F16Inf(-1), floatx.F16Fromfloat32(floatx.F16Max)}
+	if n := floatx.CountNonFinite(f16s); n != 2 {
+		t.Errorf("CountNonFinite(f16s) returned %d, wanted 2", n)
+	}
+	if n := floatx.CountNonFinite([]floatx.BFloat16{}); n != 0 {
+		t.Errorf("CountNonFinite(empty) returned %d, wanted 0", n)
+	}
+}
+
+func TestArgMax(t *testing.T) {
+	testCases := []struct {
+		in   []float32
+		want int
+	}{
+		{nil, -1},
+		{[]float32{1, 3, -5, 3}, 1},
+		{[]float32{float32(math.Copysign(0, -1)), 0}, 0},
+		{[]float32{float32(math.Inf(-1)), float32(math.Inf(-1))}, 0},
+		{[]float32{-2, -1, float32(math.Inf(1))}, 2},
+		{[]float32{1, float32(math.NaN()), float32(math.Inf(1)), float32(math.NaN())}, 1},
+	}
+	for _, tc := range testCases {
+		f16s := make([]floatx.Float16, len(tc.in))
+		floatx.FromFloat32s(f16s, tc.in)
+		if i := floatx.ArgMax(f16s); i != tc.want {
+			t.Errorf("ArgMax(%v) returned %d, wanted %d", tc.in, i, tc.want)
+		}
+		bf16s := make([]floatx.BFloat16, len(tc.in))
+		floatx.FromFloat32s(bf16s, tc.in)
+		if i := floatx.ArgMax(bf16s); i != tc.want {
+			t.Errorf("ArgMax(%v) of BFloat16 returned %d, wanted %d", tc.in, i, tc.want)
+		}
+	}
+}
+
+func TestMaxAbs(t *testing.T) {
+	if m := floatx.MaxAbs([]floatx.Float16{}); m != 0 {
+		t.Errorf("MaxAbs(empty) returned 0x%04x, wanted 0", uint16(m))
+	}
+
+	f16s := []floatx.Float16{floatx.F16Fromfloat32(1), floatx.F16Fromfloat32(-7.5), floatx.F16Fromfloat32(3)}
+	if m := floatx.MaxAbs(f16s); m.Float32() != 7.5 {
+		t.Errorf("MaxAbs(f16s) returned %v, wanted 7.5", m)
+	}
+
+	bf16s := []floatx.BFloat16{floatx.BF16Fromfloat32(-2), floatx.BF16Inf(-1), floatx.BF16Fromfloat32(3)}
+	if m := floatx.MaxAbs(bf16s); !m.IsInf(1) {
+		t.Errorf("MaxAbs(bf16s) returned %v, wanted +Inf", m)
+	}
+
+	f8s := []floatx.Float8{floatx.F8Fromfloat32(-2), floatx.F8NaN(), floatx.F8Fromfloat32(3)}
+	if m := floatx.MaxAbs(f8s); !m.IsNaN() {
+		t.Errorf("MaxAbs(f8s) returned %v, wanted NaN", m)
+	}
+}
+
+func TestMinAbs(t *testing.T) {
+	if m := floatx.MinAbs([]floatx.Float16{}); !m.IsInf(1) {
+		t.Errorf("MinAbs(empty) returned %v, wanted +Inf", m)
+	}
+
+	f16s := []floatx.Float16{floatx.F16Fromfloat32(-2), floatx.F16Fromfloat32(0.5), floatx.F16Fromfloat32(-0.25)}
+	if m := floatx.MinAbs(f16s); m.Float32() != 0.25 {
+		t.Errorf("MinAbs(f16s) returned %v, wanted 0.25", m)
+	}
+
+	f8s := []floatx.Float8{floatx.F8Fromfloat32(-2), floatx.F8NaN(), floatx.F8Fromfloat32(0)}
+	if m := floatx.MinAbs(f8s); !m.IsNaN() {
+		t.Errorf("MinAbs(f8s) returned %v, wanted NaN", m)
+	}
+}
+
+func TestReductionsE4M3(t *testing.T) {
+	e4m3s := []floatx.Float8E4M3FN{
+		floatx.F8E4M3Fromfloat32(1.5), floatx.F8E4M3Fromfloat32(-448), floatx.F8E4M3Fromfloat32(0.25),
+	}
+	if s := floatx.Sum(e4m3s); s != -446.25 {
+		t.Errorf("Sum(e4m3s) returned %v, wanted -446.25", s)
+	}
+	if m := floatx.Mean(e4m3s[:2]); m != -223.25 {
+		t.Errorf("Mean(1.5, -448) returned %v, wanted -223.25", m)
+	}
+	if n := floatx.L2Norm(e4m3s[1:2]); n != 448 {
+		t.Errorf("L2Norm(-448) returned %v, wanted 448", n)
+	}
+	if i := floatx.ArgMax(e4m3s); i != 0 {
+		t.Errorf("ArgMax(e4m3s) returned %d, wanted 0", i)
+	}
+	if m := floatx.MaxAbs(e4m3s); m.Float32() != 448 {
+		t.Errorf("MaxAbs(e4m3s) returned %v, wanted 448", m)
+	}
+	if m := floatx.MinAbs(e4m3s); m.Float32() != 0.25 {
+		t.Errorf("MinAbs(e4m3s) returned %v, wanted 0.25", m)
+	}
+
+	// NaN is the only non-finite Float8E4M3FN, and stands in for infinity
+	if m := floatx.MinAbs([]floatx.Float8E4M3FN{}); !m.IsNaN() {
+		t.Errorf("MinAbs(empty) returned %v, wanted NaN", m)
+	}
+	nan := append(e4m3s, floatx.F8E4M3Fromfloat32(float32(math.Inf(1))))
+	if n := floatx.CountNonFinite(nan); n != 1 {
+		t.Errorf("CountNonFinite returned %d, wanted 1", n)
+	}
+	if i := floatx.ArgMax(nan); i != 3 {
+		t.Errorf("ArgMax returned %d, wanted 3", i)
+	}
+	if m := floatx.MaxAbs(nan); !m.IsNaN() {
+		t.Errorf("MaxAbs returned %v, wanted NaN", m)
+	}
+	if v := floatx.Variance(nan); !math.IsNaN(float64(v)) {
+		t.Errorf("Variance returned %v, wanted NaN", v)
+	}
+}
+
+// nanFloat is a SmallFloat with the NaN payload methods.
+type nanFloat[T any] interface {
+	floatx.SmallFloat
+	Payload() uint
+	IsSignalingNaN() bool
+	Quiet() T
+}
+
+// checkNaNPayload checks the NaN payload functions and methods of T, which
+// has the layout of f.
+func checkNaNPayload[T nanFloat[T]](t *testing.T, f floatxtest.Format, withPayload func(uint, bool) T, canonical T, fromNaN32ps func(float32) (T, error)) {
+	t.Helper()
+
+	inf := uint16(1<<f.ExpBits-1) << f.ManBits
+	quietBit := uint16(1) << (f.ManBits - 1)
+	maxPayload := uint(quietBit - 1)
+
+	testCases := []struct {
+		payload     uint
+		quiet       bool
+		want        uint16
+		wantPayload uint
+	}{
+		{0, true, inf | quietBit, 0},
+		{0, false, inf | 1, 1}, // a signaling NaN needs a nonzero payload
+		{maxPayload, true, inf | quietBit | uint16(maxPayload), maxPayload},
+		{maxPayload, false, inf | uint16(maxPayload), maxPayload},
+		{maxPayload + 1, true, inf | quietBit, 0}, // only the low payload bits
+	}
+	for _, tc := range testCases {
+		nan := withPayload(tc.payload, tc.quiet)
+		if nan.Bits16() != tc.want || !nan.IsNaN() || nan.IsQuietNaN() != tc.quiet || nan.IsSignalingNaN() == tc.quiet || nan.Payload() != tc.wantPayload {
+			t.Errorf("%s NaNWithPayload(%d, %v) = 0x%x, payload %d", f.Name, tc.payload, tc.quiet, nan.Bits16(), nan.Payload())
+		}
+	}
+	if canonical.Bits16() != inf|quietBit || canonical.Payload() != 0 || !canonical.IsQuietNaN() {
+		t.Errorf("%s CanonicalNaN() = 0x%x", f.Name, canonical.Bits16())
+	}
+	if one := floatx.FromFloat32[T](1); one.Payload() != 0 || one.IsSignalingNaN() || one.Quiet() != one {
+		t.Errorf("%s: Payload, IsSignalingNaN or Quiet of 1 is wrong", f.Name)
+	}
+	if negInf := floatx.FromFloat32[T](float32(math.Inf(-1))); negInf.IsSignalingNaN() || negInf.Quiet() != negInf {
+		t.Errorf("%s: IsSignalingNaN or Quiet of -Inf is wrong", f.Name)
+	}
+
+	// round-trips through float32 and float64 keep the sign and payload
+	// and quiet the NaN
+	for u := uint32(0); u < 1<<f.Width(); u++ {
+		x := T(u)
+		if !x.IsNaN() {
+			continue
+		}
+		q := x.Quiet()
+		if !q.IsQuietNaN() || q.Payload() != x.Payload() || q.Signbit() != x.Signbit() || q.Bits16()&^quietBit != x.Bits16()&^quietBit {
+			t.Errorf("%s 0x%x.Quiet() = 0x%x", f.Name, u, q.Bits16())
+		}
+		if got := floatx.FromFloat32[T](x.Float32()); got != q {
+			t.Errorf("%s Fromfloat32(0x%x.Float32()) = 0x%x, want 0x%x", f.Name, u, got.Bits16(), q.Bits16())
+		}
+		f64 := float64(x.Float32())
+		if got := floatx.FromFloat32[T](float32(f64)); got != q {
+			t.Errorf("%s Fromfloat32(float32(float64(0x%x.Float32()))) = 0x%x, want 0x%x", f.Name, u, got.Bits16(), q.Bits16())
+		}
+		if got, err := fromNaN32ps(x.Float32()); err != nil || got != q {
+			t.Errorf("%s FromNaN32ps(0x%x.Float32()) = 0x%x, %v", f.Name, u, got.Bits16(), err)
+		}
+	}
+}
+
+func TestNaNPayload(t *testing.T) {
+	checkNaNPayload(t, floatxtest.Float16, floatx.F16NaNWithPayload, floatx.F16CanonicalNaN(), floatx.F16FromNaN32ps)
+	checkNaNPayload(t, floatxtest.BFloat16, floatx.BF16NaNWithPayload, floatx.BF16CanonicalNaN(), floatx.BF16FromNaN32ps)
+	checkNaNPayload(t, floatxtest.Float8E5M2, floatx.F8NaNWithPayload, floatx.F8CanonicalNaN(), floatx.F8FromNaN32ps)
+}
@@ -0,0 +1,468 @@
+// Package gguf reads and writes GGUF files, the model format of llama.cpp
+// and other GGML-based runtimes.
+//
+// A GGUF file has typed metadata key/values, tensor infos, and aligned
+// tensor data. Open parses the metadata and tensor infos; tensor data is
+// read only when it's requested. Float32s dequantizes F32, F16, BF16, Q8_0,
+// Q4_0, Q4_K and Q6_K tensors, decoding the Float16 block scales with this
+// module's Float16. Write writes new files, such as F16 or BF16 tensors
+// converted from safetensors.
+//
+// Tensor dimensions are in GGML order, innermost first, which is the
+// reverse of a NumPy or safetensors shape.
+package gguf
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+type ggufError string
+
+func (e ggufError) Error() string { return string(e) }
+
+const (
+	// ErrInvalidMagic is returned for a file that doesn't start with "GGUF".
+	ErrInvalidMagic = ggufError("gguf: invalid magic string")
+
+	// ErrUnsupportedVersion is returned for files older than version 2, and
+	// for big-endian files.
+	ErrUnsupportedVersion = ggufError("gguf: unsupported version")
+
+	// ErrInvalidFile is returned for a malformed file.
+	ErrInvalidFile = ggufError("gguf: invalid file")
+
+	// ErrUnsupportedType is returned for a tensor type that can't be read,
+	// dequantized or written.
+	ErrUnsupportedType = ggufError("gguf: unsupported tensor type")
+
+	// ErrNotFound is returned for a tensor name that isn't in the file.
+	ErrNotFound = ggufError("gguf: tensor not found")
+)
+
+const magic = "GGUF"
+
+// DefaultAlignment is the alignment of tensor data when the metadata has
+// no general.alignment.
+const DefaultAlignment = 32
+
+// Type is a GGML tensor type.
+type Type uint32
+
+// Tensor types. Only the types with known block sizes can be read.
+const (
+	TypeF32  Type = 0
+	TypeF16  Type = 1
+	TypeQ4_0 Type = 2
+	TypeQ4_1 Type = 3
+	TypeQ5_0 Type = 6
+	TypeQ5_1 Type = 7
+	TypeQ8_0 Type = 8
+	TypeQ8_1 Type = 9
+	TypeQ2_K Type = 10
+	TypeQ3_K Type = 11
+	TypeQ4_K Type = 12
+	TypeQ5_K Type = 13
+	TypeQ6_K Type = 14
+	TypeQ8_K Type = 15
+	TypeI8   Type = 24
+	TypeI16  Type = 25
+	TypeI32  Type = 26
+	TypeI64  Type = 27
+	TypeF64  Type = 28
+	TypeBF16 Type = 30
+)
+
+// typeTraits has the name, block length in elements, and block size in bytes
+// of each tensor type.
+var typeTraits = map[Type]struct {
+	name                 string
+	blockLen, blockBytes int
+}{
+	TypeF32:  {"F32", 1, 4},
+	TypeF16:  {"F16", 1, 2},
+	TypeQ4_0: {"Q4_0", 32, 18},
+	TypeQ4_1: {"Q4_1", 32, 20},
+	TypeQ5_0: {"Q5_0", 32, 22},
+	TypeQ5_1: {"Q5_1", 32, 24},
+	TypeQ8_0: {"Q8_0", 32, 34},
+	TypeQ8_1: {"Q8_1", 32, 36},
+	TypeQ2_K: {"Q2_K", 256, 84},
+	TypeQ3_K: {"Q3_K", 256, 110},
+	TypeQ4_K: {"Q4_K", 256, 144},
+	TypeQ5_K: {"Q5_K", 256, 176},
+	TypeQ6_K: {"Q6_K", 256, 210},
+	TypeQ8_K: {"Q8_K", 256, 292},
+	TypeI8:   {"I8", 1, 1},
+	TypeI16:  {"I16", 1, 2},
+	TypeI32:  {"I32", 1, 4},
+	TypeI64:  {"I64", 1, 8},
+	TypeF64:  {"F64", 1, 8},
+	TypeBF16: {"BF16", 1, 2},
+}
+
+// String satisfies the fmt.Stringer interface.
+func (t Type) String() string {
+	if tt, ok := typeTraits[t]; ok {
+		return tt.name
+	}
+	return fmt.Sprintf("Type(%d)", uint32(t))
+}
+
+// ValueType is the type of a metadata value.
+type ValueType uint32
+
+// Metadata value types.
+const (
+	ValueUint8   ValueType = 0
+	ValueInt8    ValueType = 1
+	ValueUint16  ValueType = 2
+	ValueInt16   ValueType = 3
+	ValueUint32  ValueType = 4
+	ValueInt32   ValueType = 5
+	ValueFloat32 ValueType = 6
+	ValueBool    ValueType = 7
+	ValueString  ValueType = 8
+	ValueArray   ValueType = 9
+	ValueUint64  ValueType = 10
+	ValueInt64   ValueType = 11
+	ValueFloat64 ValueType = 12
+)
+
+// valueSizes has the encoded size of each scalar value type.
+var valueSizes = [...]int64{1, 1, 2, 2, 4, 4, 4, 1, 0, 0, 8, 8, 8}
+
+// KV is a metadata key/value. Value has the Go type matching its
+// ValueType: uint8, int8, uint16, int16, uint32, int32, float32, bool,
+// string, uint64, int64 or float64. Arrays are slices of those types, or
+// []any for arrays of arrays.
+type KV struct {
+	Key   string
+	Value any
+}
+
+// TensorInfo describes a tensor of a GGUF file.
+type TensorInfo struct {
+	Name   string
+	Dims   []uint64 // innermost first
+	Type   Type
+	Offset uint64 // from the start of the tensor data
+}
+
+// Len returns the number of elements of t.
+func (t *TensorInfo) Len() uint64 {
+	n := uint64(1)
+	for _, d := range t.Dims {
+		n *= d
+	}
+	return n
+}
+
+// size returns the size in bytes of the data of t, and false if the type
+// is unknown or the dimensions aren't valid for it.
+func (t *TensorInfo) size() (uint64, bool) {
+	tt, ok := typeTraits[t.Type]
+	if !ok {
+		return 0, false
+	}
+	n := uint64(1)
+	for _, d := range t.Dims {
+		if d != 0 && n > math.MaxInt64/d {
+			return 0, false
+		}
+		n *= d
+	}
+	if n%uint64(tt.blockLen) != 0 || len(t.Dims) > 0 && t.Dims[0]%uint64(tt.blockLen) != 0 || n/uint64(tt.blockLen) > math.MaxInt64/uint64(tt.blockBytes) {
+		return 0, false
+	}
+	return n / uint64(tt.blockLen) * uint64(tt.blockBytes), true
+}
+
+// File is a GGUF file opened for reading.
+type File struct {
+	Version   uint32
+	Metadata  []KV
+	Tensors   []TensorInfo
+	Alignment int
+
+	r          io.ReaderAt
+	size       int64
+	dataOffset int64
+}
+
+// decoder reads the little-endian values of a GGUF header and keeps track
+// of the offset, so lengths can be checked against the rest of the file.
+type decoder struct {
+	r    *bufio.Reader
+	off  int64
+	size int64
+	err  error
+	buf  [8]byte
+}
+
+func (d *decoder) read(n int) []byte {
+	if d.err != nil {
+		return d.buf[:n]
+	}
+	_, d.err = io.ReadFull(d.r, d.buf[:n])
+	if d.err == io.EOF {
+		d.err = io.ErrUnexpectedEOF
+	}
+	d.off += int64(n)
+	return d.buf[:n]
+}
+
+func (d *decoder) uint32() uint32 { return binary.LittleEndian.Uint32(d.read(4)) }
+func (d *decoder) uint64() uint64 { return binary.LittleEndian.Uint64(d.read(8)) }
+
+// count reads a count of items of at least minSize bytes each.
+func (d *decoder) count(minSize int64) int {
+	n := d.uint64()
+	if d.err == nil && n > uint64((d.size-d.off)/minSize) {
+		d.err = fmt.Errorf("%w: count %d at offset %d is larger than the file", ErrInvalidFile, n, d.off-8)
+		return 0
+	}
+	return int(n)
+}
+
+func (d *decoder) string() string {
+	n := d.count(1)
+	if d.err != nil {
+		return ""
+	}
+	b := make([]byte, n)
+	_, d.err = io.ReadFull(d.r, b)
+	d.off += int64(n)
+	return string(b)
+}
+
+// value reads a value of type t. Arrays may nest only a few levels.
+func (d *decoder) value(t ValueType, depth int) any {
+	switch t {
+	case ValueUint8:
+		return d.read(1)[0]
+	case ValueInt8:
+		return int8(d.read(1)[0])
+	case ValueUint16:
+		return binary.LittleEndian.Uint16(d.read(2))
+	case ValueInt16:
+		return int16(binary.LittleEndian.Uint16(d.read(2)))
+	case ValueUint32:
+		return d.uint32()
+	case ValueInt32:
+		return int32(d.uint32())
+	case ValueFloat32:
+		return math.Float32frombits(d.uint32())
+	case ValueBool:
+		return d.read(1)[0] != 0
+	case ValueString:
+		return d.string()
+	case ValueUint64:
+		return d.uint64()
+	case ValueInt64:
+		return int64(d.uint64())
+	case ValueFloat64:
+		return math.Float64frombits(d.uint64())
+	case ValueArray:
+		if depth > 8 {
+			d.err = fmt.Errorf("%w: arrays nested too deeply", ErrInvalidFile)
+			return nil
+		}
+		return d.array(depth + 1)
+	}
+	d.err = fmt.Errorf("%w: unknown value type %d", ErrInvalidFile, t)
+	return nil
+}
+
+// array reads an array as a slice of the element type.
+func (d *decoder) array(depth int) any {
+	t := ValueType(d.uint32())
+	minSize := int64(8) // strings and arrays start with a count
+	if int(t) < len(valueSizes) && valueSizes[t] > 0 {
+		minSize = valueSizes[t]
+	}
+	n := d.count(minSize)
+	if d.err != nil {
+		return nil
+	}
+	switch t {
+	case ValueUint8:
+		return arrayOf[uint8](d, t, n, depth)
+	case ValueInt8:
+		return arrayOf[int8](d, t, n, depth)
+	case ValueUint16:
+		return arrayOf[uint16](d, t, n, depth)
+	case ValueInt16:
+		return arrayOf[int16](d, t, n, depth)
+	case ValueUint32:
+		return arrayOf[uint32](d, t, n, depth)
+	case ValueInt32:
+		return arrayOf[int32](d, t, n, depth)
+	case ValueFloat32:
+		return arrayOf[float32](d, t, n, depth)
+	case ValueBool:
+		return arrayOf[bool](d, t, n, depth)
+	case ValueString:
+		return arrayOf[string](d, t, n, depth)
+	case ValueUint64:
+		return arrayOf[uint64](d, t, n, depth)
+	case ValueInt64:
+		return arrayOf[int64](d, t, n, depth)
+	case ValueFloat64:
+		return arrayOf[float64](d, t, n, depth)
+	case ValueArray:
+		return arrayOf[any](d, t, n, depth)
+	}
+	d.err = fmt.Errorf("%w: unknown array type %d", ErrInvalidFile, t)
+	return nil
+}
+
+func arrayOf[T any](d *decoder, t ValueType, n, depth int) []T {
+	s := make([]T, n)
+	for i := range s {
+		s[i], _ = d.value(t, depth).(T)
+		if d.err != nil {
+			return nil
+		}
+	}
+	return s
+}
+
+// Open reads the metadata and tensor infos of the GGUF file of the given
+// size read from r. Tensor data is read from r when it's requested.
+func Open(r io.ReaderAt, size int64) (*File, error) {
+	d := &decoder{r: bufio.NewReader(io.NewSectionReader(r, 0, size)), size: size}
+	if string(d.read(4)) != magic {
+		if d.err != nil {
+			return nil, d.err
+		}
+		return nil, ErrInvalidMagic
+	}
+
+	f := &File{Version: d.uint32(), Alignment: DefaultAlignment, r: r, size: size}
+	if d.err == nil && (f.Version < 2 || f.Version > 3) {
+		return nil, fmt.Errorf("%w %d", ErrUnsupportedVersion, f.Version)
+	}
+	// a tensor info and a key/value are each at least 8+4 bytes
+	numTensors := d.count(12)
+	numKV := d.count(12)
+
+	for i := 0; i < numKV && d.err == nil; i++ {
+		kv := KV{Key: d.string()}
+		kv.Value = d.value(ValueType(d.uint32()), 0)
+		f.Metadata = append(f.Metadata, kv)
+		if kv.Key == "general.alignment" {
+			a, ok := kv.Value.(uint32)
+			if !ok || a == 0 || a&(a-1) != 0 {
+				return nil, fmt.Errorf("%w: general.alignment %v", ErrInvalidFile, kv.Value)
+			}
+			f.Alignment = int(a)
+		}
+	}
+
+	for i := 0; i < numTensors && d.err == nil; i++ {
+		t := TensorInfo{Name: d.string()}
+		n := d.uint32()
+		if d.err == nil && n > 4 {
+			// GGML_MAX_DIMS
+			return nil, fmt.Errorf("%w: tensor %q has %d dimensions", ErrInvalidFile, t.Name, n)
+		}
+		t.Dims = make([]uint64, n)
+		for j := range t.Dims {
+			t.Dims[j] = d.uint64()
+		}
+		t.Type = Type(d.uint32())
+		t.Offset = d.uint64()
+		f.Tensors = append(f.Tensors, t)
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	f.dataOffset = align(d.off, f.Alignment)
+	if f.dataOffset > size {
+		// the padding before the data section is missing
+		return nil, fmt.Errorf("%w: data section is beyond the end of the file", ErrInvalidFile)
+	}
+	dataSize := uint64(size - f.dataOffset)
+	for i := range f.Tensors {
+		t := &f.Tensors[i]
+		if t.Offset%uint64(f.Alignment) != 0 {
+			return nil, fmt.Errorf("%w: tensor %q isn't aligned", ErrInvalidFile, t.Name)
+		}
+		if n, ok := t.size(); ok && (t.Offset > dataSize || n > dataSize-t.Offset) {
+			return nil, fmt.Errorf("%w: tensor %q is beyond the end of the file", ErrInvalidFile, t.Name)
+		}
+	}
+	return f, nil
+}
+
+// align returns off rounded up to a multiple of alignment.
+func align(off int64, alignment int) int64 {
+	return (off + int64(alignment) - 1) / int64(alignment) * int64(alignment)
+}
+
+// Value returns the metadata value of key.
+func (f *File) Value(key string) (any, bool) {
+	for _, kv := range f.Metadata {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return nil, false
+}
+
+// Tensor returns the info of the named tensor.
+func (f *File) Tensor(name string) (*TensorInfo, error) {
+	for i := range f.Tensors {
+		if f.Tensors[i].Name == name {
+			return &f.Tensors[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %q", ErrNotFound, name)
+}
+
+// ReadRaw returns the data of the named tensor as stored in the file.
+func (f *File) ReadRaw(name string) ([]byte, *TensorInfo, error) {
+	t, err := f.Tensor(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	n, ok := t.size()
+	if !ok {
+		return nil, t, fmt.Errorf("%w %v for tensor %q with dimensions %v", ErrUnsupportedType, t.Type, name, t.Dims)
+	}
+	b := make([]byte, n)
+	if _, err := f.r.ReadAt(b, f.dataOffset+int64(t.Offset)); err != nil {
+		return nil, t, err
+	}
+	return b, t, nil
+}
+
+// Float32s returns the data of the named tensor dequantized to float32.
+// The tensor type must be F32, F16, BF16, Q8_0, Q4_0, Q4_K or Q6_K.
+func (f *File) Float32s(name string) ([]float32, error) {
+	t, err := f.Tensor(name)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := dequantizers[t.Type]; !ok {
+		return nil, fmt.Errorf("%w %v for tensor %q", ErrUnsupportedType, t.Type, name)
+	}
+	b, t, err := f.ReadRaw(name)
+	if err != nil {
+		return nil, err
+	}
+	dst := make([]float32, t.Len())
+	n, err := Dequantize(dst, t.Type, b)
+	if err != nil {
+		return nil, err
+	}
+	if n != len(dst) {
+		return nil, fmt.Errorf("%w: tensor %q has %d elements, not whole blocks of %v", ErrInvalidFile, name, len(dst), t.Type)
+	}
+	return dst, nil
+}
@@ -0,0 +1,362 @@
+package gguf_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"reflect"
+	"testing"
+
+	floatx "github.com/chenxingqiang/go-floatx"
+	"github.com/chenxingqiang/go-floatx/gguf"
+)
+
+// The fixtures in testdata are written by testdata/gen_fixtures.py.
+
+func open(t *testing.T, name string) (*gguf.File, []byte) {
+	t.Helper()
+	b, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := gguf.Open(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f, b
+}
+
+var wantMetadata = []gguf.KV{
+	{Key: "general.architecture", Value: "llama"},
+	{Key: "general.alignment", Value: uint32(32)},
+	{Key: "test.u8", Value: uint8(200)},
+	{Key: "test.i8", Value: int8(-100)},
+	{Key: "test.u16", Value: uint16(60000)},
+	{Key: "test.i16", Value: int16(-30000)},
+	{Key: "test.i32", Value: int32(-2000000000)},
+	{Key: "test.f32", Value: float32(0.5)},
+	{Key: "test.bool", Value: true},
+	{Key: "test.u64", Value: uint64(1 << 40)},
+	{Key: "test.i64", Value: int64(-1 << 40)},
+	{Key: "test.f64", Value: 0.1},
+	{Key: "tokenizer.ggml.tokens", Value: []string{"<s>", "</s>", "héllo"}},
+	{Key: "test.scores", Value: []float32{0, -1.5}},
+	{Key: "test.nested", Value: []any{[]uint32{1, 2}, []uint32{}}},
+}
+
+var wantTensors = []gguf.TensorInfo{
+	{Name: "f32", Dims: []uint64{3}, Type: gguf.TypeF32, Offset: 0},
+	{Name: "f16", Dims: []uint64{3, 2}, Type: gguf.TypeF16, Offset: 32},
+	{Name: "bf16", Dims: []uint64{4}, Type: gguf.TypeBF16, Offset: 64},
+	{Name: "q8_0", Dims: []uint64{64}, Type: gguf.TypeQ8_0, Offset: 96},
+	{Name: "q4_0", Dims: []uint64{32, 2}, Type: gguf.TypeQ4_0, Offset: 192},
+	{Name: "q4_k", Dims: []uint64{256, 2}, Type: gguf.TypeQ4_K, Offset: 256},
+	{Name: "q6_k", Dims: []uint64{512}, Type: gguf.TypeQ6_K, Offset: 544},
+}
+
+func TestOpen(t *testing.T) {
+	f, _ := open(t, "model.gguf")
+	if f.Version != 3 || f.Alignment != 32 {
+		t.Errorf("Version %d, Alignment %d", f.Version, f.Alignment)
+	}
+	if !reflect.DeepEqual(f.Metadata, wantMetadata) {
+		t.Errorf("Metadata = %#v", f.Metadata)
+	}
+	if !reflect.DeepEqual(f.Tensors, wantTensors) {
+		t.Errorf("Tensors = %+v", f.Tensors)
+	}
+
+	if v, ok := f.Value("general.architecture"); !ok || v != "llama" {
+		t.Errorf("Value(general.architecture) = %v, %v", v, ok)
+	}
+	if _, ok := f.Value("missing"); ok {
+		t.Error("Value(missing) returned ok")
+	}
+	if ti, err := f.Tensor("q4_k"); err != nil || ti.Len() != 512 {
+		t.Errorf("Tensor(q4_k) = %+v, %v", ti, err)
+	}
+	if _, err := f.Tensor("missing"); !errors.Is(err, gguf.ErrNotFound) {
+		t.Errorf("Tensor(missing) returned %v, wanted ErrNotFound", err)
+	}
+	if _, err := f.Float32s("missing"); !errors.Is(err, gguf.ErrNotFound) {
+		t.Errorf("Float32s(missing) returned %v, wanted ErrNotFound", err)
+	}
+	if _, _, err := f.ReadRaw("missing"); !errors.Is(err, gguf.ErrNotFound) {
+		t.Errorf("ReadRaw(missing) returned %v, wanted ErrNotFound", err)
+	}
+}
+
+func TestFloat32s(t *testing.T) {
+	// want.gguf has the tensors of model.gguf dequantized by
+	// gen_fixtures.py, which ports ggml's dequantize_row functions
+	f, _ := open(t, "model.gguf")
+	want, _ := open(t, "want.gguf")
+	for _, ti := range f.Tensors {
+		got, err := f.Float32s(ti.Name)
+		if err != nil {
+			t.Errorf("%s: %v", ti.Name, err)
+			continue
+		}
+		w, err := want.Float32s(ti.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(w) {
+			t.Errorf("%s: got %d values, wanted %d", ti.Name, len(got), len(w))
+			continue
+		}
+		for i := range got {
+			if math.Float32bits(got[i]) != math.Float32bits(w[i]) {
+				t.Errorf("%s[%d] = %v, wanted %v", ti.Name, i, got[i], w[i])
+				break
+			}
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	// rewriting the metadata and raw tensors reproduces the file
+	f, want := open(t, "model.gguf")
+	var tensors []*gguf.Tensor
+	for _, ti := range f.Tensors {
+		data, info, err := f.ReadRaw(ti.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tensors = append(tensors, &gguf.Tensor{Name: info.Name, Type: info.Type, Dims: info.Dims, Data: data})
+	}
+	var buf bytes.Buffer
+	if err := gguf.Write(&buf, f.Metadata, tensors); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("Write wrote %d bytes different from the fixture's %d", buf.Len(), len(want))
+	}
+}
+
+func TestNewTensor(t *testing.T) {
+	f16s := make([]floatx.Float16, 6)
+	floatx.FromFloat32s(f16s, []float32{1, 2, 3, 4, 5, 6})
+	bf16s := make([]floatx.BFloat16, 3)
+	floatx.FromFloat32s(bf16s, []float32{-1, 0.5, 1e30})
+
+	a, err := gguf.NewTensor("a", f16s, 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := gguf.NewTensor("b", bf16s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gguf.NewTensor("c", bf16s, 2); err != gguf.ErrShape {
+		t.Errorf("NewTensor with bad dimensions returned %v, wanted ErrShape", err)
+	}
+
+	metadata := []gguf.KV{
+		{Key: "general.alignment", Value: uint32(64)},
+		{Key: "u8s", Value: []uint8{1}},
+		{Key: "i8s", Value: []int8{-1}},
+		{Key: "u16s", Value: []uint16{2}},
+		{Key: "i16s", Value: []int16{-2}},
+		{Key: "u32s", Value: []uint32{3}},
+		{Key: "i32s", Value: []int32{-3}},
+		{Key: "f32s", Value: []float32{0.5}},
+		{Key: "flags", Value: []bool{true, false}},
+		{Key: "u64s", Value: []uint64{4}},
+		{Key: "i64s", Value: []int64{-4}},
+		{Key: "f64s", Value: []float64{0.25}},
+	}
+	var buf bytes.Buffer
+	if err := gguf.Write(&buf, metadata, []*gguf.Tensor{a, b}); err != nil {
+		t.Fatal(err)
+	}
+	f, err := gguf.Open(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Alignment != 64 || !reflect.DeepEqual(f.Metadata, metadata) || f.Tensors[1].Offset != 64 {
+		t.Errorf("Alignment %d, Metadata %v, Tensors %+v", f.Alignment, f.Metadata, f.Tensors)
+	}
+	if buf.Len()%64 != 0 {
+		t.Errorf("file length %d isn't aligned", buf.Len())
+	}
+	got, err := f.Float32s("a")
+	if want := []float32{1, 2, 3, 4, 5, 6}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("Float32s(a) = %v, %v, wanted %v", got, err, want)
+	}
+	got, err = f.Float32s("b")
+	if want := []float32{-1, 0.5, bf16s[2].Float32()}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("Float32s(b) = %v, %v, wanted %v", got, err, want)
+	}
+}
+
+type errWriter struct{ n int }
+
+func (w *errWriter) Write(p []byte) (int, error) {
+	if w.n <= 0 {
+		return 0, errors.New("write failed")
+	}
+	w.n--
+	return len(p), nil
+}
+
+func TestWriteErrors(t *testing.T) {
+	good, _ := gguf.NewTensor("good", []floatx.Float16{1, 2})
+	short := &gguf.Tensor{Name: "short", Type: gguf.TypeQ8_0, Dims: []uint64{32}, Data: make([]byte, 33)}
+	unknown := &gguf.Tensor{Name: "unknown", Type: 99, Dims: []uint64{1}, Data: make([]byte, 1)}
+
+	testCases := []struct {
+		metadata []gguf.KV
+		tensors  []*gguf.Tensor
+		err      error
+	}{
+		{[]gguf.KV{{Key: "int", Value: 1}}, nil, gguf.ErrUnsupportedValue},
+		{[]gguf.KV{{Key: "ints", Value: []int{1}}}, nil, gguf.ErrUnsupportedValue},
+		{[]gguf.KV{{Key: "nested", Value: []any{"a"}}}, nil, gguf.ErrUnsupportedValue},
+		{[]gguf.KV{{Key: "nested", Value: []any{[]any{[]int{1}}}}}, nil, gguf.ErrUnsupportedValue},
+		{[]gguf.KV{{Key: "general.alignment", Value: uint32(48)}}, nil, gguf.ErrUnsupportedValue},
+		{[]gguf.KV{{Key: "general.alignment", Value: 32}}, nil, gguf.ErrUnsupportedValue},
+		{nil, []*gguf.Tensor{short}, gguf.ErrUnsupportedType},
+		{nil, []*gguf.Tensor{unknown}, gguf.ErrUnsupportedType},
+	}
+	for _, tc := range testCases {
+		if err := gguf.Write(io.Discard, tc.metadata, tc.tensors); !errors.Is(err, tc.err) {
+			t.Errorf("Write(%v, %v) returned %v, wanted %v", tc.metadata, tc.tensors, err, tc.err)
+		}
+	}
+
+	for n := 0; n < 3; n++ {
+		if err := gguf.Write(&errWriter{n}, nil, []*gguf.Tensor{good}); err == nil {
+			t.Errorf("Write failing after %d writes returned nil", n)
+		}
+	}
+}
+
+// file returns a GGUF file with the given counts followed by rest.
+func file(version uint32, tensors, kvs uint64, rest ...[]byte) []byte {
+	b := []byte("GGUF")
+	b = binary.LittleEndian.AppendUint32(b, version)
+	b = binary.LittleEndian.AppendUint64(b, tensors)
+	b = binary.LittleEndian.AppendUint64(b, kvs)
+	for _, r := range rest {
+		b = append(b, r...)
+	}
+	return b
+}
+
+func str(s string) []byte {
+	return append(binary.LittleEndian.AppendUint64(nil, uint64(len(s))), s...)
+}
+
+func u32(v uint32) []byte { return binary.LittleEndian.AppendUint32(nil, v) }
+func u64(v uint64) []byte { return binary.LittleEndian.AppendUint64(nil, v) }
+
+// tensor returns a tensor info.
+func tensor(name string, typ gguf.Type, offset uint64, dims ...uint64) []byte {
+	b := append(str(name), u32(uint32(len(dims)))...)
+	for _, d := range dims {
+		b = append(b, u64(d)...)
+	}
+	return append(append(b, u32(uint32(typ))...), u64(offset)...)
+}
+
+func TestOpenErrors(t *testing.T) {
+	pad := make([]byte, 64)
+	testCases := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{"empty", nil, io.ErrUnexpectedEOF},
+		{"magic", []byte("GGML\x03\x00\x00\x00"), gguf.ErrInvalidMagic},
+		{"version 1", file(1, 0, 0), gguf.ErrUnsupportedVersion},
+		{"big-endian", file(3<<24, 0, 0), gguf.ErrUnsupportedVersion},
+		{"short counts", file(3, 0, 0)[:20], io.ErrUnexpectedEOF},
+		{"too many tensors", file(3, 1<<40, 0), gguf.ErrInvalidFile},
+		{"too many kvs", file(3, 0, 1<<40), gguf.ErrInvalidFile},
+		{"long key", file(3, 0, 1, u64(1<<40)), gguf.ErrInvalidFile},
+		{"value type", file(3, 0, 1, str("k"), u32(13), pad), gguf.ErrInvalidFile},
+		{"array type", file(3, 0, 1, str("k"), u32(9), u32(13), u64(1), pad), gguf.ErrInvalidFile},
+		{"long array", file(3, 0, 1, str("k"), u32(9), u32(4), u64(1<<40), pad), gguf.ErrInvalidFile},
+		{"short array", file(3, 0, 1, str("k"), u32(9), u32(8), u64(2), str("a"), u64(100)), gguf.ErrInvalidFile},
+		{"deep array", file(3, 0, 1, str("k"), u32(9), bytes.Repeat(append(u32(9), u64(1)...), 10), pad), gguf.ErrInvalidFile},
+		{"alignment type", file(3, 0, 1, str("general.alignment"), u32(10), u64(32), pad), gguf.ErrInvalidFile},
+		{"alignment", file(3, 0, 1, str("general.alignment"), u32(4), u32(12), pad), gguf.ErrInvalidFile},
+		{"dimensions", file(3, 1, 0, tensor("t", gguf.TypeF16, 0, 1, 1, 1, 1, 1), pad), gguf.ErrInvalidFile},
+		{"unaligned", file(3, 1, 0, tensor("t", gguf.TypeF16, 2, 1), pad), gguf.ErrInvalidFile},
+		{"beyond end", file(3, 1, 0, tensor("t", gguf.TypeF16, 0, 64), pad), gguf.ErrInvalidFile},
+		{"offset beyond end", file(3, 1, 0, tensor("t", gguf.TypeF16, 1<<40, 1), pad), gguf.ErrInvalidFile},
+		{"no padding", file(3, 1, 0, tensor("t", gguf.TypeF16, 0, 0)), gguf.ErrInvalidFile},
+		{"offset in padding", file(3, 1, 0, tensor("t", gguf.TypeF16, 64, 0), pad), gguf.ErrInvalidFile},
+		{"truncated tensor", file(3, 1, 0, tensor("t", gguf.TypeF16, 0, 1))[:50], io.ErrUnexpectedEOF},
+	}
+	for _, tc := range testCases {
+		if _, err := gguf.Open(bytes.NewReader(tc.data), int64(len(tc.data))); !errors.Is(err, tc.err) {
+			t.Errorf("Open %s returned %v, wanted %v", tc.name, err, tc.err)
+		}
+	}
+
+	// version 2 files are the same, and unknown tensor types can be listed
+	// but not read
+	data := file(2, 6, 0,
+		tensor("unknown", 99, 0, 1),
+		tensor("huge", gguf.TypeF16, 0, 1<<40, 1<<40),
+		tensor("large", gguf.TypeF32, 0, 1<<62),
+		tensor("partial", gguf.TypeQ8_0, 0, 33),
+		tensor("q4_1", gguf.TypeQ4_1, 0, 32),
+		tensor("scalar", gguf.TypeQ8_0, 0),
+		pad)
+	f, err := gguf.Open(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"unknown", "huge", "q4_1", "scalar"} {
+		if _, err := f.Float32s(name); !errors.Is(err, gguf.ErrUnsupportedType) {
+			t.Errorf("Float32s(%s) returned %v, wanted ErrUnsupportedType", name, err)
+		}
+	}
+	for _, name := range []string{"unknown", "huge", "large", "partial", "scalar"} {
+		if _, _, err := f.ReadRaw(name); !errors.Is(err, gguf.ErrUnsupportedType) {
+			t.Errorf("ReadRaw(%s) returned %v, wanted ErrUnsupportedType", name, err)
+		}
+	}
+	if _, _, err := f.ReadRaw("q4_1"); err != nil {
+		t.Errorf("ReadRaw(q4_1) returned %v", err)
+	}
+}
+
+// headerReaderAt fails reads past the header, which Open reads from offset 0.
+type headerReaderAt struct{ r io.ReaderAt }
+
+func (r headerReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off > 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	return r.r.ReadAt(p, off)
+}
+
+func TestReadError(t *testing.T) {
+	b, err := os.ReadFile("testdata/model.gguf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := gguf.Open(headerReaderAt{bytes.NewReader(b)}, int64(len(b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Float32s("q6_k"); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Float32s returned %v, wanted ErrUnexpectedEOF", err)
+	}
+}
+
+func TestTypeString(t *testing.T) {
+	if s := gguf.TypeQ4_K.String(); s != "Q4_K" {
+		t.Errorf("TypeQ4_K.String() = %s", s)
+	}
+	if s := gguf.Type(99).String(); s != "Type(99)" {
+		t.Errorf("Type(99).String() = %s", s)
+	}
+}
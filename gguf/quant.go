@@ -0,0 +1,127 @@
+package gguf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	floatx "github.com/chenxingqiang/go-floatx"
+)
+
+// The dequantizers below follow the dequantize_row functions of ggml, with
+// the same order of float32 operations, so they are meant to give the same
+// bits as llama.cpp. The tests compare them with testdata/gen_fixtures.py, a
+// Python port of the same functions, not with llama.cpp itself. The
+// explicit float32 conversions keep the compiler from fusing a multiply and
+// add.
+
+// dequantizers has the function that dequantizes one block of each type.
+var dequantizers = map[Type]func(dst []float32, b []byte){
+	TypeF32: func(dst []float32, b []byte) {
+		dst[0] = math.Float32frombits(binary.LittleEndian.Uint32(b))
+	},
+	TypeF16: func(dst []float32, b []byte) {
+		dst[0] = half(b)
+	},
+	TypeBF16: func(dst []float32, b []byte) {
+		dst[0] = floatx.BFloat16(binary.LittleEndian.Uint16(b)).Float32()
+	},
+	TypeQ8_0: dequantizeQ8_0,
+	TypeQ4_0: dequantizeQ4_0,
+	TypeQ4_K: dequantizeQ4_K,
+	TypeQ6_K: dequantizeQ6_K,
+}
+
+// Dequantize converts src, the data of a tensor of type typ, to float32 in
+// dst and returns the number of elements converted. Only whole blocks are
+// converted, so it is the smaller of len(dst) and the number of elements in
+// src, rounded down to a multiple of the block length. The type must be F32,
+// F16, BF16, Q8_0, Q4_0, Q4_K or Q6_K.
+func Dequantize(dst []float32, typ Type, src []byte) (int, error) {
+	dequantize, ok := dequantizers[typ]
+	if !ok {
+		return 0, fmt.Errorf("%w %v", ErrUnsupportedType, typ)
+	}
+	tt := typeTraits[typ]
+	n := len(src) / tt.blockBytes
+	if m := len(dst) / tt.blockLen; m < n {
+		n = m
+	}
+	for i := 0; i < n; i++ {
+		dequantize(dst[i*tt.blockLen:], src[i*tt.blockBytes:])
+	}
+	return n * tt.blockLen, nil
+}
+
+// half returns the Float16 at the start of b as a float32.
+func half(b []byte) float32 {
+	return floatx.Float16(binary.LittleEndian.Uint16(b)).Float32()
+}
+
+// dequantizeQ8_0 dequantizes a block of 32 int8 values with a Float16 scale.
+func dequantizeQ8_0(dst []float32, b []byte) {
+	d := half(b)
+	for i, q := range b[2:34] {
+		dst[i] = float32(int8(q)) * d
+	}
+}
+
+// dequantizeQ4_0 dequantizes a block of 32 4-bit values, offset by 8, with a
+// Float16 scale. The low nibbles are the first 16 values.
+func dequantizeQ4_0(dst []float32, b []byte) {
+	d := half(b)
+	for i, q := range b[2:18] {
+		dst[i] = float32(int(q&0x0f)-8) * d
+		dst[i+16] = float32(int(q>>4)-8) * d
+	}
+}
+
+// scaleMinK4 returns the 6-bit scale and min j of the 12 packed bytes of a
+// Q4_K block.
+func scaleMinK4(j int, q []byte) (sc, m byte) {
+	if j < 4 {
+		return q[j] & 63, q[j+4] & 63
+	}
+	return q[j+4]&0x0f | q[j-4]>>6<<4, q[j+4]>>4 | q[j]>>6<<4
+}
+
+// dequantizeQ4_K dequantizes a super-block of 256 4-bit values in 8
+// sub-blocks with 6-bit scales and mins, themselves scaled by the Float16 d
+// and dmin.
+func dequantizeQ4_K(dst []float32, b []byte) {
+	d, dmin := half(b), half(b[2:])
+	scales, q := b[4:16], b[16:144]
+	for j := 0; j < 4; j++ {
+		sc, m := scaleMinK4(2*j, scales)
+		d1, m1 := d*float32(sc), dmin*float32(m)
+		sc, m = scaleMinK4(2*j+1, scales)
+		d2, m2 := d*float32(sc), dmin*float32(m)
+		y := dst[64*j:]
+		for l, x := range q[32*j : 32*j+32] {
+			y[l] = float32(d1*float32(x&0x0f)) - m1
+			y[l+32] = float32(d2*float32(x>>4)) - m2
+		}
+	}
+}
+
+// dequantizeQ6_K dequantizes a super-block of 256 6-bit values, offset by
+// 32, in 16 sub-blocks with int8 scales and a Float16 d. The low 4 bits of
+// each value are in ql and the high 2 bits in qh.
+func dequantizeQ6_K(dst []float32, b []byte) {
+	d := half(b[208:])
+	for n := 0; n < 2; n++ {
+		ql, qh, sc := b[64*n:], b[128+32*n:], b[192+8*n:]
+		y := dst[128*n:]
+		for l := 0; l < 32; l++ {
+			is := l / 16
+			q1 := int(ql[l]&0x0f|qh[l]&3<<4) - 32
+			q2 := int(ql[l+32]&0x0f|qh[l]>>2&3<<4) - 32
+			q3 := int(ql[l]>>4|qh[l]>>4&3<<4) - 32
+			q4 := int(ql[l+32]>>4|qh[l]>>6&3<<4) - 32
+			y[l] = float32(d*float32(int8(sc[is]))) * float32(q1)
+			y[l+32] = float32(d*float32(int8(sc[is+2]))) * float32(q2)
+			y[l+64] = float32(d*float32(int8(sc[is+4]))) * float32(q3)
+			y[l+96] = float32(d*float32(int8(sc[is+6]))) * float32(q4)
+		}
+	}
+}
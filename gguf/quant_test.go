@@ -0,0 +1,47 @@
+package gguf_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/chenxingqiang/go-floatx/gguf"
+)
+
+func TestDequantize(t *testing.T) {
+	// a Q8_0 block with d = 0.5 followed by half of another block
+	src := make([]byte, 34+17)
+	src[0], src[1] = 0x00, 0x38
+	for i := 0; i < 32; i++ {
+		src[2+i] = byte(int8(i - 16))
+	}
+
+	dst := make([]float32, 40)
+	n, err := gguf.Dequantize(dst, gguf.TypeQ8_0, src)
+	if err != nil || n != 32 {
+		t.Fatalf("Dequantize returned %d, %v, wanted 32", n, err)
+	}
+	for i, v := range dst[:32] {
+		if want := float32(i-16) / 2; v != want {
+			t.Errorf("dst[%d] = %v, wanted %v", i, v, want)
+		}
+	}
+
+	// dst limits the count to whole blocks
+	if n, _ := gguf.Dequantize(dst[:31], gguf.TypeQ8_0, src); n != 0 {
+		t.Errorf("Dequantize into 31 elements returned %d, wanted 0", n)
+	}
+
+	// Q4_0 stores the low nibbles first
+	q4 := make([]byte, 18)
+	q4[0], q4[1] = 0x00, 0x3c // d = 1
+	q4[2] = 0xf0
+	if n, err := gguf.Dequantize(dst, gguf.TypeQ4_0, q4); err != nil || n != 32 ||
+		!reflect.DeepEqual([]float32{dst[0], dst[1], dst[16], dst[17]}, []float32{-8, -8, 7, -8}) {
+		t.Errorf("Dequantize Q4_0 = %d, %v, %v", n, err, dst[:32])
+	}
+
+	if _, err := gguf.Dequantize(dst, gguf.TypeQ5_K, src); !errors.Is(err, gguf.ErrUnsupportedType) {
+		t.Errorf("Dequantize Q5_K returned %v, wanted ErrUnsupportedType", err)
+	}
+}
@@ -0,0 +1,198 @@
+#!/usr/bin/env python3
+"""Writes the GGUF test fixtures.
+
+Only the standard library is needed. model.gguf has metadata of every
+value type and one tensor of each supported tensor type, with random
+blocks. want.gguf has the same tensors dequantized to F32, computed here
+with float32 rounding after every operation, in the same order as
+llama.cpp's dequantize_row functions. This is a port, so want.gguf is not
+output of llama.cpp or gguf-py; the files haven't been compared with them.
+
+The layout follows gguf-py: tensor infos, padding to the alignment, and
+each tensor's data followed by padding to the alignment.
+"""
+
+import random
+import struct
+
+ALIGN = 32
+
+# ggml type: (id, block elements, block bytes)
+TYPES = {
+    "F32": (0, 1, 4),
+    "F16": (1, 1, 2),
+    "Q4_0": (2, 32, 18),
+    "Q8_0": (8, 32, 34),
+    "Q4_K": (12, 256, 144),
+    "Q6_K": (14, 256, 210),
+    "BF16": (30, 1, 2),
+}
+
+
+def f32(x):
+    return struct.unpack("<f", struct.pack("<f", x))[0]
+
+
+def half(b):
+    return struct.unpack("<e", b)[0]
+
+
+def gstr(s):
+    b = s.encode()
+    return struct.pack("<Q", len(b)) + b
+
+
+# metadata value types
+SCALARS = {0: "B", 1: "b", 2: "H", 3: "h", 4: "I", 5: "i", 6: "f", 7: "?", 10: "Q", 11: "q", 12: "d"}
+
+
+def value(vtype, v):
+    if vtype in SCALARS:
+        return struct.pack("<" + SCALARS[vtype], v)
+    if vtype == 8:
+        return gstr(v)
+    etype, items = v
+    out = struct.pack("<IQ", etype, len(items))
+    for item in items:
+        out += value(etype, item)
+    return out
+
+
+def write(path, metadata, tensors):
+    out = b"GGUF" + struct.pack("<IQQ", 3, len(tensors), len(metadata))
+    for key, vtype, v in metadata:
+        out += gstr(key) + struct.pack("<I", vtype) + value(vtype, v)
+    offset = 0
+    for name, tname, dims, raw in tensors:
+        out += gstr(name) + struct.pack("<I", len(dims))
+        out += b"".join(struct.pack("<Q", d) for d in dims)
+        out += struct.pack("<IQ", TYPES[tname][0], offset)
+        offset += len(raw) + (-len(raw) % ALIGN)
+    out += b"\0" * (-len(out) % ALIGN)
+    for _, _, _, raw in tensors:
+        out += raw + b"\0" * (-len(raw) % ALIGN)
+    with open(path, "wb") as f:
+        f.write(out)
+
+
+rnd = random.Random(1)
+
+
+def rhalf(lo, hi):
+    return struct.pack("<e", rnd.uniform(lo, hi))
+
+
+def rbytes(n):
+    return bytes(rnd.randrange(256) for _ in range(n))
+
+
+def q8_0(nblocks):
+    raw, want = b"", []
+    for _ in range(nblocks):
+        d, qs = rhalf(-0.1, 0.1), rbytes(32)
+        raw += d + qs
+        want += [f32(q * half(d)) for q in struct.unpack("<32b", qs)]
+    return raw, want
+
+
+def q4_0(nblocks):
+    raw, want = b"", []
+    for _ in range(nblocks):
+        d, qs = rhalf(-0.1, 0.1), rbytes(16)
+        raw += d + qs
+        want += [f32(((q & 0xF) - 8) * half(d)) for q in qs]
+        want += [f32(((q >> 4) - 8) * half(d)) for q in qs]
+    return raw, want
+
+
+def scale_min_k4(j, q):
+    if j < 4:
+        return q[j] & 63, q[j + 4] & 63
+    return (q[j + 4] & 0xF) | ((q[j - 4] >> 6) << 4), (q[j + 4] >> 4) | ((q[j] >> 6) << 4)
+
+
+def q4_k(nblocks):
+    raw, want = b"", []
+    for _ in range(nblocks):
+        d, dmin, scales, qs = rhalf(0, 0.01), rhalf(0, 0.01), rbytes(12), rbytes(128)
+        raw += d + dmin + scales + qs
+        d, dmin = half(d), half(dmin)
+        for j in range(4):
+            sc, m = scale_min_k4(2 * j, scales)
+            d1, m1 = f32(d * sc), f32(dmin * m)
+            sc, m = scale_min_k4(2 * j + 1, scales)
+            d2, m2 = f32(d * sc), f32(dmin * m)
+            q = qs[32 * j : 32 * j + 32]
+            want += [f32(f32(d1 * (x & 0xF)) - m1) for x in q]
+            want += [f32(f32(d2 * (x >> 4)) - m2) for x in q]
+    return raw, want
+
+
+def q6_k(nblocks):
+    raw, want = b"", []
+    for _ in range(nblocks):
+        ql, qh, scales, d = rbytes(128), rbytes(64), rbytes(16), rhalf(-0.01, 0.01)
+        raw += ql + qh + scales + d
+        d = half(d)
+        sc = struct.unpack("<16b", scales)
+        for n in range(2):
+            y = [0.0] * 128
+            l0, h0, s0 = 64 * n, 32 * n, 8 * n
+            for l in range(32):
+                i = l // 16
+                q1 = ((ql[l0 + l] & 0xF) | (((qh[h0 + l] >> 0) & 3) << 4)) - 32
+                q2 = ((ql[l0 + l + 32] & 0xF) | (((qh[h0 + l] >> 2) & 3) << 4)) - 32
+                q3 = ((ql[l0 + l] >> 4) | (((qh[h0 + l] >> 4) & 3) << 4)) - 32
+                q4 = ((ql[l0 + l + 32] >> 4) | (((qh[h0 + l] >> 6) & 3) << 4)) - 32
+                y[l] = f32(f32(d * sc[s0 + i]) * q1)
+                y[l + 32] = f32(f32(d * sc[s0 + i + 2]) * q2)
+                y[l + 64] = f32(f32(d * sc[s0 + i + 4]) * q3)
+                y[l + 96] = f32(f32(d * sc[s0 + i + 6]) * q4)
+            want += y
+    return raw, want
+
+
+def plain(fmt, size, values):
+    raw = b"".join(struct.pack("<" + fmt, v) for v in values)
+    return raw, [f32(struct.unpack("<" + fmt, raw[i : i + size])[0]) for i in range(0, len(raw), size)]
+
+
+def bf16(values):
+    raw = b"".join(struct.pack("<f", v)[2:] for v in values)
+    return raw, [struct.unpack("<f", b"\0\0" + raw[i : i + 2])[0] for i in range(0, len(raw), 2)]
+
+
+metadata = [
+    ("general.architecture", 8, "llama"),
+    ("general.alignment", 4, ALIGN),
+    ("test.u8", 0, 200),
+    ("test.i8", 1, -100),
+    ("test.u16", 2, 60000),
+    ("test.i16", 3, -30000),
+    ("test.i32", 5, -2000000000),
+    ("test.f32", 6, 0.5),
+    ("test.bool", 7, True),
+    ("test.u64", 10, 1 << 40),
+    ("test.i64", 11, -(1 << 40)),
+    ("test.f64", 12, 0.1),
+    ("tokenizer.ggml.tokens", 9, (8, ["<s>", "</s>", "héllo"])),
+    ("test.scores", 9, (6, [0.0, -1.5])),
+    ("test.nested", 9, (9, [(4, [1, 2]), (4, [])])),
+]
+
+tensors = [
+    ("f32", "F32", [3], plain("f", 4, [0.1, -2, 1e30])),
+    ("f16", "F16", [3, 2], plain("e", 2, [0, 1, -2, 0.5, 65504, float("inf")])),
+    ("bf16", "BF16", [4], bf16([1, -3.140625, 2.0**100, float("-inf")])),
+    ("q8_0", "Q8_0", [64], q8_0(2)),
+    ("q4_0", "Q4_0", [32, 2], q4_0(2)),
+    ("q4_k", "Q4_K", [256, 2], q4_k(2)),
+    ("q6_k", "Q6_K", [512], q6_k(2)),
+]
+
+write("model.gguf", metadata, [(n, t, d, raw) for n, t, d, (raw, _) in tensors])
+write(
+    "want.gguf",
+    [],
+    [(n, "F32", d, b"".join(struct.pack("<f", v) for v in want)) for n, _, d, (_, want) in tensors],
+)
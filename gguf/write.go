@@ -0,0 +1,228 @@
+package gguf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	floatx "github.com/chenxingqiang/go-floatx"
+)
+
+const (
+	// ErrUnsupportedValue is returned by Write for a metadata value whose Go
+	// type has no GGUF value type.
+	ErrUnsupportedValue = ggufError("gguf: unsupported metadata value")
+
+	// ErrShape is returned when the dimensions don't match the length of the
+	// data.
+	ErrShape = ggufError("gguf: dimensions don't match data length")
+)
+
+// Tensor is a tensor to write.
+type Tensor struct {
+	Name string
+	Type Type
+	Dims []uint64 // innermost first
+	Data []byte   // as stored in the file, little-endian
+}
+
+// NewTensor returns an F16 or BF16 tensor holding a copy of data. The
+// dimensions default to one dimension of len(data); otherwise their product
+// must be len(data).
+func NewTensor[T floatx.Float16 | floatx.BFloat16](name string, data []T, dims ...uint64) (*Tensor, error) {
+	if dims == nil {
+		dims = []uint64{uint64(len(data))}
+	}
+	t := &Tensor{Name: name, Type: TypeF16, Dims: append([]uint64{}, dims...)}
+	if _, ok := any(data).([]floatx.BFloat16); ok {
+		t.Type = TypeBF16
+	}
+	info := TensorInfo{Dims: dims, Type: t.Type}
+	if info.Len() != uint64(len(data)) {
+		return nil, ErrShape
+	}
+	t.Data = make([]byte, 0, 2*len(data))
+	for _, v := range data {
+		t.Data = binary.LittleEndian.AppendUint16(t.Data, uint16(v))
+	}
+	return t, nil
+}
+
+// Write writes metadata and tensors to w as a version 3 GGUF file. Like
+// gguf-py, tensor data is aligned to general.alignment, or DefaultAlignment
+// if the metadata doesn't have it, and padded after the last tensor too.
+func Write(w io.Writer, metadata []KV, tensors []*Tensor) error {
+	alignment := DefaultAlignment
+	b := []byte(magic)
+	b = binary.LittleEndian.AppendUint32(b, 3)
+	b = binary.LittleEndian.AppendUint64(b, uint64(len(tensors)))
+	b = binary.LittleEndian.AppendUint64(b, uint64(len(metadata)))
+	for _, kv := range metadata {
+		if kv.Key == "general.alignment" {
+			a, ok := kv.Value.(uint32)
+			if !ok || a == 0 || a&(a-1) != 0 {
+				return fmt.Errorf("%w: general.alignment %v", ErrUnsupportedValue, kv.Value)
+			}
+			alignment = int(a)
+		}
+		b = appendString(b, kv.Key)
+		t, ok := valueType(kv.Value)
+		if !ok {
+			return fmt.Errorf("%w %T for key %q", ErrUnsupportedValue, kv.Value, kv.Key)
+		}
+		b = binary.LittleEndian.AppendUint32(b, uint32(t))
+		if b, ok = appendValue(b, kv.Value); !ok {
+			return fmt.Errorf("%w %T for key %q", ErrUnsupportedValue, kv.Value, kv.Key)
+		}
+	}
+
+	offset := int64(0)
+	for _, t := range tensors {
+		info := TensorInfo{Dims: t.Dims, Type: t.Type}
+		if n, ok := info.size(); !ok || n != uint64(len(t.Data)) {
+			return fmt.Errorf("%w %v for tensor %q with dimensions %v and %d bytes",
+				ErrUnsupportedType, t.Type, t.Name, t.Dims, len(t.Data))
+		}
+		b = appendString(b, t.Name)
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(t.Dims)))
+		for _, d := range t.Dims {
+			b = binary.LittleEndian.AppendUint64(b, d)
+		}
+		b = binary.LittleEndian.AppendUint32(b, uint32(t.Type))
+		b = binary.LittleEndian.AppendUint64(b, uint64(offset))
+		offset = align(offset+int64(len(t.Data)), alignment)
+	}
+	b = append(b, make([]byte, align(int64(len(b)), alignment)-int64(len(b)))...)
+	if _, err := w.Write(b); err != nil {
+		return err
+	}
+
+	for _, t := range tensors {
+		if _, err := w.Write(t.Data); err != nil {
+			return err
+		}
+		pad := align(int64(len(t.Data)), alignment) - int64(len(t.Data))
+		if _, err := w.Write(make([]byte, pad)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func appendString(b []byte, s string) []byte {
+	b = binary.LittleEndian.AppendUint64(b, uint64(len(s)))
+	return append(b, s...)
+}
+
+// valueType returns the GGUF value type of v.
+func valueType(v any) (ValueType, bool) {
+	switch v.(type) {
+	case uint8:
+		return ValueUint8, true
+	case int8:
+		return ValueInt8, true
+	case uint16:
+		return ValueUint16, true
+	case int16:
+		return ValueInt16, true
+	case uint32:
+		return ValueUint32, true
+	case int32:
+		return ValueInt32, true
+	case float32:
+		return ValueFloat32, true
+	case bool:
+		return ValueBool, true
+	case string:
+		return ValueString, true
+	case uint64:
+		return ValueUint64, true
+	case int64:
+		return ValueInt64, true
+	case float64:
+		return ValueFloat64, true
+	case []uint8, []int8, []uint16, []int16, []uint32, []int32, []float32,
+		[]bool, []string, []uint64, []int64, []float64, []any:
+		return ValueArray, true
+	}
+	return 0, false
+}
+
+// appendValue appends the encoding of v, which has a valueType.
+func appendValue(b []byte, v any) ([]byte, bool) {
+	switch v := v.(type) {
+	case uint8:
+		return append(b, v), true
+	case int8:
+		return append(b, uint8(v)), true
+	case uint16:
+		return binary.LittleEndian.AppendUint16(b, v), true
+	case int16:
+		return binary.LittleEndian.AppendUint16(b, uint16(v)), true
+	case uint32:
+		return binary.LittleEndian.AppendUint32(b, v), true
+	case int32:
+		return binary.LittleEndian.AppendUint32(b, uint32(v)), true
+	case float32:
+		return binary.LittleEndian.AppendUint32(b, math.Float32bits(v)), true
+	case bool:
+		if v {
+			return append(b, 1), true
+		}
+		return append(b, 0), true
+	case string:
+		return appendString(b, v), true
+	case uint64:
+		return binary.LittleEndian.AppendUint64(b, v), true
+	case int64:
+		return binary.LittleEndian.AppendUint64(b, uint64(v)), true
+	case float64:
+		return binary.LittleEndian.AppendUint64(b, math.Float64bits(v)), true
+	case []uint8:
+		return appendArray(b, ValueUint8, v)
+	case []int8:
+		return appendArray(b, ValueInt8, v)
+	case []uint16:
+		return appendArray(b, ValueUint16, v)
+	case []int16:
+		return appendArray(b, ValueInt16, v)
+	case []uint32:
+		return appendArray(b, ValueUint32, v)
+	case []int32:
+		return appendArray(b, ValueInt32, v)
+	case []float32:
+		return appendArray(b, ValueFloat32, v)
+	case []bool:
+		return appendArray(b, ValueBool, v)
+	case []string:
+		return appendArray(b, ValueString, v)
+	case []uint64:
+		return appendArray(b, ValueUint64, v)
+	case []int64:
+		return appendArray(b, ValueInt64, v)
+	case []float64:
+		return appendArray(b, ValueFloat64, v)
+	case []any:
+		// every element must be an array
+		for _, e := range v {
+			if t, _ := valueType(e); t != ValueArray {
+				return b, false
+			}
+		}
+		return appendArray(b, ValueArray, v)
+	}
+	return b, false
+}
+
+func appendArray[T any](b []byte, t ValueType, s []T) ([]byte, bool) {
+	b = binary.LittleEndian.AppendUint32(b, uint32(t))
+	b = binary.LittleEndian.AppendUint64(b, uint64(len(s)))
+	ok := true
+	for _, v := range s {
+		if b, ok = appendValue(b, v); !ok {
+			return b, false
+		}
+	}
+	return b, true
+}
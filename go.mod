@@ -1,3 +1,3 @@
 module github.com/chenxingqiang/go-floatx
 
-go 1.11
+go 1.21
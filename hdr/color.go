@@ -0,0 +1,140 @@
+// Package hdr provides image/color models and an image type with Float16
+// channels, so image/draw can composite high dynamic range images.
+//
+// Samples are linear, with 0 to 1 the range of the 16-bit colors of
+// image/color. They may be negative or greater than 1, and are only
+// clamped when converted to 16-bit colors.
+package hdr
+
+import (
+	"image/color"
+	"math"
+
+	floatx "github.com/chenxingqiang/go-floatx"
+)
+
+// RGBAF16 is an alpha-premultiplied color with Float16 channels.
+type RGBAF16 struct {
+	R, G, B, A floatx.Float16
+}
+
+// RGBA returns the color clamped to [0, 1], with R, G and B at most A, as
+// in color.Color.
+func (c RGBAF16) RGBA() (r, g, b, a uint32) {
+	a = unit(c.A.Float32())
+	return min(unit(c.R.Float32()), a), min(unit(c.G.Float32()), a), min(unit(c.B.Float32()), a), a
+}
+
+// NRGBAF16 is a non-alpha-premultiplied color with Float16 channels.
+type NRGBAF16 struct {
+	R, G, B, A floatx.Float16
+}
+
+// RGBA returns the color clamped to [0, 1] and premultiplied, as in
+// color.Color.
+func (c NRGBAF16) RGBA() (r, g, b, a uint32) {
+	af := clamp(c.A.Float32())
+	return unit(clamp(c.R.Float32()) * af), unit(clamp(c.G.Float32()) * af), unit(clamp(c.B.Float32()) * af), unit(af)
+}
+
+// Models for the Float16 colors. Converting between RGBAF16 and NRGBAF16
+// keeps values outside [0, 1]; other colors are converted from the 16-bit
+// values of their RGBA method.
+var (
+	RGBAF16Model  color.Model = color.ModelFunc(rgbaF16Model)
+	NRGBAF16Model color.Model = color.ModelFunc(nrgbaF16Model)
+)
+
+func rgbaF16Model(c color.Color) color.Color {
+	switch c := c.(type) {
+	case RGBAF16:
+		return c
+	case NRGBAF16:
+		a := c.A.Float32()
+		return RGBAF16{mul(c.R, a), mul(c.G, a), mul(c.B, a), c.A}
+	}
+	r, g, b, a := c.RGBA()
+	return RGBAF16{f16(r), f16(g), f16(b), f16(a)}
+}
+
+func nrgbaF16Model(c color.Color) color.Color {
+	switch c := c.(type) {
+	case NRGBAF16:
+		return c
+	case RGBAF16:
+		a := c.A.Float32()
+		if a == 0 {
+			return NRGBAF16{}
+		}
+		return NRGBAF16{mul(c.R, 1/a), mul(c.G, 1/a), mul(c.B, 1/a), c.A}
+	}
+	r, g, b, a := c.RGBA()
+	if a == 0 {
+		return NRGBAF16{}
+	}
+	af := float32(a)
+	return NRGBAF16{
+		floatx.F16Fromfloat32(float32(r) / af),
+		floatx.F16Fromfloat32(float32(g) / af),
+		floatx.F16Fromfloat32(float32(b) / af),
+		f16(a),
+	}
+}
+
+// mul returns v*x rounded to a Float16.
+func mul(v floatx.Float16, x float32) floatx.Float16 {
+	return floatx.F16Fromfloat32(v.Float32() * x)
+}
+
+// f16 returns the 16-bit sample v scaled to [0, 1].
+func f16(v uint32) floatx.Float16 {
+	return floatx.F16Fromfloat32(float32(v) / 0xffff)
+}
+
+// clamp returns v clamped to [0, 1], and 0 for NaN.
+func clamp(v float32) float32 {
+	switch {
+	case v >= 1:
+		return 1
+	case v > 0:
+		return v
+	}
+	return 0
+}
+
+// unit returns v clamped to [0, 1] and scaled to a 16-bit sample.
+func unit(v float32) uint32 {
+	return uint32(clamp(v)*0xffff + 0.5)
+}
+
+// Transfer is the transfer function of 16-bit samples converted to or
+// from linear Float16 samples.
+type Transfer int
+
+// Transfer functions.
+const (
+	Linear Transfer = iota // the 16-bit samples are linear
+	SRGB                   // the 16-bit samples are sRGB encoded
+)
+
+// decode returns the linear value of the sample v in [0, 1].
+func (t Transfer) decode(v float64) float64 {
+	if t != SRGB {
+		return v
+	}
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+// encode returns the encoded sample of the linear value v in [0, 1].
+func (t Transfer) encode(v float64) float64 {
+	if t != SRGB {
+		return v
+	}
+	if v <= 0.0031308 {
+		return v * 12.92
+	}
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
@@ -0,0 +1,173 @@
+package hdr_test
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"testing"
+
+	floatx "github.com/chenxingqiang/go-floatx"
+	"github.com/chenxingqiang/go-floatx/hdr"
+)
+
+func h(f float32) floatx.Float16 { return floatx.F16Fromfloat32(f) }
+
+func rgba(c color.Color) [4]uint32 {
+	r, g, b, a := c.RGBA()
+	return [4]uint32{r, g, b, a}
+}
+
+func TestRGBA(t *testing.T) {
+	nan := floatx.F16NaN()
+	testCases := []struct {
+		c    color.Color
+		want [4]uint32
+	}{
+		{hdr.RGBAF16{h(0.25), h(0.5), h(1), h(1)}, [4]uint32{0x4000, 0x8000, 0xffff, 0xffff}},
+		// clamped to [0, alpha]
+		{hdr.RGBAF16{h(2), h(-1), nan, h(0.5)}, [4]uint32{0x8000, 0, 0, 0x8000}},
+		{hdr.RGBAF16{h(1), h(1), h(1), h(3)}, [4]uint32{0xffff, 0xffff, 0xffff, 0xffff}},
+		{hdr.NRGBAF16{h(1), h(0.5), h(2), h(0.5)}, [4]uint32{0x8000, 0x4000, 0x8000, 0x8000}},
+		{hdr.NRGBAF16{h(1), h(-1), nan, h(0)}, [4]uint32{0, 0, 0, 0}},
+		{hdr.NRGBAF16{h(1), h(1), h(1), nan}, [4]uint32{0, 0, 0, 0}},
+	}
+	for _, tc := range testCases {
+		if got := rgba(tc.c); got != tc.want {
+			t.Errorf("%v.RGBA() = %#x, want %#x", tc.c, got, tc.want)
+		}
+	}
+}
+
+func TestModels(t *testing.T) {
+	testCases := []struct {
+		m    color.Model
+		c    color.Color
+		want color.Color
+	}{
+		{hdr.RGBAF16Model, hdr.RGBAF16{h(4), h(-1), h(0.5), h(0.5)}, hdr.RGBAF16{h(4), h(-1), h(0.5), h(0.5)}},
+		{hdr.RGBAF16Model, hdr.NRGBAF16{h(8), h(-1), h(0.5), h(0.5)}, hdr.RGBAF16{h(4), h(-0.5), h(0.25), h(0.5)}},
+		{hdr.RGBAF16Model, color.RGBA64{0xffff, 0, 0x8000, 0xffff}, hdr.RGBAF16{h(1), h(0), h(0x8000 / 65535.0), h(1)}},
+		{hdr.RGBAF16Model, color.NRGBA{0xff, 0, 0, 0}, hdr.RGBAF16{}},
+		{hdr.NRGBAF16Model, hdr.NRGBAF16{h(8), h(-1), h(0.5), h(0.5)}, hdr.NRGBAF16{h(8), h(-1), h(0.5), h(0.5)}},
+		{hdr.NRGBAF16Model, hdr.RGBAF16{h(4), h(-0.5), h(0.25), h(0.5)}, hdr.NRGBAF16{h(8), h(-1), h(0.5), h(0.5)}},
+		{hdr.NRGBAF16Model, hdr.RGBAF16{h(4), h(1), h(1), h(0)}, hdr.NRGBAF16{}},
+		{hdr.NRGBAF16Model, color.RGBA64{0x4000, 0, 0x8000, 0x8000}, hdr.NRGBAF16{h(0.5), h(0), h(1), h(0x8000 / 65535.0)}},
+		{hdr.NRGBAF16Model, color.Transparent, hdr.NRGBAF16{}},
+	}
+	for _, tc := range testCases {
+		if got := tc.m.Convert(tc.c); got != tc.want {
+			t.Errorf("Convert(%v) = %v, want %v", tc.c, got, tc.want)
+		}
+	}
+}
+
+func TestImage(t *testing.T) {
+	m := hdr.NewRGBAF16Image(image.Rect(-1, -2, 3, 2))
+	if m.ColorModel() != hdr.RGBAF16Model || m.Bounds() != image.Rect(-1, -2, 3, 2) || len(m.Pix) != 64 || m.Stride != 16 {
+		t.Fatalf("NewRGBAF16Image returned %v", m.Bounds())
+	}
+	c := hdr.RGBAF16{h(3), h(0.5), h(0), h(1)}
+	m.Set(2, 1, c)
+	m.Set(3, 1, c) // outside
+	if got := m.At(2, 1); got != c {
+		t.Errorf("At(2, 1) = %v, want %v", got, c)
+	}
+	if got := m.At(3, 1); got != (hdr.RGBAF16{}) {
+		t.Errorf("At(3, 1) = %v, want transparent", got)
+	}
+	if got, want := m.RGBA64At(2, 1), (color.RGBA64{0xffff, 0x8000, 0, 0xffff}); got != want {
+		t.Errorf("RGBA64At(2, 1) = %v, want %v", got, want)
+	}
+	if i := m.PixOffset(2, 1); m.Pix[i] != h(3) || i != 3*16+3*4 {
+		t.Errorf("PixOffset(2, 1) = %d", i)
+	}
+	m.SetRGBA64(-1, -2, color.RGBA64{0, 0xffff, 0, 0xffff})
+	if got, want := m.RGBAF16At(-1, -2), (hdr.RGBAF16{h(0), h(1), h(0), h(1)}); got != want {
+		t.Errorf("RGBAF16At(-1, -2) = %v, want %v", got, want)
+	}
+
+	sub := m.SubImage(image.Rect(2, 1, 10, 10)).(*hdr.RGBAF16Image)
+	if sub.Bounds() != image.Rect(2, 1, 3, 2) || sub.At(2, 1) != c {
+		t.Errorf("SubImage has bounds %v and %v at (2, 1)", sub.Bounds(), sub.At(2, 1))
+	}
+	if !sub.Opaque() || m.Opaque() {
+		t.Errorf("Opaque() = %v, %v, want true, false", sub.Opaque(), m.Opaque())
+	}
+	sub.SetRGBAF16(2, 1, hdr.RGBAF16{})
+	if m.At(2, 1) != (hdr.RGBAF16{}) {
+		t.Error("SubImage doesn't share pixels")
+	}
+	if empty := m.SubImage(image.Rect(5, 5, 6, 6)); !empty.Bounds().Empty() || !empty.(*hdr.RGBAF16Image).Opaque() {
+		t.Errorf("SubImage outside the bounds = %v", empty.Bounds())
+	}
+}
+
+func TestDraw(t *testing.T) {
+	// image/draw works through the image.RGBA64Image and draw.RGBA64Image
+	// interfaces
+	var _ draw.RGBA64Image = &hdr.RGBAF16Image{}
+	m := hdr.NewRGBAF16Image(image.Rect(0, 0, 4, 4))
+	draw.Draw(m, m.Bounds(), image.NewUniform(color.RGBA64{0, 0, 0xffff, 0xffff}), image.Point{}, draw.Src)
+	draw.Draw(m, image.Rect(0, 0, 2, 4), image.NewUniform(color.RGBA64{0x8000, 0, 0, 0x8000}), image.Point{}, draw.Over)
+	if got, want := m.RGBAF16At(1, 3), (hdr.RGBAF16{h(0x8000 / 65535.0), h(0), h(0x7fff / 65535.0), h(1)}); got != want {
+		t.Errorf("after Over, (1, 3) = %v, want %v", got, want)
+	}
+	dst := image.NewRGBA64(m.Bounds())
+	draw.Draw(dst, dst.Bounds(), m, image.Point{}, draw.Src)
+	if got, want := dst.RGBA64At(3, 0), (color.RGBA64{0, 0, 0xffff, 0xffff}); got != want {
+		t.Errorf("drawn from the image, (3, 0) = %v, want %v", got, want)
+	}
+}
+
+func TestRGBA64(t *testing.T) {
+	src := image.NewRGBA64(image.Rect(0, 0, 256, 3))
+	for x := 0; x < 256; x++ {
+		v := uint16(x * 0x101)
+		src.SetRGBA64(x, 0, color.RGBA64{v, v, v, 0xffff})
+		src.SetRGBA64(x, 1, color.RGBA64{v / 2, 0, v / 4, 0x8000})
+		src.SetRGBA64(x, 2, color.RGBA64{v, v, v, v})
+	}
+	for _, tr := range []hdr.Transfer{hdr.Linear, hdr.SRGB} {
+		m := hdr.FromRGBA64(src, tr)
+		if m.Bounds() != src.Bounds() {
+			t.Fatalf("FromRGBA64 bounds %v", m.Bounds())
+		}
+		got := m.ToRGBA64(tr)
+		for x := 0; x < 256; x++ {
+			for y := 0; y < 3; y++ {
+				want := src.RGBA64At(x, y)
+				g := got.RGBA64At(x, y)
+				for i, d := range []int{int(g.R) - int(want.R), int(g.G) - int(want.G), int(g.B) - int(want.B), int(g.A) - int(want.A)} {
+					if d < -16 || d > 16 {
+						t.Fatalf("transfer %d: (%d, %d) channel %d round trips to %v, want %v", tr, x, y, i, g, want)
+					}
+				}
+			}
+		}
+	}
+
+	// sRGB mid gray is about 0.214 linear, and the transfer function
+	// applies to the unpremultiplied color
+	m := hdr.FromRGBA64(src, hdr.SRGB)
+	if r := m.RGBAF16At(0x80, 0).R.Float32(); math.Abs(float64(r)-0.2158605) > 1e-3 {
+		t.Errorf("linear value of sRGB 0x8080 is %v, want 0.2158605", r)
+	}
+	if r := m.RGBAF16At(0x80, 1).R.Float32(); math.Abs(float64(r)-0.2158605*0x8000/0xffff) > 1e-3 {
+		t.Errorf("linear value of half transparent sRGB 0x8080 is %v, want 0.1079", r)
+	}
+	if r := hdr.FromRGBA64(src, hdr.Linear).RGBAF16At(0x80, 0).R.Float32(); r != h(0x8080/65535.0).Float32() {
+		t.Errorf("linear value of linear 0x8080 is %v", r)
+	}
+
+	// out of range values are clamped
+	m = hdr.NewRGBAF16Image(image.Rect(0, 0, 2, 1))
+	m.SetRGBAF16(0, 0, hdr.RGBAF16{h(4), h(-1), floatx.F16NaN(), h(0.5)})
+	m.SetRGBAF16(1, 0, hdr.RGBAF16{h(4), h(4), h(4), h(-1)})
+	if got, want := m.ToRGBA64(hdr.SRGB).RGBA64At(0, 0), (color.RGBA64{0x8000, 0, 0, 0x8000}); got != want {
+		t.Errorf("ToRGBA64 of an HDR color = %v, want %v", got, want)
+	}
+	if got := m.ToRGBA64(hdr.Linear).RGBA64At(1, 0); got != (color.RGBA64{}) {
+		t.Errorf("ToRGBA64 of a negative alpha = %v, want transparent", got)
+	}
+}
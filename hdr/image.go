@@ -0,0 +1,154 @@
+package hdr
+
+import (
+	"image"
+	"image/color"
+
+	floatx "github.com/chenxingqiang/go-floatx"
+)
+
+// RGBAF16Image is an in-memory image of RGBAF16 colors. It's an
+// image.RGBA64Image and a draw.Image, so the image/draw functions work on
+// it, though they clamp the colors they compute to [0, 1].
+type RGBAF16Image struct {
+	// Pix holds the R, G, B and A samples of the pixels in row-major
+	// order. The pixel at (x, y) starts at Pix[(y-Rect.Min.Y)*Stride +
+	// (x-Rect.Min.X)*4].
+	Pix []floatx.Float16
+	// Stride is the Pix distance between vertically adjacent pixels.
+	Stride int
+	// Rect is the image's bounds.
+	Rect image.Rectangle
+}
+
+// NewRGBAF16Image returns a new transparent RGBAF16Image with the given
+// bounds.
+func NewRGBAF16Image(r image.Rectangle) *RGBAF16Image {
+	return &RGBAF16Image{
+		Pix:    make([]floatx.Float16, 4*r.Dx()*r.Dy()),
+		Stride: 4 * r.Dx(),
+		Rect:   r,
+	}
+}
+
+// ColorModel returns RGBAF16Model.
+func (m *RGBAF16Image) ColorModel() color.Model { return RGBAF16Model }
+
+// Bounds returns m.Rect.
+func (m *RGBAF16Image) Bounds() image.Rectangle { return m.Rect }
+
+// At returns the RGBAF16 color of the pixel at (x, y).
+func (m *RGBAF16Image) At(x, y int) color.Color {
+	return m.RGBAF16At(x, y)
+}
+
+// RGBA64At returns the color of the pixel at (x, y) clamped to [0, 1].
+func (m *RGBAF16Image) RGBA64At(x, y int) color.RGBA64 {
+	r, g, b, a := m.RGBAF16At(x, y).RGBA()
+	return color.RGBA64{uint16(r), uint16(g), uint16(b), uint16(a)}
+}
+
+// RGBAF16At returns the color of the pixel at (x, y), or a transparent
+// color if (x, y) is outside the bounds.
+func (m *RGBAF16Image) RGBAF16At(x, y int) RGBAF16 {
+	if !(image.Point{x, y}.In(m.Rect)) {
+		return RGBAF16{}
+	}
+	p := m.Pix[m.PixOffset(x, y):]
+	return RGBAF16{p[0], p[1], p[2], p[3]}
+}
+
+// PixOffset returns the index of the first sample of the pixel at (x, y)
+// in Pix.
+func (m *RGBAF16Image) PixOffset(x, y int) int {
+	return (y-m.Rect.Min.Y)*m.Stride + (x-m.Rect.Min.X)*4
+}
+
+// Set sets the pixel at (x, y) to c converted by RGBAF16Model.
+func (m *RGBAF16Image) Set(x, y int, c color.Color) {
+	m.SetRGBAF16(x, y, RGBAF16Model.Convert(c).(RGBAF16))
+}
+
+// SetRGBA64 sets the pixel at (x, y) to c.
+func (m *RGBAF16Image) SetRGBA64(x, y int, c color.RGBA64) {
+	m.SetRGBAF16(x, y, RGBAF16{f16(uint32(c.R)), f16(uint32(c.G)), f16(uint32(c.B)), f16(uint32(c.A))})
+}
+
+// SetRGBAF16 sets the pixel at (x, y) to c. It does nothing if (x, y) is
+// outside the bounds.
+func (m *RGBAF16Image) SetRGBAF16(x, y int, c RGBAF16) {
+	if !(image.Point{x, y}.In(m.Rect)) {
+		return
+	}
+	p := m.Pix[m.PixOffset(x, y):]
+	p[0], p[1], p[2], p[3] = c.R, c.G, c.B, c.A
+}
+
+// SubImage returns the part of m visible through r, sharing its pixels.
+func (m *RGBAF16Image) SubImage(r image.Rectangle) image.Image {
+	r = r.Intersect(m.Rect)
+	if r.Empty() {
+		return &RGBAF16Image{}
+	}
+	return &RGBAF16Image{
+		Pix:    m.Pix[m.PixOffset(r.Min.X, r.Min.Y):],
+		Stride: m.Stride,
+		Rect:   r,
+	}
+}
+
+// Opaque reports whether every pixel of m has an alpha of at least 1.
+func (m *RGBAF16Image) Opaque() bool {
+	for y := m.Rect.Min.Y; y < m.Rect.Max.Y; y++ {
+		i := m.PixOffset(m.Rect.Min.X, y)
+		for x := 0; x < m.Rect.Dx(); x++ {
+			if !(m.Pix[i+4*x+3].Float32() >= 1) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// FromRGBA64 returns src converted to an RGBAF16Image with the same bounds,
+// decoding its color samples with the transfer function t.
+func FromRGBA64(src *image.RGBA64, t Transfer) *RGBAF16Image {
+	r := src.Bounds()
+	m := NewRGBAF16Image(r)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			c := src.RGBA64At(x, y)
+			if c.A == 0 {
+				continue
+			}
+			// the transfer function applies to the unpremultiplied color
+			a := float64(c.A) / 0xffff
+			v := func(s uint16) floatx.Float16 {
+				return floatx.F16Fromfloat32(float32(t.decode(min(float64(s)/float64(c.A), 1)) * a))
+			}
+			m.SetRGBAF16(x, y, RGBAF16{v(c.R), v(c.G), v(c.B), f16(uint32(c.A))})
+		}
+	}
+	return m
+}
+
+// ToRGBA64 returns m converted to an image.RGBA64 with the same bounds,
+// clamping its colors to [0, 1] and encoding them with the transfer
+// function t.
+func (m *RGBAF16Image) ToRGBA64(t Transfer) *image.RGBA64 {
+	dst := image.NewRGBA64(m.Rect)
+	for y := m.Rect.Min.Y; y < m.Rect.Max.Y; y++ {
+		for x := m.Rect.Min.X; x < m.Rect.Max.X; x++ {
+			c := m.RGBAF16At(x, y)
+			a := clamp(c.A.Float32())
+			if a == 0 {
+				continue
+			}
+			v := func(s floatx.Float16) uint16 {
+				return uint16(unit(float32(t.encode(float64(min(clamp(s.Float32()), a)/a)) * float64(a))))
+			}
+			dst.SetRGBA64(x, y, color.RGBA64{v(c.R), v(c.G), v(c.B), uint16(unit(a))})
+		}
+	}
+	return dst
+}
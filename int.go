@@ -0,0 +1,171 @@
+package floatx
+
+import (
+	"math"
+	"math/bits"
+)
+
+// FromInt64 returns the T nearest to i, using IEEE default rounding
+// (nearest int, with ties to even). Magnitudes beyond the largest finite
+// value round to ±Inf like conversions from float32.
+func FromInt64[T SmallFloat](i int64) T {
+	if i < 0 {
+		// -i overflows for math.MinInt64, but uint64 of it is still 1<<63
+		return Neg(FromUint64[T](uint64(-i)))
+	}
+	return FromUint64[T](uint64(i))
+}
+
+// FromUint64 returns the T nearest to u, using IEEE default rounding
+// (nearest int, with ties to even).
+func FromUint64[T SmallFloat](u uint64) T {
+	// float64(u) rounds to nearest beyond 53 bits. Round to odd instead,
+	// which FromFloat64 keeps as its sticky bit, so u is rounded once.
+	if shift := bits.Len64(u) - 53; shift > 0 && u&(1<<shift-1) != 0 {
+		u = u&^(1<<shift-1) | 1<<shift
+	}
+	return FromFloat64[T](float64(u))
+}
+
+// FromInt8 returns the T nearest to i, see FromInt64.
+func FromInt8[T SmallFloat](i int8) T { return FromInt64[T](int64(i)) }
+
+// FromInt16 returns the T nearest to i, see FromInt64.
+func FromInt16[T SmallFloat](i int16) T { return FromInt64[T](int64(i)) }
+
+// FromInt32 returns the T nearest to i, see FromInt64.
+func FromInt32[T SmallFloat](i int32) T { return FromInt64[T](int64(i)) }
+
+// FromUint8 returns the T nearest to u, see FromUint64.
+func FromUint8[T SmallFloat](u uint8) T { return FromUint64[T](uint64(u)) }
+
+// FromUint16 returns the T nearest to u, see FromUint64.
+func FromUint16[T SmallFloat](u uint16) T { return FromUint64[T](uint64(u)) }
+
+// FromUint32 returns the T nearest to u, see FromUint64.
+func FromUint32[T SmallFloat](u uint32) T { return FromUint64[T](uint64(u)) }
+
+// toInt returns f truncated toward zero and clamped to [lo, hi], and
+// whether f was in range. NaN returns 0, false.
+func toInt[T SmallFloat](f T, lo, hi int64) (int64, bool) {
+	if f.IsNaN() {
+		return 0, false
+	}
+	// float64(hi)+1 is 1<<63 for MaxInt64, which is outside the range
+	v := float64(Trunc(f).Float32())
+	switch {
+	case v < float64(lo):
+		return lo, false
+	case v >= float64(hi)+1:
+		return hi, false
+	}
+	return int64(v), true
+}
+
+// toUint is toInt for unsigned ranges [0, hi].
+func toUint[T SmallFloat](f T, hi uint64) (uint64, bool) {
+	if f.IsNaN() {
+		return 0, false
+	}
+	// float64(hi)+1 is 1<<64 for MaxUint64, which is outside the range
+	v := float64(Trunc(f).Float32())
+	switch {
+	case v < 0:
+		return 0, false
+	case v >= float64(hi)+1:
+		return hi, false
+	}
+	return uint64(v), true
+}
+
+// ToInt64 returns f truncated toward zero, like Go's conversion int64(x).
+// For NaN, ±Inf and values outside the range of int64, where Go's
+// conversion is implementation-defined, ok is false and the result is
+// clamped to the range (0 for NaN), so ignoring ok gives a saturating
+// conversion.
+func ToInt64[T SmallFloat](f T) (i int64, ok bool) {
+	return toInt(f, math.MinInt64, math.MaxInt64)
+}
+
+// ToInt8 returns f truncated toward zero, see ToInt64.
+func ToInt8[T SmallFloat](f T) (i int8, ok bool) {
+	i64, ok := toInt(f, math.MinInt8, math.MaxInt8)
+	return int8(i64), ok
+}
+
+// ToInt16 returns f truncated toward zero, see ToInt64.
+func ToInt16[T SmallFloat](f T) (i int16, ok bool) {
+	i64, ok := toInt(f, math.MinInt16, math.MaxInt16)
+	return int16(i64), ok
+}
+
+// ToInt32 returns f truncated toward zero, see ToInt64.
+func ToInt32[T SmallFloat](f T) (i int32, ok bool) {
+	i64, ok := toInt(f, math.MinInt32, math.MaxInt32)
+	return int32(i64), ok
+}
+
+// ToUint64 returns f truncated toward zero, like Go's conversion uint64(x).
+// Values in (-1, 0] truncate to zero and are in range. For NaN, ±Inf and
+// other values outside the range of uint64, ok is false and the result is
+// clamped to the range (0 for NaN and negative values).
+func ToUint64[T SmallFloat](f T) (u uint64, ok bool) {
+	return toUint(f, math.MaxUint64)
+}
+
+// ToUint8 returns f truncated toward zero, see ToUint64.
+func ToUint8[T SmallFloat](f T) (u uint8, ok bool) {
+	u64, ok := toUint(f, math.MaxUint8)
+	return uint8(u64), ok
+}
+
+// ToUint16 returns f truncated toward zero, see ToUint64.
+func ToUint16[T SmallFloat](f T) (u uint16, ok bool) {
+	u64, ok := toUint(f, math.MaxUint16)
+	return uint16(u64), ok
+}
+
+// ToUint32 returns f truncated toward zero, see ToUint64.
+func ToUint32[T SmallFloat](f T) (u uint32, ok bool) {
+	u64, ok := toUint(f, math.MaxUint32)
+	return uint32(u64), ok
+}
+
+// ToInt32 returns ToInt32(f).
+func (f Float16) ToInt32() (i int32, ok bool) { return ToInt32(f) }
+
+// ToInt64 returns ToInt64(f).
+func (f Float16) ToInt64() (i int64, ok bool) { return ToInt64(f) }
+
+// ToInt8Sat returns f truncated toward zero and saturated to [-128, 127].
+// NaN returns 0.
+func (f Float16) ToInt8Sat() int8 {
+	i, _ := ToInt8(f)
+	return i
+}
+
+// ToInt32 returns ToInt32(f).
+func (f BFloat16) ToInt32() (i int32, ok bool) { return ToInt32(f) }
+
+// ToInt64 returns ToInt64(f).
+func (f BFloat16) ToInt64() (i int64, ok bool) { return ToInt64(f) }
+
+// ToInt8Sat returns f truncated toward zero and saturated to [-128, 127],
+// see Float16.ToInt8Sat.
+func (f BFloat16) ToInt8Sat() int8 {
+	i, _ := ToInt8(f)
+	return i
+}
+
+// ToInt32 returns ToInt32(f).
+func (f Float8) ToInt32() (i int32, ok bool) { return ToInt32(f) }
+
+// ToInt64 returns ToInt64(f).
+func (f Float8) ToInt64() (i int64, ok bool) { return ToInt64(f) }
+
+// ToInt8Sat returns f truncated toward zero and saturated to [-128, 127],
+// see Float16.ToInt8Sat.
+func (f Float8) ToInt8Sat() int8 {
+	i, _ := ToInt8(f)
+	return i
+}
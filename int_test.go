@@ -0,0 +1,237 @@
+package floatx_test
+
+import (
+	"math"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	floatx "github.com/chenxingqiang/go-floatx"
+)
+
+// nearest returns the T nearest to i with ties to even, by comparing the
+// exact distance from i to the candidates around guess. Infinity counts as
+// the next power of 2 past the largest finite value.
+func nearest[T floatx.SmallFloat](i *big.Int, guess T, maxExp int) T {
+	bi := new(big.Float).SetPrec(200).SetInt(i)
+
+	best, bestDist := guess, (*big.Float)(nil)
+	for _, c := range []T{floatx.NextDown(guess), guess, floatx.NextUp(guess)} {
+		v := new(big.Float).SetPrec(200).SetFloat64(float64(c.Float32()))
+		if c.IsInf(0) {
+			v.SetMantExp(big.NewFloat(0.5), maxExp+1)
+			if c.Signbit() {
+				v.Neg(v)
+			}
+		}
+		d := new(big.Float).Sub(bi, v)
+		d.Abs(d)
+
+		if bestDist == nil {
+			best, bestDist = c, d
+			continue
+		}
+		switch d.Cmp(bestDist) {
+		case -1:
+			best, bestDist = c, d
+		case 0:
+			if uint16(c)&1 == 0 {
+				best = c
+			}
+		}
+	}
+	return best
+}
+
+// intTestInputs returns all int16 values, powers of 2 with offsets around
+// them, and random values of every magnitude.
+func intTestInputs() []int64 {
+	var s []int64
+	for i := math.MinInt16; i <= math.MaxInt16; i++ {
+		s = append(s, int64(i))
+	}
+	for e := 16; e < 63; e++ {
+		for d := int64(-4); d <= 4; d++ {
+			p := int64(1) << e
+			s = append(s, p+d, -p+d, p+p>>1+d, p+p>>4+d, p+p>>8+d, p+p>>12+d)
+		}
+	}
+	s = append(s, math.MaxInt64, math.MinInt64, math.MinInt64+1)
+
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100000; i++ {
+		s = append(s, r.Int63()>>r.Intn(63), -(r.Int63() >> r.Intn(63)))
+	}
+	return s
+}
+
+func checkFromInt[T floatx.SmallFloat](t *testing.T, name string, maxExp int) {
+	t.Helper()
+
+	for _, i := range intTestInputs() {
+		got := floatx.FromInt64[T](i)
+		// float32(i) rounds once, so the nearest T is at most one step away
+		if want := nearest(big.NewInt(i), floatx.FromFloat32[T](float32(i)), maxExp); got != want {
+			t.Errorf("FromInt64[%s](%d) = %v (0x%04x), wanted %v (0x%04x)", name, i, got, uint16(got), want, uint16(want))
+		}
+
+		if i >= 0 {
+			if got := floatx.FromUint64[T](uint64(i)); got != floatx.FromInt64[T](i) {
+				t.Errorf("FromUint64[%s](%d) = %v, wanted %v", name, i, got, floatx.FromInt64[T](i))
+			}
+		}
+	}
+
+	for _, u := range []uint64{math.MaxUint64, 1 << 63, 1<<63 + 1, 3 << 62} {
+		f := new(big.Float).SetUint64(u)
+		f32, _ := f.Float32()
+		if got, want := floatx.FromUint64[T](u), nearest(new(big.Int).SetUint64(u), floatx.FromFloat32[T](f32), maxExp); got != want {
+			t.Errorf("FromUint64[%s](%d) = %v, wanted %v", name, u, got, want)
+		}
+	}
+}
+
+func TestFromInt(t *testing.T) {
+	checkFromInt[floatx.Float16](t, "Float16", floatx.F16MaxExp)
+	checkFromInt[floatx.BFloat16](t, "BFloat16", floatx.BF16MaxExp)
+	checkFromInt[floatx.Float8](t, "Float8", floatx.F8MaxExp)
+
+	if got := floatx.FromInt8[floatx.Float16](-128); got.Float32() != -128 {
+		t.Errorf("FromInt8(-128) = %v", got)
+	}
+	if got := floatx.FromInt16[floatx.Float16](-2049); got.Float32() != -2048 {
+		t.Errorf("FromInt16(-2049) = %v, wanted -2048", got)
+	}
+	if got := floatx.FromInt32[floatx.Float16](65520); !got.IsInf(1) {
+		t.Errorf("FromInt32(65520) = %v, wanted +Inf", got)
+	}
+	if got := floatx.FromUint8[floatx.Float8](255); got.Float32() != 256 {
+		t.Errorf("FromUint8[Float8](255) = %v, wanted 256", got)
+	}
+	if got := floatx.FromUint16[floatx.BFloat16](257); got.Float32() != 256 {
+		t.Errorf("FromUint16[BFloat16](257) = %v, wanted 256", got)
+	}
+	if got := floatx.FromUint32[floatx.BFloat16](259); got.Float32() != 260 {
+		t.Errorf("FromUint32[BFloat16](259) = %v, wanted 260", got)
+	}
+}
+
+// wantInt returns x truncated and clamped to [lo, hi], and whether it was in
+// range, using float64 arithmetic.
+func wantInt(x float64, lo, hi int64) (int64, bool) {
+	x = math.Trunc(x)
+	switch {
+	case math.IsNaN(x):
+		return 0, false
+	case x < float64(lo):
+		return lo, false
+	case x >= -float64(lo):
+		// hi is -lo-1, and -lo is exact
+		return hi, false
+	}
+	return int64(x), true
+}
+
+func TestToInt(t *testing.T) {
+	type conv struct {
+		i32  func() (int32, bool)
+		i64  func() (int64, bool)
+		sat8 func() int8
+		x    float64
+	}
+	var convs []conv
+	for i := 0; i < 65536; i++ {
+		f16, bf16 := floatx.Float16(i), floatx.BFloat16(i)
+		convs = append(convs,
+			conv{f16.ToInt32, f16.ToInt64, f16.ToInt8Sat, float64(f16.Float32())},
+			conv{bf16.ToInt32, bf16.ToInt64, bf16.ToInt8Sat, float64(bf16.Float32())})
+	}
+	for i := 0; i < 256; i++ {
+		f8 := floatx.Float8(i)
+		convs = append(convs, conv{f8.ToInt32, f8.ToInt64, f8.ToInt8Sat, float64(f8.Float32())})
+	}
+
+	for _, c := range convs {
+		i32, ok := c.i32()
+		if w, wok := wantInt(c.x, math.MinInt32, math.MaxInt32); int64(i32) != w || ok != wok {
+			t.Errorf("ToInt32(%v) = %d, %v, wanted %d, %v", c.x, i32, ok, w, wok)
+		}
+		i64, ok := c.i64()
+		if w, wok := wantInt(c.x, math.MinInt64, math.MaxInt64); i64 != w || ok != wok {
+			t.Errorf("ToInt64(%v) = %d, %v, wanted %d, %v", c.x, i64, ok, w, wok)
+		}
+		if w, _ := wantInt(c.x, math.MinInt8, math.MaxInt8); int64(c.sat8()) != w {
+			t.Errorf("ToInt8Sat(%v) = %d, wanted %d", c.x, c.sat8(), w)
+		}
+	}
+
+	// round trip every int16 value that is exact in Float16
+	for i := -floatx.F16MaxExactInt; i <= floatx.F16MaxExactInt; i++ {
+		if got, ok := floatx.FromInt32[floatx.Float16](int32(i)).ToInt32(); !ok || int(got) != i {
+			t.Errorf("FromInt32(%d).ToInt32() = %d, %v", i, got, ok)
+		}
+	}
+}
+
+// wantUint returns x truncated and clamped to [0, hi], and whether it was
+// in range, using float64 arithmetic.
+func wantUint(x float64, hi uint64) (uint64, bool) {
+	x = math.Trunc(x)
+	switch {
+	case math.IsNaN(x), x < 0:
+		return 0, false
+	case x >= 2*float64(hi/2+1):
+		// hi+1 is a power of 2, and 2*(hi/2+1) is exact
+		return hi, false
+	}
+	return uint64(x), true
+}
+
+// checkToInts compares the generic integer conversions of f with wantInt
+// and wantUint.
+func checkToInts[T floatx.SmallFloat](t *testing.T, f T) {
+	t.Helper()
+	x := float64(f.Float32())
+
+	for _, c := range []struct {
+		name   string
+		conv   func() (int64, bool)
+		lo, hi int64
+	}{
+		{"ToInt8", func() (int64, bool) { i, ok := floatx.ToInt8(f); return int64(i), ok }, math.MinInt8, math.MaxInt8},
+		{"ToInt16", func() (int64, bool) { i, ok := floatx.ToInt16(f); return int64(i), ok }, math.MinInt16, math.MaxInt16},
+		{"ToInt32", func() (int64, bool) { i, ok := floatx.ToInt32(f); return int64(i), ok }, math.MinInt32, math.MaxInt32},
+		{"ToInt64", func() (int64, bool) { return floatx.ToInt64(f) }, math.MinInt64, math.MaxInt64},
+	} {
+		got, ok := c.conv()
+		if w, wok := wantInt(x, c.lo, c.hi); got != w || ok != wok {
+			t.Errorf("%s(%v) = %d, %v, wanted %d, %v", c.name, x, got, ok, w, wok)
+		}
+	}
+
+	for _, c := range []struct {
+		name string
+		conv func() (uint64, bool)
+		hi   uint64
+	}{
+		{"ToUint8", func() (uint64, bool) { u, ok := floatx.ToUint8(f); return uint64(u), ok }, math.MaxUint8},
+		{"ToUint16", func() (uint64, bool) { u, ok := floatx.ToUint16(f); return uint64(u), ok }, math.MaxUint16},
+		{"ToUint32", func() (uint64, bool) { u, ok := floatx.ToUint32(f); return uint64(u), ok }, math.MaxUint32},
+		{"ToUint64", func() (uint64, bool) { return floatx.ToUint64(f) }, math.MaxUint64},
+	} {
+		got, ok := c.conv()
+		if w, wok := wantUint(x, c.hi); got != w || ok != wok {
+			t.Errorf("%s(%v) = %d, %v, wanted %d, %v", c.name, x, got, ok, w, wok)
+		}
+	}
+}
+
+func TestToInts(t *testing.T) {
+	for i := 0; i < 65536; i++ {
+		checkToInts(t, floatx.Float16(i))
+		checkToInts(t, floatx.BFloat16(i))
+	}
+	for i := 0; i < 256; i++ {
+		checkToInts(t, floatx.Float8(i))
+	}
+}
@@ -0,0 +1,337 @@
+package floatx
+
+import "math"
+
+// The functions in this file are the IEEE 754 quiet-computational and
+// sign bit operations of package math for the types of this package.
+// They operate on the bit representation, so they handle subnormals and
+// signed zeros exactly. Operations that compute a result from a NaN
+// return it quieted, while Abs, Neg and Copysign only change the sign bit.
+
+// formatOf returns the format of T.
+func formatOf[T SmallFloat]() format {
+	var f T
+	switch any(f).(type) {
+	case BFloat16:
+		return formatBF16
+	case Float8:
+		return formatF8
+	}
+	return formatF16
+}
+
+func (fm format) signBit() uint16 { return 1 << (fm.expBits + fm.manBits) }
+func (fm format) expMask() uint16 { return (1<<fm.expBits - 1) << fm.manBits }
+func (fm format) manMask() uint16 { return 1<<fm.manBits - 1 }
+func (fm format) bias() int       { return 1<<(fm.expBits-1) - 1 }
+
+// quiet returns the NaN x with the quiet bit set.
+func quiet[T SmallFloat](x T) T {
+	return x | T(1)<<(formatOf[T]().manBits-1)
+}
+
+// Abs returns the absolute value of x.
+func Abs[T SmallFloat](x T) T {
+	return x &^ T(formatOf[T]().signBit())
+}
+
+// Neg returns x with its sign bit flipped.
+func Neg[T SmallFloat](x T) T {
+	return x ^ T(formatOf[T]().signBit())
+}
+
+// Copysign returns a value with the magnitude of f and the sign of sign.
+func Copysign[T SmallFloat](f, sign T) T {
+	signBit := T(formatOf[T]().signBit())
+	return f&^signBit | sign&signBit
+}
+
+// NextUp returns the least value of T that compares greater than x.
+//
+// Special cases are:
+//
+//	NextUp(±0) = smallest positive subnormal
+//	NextUp(largest finite) = +Inf
+//	NextUp(+Inf) = +Inf
+//	NextUp(-Inf) = -(largest finite)
+//	NextUp(NaN) = NaN
+func NextUp[T SmallFloat](x T) T {
+	signBit := T(formatOf[T]().signBit())
+	switch {
+	case x.IsNaN():
+		return quiet(x)
+	case x.IsInf(1):
+		return x
+	case x&^signBit == 0:
+		return 1
+	case x&signBit != 0:
+		return x - 1
+	}
+	return x + 1
+}
+
+// NextDown returns the greatest value of T that compares less than x.
+// It is -NextUp(-x).
+func NextDown[T SmallFloat](x T) T {
+	return Neg(NextUp(Neg(x)))
+}
+
+// Nextafter returns the next representable value after x towards y.
+//
+// Special cases are:
+//
+//	Nextafter(x, x) = x
+//	Nextafter(NaN, y) = NaN
+//	Nextafter(x, NaN) = NaN
+func Nextafter[T SmallFloat](x, y T) T {
+	switch {
+	case x.IsNaN():
+		return quiet(x)
+	case y.IsNaN():
+		return quiet(y)
+	}
+
+	fx, fy := x.Float32(), y.Float32()
+	switch {
+	case fx == fy:
+		return x
+	case fy > fx:
+		return NextUp(x)
+	}
+	return NextDown(x)
+}
+
+// Frexp breaks f into a normalized fraction and an integral power of two.
+// It returns frac and exp satisfying f == frac × 2**exp, with the absolute
+// value of frac in the interval [½, 1).
+//
+// Special cases are:
+//
+//	Frexp(±0) = ±0, 0
+//	Frexp(±Inf) = ±Inf, 0
+//	Frexp(NaN) = NaN, 0
+func Frexp[T SmallFloat](f T) (frac T, exp int) {
+	fm := formatOf[T]()
+	u := uint16(f)
+
+	switch {
+	case f.IsNaN():
+		return quiet(f), 0
+	case u&^fm.signBit() == 0, f.IsInf(0):
+		return f, 0
+	}
+
+	e := int(u&fm.expMask()>>fm.manBits) - fm.bias() + 1
+	man := u & fm.manMask()
+	if u&fm.expMask() == 0 {
+		// subnormal, shift the leading 1 into the implicit bit
+		for man&(1<<fm.manBits) == 0 {
+			man <<= 1
+			e--
+		}
+		e++
+		man &= fm.manMask()
+	}
+	return T(u&fm.signBit() | uint16(fm.bias()-1)<<fm.manBits | man), e
+}
+
+// Ldexp is the inverse of Frexp. It returns frac × 2**exp rounded to T
+// using IEEE default rounding (nearest int, with ties to even). It is the
+// IEEE 754 scaleB operation.
+//
+// Special cases are:
+//
+//	Ldexp(±0, exp) = ±0
+//	Ldexp(±Inf, exp) = ±Inf
+//	Ldexp(NaN, exp) = NaN
+func Ldexp[T SmallFloat](frac T, exp int) T {
+	fm := formatOf[T]()
+	u := uint16(frac)
+
+	switch {
+	case frac.IsNaN():
+		return quiet(frac)
+	case u&^fm.signBit() == 0, frac.IsInf(0):
+		return frac
+	}
+
+	// frac is man × 2**(e - manBits), with man normalized below
+	e := int(u & fm.expMask() >> fm.manBits)
+	man := u & fm.manMask()
+	if e == 0 {
+		e = 1
+		for man&(1<<fm.manBits) == 0 {
+			man <<= 1
+			e--
+		}
+	} else {
+		man |= 1 << fm.manBits
+	}
+
+	// clamp exp to keep e in range, any larger scaling over- or underflows
+	if exp > 1000 {
+		exp = 1000
+	} else if exp < -1000 {
+		exp = -1000
+	}
+	e += exp
+
+	sign := u & fm.signBit()
+	if e >= int(fm.expMask()>>fm.manBits) {
+		return T(sign | fm.expMask())
+	}
+	if e <= 0 {
+		// subnormal, round off 1-e bits
+		shift := uint(1 - e)
+		if shift > uint(fm.manBits)+2 {
+			shift = uint(fm.manBits) + 2
+		}
+		q := man >> shift
+		rem := uint32(man & (1<<shift - 1))
+		if rem != 0 && roundUp(ToNearestEven, false, q&1 != 0, rem, 1<<(shift-1)) {
+			// may carry into the exponent, giving the smallest normal
+			q++
+		}
+		return T(sign | q)
+	}
+	return T(sign | uint16(e)<<fm.manBits | man&fm.manMask())
+}
+
+// Logb returns the binary exponent of x, rounded to T if it isn't exactly
+// representable (some exponents of subnormal Float8 values aren't).
+//
+// Special cases are:
+//
+//	Logb(±Inf) = +Inf
+//	Logb(0) = -Inf
+//	Logb(NaN) = NaN
+func Logb[T SmallFloat](x T) T {
+	switch {
+	case x.IsNaN():
+		return quiet(x)
+	case x.IsInf(0):
+		return Abs(x)
+	case uint16(Abs(x)) == 0:
+		return FromFloat32[T](float32(math.Inf(-1)))
+	}
+	return FromFloat32[T](float32(Ilogb(x)))
+}
+
+// Ilogb returns the binary exponent of x as an integer.
+//
+// Special cases are:
+//
+//	Ilogb(±Inf) = MaxInt32
+//	Ilogb(0) = MinInt32
+//	Ilogb(NaN) = MaxInt32
+func Ilogb[T SmallFloat](x T) int {
+	switch {
+	case x.IsNaN(), x.IsInf(0):
+		return math.MaxInt32
+	case uint16(Abs(x)) == 0:
+		return math.MinInt32
+	}
+	_, exp := Frexp(x)
+	return exp - 1
+}
+
+// Modf returns integer and fractional values of T that sum to f. Both
+// values have the same sign as f.
+//
+// Special cases are:
+//
+//	Modf(±Inf) = ±Inf, NaN
+//	Modf(NaN) = NaN, NaN
+func Modf[T SmallFloat](f T) (integer T, frac T) {
+	switch {
+	case f.IsNaN():
+		return quiet(f), quiet(f)
+	case f.IsInf(0):
+		return f, FromFloat32[T](float32(math.NaN()))
+	}
+
+	integer = Trunc(f)
+	// the difference is exact, and representable in T
+	frac = FromFloat32[T](f.Float32() - integer.Float32())
+	return integer, Copysign(frac, f)
+}
+
+// Trunc returns the integer value of x rounded toward zero.
+// Trunc(±0) = ±0, Trunc(±Inf) = ±Inf and Trunc(NaN) = NaN.
+func Trunc[T SmallFloat](x T) T {
+	return roundToInt(x, ToZero)
+}
+
+// Floor returns the greatest integer value less than or equal to x.
+// Floor(±0) = ±0, Floor(±Inf) = ±Inf and Floor(NaN) = NaN.
+func Floor[T SmallFloat](x T) T {
+	return roundToInt(x, ToNegativeInf)
+}
+
+// Ceil returns the least integer value greater than or equal to x.
+// Ceil(±0) = ±0, Ceil(±Inf) = ±Inf and Ceil(NaN) = NaN.
+func Ceil[T SmallFloat](x T) T {
+	return roundToInt(x, ToPositiveInf)
+}
+
+// Round returns the nearest integer, rounding half away from zero.
+// Round(±0) = ±0, Round(±Inf) = ±Inf and Round(NaN) = NaN.
+func Round[T SmallFloat](x T) T {
+	return roundToInt(x, ToNearestAway)
+}
+
+// RoundToEven returns the nearest integer, rounding ties to even.
+// RoundToEven(±0) = ±0, RoundToEven(±Inf) = ±Inf and RoundToEven(NaN) = NaN.
+func RoundToEven[T SmallFloat](x T) T {
+	return roundToInt(x, ToNearestEven)
+}
+
+// roundToInt returns x rounded to an integer value using mode. The result
+// keeps the sign of x, including for zero.
+func roundToInt[T SmallFloat](x T, mode RoundingMode) T {
+	fm := formatOf[T]()
+	u := uint16(x)
+	if x.IsNaN() {
+		return quiet(x)
+	}
+
+	// x is man × 2**-shift, where shift is the number of fraction bits
+	e := int(u & fm.expMask() >> fm.manBits)
+	man := u & fm.manMask()
+	if e == 0 {
+		e = 1
+	} else {
+		man |= 1 << fm.manBits
+	}
+	shift := fm.bias() + int(fm.manBits) - e
+	if shift <= 0 {
+		// integral, or Inf
+		return x
+	}
+
+	sign := u & fm.signBit()
+	if shift > int(fm.manBits)+1 {
+		// |x| < ½, clamp so half is above any man
+		shift = int(fm.manBits) + 2
+	}
+	q := man >> shift
+	rem := uint32(man & (1<<shift - 1))
+	if rem == 0 {
+		return x
+	}
+	up := roundUp(mode, sign != 0, q&1 != 0, rem, 1<<(shift-1))
+
+	if shift > int(fm.manBits) {
+		// |x| < 1, so the result is 0 or 1
+		if up {
+			return T(sign | uint16(fm.bias())<<fm.manBits)
+		}
+		return T(sign)
+	}
+	u &^= 1<<shift - 1
+	if up {
+		// may carry into the exponent
+		u += 1 << shift
+	}
+	return T(u)
+}
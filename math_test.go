@@ -0,0 +1,283 @@
+package floatx_test
+
+import (
+	"math"
+	"sort"
+	"testing"
+
+	floatx "github.com/chenxingqiang/go-floatx"
+)
+
+// allValues returns every value of T in bit order.
+func allValues[T floatx.SmallFloat]() []T {
+	var f T
+	n := 65536
+	if _, ok := any(f).(floatx.Float8); ok {
+		n = 256
+	}
+	s := make([]T, n)
+	for i := range s {
+		s[i] = T(uint16(i))
+	}
+	return s
+}
+
+// same reports whether a float64 result and a T are the same value,
+// including the sign of zero, or are both NaN.
+func same[T floatx.SmallFloat](want float64, got T) bool {
+	g := float64(got.Float32())
+	if math.IsNaN(want) {
+		return got.IsNaN()
+	}
+	return g == want && math.Signbit(g) == math.Signbit(want)
+}
+
+func checkMath[T floatx.SmallFloat](t *testing.T, name string) {
+	t.Helper()
+
+	roundFuncs := []struct {
+		name string
+		f    func(T) T
+		want func(float64) float64
+	}{
+		{"Trunc", floatx.Trunc[T], math.Trunc},
+		{"Floor", floatx.Floor[T], math.Floor},
+		{"Ceil", floatx.Ceil[T], math.Ceil},
+		{"Round", floatx.Round[T], math.Round},
+		{"RoundToEven", floatx.RoundToEven[T], math.RoundToEven},
+		{"Abs", floatx.Abs[T], math.Abs},
+		{"Neg", floatx.Neg[T], func(x float64) float64 { return -x }},
+	}
+
+	for _, x := range allValues[T]() {
+		x64 := float64(x.Float32())
+
+		for _, fn := range roundFuncs {
+			if got := fn.f(x); !same(fn.want(x64), got) {
+				t.Errorf("%s(%s 0x%04x) = %v, wanted %v", fn.name, name, uint16(x), got, fn.want(x64))
+			}
+			if x.IsNaN() && fn.name != "Abs" && fn.name != "Neg" && !fn.f(x).IsQuietNaN() {
+				t.Errorf("%s(%s 0x%04x) = 0x%04x, wanted quiet NaN", fn.name, name, uint16(x), uint16(fn.f(x)))
+			}
+		}
+
+		ipart, frac := floatx.Modf(x)
+		wi, wf := math.Modf(x64)
+		if !x.IsNaN() && !x.IsInf(0) {
+			// both parts have the sign of x
+			wf = math.Copysign(wf, x64)
+		}
+		if !same(wi, ipart) || !same(wf, frac) {
+			t.Errorf("Modf(%s 0x%04x) = %v, %v, wanted %v, %v", name, uint16(x), ipart, frac, wi, wf)
+		}
+
+		fr, exp := floatx.Frexp(x)
+		wfr, wexp := math.Frexp(x64)
+		if !same(wfr, fr) || exp != wexp {
+			t.Errorf("Frexp(%s 0x%04x) = %v, %d, wanted %v, %d", name, uint16(x), fr, exp, wfr, wexp)
+		}
+
+		if got, want := floatx.Ilogb(x), math.Ilogb(x64); got != want {
+			t.Errorf("Ilogb(%s 0x%04x) = %d, wanted %d", name, uint16(x), got, want)
+		}
+		if got, want := floatx.Logb(x), floatx.FromFloat32[T](float32(math.Logb(x64))); uint16(got) != uint16(want) && !(got.IsNaN() && want.IsNaN()) {
+			t.Errorf("Logb(%s 0x%04x) = %v, wanted %v", name, uint16(x), got, want)
+		}
+
+		for _, sign := range []T{0, floatx.Neg[T](0)} {
+			if got, want := floatx.Copysign(x, sign), math.Copysign(x64, float64(sign.Float32())); !same(want, got) {
+				t.Errorf("Copysign(%s 0x%04x, %v) = %v, wanted %v", name, uint16(x), sign, got, want)
+			}
+		}
+	}
+}
+
+func TestMath(t *testing.T) {
+	checkMath[floatx.Float16](t, "Float16")
+	checkMath[floatx.BFloat16](t, "BFloat16")
+	checkMath[floatx.Float8](t, "Float8")
+}
+
+// checkNext compares NextUp, NextDown and Nextafter with the neighbours of
+// each value in the sorted list of all values of T.
+func checkNext[T floatx.SmallFloat](t *testing.T, name string) {
+	t.Helper()
+
+	var sorted []float64
+	for _, x := range allValues[T]() {
+		if !x.IsNaN() && !(x.Float32() == 0 && x.Signbit()) {
+			sorted = append(sorted, float64(x.Float32()))
+		}
+	}
+	sort.Float64s(sorted)
+
+	posInf := floatx.FromFloat32[T](float32(math.Inf(1)))
+	negInf := floatx.Neg(posInf)
+
+	for _, x := range allValues[T]() {
+		if x.IsNaN() {
+			for _, r := range []T{floatx.NextUp(x), floatx.NextDown(x), floatx.Nextafter(x, 0), floatx.Nextafter(0, x)} {
+				if !r.IsQuietNaN() {
+					t.Errorf("Next of %s NaN 0x%04x = 0x%04x, wanted quiet NaN", name, uint16(x), uint16(r))
+				}
+			}
+			continue
+		}
+
+		x64 := float64(x.Float32())
+		i := sort.SearchFloat64s(sorted, x64)
+		up, down := sorted[len(sorted)-1], sorted[0]
+		if i+1 < len(sorted) {
+			up = sorted[i+1]
+		}
+		if i > 0 {
+			down = sorted[i-1]
+		}
+
+		if got := floatx.NextUp(x); float64(got.Float32()) != up {
+			t.Errorf("NextUp(%s %v) = %v, wanted %v", name, x, got, up)
+		}
+		if got := floatx.NextDown(x); float64(got.Float32()) != down {
+			t.Errorf("NextDown(%s %v) = %v, wanted %v", name, x, got, down)
+		}
+		if got := floatx.Nextafter(x, posInf); got != floatx.NextUp(x) && !x.IsInf(1) {
+			t.Errorf("Nextafter(%s %v, +Inf) = %v, wanted NextUp", name, x, got)
+		}
+		if got := floatx.Nextafter(x, negInf); got != floatx.NextDown(x) && !x.IsInf(-1) {
+			t.Errorf("Nextafter(%s %v, -Inf) = %v, wanted NextDown", name, x, got)
+		}
+		if got := floatx.Nextafter(x, x); got != x {
+			t.Errorf("Nextafter(%s %v, itself) = %v, wanted %v", name, x, got, x)
+		}
+	}
+
+	// signed zeros
+	minSub := T(1)
+	if got := floatx.NextDown(minSub); uint16(got) != 0 {
+		t.Errorf("NextDown(%s smallest subnormal) = 0x%04x, wanted +0", name, uint16(got))
+	}
+	if got := floatx.NextUp(floatx.Neg(minSub)); uint16(got) != uint16(floatx.Neg[T](0)) {
+		t.Errorf("NextUp(%s -smallest subnormal) = 0x%04x, wanted -0", name, uint16(got))
+	}
+	if got := floatx.Nextafter(0, floatx.Neg[T](0)); uint16(got) != 0 {
+		t.Errorf("Nextafter(%s +0, -0) = 0x%04x, wanted +0", name, uint16(got))
+	}
+}
+
+func TestNext(t *testing.T) {
+	checkNext[floatx.Float16](t, "Float16")
+	checkNext[floatx.BFloat16](t, "BFloat16")
+	checkNext[floatx.Float8](t, "Float8")
+}
+
+// wantLdexp returns x × 2**exp rounded to the nearest multiple of minSub,
+// or ±Inf if it is at least limit, the first power of 2 past the largest
+// finite value.
+func wantLdexp(x float64, exp int, minSub, limit float64) float64 {
+	r := math.Ldexp(x, exp)
+	if math.Abs(r) >= limit {
+		return math.Copysign(math.Inf(1), x)
+	}
+	if math.Abs(r) < minSub*0x1p30 {
+		r = math.Copysign(math.RoundToEven(r/minSub)*minSub, x)
+	}
+	return r
+}
+
+func checkLdexp[T floatx.SmallFloat](t *testing.T, name string, minSub, limit float64) {
+	t.Helper()
+
+	for _, x := range allValues[T]() {
+		x64 := float64(x.Float32())
+		for exp := -300; exp <= 300; exp++ {
+			got := floatx.Ldexp(x, exp)
+			want := x64
+			if !x.IsNaN() && !x.IsInf(0) && x64 != 0 {
+				want = wantLdexp(x64, exp, minSub, limit)
+			}
+			if !same(want, got) {
+				t.Errorf("Ldexp(%s %v, %d) = %v, wanted %v", name, x, exp, got, want)
+			}
+		}
+
+		if x.IsNaN() || x.IsInf(0) {
+			continue
+		}
+		// Ldexp is the inverse of Frexp
+		if frac, exp := floatx.Frexp(x); floatx.Ldexp(frac, exp) != x {
+			t.Errorf("Ldexp(Frexp(%s %v)) = %v", name, x, floatx.Ldexp(frac, exp))
+		}
+	}
+
+	one := floatx.FromFloat32[T](1)
+	if got := floatx.Ldexp(one, math.MaxInt); !got.IsInf(1) {
+		t.Errorf("Ldexp(%s 1, MaxInt) = %v, wanted +Inf", name, got)
+	}
+	if got := floatx.Ldexp(floatx.Neg(one), math.MinInt); uint16(got) != uint16(floatx.Neg[T](0)) {
+		t.Errorf("Ldexp(%s -1, MinInt) = %v, wanted -0", name, got)
+	}
+	if got := floatx.Ldexp(floatx.FromFloat32[T](float32(math.NaN())), 1); !got.IsQuietNaN() {
+		t.Errorf("Ldexp(%s NaN, 1) = %v, wanted NaN", name, got)
+	}
+}
+
+func TestLdexp(t *testing.T) {
+	checkLdexp[floatx.Float16](t, "Float16", 0x1p-24, 0x1p16)
+	checkLdexp[floatx.BFloat16](t, "BFloat16", 0x1p-133, 0x1p128)
+	checkLdexp[floatx.Float8](t, "Float8", 0x1p-16, 0x1p16)
+}
+
+// checkConstants derives the limits of T from its values and compares them
+// with the constants of the format.
+func checkConstants[T floatx.SmallFloat](t *testing.T, name string, maxv, minNorm, minSub, eps float64, maxExactInt, digits, maxExp, minExp int) {
+	t.Helper()
+
+	value := func(x T) float64 { return float64(x.Float32()) }
+	one := floatx.FromFloat32[T](1)
+	inf := floatx.FromFloat32[T](float32(math.Inf(1)))
+
+	var norm T = 1
+	for !norm.IsNormal() {
+		norm++
+	}
+
+	exact := 1
+	for {
+		f := float32(exact + 1)
+		if floatx.FromFloat32[T](f).Float32() != f {
+			break
+		}
+		exact++
+	}
+
+	_, maxE := floatx.Frexp(floatx.NextDown(inf))
+	_, minE := floatx.Frexp(norm)
+
+	testCases := []struct {
+		what      string
+		got, want float64
+	}{
+		{"Max", maxv, value(floatx.NextDown(inf))},
+		{"SmallestNormal", minNorm, value(norm)},
+		{"SmallestSubnormal", minSub, value(T(1))},
+		{"Epsilon", eps, value(floatx.NextUp(one)) - 1},
+		{"MaxExactInt", float64(maxExactInt), float64(exact)},
+		{"Digits", float64(digits), 1 - math.Log2(value(floatx.NextUp(one))-1)},
+		{"MaxExp", float64(maxExp), float64(maxE)},
+		{"MinExp", float64(minExp), float64(minE)},
+	}
+	for _, tc := range testCases {
+		if tc.got != tc.want {
+			t.Errorf("%s%s = %v, wanted %v", name, tc.what, tc.got, tc.want)
+		}
+	}
+}
+
+func TestConstants(t *testing.T) {
+	checkConstants[floatx.Float16](t, "F16", floatx.F16Max, floatx.F16SmallestNormal, floatx.F16SmallestSubnormal, floatx.F16Epsilon,
+		floatx.F16MaxExactInt, floatx.F16Digits, floatx.F16MaxExp, floatx.F16MinExp)
+	checkConstants[floatx.BFloat16](t, "BF16", floatx.BF16Max, floatx.BF16SmallestNormal, floatx.BF16SmallestSubnormal, floatx.BF16Epsilon,
+		floatx.BF16MaxExactInt, floatx.BF16Digits, floatx.BF16MaxExp, floatx.BF16MinExp)
+	checkConstants[floatx.Float8](t, "F8", floatx.F8Max, floatx.F8SmallestNormal, floatx.F8SmallestSubnormal, floatx.F8Epsilon,
+		floatx.F8MaxExactInt, floatx.F8Digits, floatx.F8MaxExp, floatx.F8MinExp)
+}
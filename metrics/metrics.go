@@ -0,0 +1,204 @@
+// Package metrics measures the error of quantizing float32 data to the
+// floating-point formats of github.com/chenxingqiang/go-floatx.
+//
+// The slice functions compare a float32 reference with its quantized
+// version. Like the slice helpers in floatx, they use the first
+// min(len(ref), len(q)) elements and don't allocate.
+package metrics
+
+import (
+	"math"
+
+	floatx "github.com/chenxingqiang/go-floatx"
+)
+
+// layout returns the sign bit and the bits of the largest finite magnitude of T.
+func layout[T floatx.SmallFloat]() (signBit, maxBits uint16) {
+	var f T
+	switch any(f).(type) {
+	case floatx.BFloat16:
+		return 0x8000, 0x7f7f
+	case floatx.Float8:
+		return 0x80, 0x7b
+	}
+	return 0x8000, 0x7bff
+}
+
+// ULP returns the unit in the last place of x, the distance from |x| to the
+// next value of larger magnitude. ULP of the largest finite value is the
+// spacing below it, ULP of ±Inf is +Inf and ULP of NaN is NaN.
+func ULP[T floatx.SmallFloat](x T) float64 {
+	signBit, maxBits := layout[T]()
+
+	switch {
+	case x.IsNaN():
+		return math.NaN()
+	case x.IsInf(0):
+		return math.Inf(1)
+	}
+
+	a := uint16(x) &^ signBit
+	if a == maxBits {
+		a--
+	}
+	return float64(T(a+1).Float32()) - float64(T(a).Float32())
+}
+
+// ULPDistance returns the number of representable values of T between a and
+// b plus one, so ULPDistance of adjacent values is 1 and of equal values is 0.
+// Positive and negative zero are equal. Infinity is one step past the largest
+// finite value. ULPDistance returns -1 if a or b is NaN.
+func ULPDistance[T floatx.SmallFloat](a, b T) int {
+	if a.IsNaN() || b.IsNaN() {
+		return -1
+	}
+	d := ordered(a) - ordered(b)
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// ordered maps x to an int with the same ordering as the values of T.
+func ordered[T floatx.SmallFloat](x T) int {
+	signBit, _ := layout[T]()
+	u := uint16(x)
+	if u&signBit != 0 {
+		return -int(u &^ signBit)
+	}
+	return int(u)
+}
+
+func minLen(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// MaxAbsError returns the largest |ref[i] - q[i]|.
+// It returns NaN if any difference is NaN and 0 for empty slices.
+func MaxAbsError[T floatx.SmallFloat](ref []float32, q []T) float64 {
+	n := minLen(len(ref), len(q))
+
+	maxErr := 0.0
+	for i := 0; i < n; i++ {
+		d := math.Abs(float64(ref[i]) - float64(q[i].Float32()))
+		if math.IsNaN(d) {
+			return d
+		}
+		if d > maxErr {
+			maxErr = d
+		}
+	}
+	return maxErr
+}
+
+// MeanRelError returns the mean of |ref[i] - q[i]| / |ref[i]| over the
+// elements where ref[i] is nonzero. It returns 0 if there are no such
+// elements.
+func MeanRelError[T floatx.SmallFloat](ref []float32, q []T) float64 {
+	n := minLen(len(ref), len(q))
+
+	sum := 0.0
+	count := 0
+	for i := 0; i < n; i++ {
+		r := float64(ref[i])
+		if r == 0 {
+			continue
+		}
+		sum += math.Abs(r-float64(q[i].Float32())) / math.Abs(r)
+		count++
+	}
+	if count == 0 {
+		return 0
+	}
+	return sum / float64(count)
+}
+
+// SQNR returns the signal-to-quantization-noise ratio in decibels,
+// 10*log10(Σref[i]² / Σ(ref[i]-q[i])²).
+// It returns +Inf if q equals ref and NaN for empty or all-zero slices.
+func SQNR[T floatx.SmallFloat](ref []float32, q []T) float64 {
+	n := minLen(len(ref), len(q))
+
+	var signal, noise float64
+	for i := 0; i < n; i++ {
+		r := float64(ref[i])
+		d := r - float64(q[i].Float32())
+		signal += r * r
+		noise += d * d
+	}
+	if signal == 0 {
+		return math.NaN()
+	}
+	return 10 * math.Log10(signal/noise)
+}
+
+// CosineSimilarity returns Σref[i]q[i] / (‖ref‖‖q‖).
+// It returns NaN if either vector is all zeros or the slices are empty.
+func CosineSimilarity[T floatx.SmallFloat](ref []float32, q []T) float64 {
+	n := minLen(len(ref), len(q))
+
+	var dot, rr, qq float64
+	for i := 0; i < n; i++ {
+		r := float64(ref[i])
+		v := float64(q[i].Float32())
+		dot += r * v
+		rr += r * r
+		qq += v * v
+	}
+	if rr == 0 || qq == 0 {
+		return math.NaN()
+	}
+	return dot / (math.Sqrt(rr) * math.Sqrt(qq))
+}
+
+// Histogram counts the elements of a float32 slice by the precision of
+// converting them to a format.
+type Histogram struct {
+	Exact     int // includes zero, infinity and NaN
+	Unknown   int // always 0 from PrecisionHistogram, see F16PrecisionUnknown
+	Inexact   int
+	Underflow int
+	Overflow  int
+}
+
+// Total returns the number of elements counted.
+func (h Histogram) Total() int {
+	return h.Exact + h.Unknown + h.Inexact + h.Underflow + h.Overflow
+}
+
+// PrecisionHistogram returns the Histogram of converting ref to T, so a
+// whole tensor can be checked for overflow or underflow before quantizing.
+// Each element is classified by the format's PrecisionExactFromfloat32
+// function, so the counts describe the values the conversion actually
+// produces: values that round to infinity are overflows and nonzero values
+// that round to zero are underflows.
+func PrecisionHistogram[T floatx.SmallFloat](ref []float32) Histogram {
+	var counts [5]int
+
+	var f T
+	switch any(f).(type) {
+	case floatx.Float16:
+		for _, f32 := range ref {
+			counts[floatx.F16PrecisionExactFromfloat32(f32)]++
+		}
+	case floatx.BFloat16:
+		for _, f32 := range ref {
+			counts[floatx.BF16PrecisionExactFromfloat32(f32)]++
+		}
+	case floatx.Float8:
+		for _, f32 := range ref {
+			counts[floatx.F8PrecisionExactFromfloat32(f32)]++
+		}
+	}
+
+	return Histogram{
+		Exact:     counts[floatx.F16PrecisionExact],
+		Unknown:   counts[floatx.F16PrecisionUnknown],
+		Inexact:   counts[floatx.F16PrecisionInexact],
+		Underflow: counts[floatx.F16PrecisionUnderflow],
+		Overflow:  counts[floatx.F16PrecisionOverflow],
+	}
+}
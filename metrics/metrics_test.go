@@ -0,0 +1,190 @@
+package metrics_test
+
+import (
+	"math"
+	"testing"
+
+	floatx "github.com/chenxingqiang/go-floatx"
+	"github.com/chenxingqiang/go-floatx/metrics"
+)
+
+// wantULP returns 2^(max(exponent(x), emin) - manBits) for finite x.
+func wantULP(x float64, manBits, emin int) float64 {
+	exp := emin
+	if x != 0 {
+		_, e := math.Frexp(math.Abs(x))
+		if e-1 > emin {
+			exp = e - 1
+		}
+	}
+	return math.Ldexp(1, exp-manBits)
+}
+
+func TestULP(t *testing.T) {
+	for i := 0; i < 65536; i++ {
+		f16 := floatx.Float16(i)
+		bf16 := floatx.BFloat16(i)
+
+		checkULP(t, "Float16", uint16(i), metrics.ULP(f16), float64(f16.Float32()), 10, -14, f16.IsFinite())
+		checkULP(t, "BFloat16", uint16(i), metrics.ULP(bf16), float64(bf16.Float32()), 7, -126, bf16.IsFinite())
+	}
+	for i := 0; i < 256; i++ {
+		f8 := floatx.Float8(i)
+		checkULP(t, "Float8", uint16(i), metrics.ULP(f8), float64(f8.Float32()), 2, -14, f8.IsFinite())
+	}
+
+	if u := metrics.ULP(floatx.F16Inf(-1)); !math.IsInf(u, 1) {
+		t.Errorf("ULP(-Inf) = %v, wanted +Inf", u)
+	}
+	if u := metrics.ULP(floatx.F16NaN()); !math.IsNaN(u) {
+		t.Errorf("ULP(NaN) = %v, wanted NaN", u)
+	}
+	if u := metrics.ULP(floatx.F16Fromfloat32(65504)); u != 32 {
+		t.Errorf("ULP(65504) = %v, wanted 32", u)
+	}
+}
+
+func checkULP(t *testing.T, name string, u16 uint16, got, x float64, manBits, emin int, finite bool) {
+	t.Helper()
+	if !finite {
+		return
+	}
+	if want := wantULP(x, manBits, emin); got != want {
+		t.Errorf("ULP(%s(0x%04x)) = %v, wanted %v", name, u16, got, want)
+	}
+}
+
+func TestULPDistance(t *testing.T) {
+	// adjacent finite values and infinity are 1 apart
+	for i := 0; i < 0x7c00; i++ {
+		for _, sign := range []uint16{0, 0x8000} {
+			a := floatx.Float16(uint16(i) | sign)
+			b := floatx.Float16(uint16(i+1) | sign)
+			if d := metrics.ULPDistance(a, b); d != 1 {
+				t.Errorf("ULPDistance(0x%04x, 0x%04x) = %d, wanted 1", uint16(a), uint16(b), d)
+			}
+		}
+	}
+
+	testCases := []struct {
+		a, b float32
+		want int
+	}{
+		{0, float32(math.Copysign(0, -1)), 0},
+		{1, 1, 0},
+		{1, -1, 2 * 0x3c00},
+		{0x1p-24, -0x1p-24, 2},
+		{65504, float32(math.Inf(1)), 1},
+		{1, 2, 1024},
+	}
+	for _, tc := range testCases {
+		a, b := floatx.F16Fromfloat32(tc.a), floatx.F16Fromfloat32(tc.b)
+		if d := metrics.ULPDistance(a, b); d != tc.want {
+			t.Errorf("ULPDistance(%v, %v) = %d, wanted %d", tc.a, tc.b, d, tc.want)
+		}
+		if d := metrics.ULPDistance(b, a); d != tc.want {
+			t.Errorf("ULPDistance(%v, %v) = %d, wanted %d", tc.b, tc.a, d, tc.want)
+		}
+	}
+
+	if d := metrics.ULPDistance(floatx.F8Fromfloat32(1), floatx.F8Fromfloat32(2)); d != 4 {
+		t.Errorf("ULPDistance(Float8 1, 2) = %d, wanted 4", d)
+	}
+	if d := metrics.ULPDistance(floatx.BF16Fromfloat32(-1), floatx.BF16Fromfloat32(1)); d != 2*0x3f80 {
+		t.Errorf("ULPDistance(BFloat16 -1, 1) = %d, wanted %d", d, 2*0x3f80)
+	}
+	if d := metrics.ULPDistance(floatx.F16NaN(), 0); d != -1 {
+		t.Errorf("ULPDistance(NaN, 0) = %d, wanted -1", d)
+	}
+}
+
+func quantize[T floatx.SmallFloat](ref []float32) []T {
+	q := make([]T, len(ref))
+	floatx.FromFloat32s(q, ref)
+	return q
+}
+
+func TestMaxAbsError(t *testing.T) {
+	ref := []float32{1, 1.0 / 3, -1000.3, 0}
+	if e := metrics.MaxAbsError(ref, quantize[floatx.Float16](ref)); e != 0.20001220703125 {
+		t.Errorf("MaxAbsError = %v, wanted 0.20001220703125", e)
+	}
+	if e := metrics.MaxAbsError(ref[:1], quantize[floatx.BFloat16](ref)); e != 0 {
+		t.Errorf("MaxAbsError = %v, wanted 0", e)
+	}
+	if e := metrics.MaxAbsError([]float32{1, float32(math.NaN())}, []floatx.Float8{0x3c, 0x3c}); !math.IsNaN(e) {
+		t.Errorf("MaxAbsError = %v, wanted NaN", e)
+	}
+}
+
+func TestMeanRelError(t *testing.T) {
+	ref := []float32{1, 3, 0, -5}
+	q := []floatx.Float8{floatx.F8Fromfloat32(1), floatx.F8Fromfloat32(2.5), floatx.F8Fromfloat32(7), floatx.F8Fromfloat32(-4)}
+	want := (0 + 0.5/3 + 1.0/5) / 3
+	if e := metrics.MeanRelError(ref, q); math.Abs(e-want) > 1e-12 {
+		t.Errorf("MeanRelError = %v, wanted %v", e, want)
+	}
+	if e := metrics.MeanRelError([]float32{0}, q); e != 0 {
+		t.Errorf("MeanRelError of zeros = %v, wanted 0", e)
+	}
+}
+
+func TestSQNR(t *testing.T) {
+	ref := []float32{3, 4}
+	q := []floatx.Float16{floatx.F16Fromfloat32(3), floatx.F16Fromfloat32(4.5)}
+	// signal 25, noise 0.25
+	if s := metrics.SQNR(ref, q); math.Abs(s-20) > 1e-12 {
+		t.Errorf("SQNR = %v, wanted 20", s)
+	}
+	if s := metrics.SQNR(ref, quantize[floatx.Float16](ref)); !math.IsInf(s, 1) {
+		t.Errorf("SQNR of exact = %v, wanted +Inf", s)
+	}
+	if s := metrics.SQNR([]float32{}, q); !math.IsNaN(s) {
+		t.Errorf("SQNR of empty = %v, wanted NaN", s)
+	}
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	ref := []float32{1, 0}
+	q := []floatx.BFloat16{floatx.BF16Fromfloat32(1), floatx.BF16Fromfloat32(1)}
+	if c := metrics.CosineSimilarity(ref, q); math.Abs(c-math.Sqrt2/2) > 1e-12 {
+		t.Errorf("CosineSimilarity = %v, wanted %v", c, math.Sqrt2/2)
+	}
+	if c := metrics.CosineSimilarity(ref, quantize[floatx.BFloat16](ref)); c != 1 {
+		t.Errorf("CosineSimilarity of exact = %v, wanted 1", c)
+	}
+	if c := metrics.CosineSimilarity(ref, []floatx.BFloat16{0, 0}); !math.IsNaN(c) {
+		t.Errorf("CosineSimilarity with zero vector = %v, wanted NaN", c)
+	}
+}
+
+func TestPrecisionHistogram(t *testing.T) {
+	ref := []float32{0, 1, 0.1, 1e10, 1e-10, 0x1p-20, float32(math.NaN())}
+	limits := []float32{65504, 65520, -65520, 0x1p-24, 0x1p-25, 0x1.8p-25}
+
+	testCases := []struct {
+		name string
+		n    int
+		got  metrics.Histogram
+		want metrics.Histogram
+	}{
+		{"Float16", len(ref), metrics.PrecisionHistogram[floatx.Float16](ref), metrics.Histogram{Exact: 4, Inexact: 1, Underflow: 1, Overflow: 1}},
+		{"BFloat16", len(ref), metrics.PrecisionHistogram[floatx.BFloat16](ref), metrics.Histogram{Exact: 4, Inexact: 3}},
+		{"Float8", len(ref), metrics.PrecisionHistogram[floatx.Float8](ref), metrics.Histogram{Exact: 3, Inexact: 1, Underflow: 2, Overflow: 1}},
+
+		// the Float16 limits, classified by the rounded result:
+		// 65520 rounds to infinity, 0x1p-25 rounds to zero and
+		// 0x1.8p-25 rounds up to 0x1p-24
+		{"Float16 limits", len(limits), metrics.PrecisionHistogram[floatx.Float16](limits), metrics.Histogram{Exact: 2, Inexact: 1, Underflow: 1, Overflow: 2}},
+		{"BFloat16 limits", len(limits), metrics.PrecisionHistogram[floatx.BFloat16](limits), metrics.Histogram{Exact: 3, Inexact: 3}},
+		{"Float8 limits", len(limits), metrics.PrecisionHistogram[floatx.Float8](limits), metrics.Histogram{Underflow: 3, Overflow: 3}},
+	}
+	for _, tc := range testCases {
+		if tc.got != tc.want {
+			t.Errorf("PrecisionHistogram[%s] = %+v, wanted %+v", tc.name, tc.got, tc.want)
+		}
+		if tc.got.Total() != tc.n {
+			t.Errorf("PrecisionHistogram[%s].Total() = %d, wanted %d", tc.name, tc.got.Total(), tc.n)
+		}
+	}
+}
@@ -0,0 +1,469 @@
+// Package npy reads and writes NumPy .npy and .npz files holding arrays of
+// the floating-point types of github.com/chenxingqiang/go-floatx.
+//
+// Supported dtypes are '<f2' and '>f2' (Float16), 'bfloat16' (BFloat16),
+// 'float8_e5m2' (Float8) and 'float8_e4m3fn' (Float8E4M3FN), the names of
+// the ml_dtypes package. ReadFloat32s also reads '<f4' and '>f4'.
+//
+// NumPy doesn't write those names for ml_dtypes arrays: numpy.save stores
+// the void dtype of the same size, '<V2' for bfloat16 and '|V1' for the
+// float8 types, which doesn't say which format the bytes hold. Read accepts
+// a void dtype whose size matches T and takes T as the format, so the
+// caller's type is the dtype hint. ReadFloat32s has no hint and rejects
+// void dtypes. Void data is little-endian unless the descr starts with '>'.
+// Write writes the ml_dtypes names.
+//
+// Reading never consumes more of r than the header and array data, so r may
+// hold further data, such as another .npy file, after the array.
+//
+// Data is returned in file order. For arrays with Header.FortranOrder set
+// that is column-major order.
+package npy
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	floatx "github.com/chenxingqiang/go-floatx"
+)
+
+const (
+	// ErrInvalidMagic is returned for data that doesn't start with the .npy
+	// magic string.
+	ErrInvalidMagic = npyError("npy: invalid magic string")
+
+	// ErrInvalidHeader is returned for a malformed header.
+	ErrInvalidHeader = npyError("npy: invalid header")
+
+	// ErrUnsupportedVersion is returned for format versions other than 1.0,
+	// 2.0 and 3.0.
+	ErrUnsupportedVersion = npyError("npy: unsupported format version")
+
+	// ErrUnsupportedDtype is returned for dtypes this package can't decode
+	// into the requested type.
+	ErrUnsupportedDtype = npyError("npy: unsupported dtype")
+
+	// ErrShape is returned by Write if the shape doesn't match the data.
+	ErrShape = npyError("npy: shape doesn't match data length")
+)
+
+type npyError string
+
+func (e npyError) Error() string { return string(e) }
+
+const magic = "\x93NUMPY"
+
+// Header is the header of a .npy file.
+type Header struct {
+	Descr        string // dtype, for example '<f2'
+	FortranOrder bool   // data is in column-major order
+	Shape        []int  // empty for a scalar
+}
+
+// Len returns the number of elements, the product of the shape, or -1 if
+// that overflows an int.
+func (h *Header) Len() int {
+	n := 1
+	for _, d := range h.Shape {
+		if d != 0 && n > math.MaxInt/d {
+			return -1
+		}
+		n *= d
+	}
+	return n
+}
+
+// ReadHeader reads the header of a .npy file from r, leaving r at the start
+// of the array data.
+func ReadHeader(r io.Reader) (*Header, error) {
+	var pre [8]byte
+	if _, err := io.ReadFull(r, pre[:]); err != nil {
+		return nil, err
+	}
+	if string(pre[:6]) != magic {
+		return nil, ErrInvalidMagic
+	}
+
+	var hlen uint32
+	switch pre[6] {
+	case 1:
+		var b [2]byte
+		if _, err := io.ReadFull(r, b[:]); err != nil {
+			return nil, err
+		}
+		hlen = uint32(binary.LittleEndian.Uint16(b[:]))
+	case 2, 3:
+		var b [4]byte
+		if _, err := io.ReadFull(r, b[:]); err != nil {
+			return nil, err
+		}
+		hlen = binary.LittleEndian.Uint32(b[:])
+	default:
+		return nil, fmt.Errorf("%w %d.%d", ErrUnsupportedVersion, pre[6], pre[7])
+	}
+	if hlen > 1<<20 {
+		return nil, ErrInvalidHeader
+	}
+
+	buf := make([]byte, hlen)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, err
+	}
+	return parseHeader(string(buf))
+}
+
+// parseHeader parses the Python dict literal of a header, for example
+// {'descr': '<f2', 'fortran_order': False, 'shape': (2, 3), }
+func parseHeader(s string) (*Header, error) {
+	p := &parser{s: s}
+	h := &Header{}
+	seen := map[string]bool{}
+
+	if !p.consume('{') {
+		return nil, ErrInvalidHeader
+	}
+	for !p.consume('}') {
+		key, ok := p.str()
+		if !ok || !p.consume(':') {
+			return nil, ErrInvalidHeader
+		}
+		switch key {
+		case "descr":
+			h.Descr, ok = p.str()
+		case "fortran_order":
+			h.FortranOrder, ok = p.boolean()
+		case "shape":
+			h.Shape, ok = p.tuple()
+		default:
+			ok = false
+		}
+		if !ok {
+			return nil, fmt.Errorf("%w: bad value for %q", ErrInvalidHeader, key)
+		}
+		seen[key] = true
+		if !p.consume(',') {
+			if !p.consume('}') {
+				return nil, ErrInvalidHeader
+			}
+			break
+		}
+	}
+	if len(seen) != 3 {
+		return nil, fmt.Errorf("%w: missing keys", ErrInvalidHeader)
+	}
+	return h, nil
+}
+
+type parser struct {
+	s string
+}
+
+func (p *parser) skipSpace() {
+	p.s = strings.TrimLeft(p.s, " \t\r\n")
+}
+
+// consume skips spaces and c if it comes next, and reports whether it did.
+func (p *parser) consume(c byte) bool {
+	p.skipSpace()
+	if len(p.s) > 0 && p.s[0] == c {
+		p.s = p.s[1:]
+		return true
+	}
+	return false
+}
+
+// str returns a single or double quoted string without escapes.
+func (p *parser) str() (string, bool) {
+	p.skipSpace()
+	if len(p.s) == 0 || (p.s[0] != '\'' && p.s[0] != '"') {
+		return "", false
+	}
+	end := strings.IndexByte(p.s[1:], p.s[0])
+	if end < 0 {
+		return "", false
+	}
+	v := p.s[1 : end+1]
+	p.s = p.s[end+2:]
+	return v, true
+}
+
+func (p *parser) boolean() (bool, bool) {
+	p.skipSpace()
+	for _, b := range []bool{false, true} {
+		word := "False"
+		if b {
+			word = "True"
+		}
+		if strings.HasPrefix(p.s, word) {
+			p.s = p.s[len(word):]
+			return b, true
+		}
+	}
+	return false, false
+}
+
+// tuple returns a tuple of non-negative ints, allowing Python 2 long
+// literals like 3L.
+func (p *parser) tuple() ([]int, bool) {
+	if !p.consume('(') {
+		return nil, false
+	}
+	shape := []int{}
+	for !p.consume(')') {
+		p.skipSpace()
+		end := strings.IndexAny(p.s, ",)L ")
+		if end <= 0 {
+			return nil, false
+		}
+		d, err := strconv.Atoi(p.s[:end])
+		if err != nil || d < 0 {
+			return nil, false
+		}
+		shape = append(shape, d)
+		p.s = p.s[end:]
+		p.consume('L')
+		if !p.consume(',') && !strings.HasPrefix(strings.TrimLeft(p.s, " "), ")") {
+			return nil, false
+		}
+	}
+	return shape, true
+}
+
+// dtype is a decoded descr.
+type dtype struct {
+	size      int
+	bigEndian bool
+	decode    func(u uint32) float32 // bits to float32, nil for void
+	kind      string                 // matches descrOf for the small floats
+}
+
+func parseDescr(descr string) (dtype, bool) {
+	switch descr {
+	case "|V1", "<V1", "=V1", ">V1":
+		return dtype{1, false, nil, "V1"}, true
+	case "|V2", "<V2", "=V2", ">V2":
+		return dtype{2, descr[0] == '>', nil, "V2"}, true
+	case "<f2", "=f2", ">f2":
+		return dtype{2, descr[0] == '>', func(u uint32) float32 { return floatx.Float16(u).Float32() }, "<f2"}, true
+	case "bfloat16":
+		return dtype{2, false, func(u uint32) float32 { return floatx.BFloat16(u).Float32() }, "bfloat16"}, true
+	case "float8_e5m2":
+		return dtype{1, false, func(u uint32) float32 { return floatx.Float8(u).Float32() }, "float8_e5m2"}, true
+	case "float8_e4m3fn":
+		return dtype{1, false, func(u uint32) float32 { return floatx.Float8E4M3FN(u).Float32() }, "float8_e4m3fn"}, true
+	case "<f4", "=f4", ">f4":
+		return dtype{4, descr[0] == '>', math.Float32frombits, "<f4"}, true
+	}
+	return dtype{}, false
+}
+
+// sizeOf returns the size in bytes of a T.
+func sizeOf[T floatx.AnyFloat]() int {
+	var f T
+	switch any(f).(type) {
+	case floatx.Float8, floatx.Float8E4M3FN:
+		return 1
+	}
+	return 2
+}
+
+// descrOf returns the descr written for T.
+func descrOf[T floatx.AnyFloat]() string {
+	var f T
+	switch any(f).(type) {
+	case floatx.BFloat16:
+		return "bfloat16"
+	case floatx.Float8:
+		return "float8_e5m2"
+	case floatx.Float8E4M3FN:
+		return "float8_e4m3fn"
+	}
+	return "<f2"
+}
+
+// readData reads n elements of dt from r and calls put with the bits of
+// each. It reads in chunks, so a corrupt shape fails at the end of the data
+// instead of allocating for it.
+func readData(r io.Reader, dt dtype, n int, put func(u uint32)) error {
+	buf := make([]byte, 64*1024)
+	i := 0
+	for i < n {
+		m := n - i
+		if m > len(buf)/dt.size {
+			m = len(buf) / dt.size
+		}
+		b := buf[:m*dt.size]
+		if _, err := io.ReadFull(r, b); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return err
+		}
+		for j := 0; j < m; j++ {
+			var u uint32
+			switch {
+			case dt.size == 1:
+				u = uint32(b[j])
+			case dt.size == 2 && dt.bigEndian:
+				u = uint32(binary.BigEndian.Uint16(b[2*j:]))
+			case dt.size == 2:
+				u = uint32(binary.LittleEndian.Uint16(b[2*j:]))
+			case dt.bigEndian:
+				u = binary.BigEndian.Uint32(b[4*j:])
+			default:
+				u = binary.LittleEndian.Uint32(b[4*j:])
+			}
+			put(u)
+		}
+		i += m
+	}
+	return nil
+}
+
+// capacity returns the initial capacity for n elements, limited so a corrupt
+// header can't make Read allocate much more than the file holds.
+func capacity(n int) int {
+	if n > 1<<20 {
+		return 1 << 20
+	}
+	return n
+}
+
+// Read reads a .npy file from r into a slice of T. The dtype must be the
+// one for T: '<f2' or '>f2' for Float16, 'bfloat16' for BFloat16,
+// 'float8_e5m2' for Float8 and 'float8_e4m3fn' for Float8E4M3FN, or the
+// void dtype NumPy writes for T, such as '<V2' for BFloat16 or '|V1' for
+// Float8 and Float8E4M3FN.
+func Read[T floatx.AnyFloat](r io.Reader) ([]T, *Header, error) {
+	h, err := ReadHeader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	dt, ok := parseDescr(h.Descr)
+	void := dt.decode == nil && dt.size == sizeOf[T]()
+	if !ok || dt.kind != descrOf[T]() && !void {
+		return nil, h, fmt.Errorf("%w %q for %T", ErrUnsupportedDtype, h.Descr, T(0))
+	}
+
+	n := h.Len()
+	if n < 0 {
+		return nil, h, ErrInvalidHeader
+	}
+	data := make([]T, 0, capacity(n))
+	err = readData(r, dt, n, func(u uint32) {
+		data = append(data, T(u))
+	})
+	if err != nil {
+		return nil, h, err
+	}
+	return data, h, nil
+}
+
+// ReadFloat32s reads a .npy file from r with any supported dtype other than
+// a void dtype and converts it to float32, which is lossless.
+func ReadFloat32s(r io.Reader) ([]float32, *Header, error) {
+	h, err := ReadHeader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	dt, ok := parseDescr(h.Descr)
+	if !ok {
+		return nil, h, fmt.Errorf("%w %q", ErrUnsupportedDtype, h.Descr)
+	}
+	if dt.decode == nil {
+		return nil, h, fmt.Errorf("%w %q: the format of a void dtype is unknown, use Read", ErrUnsupportedDtype, h.Descr)
+	}
+
+	n := h.Len()
+	if n < 0 {
+		return nil, h, ErrInvalidHeader
+	}
+	data := make([]float32, 0, capacity(n))
+	err = readData(r, dt, n, func(u uint32) {
+		data = append(data, dt.decode(u))
+	})
+	if err != nil {
+		return nil, h, err
+	}
+	return data, h, nil
+}
+
+// Write writes data as a C-order .npy file to w. The shape defaults to
+// one dimension of len(data); otherwise its product must be len(data).
+func Write[T floatx.AnyFloat](w io.Writer, data []T, shape ...int) error {
+	if shape == nil {
+		shape = []int{len(data)}
+	}
+	h := &Header{Descr: descrOf[T](), Shape: shape}
+	if h.Len() != len(data) {
+		return ErrShape
+	}
+	if err := writeHeader(w, h); err != nil {
+		return err
+	}
+
+	size := sizeOf[T]()
+	buf := make([]byte, 0, 64*1024)
+	for _, v := range data {
+		if size == 1 {
+			buf = append(buf, byte(v))
+		} else {
+			buf = binary.LittleEndian.AppendUint16(buf, uint16(v))
+		}
+		if len(buf) == cap(buf) {
+			if _, err := w.Write(buf); err != nil {
+				return err
+			}
+			buf = buf[:0]
+		}
+	}
+	_, err := w.Write(buf)
+	return err
+}
+
+// writeHeader writes h, which is in C order, like NumPy: version 1.0 unless the header needs
+// 2.0, padded with spaces and a newline to a multiple of 64 bytes.
+func writeHeader(w io.Writer, h *Header) error {
+	var sb strings.Builder
+	sb.WriteString("{'descr': '")
+	sb.WriteString(h.Descr)
+	sb.WriteString("', 'fortran_order': False, 'shape': (")
+	for i, d := range h.Shape {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(strconv.Itoa(d))
+	}
+	if len(h.Shape) == 1 {
+		// Python's repr of a 1-tuple
+		sb.WriteString(",")
+	}
+	sb.WriteString("), }")
+
+	dict := sb.String()
+	pre := []byte(magic + "\x01\x00")
+	lenSize := 2
+	if len(dict)+1+len(pre)+2 > math.MaxUint16 {
+		pre = []byte(magic + "\x02\x00")
+		lenSize = 4
+	}
+	total := len(pre) + lenSize + len(dict) + 1
+	pad := (64 - total%64) % 64
+	hlen := len(dict) + pad + 1
+
+	b := append([]byte{}, pre...)
+	if lenSize == 2 {
+		b = binary.LittleEndian.AppendUint16(b, uint16(hlen))
+	} else {
+		b = binary.LittleEndian.AppendUint32(b, uint32(hlen))
+	}
+	b = append(b, dict...)
+	b = append(b, strings.Repeat(" ", pad)...)
+	b = append(b, '\n')
+	_, err := w.Write(b)
+	return err
+}
@@ -0,0 +1,332 @@
+package npy_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	floatx "github.com/chenxingqiang/go-floatx"
+	"github.com/chenxingqiang/go-floatx/npy"
+)
+
+// The fixtures in testdata are written by testdata/gen_fixtures.py.
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	b, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestRead(t *testing.T) {
+	inf := float32(math.Inf(1))
+	testCases := []struct {
+		file    string
+		descr   string
+		fortran bool
+		shape   []int
+		want    []float32
+		read    func(io.Reader) ([]float32, *npy.Header, error)
+	}{
+		{"f16.npy", "<f2", false, []int{2, 3}, []float32{0, 1, -2, 0.5, 65504, inf}, readAs[floatx.Float16]},
+		{"f16_be.npy", ">f2", false, []int{3}, []float32{1, -0.5, 0x1p-24}, readAs[floatx.Float16]},
+		{"f16_fortran.npy", "<f2", true, []int{2, 2}, []float32{1, 3, 2, 4}, readAs[floatx.Float16]},
+		{"f16_scalar.npy", "<f2", false, []int{}, []float32{1.5}, readAs[floatx.Float16]},
+		{"f16_v2.npy", "<f2", false, []int{2}, []float32{-1, 2}, readAs[floatx.Float16]},
+		{"bf16.npy", "bfloat16", false, []int{4}, []float32{1, -3.140625, 0x1p100, -inf}, readAs[floatx.BFloat16]},
+		{"f8.npy", "float8_e5m2", false, []int{2, 2}, []float32{1, -2, 57344, 0x1p-16}, readAs[floatx.Float8]},
+	}
+
+	for _, tc := range testCases {
+		for _, read := range []func(io.Reader) ([]float32, *npy.Header, error){tc.read, npy.ReadFloat32s} {
+			got, h, err := read(bytes.NewReader(readFixture(t, tc.file)))
+			if err != nil {
+				t.Errorf("%s: %v", tc.file, err)
+				continue
+			}
+			if h.Descr != tc.descr || h.FortranOrder != tc.fortran || !reflect.DeepEqual(h.Shape, tc.shape) {
+				t.Errorf("%s: header %+v, wanted %q %v %v", tc.file, h, tc.descr, tc.fortran, tc.shape)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("%s: data %v, wanted %v", tc.file, got, tc.want)
+			}
+		}
+	}
+
+	got, _, err := npy.ReadFloat32s(bytes.NewReader(readFixture(t, "f4.npy")))
+	if want := []float32{0.1, -2, 1e30}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("f4.npy: %v, %v, wanted %v", got, err, want)
+	}
+	e4m3 := header("{'descr': 'float8_e4m3fn', 'fortran_order': False, 'shape': (2,), }") + "\x7e\xb8"
+	for _, read := range []func(io.Reader) ([]float32, *npy.Header, error){readAs[floatx.Float8E4M3FN], npy.ReadFloat32s} {
+		got, _, err = read(strings.NewReader(e4m3))
+		if err != nil || !reflect.DeepEqual(got, []float32{448, -1}) {
+			t.Errorf("float8_e4m3fn: %v, %v, wanted [448 -1]", got, err)
+		}
+	}
+	got, _, err = npy.ReadFloat32s(strings.NewReader(header("{'descr': '>f4', 'fortran_order': False, 'shape': (1,), }") + "\xc0\x00\x00\x00"))
+	if err != nil || !reflect.DeepEqual(got, []float32{-2}) {
+		t.Errorf(">f4: %v, %v, wanted [-2]", got, err)
+	}
+}
+
+func TestReadVoid(t *testing.T) {
+	// the type passed to Read decides the format of a void dtype
+	testCases := []struct {
+		file string
+		want []float32
+		read func(io.Reader) ([]float32, *npy.Header, error)
+	}{
+		{"bf16_void.npy", []float32{1, -0.5}, readAs[floatx.BFloat16]},
+		{"bf16_void.npy", []float32{1.875, -1.75}, readAs[floatx.Float16]},
+		{"f8_void.npy", []float32{1, -2, 57344}, readAs[floatx.Float8]},
+		{"f8_void.npy", []float32{1.5, -2, 352}, readAs[floatx.Float8E4M3FN]},
+	}
+	for _, tc := range testCases {
+		got, _, err := tc.read(bytes.NewReader(readFixture(t, tc.file)))
+		if err != nil || !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: %v, %v, wanted %v", tc.file, got, err, tc.want)
+		}
+	}
+
+	got, _, err := readAs[floatx.BFloat16](strings.NewReader(header("{'descr': '>V2', 'fortran_order': False, 'shape': (1,), }") + "\xbf\x00"))
+	if err != nil || !reflect.DeepEqual(got, []float32{-0.5}) {
+		t.Errorf(">V2: %v, %v, wanted [-0.5]", got, err)
+	}
+
+	for _, file := range []string{"bf16_void.npy", "f8_void.npy"} {
+		if _, _, err := npy.ReadFloat32s(bytes.NewReader(readFixture(t, file))); !errors.Is(err, npy.ErrUnsupportedDtype) {
+			t.Errorf("ReadFloat32s(%s) returned %v, wanted ErrUnsupportedDtype", file, err)
+		}
+	}
+	if _, _, err := npy.Read[floatx.Float8](bytes.NewReader(readFixture(t, "bf16_void.npy"))); !errors.Is(err, npy.ErrUnsupportedDtype) {
+		t.Errorf("Read[Float8] of <V2 returned %v, wanted ErrUnsupportedDtype", err)
+	}
+}
+
+// readAs reads a T array and converts it to float32.
+func readAs[T floatx.AnyFloat](r io.Reader) ([]float32, *npy.Header, error) {
+	data, h, err := npy.Read[T](r)
+	f32s := make([]float32, len(data))
+	for i, f := range data {
+		f32s[i] = f.Float32()
+	}
+	return f32s, h, err
+}
+
+func TestWrite(t *testing.T) {
+	// Write produces the same bytes as NumPy
+	testCases := []struct {
+		file  string
+		write func(w io.Writer) error
+	}{
+		{"f16.npy", func(w io.Writer) error {
+			return npy.Write(w, f16s(0, 1, -2, 0.5, 65504, float32(math.Inf(1))), 2, 3)
+		}},
+		{"f16_scalar.npy", func(w io.Writer) error { return npy.Write(w, f16s(1.5), []int{}...) }},
+		{"bf16.npy", func(w io.Writer) error {
+			return npy.Write(w, []floatx.BFloat16{0x3f80, 0xc049, 0x7180, 0xff80})
+		}},
+		{"f8.npy", func(w io.Writer) error { return npy.Write(w, []floatx.Float8{0x3c, 0xc0, 0x7b, 0x01}, 2, 2) }},
+	}
+	for _, tc := range testCases {
+		var buf bytes.Buffer
+		if err := tc.write(&buf); err != nil {
+			t.Errorf("%s: %v", tc.file, err)
+		}
+		if want := readFixture(t, tc.file); !bytes.Equal(buf.Bytes(), want) {
+			t.Errorf("%s: wrote\n%q\nwanted\n%q", tc.file, buf.Bytes(), want)
+		}
+	}
+
+	if err := npy.Write(io.Discard, f16s(1, 2, 3), 2, 2); err != npy.ErrShape {
+		t.Errorf("Write with bad shape returned %v, wanted ErrShape", err)
+	}
+}
+
+func TestRoundTripE4M3(t *testing.T) {
+	data := make([]floatx.Float8E4M3FN, 256)
+	for i := range data {
+		data[i] = floatx.Float8E4M3FN(i)
+	}
+
+	var buf bytes.Buffer
+	if err := npy.Write(&buf, data, 16, 16); err != nil {
+		t.Fatal(err)
+	}
+	got, h, err := npy.Read[floatx.Float8E4M3FN](&buf)
+	if err != nil || !reflect.DeepEqual(got, data) {
+		t.Errorf("round trip failed: %v", err)
+	}
+	if h.Descr != "float8_e4m3fn" || !reflect.DeepEqual(h.Shape, []int{16, 16}) {
+		t.Errorf("header %+v, wanted float8_e4m3fn [16 16]", h)
+	}
+
+	// the two float8 formats aren't interchangeable
+	buf.Reset()
+	if err := npy.Write(&buf, data); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := npy.Read[floatx.Float8](&buf); !errors.Is(err, npy.ErrUnsupportedDtype) {
+		t.Errorf("Read[Float8] of float8_e4m3fn returned %v, wanted ErrUnsupportedDtype", err)
+	}
+}
+
+func TestReadConcatenated(t *testing.T) {
+	// Read stops at the end of the array, so the next file is intact
+	var buf bytes.Buffer
+	if err := npy.Write(&buf, f16s(1, 2, 3)); err != nil {
+		t.Fatal(err)
+	}
+	if err := npy.Write(&buf, []floatx.BFloat16{0x3f80}); err != nil {
+		t.Fatal(err)
+	}
+
+	r := errReader{&buf} // not an io.ByteReader
+	if got, _, err := npy.Read[floatx.Float16](r); err != nil || !reflect.DeepEqual(got, f16s(1, 2, 3)) {
+		t.Errorf("first Read = %v, %v", got, err)
+	}
+	if got, _, err := npy.ReadFloat32s(r); err != nil || !reflect.DeepEqual(got, []float32{1}) {
+		t.Errorf("second Read = %v, %v", got, err)
+	}
+}
+
+func f16s(f32s ...float32) []floatx.Float16 {
+	s := make([]floatx.Float16, len(f32s))
+	floatx.FromFloat32s(s, f32s)
+	return s
+}
+
+func TestWriteLarge(t *testing.T) {
+	// more than one write buffer, and a header that needs format 2.0
+	data := make([]floatx.Float16, 100000)
+	for i := range data {
+		data[i] = floatx.Float16(i)
+	}
+	shape := make([]int, 30000)
+	for i := range shape {
+		shape[i] = 1
+	}
+	shape[0] = len(data)
+
+	for _, s := range [][]int{nil, shape} {
+		var buf bytes.Buffer
+		if err := npy.Write(&buf, data, s...); err != nil {
+			t.Fatal(err)
+		}
+		if s != nil && buf.Bytes()[6] != 2 {
+			t.Errorf("Write with %d dimensions used format %d, wanted 2", len(s), buf.Bytes()[6])
+		}
+		if (buf.Len()-len(data)*2)%64 != 0 {
+			t.Errorf("header length %d isn't a multiple of 64", buf.Len()-len(data)*2)
+		}
+		got, h, err := npy.Read[floatx.Float16](&buf)
+		if err != nil || !reflect.DeepEqual(got, data) || h.Len() != len(data) {
+			t.Errorf("round trip failed: %v", err)
+		}
+	}
+}
+
+type errWriter struct{ n int }
+
+func (w *errWriter) Write(p []byte) (int, error) {
+	if w.n <= 0 {
+		return 0, errors.New("write failed")
+	}
+	w.n--
+	return len(p), nil
+}
+
+func TestWriteError(t *testing.T) {
+	data := make([]floatx.BFloat16, 100000)
+	for n := 0; n < 3; n++ {
+		if err := npy.Write(&errWriter{n}, data); err == nil {
+			t.Errorf("Write failing after %d writes returned nil", n)
+		}
+	}
+}
+
+func header(dict string) string {
+	return "\x93NUMPY\x01\x00" + string([]byte{byte(len(dict)), 0}) + dict
+}
+
+func TestReadErrors(t *testing.T) {
+	testCases := []struct {
+		name string
+		data string
+		err  error
+	}{
+		{"empty", "", io.EOF},
+		{"magic", "\x93NUMPZ\x01\x00\x00\x00", npy.ErrInvalidMagic},
+		{"version", "\x93NUMPY\x04\x00\x00\x00", npy.ErrUnsupportedVersion},
+		{"short v1 length", "\x93NUMPY\x01\x00\x00", io.ErrUnexpectedEOF},
+		{"short v2 length", "\x93NUMPY\x02\x00\x00", io.ErrUnexpectedEOF},
+		{"huge header", "\x93NUMPY\x03\x00\x00\x00\x00\x01", npy.ErrInvalidHeader},
+		{"short header", "\x93NUMPY\x01\x00\x10\x00{", io.ErrUnexpectedEOF},
+		{"not a dict", header("[]"), npy.ErrInvalidHeader},
+		{"bad key", header("{descr: '<f2'}"), npy.ErrInvalidHeader},
+		{"unknown key", header("{'dtype': '<f2'}"), npy.ErrInvalidHeader},
+		{"unterminated", header("{'descr': '<f2}"), npy.ErrInvalidHeader},
+		{"bad bool", header("{'fortran_order': 0}"), npy.ErrInvalidHeader},
+		{"bad tuple", header("{'shape': [1]}"), npy.ErrInvalidHeader},
+		{"bad dim", header("{'shape': (-1,)}"), npy.ErrInvalidHeader},
+		{"empty dim", header("{'shape': (,)}"), npy.ErrInvalidHeader},
+		{"missing comma", header("{'shape': (1 2)}"), npy.ErrInvalidHeader},
+		{"missing dict comma", header("{'shape': (1,) 'descr': '<f2'}"), npy.ErrInvalidHeader},
+		{"missing keys", header("{'shape': (1,)}"), npy.ErrInvalidHeader},
+		{"overflow", header("{'descr': '<f2', 'fortran_order': False, 'shape': (4294967296, 4294967296),}"), npy.ErrInvalidHeader},
+		{"dtype", header("{'descr': '<i4', 'fortran_order': False, 'shape': (1,)}"), npy.ErrUnsupportedDtype},
+		{"short data", header("{'descr': '<f2', 'fortran_order': False, 'shape': (2,)}") + "\x00\x3c\x00", io.ErrUnexpectedEOF},
+		{"no data", header("{'descr': '<f2', 'fortran_order': False, 'shape': (2,)}"), io.ErrUnexpectedEOF},
+		{"huge shape", header("{'descr': '<f2', 'fortran_order': False, 'shape': (1000000000,)}"), io.ErrUnexpectedEOF},
+	}
+	for _, tc := range testCases {
+		_, _, err := npy.Read[floatx.Float16](strings.NewReader(tc.data))
+		if !errors.Is(err, tc.err) {
+			t.Errorf("Read %s returned %v, wanted %v", tc.name, err, tc.err)
+		}
+		_, _, err = npy.ReadFloat32s(strings.NewReader(tc.data))
+		if !errors.Is(err, tc.err) {
+			t.Errorf("ReadFloat32s %s returned %v, wanted %v", tc.name, err, tc.err)
+		}
+	}
+
+	// a valid dtype that isn't the requested type
+	if _, _, err := npy.Read[floatx.BFloat16](bytes.NewReader(readFixture(t, "f16.npy"))); !errors.Is(err, npy.ErrUnsupportedDtype) {
+		t.Errorf("Read[BFloat16] of <f2 returned %v, wanted ErrUnsupportedDtype", err)
+	}
+}
+
+type errReader struct{ r io.Reader }
+
+func (r errReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if err == io.EOF {
+		err = errors.New("read failed")
+	}
+	return n, err
+}
+
+func TestReadError(t *testing.T) {
+	data := readFixture(t, "f16.npy")
+	_, _, err := npy.Read[floatx.Float16](errReader{bytes.NewReader(data[:len(data)-1])})
+	if err == nil || errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Read returned %v, wanted the reader's error", err)
+	}
+}
+
+func TestParseHeader(t *testing.T) {
+	// Python 2 style, double quotes, no trailing comma
+	dict := `{"descr": "<f2", "fortran_order": True, "shape": (2L, 1L)}`
+	h, err := npy.ReadHeader(strings.NewReader(header(dict)))
+	if err != nil || h.Descr != "<f2" || !h.FortranOrder || !reflect.DeepEqual(h.Shape, []int{2, 1}) {
+		t.Errorf("ReadHeader(%s) = %+v, %v", dict, h, err)
+	}
+}
@@ -0,0 +1,90 @@
+package npy
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"strings"
+
+	floatx "github.com/chenxingqiang/go-floatx"
+)
+
+// ErrNotFound is returned for an array name that isn't in an .npz archive.
+const ErrNotFound = npyError("npy: array not found in archive")
+
+// NPZ is an .npz archive opened for reading: a zip archive of .npy files,
+// as written by numpy.savez and numpy.savez_compressed.
+type NPZ struct {
+	zr *zip.Reader
+}
+
+// OpenNPZ opens the .npz archive of the given size read from r.
+func OpenNPZ(r io.ReaderAt, size int64) (*NPZ, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	return &NPZ{zr: zr}, nil
+}
+
+// Names returns the names of the arrays in z, without the .npy suffix, in
+// archive order.
+func (z *NPZ) Names() []string {
+	names := make([]string, 0, len(z.zr.File))
+	for _, f := range z.zr.File {
+		names = append(names, strings.TrimSuffix(f.Name, ".npy"))
+	}
+	return names
+}
+
+// Open returns a reader for the .npy file of the named array, for use with
+// ReadHeader, Read or ReadFloat32s. The caller must close it.
+func (z *NPZ) Open(name string) (io.ReadCloser, error) {
+	for _, f := range z.zr.File {
+		if f.Name == name+".npy" || f.Name == name {
+			return f.Open()
+		}
+	}
+	return nil, fmt.Errorf("%w: %q", ErrNotFound, name)
+}
+
+// ReadNPZ reads the named array of z into a slice of T, see Read.
+func ReadNPZ[T floatx.AnyFloat](z *NPZ, name string) ([]T, *Header, error) {
+	rc, err := z.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rc.Close()
+	return Read[T](rc)
+}
+
+// NPZWriter writes an .npz archive.
+type NPZWriter struct {
+	zw       *zip.Writer
+	compress bool
+}
+
+// NewNPZWriter returns an NPZWriter writing to w. Like numpy.savez, arrays
+// are stored uncompressed unless compress is true, which matches
+// numpy.savez_compressed.
+func NewNPZWriter(w io.Writer, compress bool) *NPZWriter {
+	return &NPZWriter{zw: zip.NewWriter(w), compress: compress}
+}
+
+// WriteNPZ adds data to z as the array name, see Write.
+func WriteNPZ[T floatx.AnyFloat](z *NPZWriter, name string, data []T, shape ...int) error {
+	method := zip.Store
+	if z.compress {
+		method = zip.Deflate
+	}
+	w, err := z.zw.CreateHeader(&zip.FileHeader{Name: name + ".npy", Method: method})
+	if err != nil {
+		return err
+	}
+	return Write(w, data, shape...)
+}
+
+// Close finishes writing the archive. It doesn't close the underlying writer.
+func (z *NPZWriter) Close() error {
+	return z.zw.Close()
+}
@@ -0,0 +1,81 @@
+package npy_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	floatx "github.com/chenxingqiang/go-floatx"
+	"github.com/chenxingqiang/go-floatx/npy"
+)
+
+func TestReadNPZ(t *testing.T) {
+	for _, file := range []string{"arrays.npz", "arrays_compressed.npz"} {
+		b := readFixture(t, file)
+		z, err := npy.OpenNPZ(bytes.NewReader(b), int64(len(b)))
+		if err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		if names := z.Names(); !reflect.DeepEqual(names, []string{"weights", "bias"}) {
+			t.Errorf("%s: Names() = %v", file, names)
+		}
+
+		w, h, err := npy.ReadNPZ[floatx.Float16](z, "weights")
+		if err != nil || !reflect.DeepEqual(h.Shape, []int{2, 3}) || w[4].Float32() != 65504 {
+			t.Errorf("%s: weights = %v, %+v, %v", file, w, h, err)
+		}
+		bias, _, err := npy.ReadNPZ[floatx.BFloat16](z, "bias.npy")
+		if err != nil || len(bias) != 2 || bias[0].Float32() != 0.5 || bias[1].Float32() != -1 {
+			t.Errorf("%s: bias = %v, %v", file, bias, err)
+		}
+		if _, _, err := npy.ReadNPZ[floatx.Float16](z, "missing"); !errors.Is(err, npy.ErrNotFound) {
+			t.Errorf("%s: ReadNPZ(missing) returned %v, wanted ErrNotFound", file, err)
+		}
+	}
+
+	if _, err := npy.OpenNPZ(bytes.NewReader([]byte("not a zip")), 9); err == nil {
+		t.Error("OpenNPZ of garbage returned nil error")
+	}
+}
+
+func TestWriteNPZ(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		var buf bytes.Buffer
+		z := npy.NewNPZWriter(&buf, compress)
+		if err := npy.WriteNPZ(z, "weights", f16s(0, 1, -2, 0.5, 65504, 1), 2, 3); err != nil {
+			t.Fatal(err)
+		}
+		if err := npy.WriteNPZ(z, "f8", []floatx.Float8{0x3c}); err != nil {
+			t.Fatal(err)
+		}
+		if err := z.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		r, err := npy.OpenNPZ(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rc, err := r.Open("f8")
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, _, err := npy.ReadFloat32s(rc)
+		rc.Close()
+		if err != nil || !reflect.DeepEqual(got, []float32{1}) {
+			t.Errorf("f8 = %v, %v", got, err)
+		}
+		w, _, err := npy.ReadNPZ[floatx.Float16](r, "weights")
+		if err != nil || len(w) != 6 {
+			t.Errorf("weights = %v, %v", w, err)
+		}
+	}
+
+	z := npy.NewNPZWriter(io.Discard, false)
+	if err := npy.WriteNPZ(z, strings.Repeat("x", 1<<16), f16s(1)); err == nil {
+		t.Error("WriteNPZ with a name too long for zip returned nil error")
+	}
+}
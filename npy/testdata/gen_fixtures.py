@@ -0,0 +1,59 @@
+#!/usr/bin/env python3
+"""Writes the .npy and .npz test fixtures.
+
+The files use the layout numpy.save and numpy.savez write (format 1.0 with
+headers padded to 64 bytes), but this script only needs the standard
+library and none of the files were written by NumPy. Run it from this
+directory.
+
+For ml_dtypes arrays, NumPy writes void descrs ('<V2' for bfloat16, '|V1'
+for the float8 types) rather than the dtype names. bf16.npy and f8.npy use
+the names, as Write does; bf16_void.npy and f8_void.npy use the void
+descrs.
+"""
+import struct
+import zipfile
+
+
+def npy(descr, shape, data, fortran=False, version=1):
+    shape_repr = "(%s,)" % shape[0] if len(shape) == 1 else "(%s)" % ", ".join(map(str, shape))
+    header = "{'descr': '%s', 'fortran_order': %s, 'shape': %s, }" % (descr, fortran, shape_repr)
+    pre = b"\x93NUMPY" + bytes([version, 0])
+    len_size = 2 if version == 1 else 4
+    pad = (64 - (len(pre) + len_size + len(header) + 1) % 64) % 64
+    header = header + " " * pad + "\n"
+    length = struct.pack("<H" if version == 1 else "<I", len(header))
+    return pre + length + header.encode("latin1") + data
+
+
+def f16(order, values):
+    return b"".join(struct.pack(order + "e", v) for v in values)
+
+
+def bf16(values):
+    return b"".join(struct.pack("<H", struct.unpack("<I", struct.pack("<f", v))[0] >> 16) for v in values)
+
+
+inf = float("inf")
+files = {
+    "f16.npy": npy("<f2", (2, 3), f16("<", [0, 1, -2, 0.5, 65504, inf])),
+    "f16_be.npy": npy(">f2", (3,), f16(">", [1, -0.5, 2**-24])),
+    "f16_fortran.npy": npy("<f2", (2, 2), f16("<", [1, 3, 2, 4]), fortran=True),
+    "f16_scalar.npy": npy("<f2", (), f16("<", [1.5])),
+    "f16_v2.npy": npy("<f2", (2,), f16("<", [-1, 2]), version=2),
+    "bf16.npy": npy("bfloat16", (4,), bf16([1, -3.140625, 2**100, -inf])),
+    "f8.npy": npy("float8_e5m2", (2, 2), bytes([0x3C, 0xC0, 0x7B, 0x01])),
+    "bf16_void.npy": npy("<V2", (2,), bf16([1, -0.5])),
+    "f8_void.npy": npy("|V1", (3,), bytes([0x3C, 0xC0, 0x7B])),
+    "f4.npy": npy("<f4", (3,), struct.pack("<3f", 0.1, -2, 1e30)),
+}
+
+for name, data in files.items():
+    with open(name, "wb") as f:
+        f.write(data)
+
+for name, method in [("arrays.npz", zipfile.ZIP_STORED), ("arrays_compressed.npz", zipfile.ZIP_DEFLATED)]:
+    with zipfile.ZipFile(name, "w", method) as z:
+        # fixed timestamps keep the archives reproducible
+        z.writestr(zipfile.ZipInfo("weights.npy", (1980, 1, 1, 0, 0, 0)), files["f16.npy"], method)
+        z.writestr(zipfile.ZipInfo("bias.npy", (1980, 1, 1, 0, 0, 0)), npy("bfloat16", (2,), bf16([0.5, -1])), method)
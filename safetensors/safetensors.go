@@ -0,0 +1,334 @@
+// Package safetensors reads and writes safetensors files, the tensor format
+// of Hugging Face checkpoints, with F16, BF16, F8_E4M3 and F8_E5M2 tensors.
+//
+// A safetensors file is an 8-byte little-endian header length, a JSON header
+// describing each tensor's dtype, shape and byte range, and the tensor data
+// in little-endian byte order. Open memory-maps the file where the platform
+// supports it, and View returns tensors as slices of this package's types
+// without copying when the host is little-endian and the data is aligned.
+// Float32s converts a tensor to float32 only when it's called.
+//
+// Files with other dtypes can be opened, and their raw bytes are available
+// from Tensor.Bytes, but they can't be viewed or converted.
+package safetensors
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"unsafe"
+
+	floatx "github.com/chenxingqiang/go-floatx"
+)
+
+type safetensorsError string
+
+func (e safetensorsError) Error() string { return string(e) }
+
+const (
+	// ErrInvalidHeader is returned for a file whose header is malformed or
+	// doesn't describe its data.
+	ErrInvalidHeader = safetensorsError("safetensors: invalid header")
+
+	// ErrUnsupportedDtype is returned when a tensor's dtype isn't the one
+	// requested, or can't be converted to float32.
+	ErrUnsupportedDtype = safetensorsError("safetensors: unsupported dtype")
+
+	// ErrNotFound is returned for a tensor name that isn't in the file.
+	ErrNotFound = safetensorsError("safetensors: tensor not found")
+
+	// ErrShape is returned when the shape doesn't match the length of the data.
+	ErrShape = safetensorsError("safetensors: shape doesn't match data length")
+)
+
+// Dtype is the data type of a tensor, as named in the header.
+type Dtype string
+
+// Dtypes supported by View and Float32s.
+const (
+	F16    Dtype = "F16"
+	BF16   Dtype = "BF16"
+	F8E4M3 Dtype = "F8_E4M3"
+	F8E5M2 Dtype = "F8_E5M2"
+	F32    Dtype = "F32" // Float32s only
+)
+
+// dtypeSizes has the element size of every dtype in the specification.
+var dtypeSizes = map[Dtype]int{
+	"BOOL": 1, "U8": 1, "I8": 1, F8E5M2: 1, F8E4M3: 1,
+	"I16": 2, "U16": 2, F16: 2, BF16: 2,
+	"I32": 4, "U32": 4, F32: 4,
+	"I64": 8, "U64": 8, "F64": 8,
+}
+
+// maxHeaderSize is the largest header accepted, the same limit as the
+// reference implementation.
+const maxHeaderSize = 100 << 20
+
+// Element is a constraint that permits the types a tensor can be viewed as.
+type Element interface {
+	floatx.Float16 | floatx.BFloat16 | floatx.Float8 | floatx.Float8E4M3FN
+}
+
+// dtypeOf returns the dtype of T.
+func dtypeOf[T Element]() Dtype {
+	var f T
+	switch any(f).(type) {
+	case floatx.BFloat16:
+		return BF16
+	case floatx.Float8:
+		return F8E5M2
+	case floatx.Float8E4M3FN:
+		return F8E4M3
+	}
+	return F16
+}
+
+// Tensor is a tensor of a safetensors file.
+type Tensor struct {
+	Dtype Dtype
+	Shape []int // empty for a scalar
+	data  []byte
+}
+
+// Bytes returns the data of t in little-endian byte order. For a tensor of
+// an opened file it is the file's memory, which must not be modified.
+func (t *Tensor) Bytes() []byte {
+	return t.data
+}
+
+// Len returns the number of elements in t, or 0 if t.Dtype isn't in the
+// specification.
+func (t *Tensor) Len() int {
+	size, ok := dtypeSizes[t.Dtype]
+	if !ok {
+		return 0
+	}
+	return len(t.data) / size
+}
+
+// nativeLittleEndian reports whether the host stores integers in
+// little-endian byte order, so tensor data can be used in place.
+var nativeLittleEndian = func() bool {
+	x := uint16(1)
+	return *(*byte)(unsafe.Pointer(&x)) == 1
+}()
+
+// View returns the data of t as a slice of T, which must match the dtype of
+// t. The slice shares the tensor's memory when the host is little-endian and
+// the data is aligned for T, otherwise it's a copy. Either way it must not be
+// modified, and for an opened file it can't be used after File.Close.
+func View[T Element](t *Tensor) ([]T, error) {
+	if t.Dtype != dtypeOf[T]() {
+		return nil, fmt.Errorf("%w %s for %T", ErrUnsupportedDtype, t.Dtype, T(0))
+	}
+	var f T
+	size := int(unsafe.Sizeof(f))
+	n := len(t.data) / size
+	if n == 0 {
+		return []T{}, nil
+	}
+	p := unsafe.Pointer(&t.data[0])
+	if size == 1 || nativeLittleEndian && uintptr(p)%uintptr(size) == 0 {
+		return unsafe.Slice((*T)(p), n), nil
+	}
+	s := make([]T, n)
+	for i := range s {
+		s[i] = T(binary.LittleEndian.Uint16(t.data[2*i:]))
+	}
+	return s, nil
+}
+
+// Float32s returns the data of t converted to float32. The dtype of t must be
+// F16, BF16, F8_E4M3, F8_E5M2 or F32.
+func (t *Tensor) Float32s() ([]float32, error) {
+	switch t.Dtype {
+	case F16:
+		return toFloat32s[floatx.Float16](t), nil
+	case BF16:
+		return toFloat32s[floatx.BFloat16](t), nil
+	case F8E5M2:
+		return toFloat32s[floatx.Float8](t), nil
+	case F8E4M3:
+		s, _ := View[floatx.Float8E4M3FN](t)
+		f32s := make([]float32, len(s))
+		floatx.F8E4M3ToFloat32s(f32s, s)
+		return f32s, nil
+	case F32:
+		f32s := make([]float32, t.Len())
+		for i := range f32s {
+			f32s[i] = math.Float32frombits(binary.LittleEndian.Uint32(t.data[4*i:]))
+		}
+		return f32s, nil
+	}
+	return nil, fmt.Errorf("%w %s", ErrUnsupportedDtype, t.Dtype)
+}
+
+// toFloat32s converts t, which has the dtype of T, to float32.
+func toFloat32s[T floatx.SmallFloat](t *Tensor) []float32 {
+	s, _ := View[T](t)
+	f32s := make([]float32, len(s))
+	floatx.ToFloat32s(f32s, s)
+	return f32s
+}
+
+// File is a safetensors file.
+type File struct {
+	Metadata map[string]string // the optional __metadata__ of the header
+
+	names   []string // in data order
+	tensors map[string]*Tensor
+	close   func() error
+}
+
+// Open opens and memory-maps the named safetensors file. Platforms without
+// memory mapping read the whole file instead. The file must be closed with
+// Close when its tensors are no longer used.
+func Open(name string) (*File, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := fi.Size()
+	if size < 8 || int64(int(size)) != size {
+		return nil, fmt.Errorf("%w: file size %d", ErrInvalidHeader, size)
+	}
+	data, unmap, err := mmap(f, int(size))
+	if err != nil {
+		return nil, err
+	}
+	sf, err := Parse(data)
+	if err != nil {
+		unmap()
+		return nil, err
+	}
+	sf.close = unmap
+	return sf, nil
+}
+
+// Close releases the memory of a file opened with Open. It does nothing for
+// a file from Parse.
+func (f *File) Close() error {
+	if f.close == nil {
+		return nil
+	}
+	err := f.close()
+	f.close = nil
+	return err
+}
+
+// tensorInfo is a tensor entry of the header.
+type tensorInfo struct {
+	Dtype       Dtype    `json:"dtype"`
+	Shape       []int    `json:"shape"`
+	DataOffsets [2]int64 `json:"data_offsets"`
+}
+
+// Parse parses a safetensors file held in data. The tensors share the
+// memory of data.
+func Parse(data []byte) (*File, error) {
+	if len(data) < 8 {
+		return nil, fmt.Errorf("%w: file too short", ErrInvalidHeader)
+	}
+	n := binary.LittleEndian.Uint64(data)
+	if n > maxHeaderSize || n > uint64(len(data)-8) {
+		return nil, fmt.Errorf("%w: header length %d", ErrInvalidHeader, n)
+	}
+	header := data[8 : 8+n]
+	buf := data[8+n:]
+	if len(header) == 0 || header[0] != '{' {
+		return nil, fmt.Errorf("%w: header isn't a JSON object", ErrInvalidHeader)
+	}
+
+	var entries map[string]json.RawMessage
+	if err := json.Unmarshal(header, &entries); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidHeader, err)
+	}
+
+	f := &File{tensors: make(map[string]*Tensor, len(entries))}
+	offsets := make(map[string]int64, len(entries))
+	for name, raw := range entries {
+		if name == "__metadata__" {
+			if err := json.Unmarshal(raw, &f.Metadata); err != nil {
+				return nil, fmt.Errorf("%w: __metadata__: %v", ErrInvalidHeader, err)
+			}
+			continue
+		}
+
+		var info tensorInfo
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&info); err != nil {
+			return nil, fmt.Errorf("%w: tensor %q: %v", ErrInvalidHeader, name, err)
+		}
+		size, ok := dtypeSizes[info.Dtype]
+		if !ok {
+			return nil, fmt.Errorf("%w: tensor %q: unknown dtype %q", ErrInvalidHeader, name, info.Dtype)
+		}
+		count, ok := elements(info.Shape)
+		begin, end := info.DataOffsets[0], info.DataOffsets[1]
+		if info.Shape == nil || !ok || begin < 0 || begin > end || end > int64(len(buf)) || end-begin != int64(count)*int64(size) {
+			return nil, fmt.Errorf("%w: tensor %q: shape %v doesn't match data offsets %v",
+				ErrInvalidHeader, name, info.Shape, info.DataOffsets)
+		}
+		f.tensors[name] = &Tensor{Dtype: info.Dtype, Shape: info.Shape, data: buf[begin:end:end]}
+		f.names = append(f.names, name)
+		offsets[name] = begin
+	}
+
+	// like the reference implementation, the tensors must fill the data
+	// without gaps or overlaps
+	sort.Slice(f.names, func(i, j int) bool {
+		oi, oj := offsets[f.names[i]], offsets[f.names[j]]
+		li, lj := len(f.tensors[f.names[i]].data), len(f.tensors[f.names[j]].data)
+		return oi < oj || oi == oj && (li < lj || li == lj && f.names[i] < f.names[j])
+	})
+	next := int64(0)
+	for _, name := range f.names {
+		if offsets[name] != next {
+			return nil, fmt.Errorf("%w: tensor %q: data offsets aren't contiguous", ErrInvalidHeader, name)
+		}
+		next += int64(len(f.tensors[name].data))
+	}
+	if next != int64(len(buf)) {
+		return nil, fmt.Errorf("%w: %d bytes of data after the last tensor", ErrInvalidHeader, int64(len(buf))-next)
+	}
+	return f, nil
+}
+
+// elements returns the number of elements of shape, and false if a
+// dimension is negative or the count overflows.
+func elements(shape []int) (int, bool) {
+	n := 1
+	for _, d := range shape {
+		if d < 0 || d > 0 && n > math.MaxInt/8/d {
+			return 0, false
+		}
+		n *= d
+	}
+	return n, true
+}
+
+// Names returns the names of the tensors in f in data order.
+func (f *File) Names() []string {
+	return append([]string(nil), f.names...)
+}
+
+// Tensor returns the named tensor of f.
+func (f *File) Tensor(name string) (*Tensor, error) {
+	t, ok := f.tensors[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrNotFound, name)
+	}
+	return t, nil
+}
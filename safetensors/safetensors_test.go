@@ -0,0 +1,322 @@
+package safetensors_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	floatx "github.com/chenxingqiang/go-floatx"
+	"github.com/chenxingqiang/go-floatx/safetensors"
+)
+
+// testdata/model.safetensors is written by testdata/gen_fixtures.py.
+const model = "testdata/model.safetensors"
+
+func TestOpen(t *testing.T) {
+	f, err := safetensors.Open(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	checkModel(t, f)
+
+	if err := f.Close(); err != nil {
+		t.Errorf("Close() = %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Errorf("second Close() = %v", err)
+	}
+}
+
+func checkModel(t *testing.T, f *safetensors.File) {
+	t.Helper()
+	if want := []string{"norm", "step", "bias", "weight", "grad", "scale"}; !reflect.DeepEqual(f.Names(), want) {
+		t.Errorf("Names() = %v, wanted %v", f.Names(), want)
+	}
+	if !reflect.DeepEqual(f.Metadata, map[string]string{"format": "pt"}) {
+		t.Errorf("Metadata = %v", f.Metadata)
+	}
+
+	inf := float32(math.Inf(1))
+	testCases := []struct {
+		name  string
+		dtype safetensors.Dtype
+		shape []int
+		want  []float32
+	}{
+		{"weight", safetensors.F16, []int{2, 3}, []float32{0, 1, -2, 0.5, 65504, inf}},
+		{"bias", safetensors.BF16, []int{2}, []float32{0.5, -1}},
+		{"scale", safetensors.F8E4M3, []int{4}, []float32{1, -2, 448, 0x1p-9}},
+		{"grad", safetensors.F8E5M2, []int{2, 2}, []float32{1, -2, 57344, 0x1p-16}},
+		{"norm", safetensors.F32, []int{2}, []float32{0.1, -2}},
+	}
+	for _, tc := range testCases {
+		tensor, err := f.Tensor(tc.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tensor.Dtype != tc.dtype || !reflect.DeepEqual(tensor.Shape, tc.shape) || tensor.Len() != len(tc.want) {
+			t.Errorf("%s: dtype %s, shape %v, len %d", tc.name, tensor.Dtype, tensor.Shape, tensor.Len())
+		}
+		got, err := tensor.Float32s()
+		if err != nil || !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: Float32s() = %v, %v, wanted %v", tc.name, got, err, tc.want)
+		}
+	}
+
+	w, err := tensorView[floatx.Float16](f, "weight")
+	if err != nil || len(w) != 6 || w[4] != 0x7bff {
+		t.Errorf("View weight = %v, %v", w, err)
+	}
+	b, err := tensorView[floatx.BFloat16](f, "bias")
+	if err != nil || !reflect.DeepEqual(b, []floatx.BFloat16{0x3f00, 0xbf80}) {
+		t.Errorf("View bias = %v, %v", b, err)
+	}
+	s, err := tensorView[floatx.Float8E4M3FN](f, "scale")
+	if err != nil || !reflect.DeepEqual(s, []floatx.Float8E4M3FN{0x38, 0xc0, 0x7e, 0x01}) {
+		t.Errorf("View scale = %v, %v", s, err)
+	}
+	g, err := tensorView[floatx.Float8](f, "grad")
+	if err != nil || !reflect.DeepEqual(g, []floatx.Float8{0x3c, 0xc0, 0x7b, 0x01}) {
+		t.Errorf("View grad = %v, %v", g, err)
+	}
+
+	step, _ := f.Tensor("step")
+	if !reflect.DeepEqual(step.Shape, []int{}) || binary.LittleEndian.Uint32(step.Bytes()) != 7 {
+		t.Errorf("step = %v %v", step.Shape, step.Bytes())
+	}
+	if _, err := step.Float32s(); !errors.Is(err, safetensors.ErrUnsupportedDtype) {
+		t.Errorf("Float32s() of I32 returned %v, wanted ErrUnsupportedDtype", err)
+	}
+	if _, err := tensorView[floatx.BFloat16](f, "weight"); !errors.Is(err, safetensors.ErrUnsupportedDtype) {
+		t.Errorf("View[BFloat16] of F16 returned %v, wanted ErrUnsupportedDtype", err)
+	}
+	if _, err := f.Tensor("missing"); !errors.Is(err, safetensors.ErrNotFound) {
+		t.Errorf("Tensor(missing) returned %v, wanted ErrNotFound", err)
+	}
+}
+
+func tensorView[T safetensors.Element](f *safetensors.File, name string) ([]T, error) {
+	t, err := f.Tensor(name)
+	if err != nil {
+		return nil, err
+	}
+	return safetensors.View[T](t)
+}
+
+func TestParseUnaligned(t *testing.T) {
+	// one more byte of header makes the F16 and BF16 data unaligned, so
+	// View copies it
+	data, err := os.ReadFile(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := binary.LittleEndian.Uint64(data)
+	b := binary.LittleEndian.AppendUint64(nil, n+1)
+	b = append(b, data[8:8+n]...)
+	b = append(b, ' ')
+	b = append(b, data[8+n:]...)
+
+	f, err := safetensors.Parse(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkModel(t, f)
+	if err := f.Close(); err != nil {
+		t.Errorf("Close() = %v", err)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	// rewriting the tensors of the fixture reproduces it
+	want, err := os.ReadFile(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := safetensors.Parse(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tensors := make(map[string]*safetensors.Tensor)
+	for _, name := range f.Names() {
+		tensors[name], _ = f.Tensor(name)
+	}
+	var buf bytes.Buffer
+	if err := safetensors.Write(&buf, tensors, f.Metadata); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("Write wrote\n%q\nwanted\n%q", buf.Bytes(), want)
+	}
+}
+
+func TestWriteOpen(t *testing.T) {
+	f16s := make([]floatx.Float16, 6)
+	floatx.FromFloat32s(f16s, []float32{1, 2, 3, 4, 5, 6})
+	weight, err := safetensors.NewTensor(f16s, 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scale, err := safetensors.NewTensor([]floatx.Float8E4M3FN{floatx.F8E4M3Fromfloat32(0.5)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	empty, err := safetensors.NewTensor([]floatx.BFloat16{}, 0, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "w.safetensors")
+	out, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tensors := map[string]*safetensors.Tensor{"w": weight, "s": scale, "e": empty}
+	if err := safetensors.Write(out, tensors, nil); err != nil {
+		t.Fatal(err)
+	}
+	out.Close()
+
+	f, err := safetensors.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if f.Metadata != nil || !reflect.DeepEqual(f.Names(), []string{"e", "w", "s"}) {
+		t.Errorf("Metadata %v, Names() %v", f.Metadata, f.Names())
+	}
+	w, err := tensorView[floatx.Float16](f, "w")
+	if err != nil || !reflect.DeepEqual(w, f16s) {
+		t.Errorf("View w = %v, %v", w, err)
+	}
+	s, err := tensorView[floatx.Float8E4M3FN](f, "s")
+	if err != nil || len(s) != 1 || s[0].Float32() != 0.5 {
+		t.Errorf("View s = %v, %v", s, err)
+	}
+	e, err := tensorView[floatx.BFloat16](f, "e")
+	if err != nil || e == nil || len(e) != 0 {
+		t.Errorf("View e = %v, %v", e, err)
+	}
+	et, _ := f.Tensor("e")
+	if !reflect.DeepEqual(et.Shape, []int{0, 4}) {
+		t.Errorf("e shape = %v", et.Shape)
+	}
+}
+
+type errWriter struct{ n int }
+
+func (w *errWriter) Write(p []byte) (int, error) {
+	if w.n <= 0 {
+		return 0, errors.New("write failed")
+	}
+	w.n--
+	return len(p), nil
+}
+
+func TestWriteErrors(t *testing.T) {
+	if _, err := safetensors.NewTensor([]floatx.Float8{1, 2}, 3); err != safetensors.ErrShape {
+		t.Errorf("NewTensor with bad shape returned %v, wanted ErrShape", err)
+	}
+	if _, err := safetensors.NewTensor([]floatx.Float8{1, 2}, -1, -2); err != safetensors.ErrShape {
+		t.Errorf("NewTensor with negative shape returned %v, wanted ErrShape", err)
+	}
+
+	good, _ := safetensors.NewTensor([]floatx.Float16{1, 2})
+	bad, _ := safetensors.NewTensor([]floatx.Float16{1, 2})
+	bad.Shape = []int{3}
+	unknown, _ := safetensors.NewTensor([]floatx.Float16{1, 2})
+	unknown.Dtype = "C64"
+	for _, tensor := range []*safetensors.Tensor{unknown, {}} {
+		if n := tensor.Len(); n != 0 {
+			t.Errorf("Len of dtype %q returned %d, wanted 0", tensor.Dtype, n)
+		}
+	}
+	testCases := []struct {
+		tensors map[string]*safetensors.Tensor
+		err     error
+	}{
+		{map[string]*safetensors.Tensor{"__metadata__": good}, safetensors.ErrInvalidHeader},
+		{map[string]*safetensors.Tensor{"bad": bad}, safetensors.ErrShape},
+		{map[string]*safetensors.Tensor{"unknown": unknown}, safetensors.ErrUnsupportedDtype},
+	}
+	for _, tc := range testCases {
+		if err := safetensors.Write(&bytes.Buffer{}, tc.tensors, nil); !errors.Is(err, tc.err) {
+			t.Errorf("Write(%v) returned %v, wanted %v", tc.tensors, err, tc.err)
+		}
+	}
+
+	for n := 0; n < 2; n++ {
+		if err := safetensors.Write(&errWriter{n}, map[string]*safetensors.Tensor{"a": good}, nil); err == nil {
+			t.Errorf("Write failing after %d writes returned nil", n)
+		}
+	}
+}
+
+func file(header string, data string) []byte {
+	b := binary.LittleEndian.AppendUint64(nil, uint64(len(header)))
+	return append(append(b, header...), data...)
+}
+
+func TestParseErrors(t *testing.T) {
+	testCases := []struct {
+		name string
+		data []byte
+	}{
+		{"short", []byte{1, 0, 0}},
+		{"huge header", binary.LittleEndian.AppendUint64(nil, 1<<40)},
+		{"truncated header", file(`{}`, "")[:9]},
+		{"empty header", file(``, "")},
+		{"not an object", file(`[]`, "")},
+		{"bad json", file(`{"a":`, "")},
+		{"bad metadata", file(`{"__metadata__":{"a":1}}`, "")},
+		{"bad tensor", file(`{"a":[]}`, "")},
+		{"unknown field", file(`{"a":{"dtype":"F16","shape":[1],"data_offsets":[0,2],"x":1}}`, "\x00\x3c")},
+		{"unknown dtype", file(`{"a":{"dtype":"F17","shape":[1],"data_offsets":[0,2]}}`, "\x00\x3c")},
+		{"missing shape", file(`{"a":{"dtype":"F16","data_offsets":[0,2]}}`, "\x00\x3c")},
+		{"negative dim", file(`{"a":{"dtype":"F16","shape":[-1],"data_offsets":[0,2]}}`, "\x00\x3c")},
+		{"huge shape", file(`{"a":{"dtype":"F16","shape":[4294967296,4294967296],"data_offsets":[0,2]}}`, "\x00\x3c")},
+		{"wrong size", file(`{"a":{"dtype":"F16","shape":[2],"data_offsets":[0,2]}}`, "\x00\x3c")},
+		{"out of range", file(`{"a":{"dtype":"F16","shape":[1],"data_offsets":[2,4]}}`, "\x00\x3c")},
+		{"negative offset", file(`{"a":{"dtype":"F16","shape":[1],"data_offsets":[-2,0]}}`, "\x00\x3c")},
+		{"gap", file(`{"a":{"dtype":"U8","shape":[1],"data_offsets":[1,2]}}`, "\x00\x3c")},
+		{"overlap", file(`{"a":{"dtype":"U8","shape":[2],"data_offsets":[0,2]},"b":{"dtype":"U8","shape":[1],"data_offsets":[1,2]}}`, "\x00\x3c")},
+		{"trailing data", file(`{"a":{"dtype":"U8","shape":[1],"data_offsets":[0,1]}}`, "\x00\x3c")},
+	}
+	for _, tc := range testCases {
+		if _, err := safetensors.Parse(tc.data); !errors.Is(err, safetensors.ErrInvalidHeader) {
+			t.Errorf("Parse %s returned %v, wanted ErrInvalidHeader", tc.name, err)
+		}
+	}
+
+	// scalars and the same offsets for empty tensors are fine
+	f, err := safetensors.Parse(file(`{"a":{"dtype":"F16","shape":[],"data_offsets":[0,2]},"b":{"dtype":"F16","shape":[0],"data_offsets":[0,0]}}`, "\x00\x3c"))
+	if err != nil || !reflect.DeepEqual(f.Names(), []string{"b", "a"}) {
+		t.Errorf("Parse returned %v, %v", f, err)
+	}
+}
+
+func TestOpenErrors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := safetensors.Open(filepath.Join(dir, "missing")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Open of a missing file returned %v", err)
+	}
+	if _, err := safetensors.Open(dir); err == nil {
+		t.Error("Open of a directory returned nil error")
+	}
+
+	short := filepath.Join(dir, "short")
+	os.WriteFile(short, []byte{1}, 0o644)
+	if _, err := safetensors.Open(short); !errors.Is(err, safetensors.ErrInvalidHeader) {
+		t.Errorf("Open of a short file returned %v, wanted ErrInvalidHeader", err)
+	}
+	bad := filepath.Join(dir, "bad")
+	os.WriteFile(bad, file(`[]`, ""), 0o644)
+	if _, err := safetensors.Open(bad); !errors.Is(err, safetensors.ErrInvalidHeader) {
+		t.Errorf("Open of a bad file returned %v, wanted ErrInvalidHeader", err)
+	}
+}
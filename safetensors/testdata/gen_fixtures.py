@@ -0,0 +1,44 @@
+#!/usr/bin/env python3
+"""Writes the safetensors test fixtures.
+
+Only the standard library is needed. The layout follows the reference
+implementation: __metadata__ first, tensors ordered by decreasing element
+size and then by name, and the header padded with spaces to a multiple of 8.
+"""
+
+import json
+import struct
+
+SIZES = {"F32": 4, "I32": 4, "F16": 2, "BF16": 2, "F8_E4M3": 1, "F8_E5M2": 1}
+
+
+def write(path, tensors, metadata=None):
+    order = sorted(tensors, key=lambda n: (-SIZES[tensors[n][0]], n))
+    header = {}
+    if metadata:
+        header["__metadata__"] = metadata
+    offset = 0
+    data = b""
+    for name in order:
+        dtype, shape, raw = tensors[name]
+        header[name] = {"dtype": dtype, "shape": shape, "data_offsets": [offset, offset + len(raw)]}
+        offset += len(raw)
+        data += raw
+    h = json.dumps(header, separators=(",", ":")).encode()
+    h += b" " * (-len(h) % 8)
+    with open(path, "wb") as f:
+        f.write(struct.pack("<Q", len(h)) + h + data)
+
+
+write(
+    "model.safetensors",
+    {
+        "weight": ("F16", [2, 3], struct.pack("<6e", 0, 1, -2, 0.5, 65504, float("inf"))),
+        "bias": ("BF16", [2], bytes.fromhex("003f80bf")),
+        "scale": ("F8_E4M3", [4], bytes.fromhex("38c07e01")),
+        "grad": ("F8_E5M2", [2, 2], bytes.fromhex("3cc07b01")),
+        "norm": ("F32", [2], struct.pack("<2f", 0.1, -2)),
+        "step": ("I32", [], struct.pack("<i", 7)),
+    },
+    {"format": "pt"},
+)
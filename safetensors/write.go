@@ -0,0 +1,108 @@
+package safetensors
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unsafe"
+)
+
+// NewTensor returns a tensor holding a little-endian copy of data. The shape
+// defaults to one dimension of len(data); otherwise its product must be
+// len(data).
+func NewTensor[T Element](data []T, shape ...int) (*Tensor, error) {
+	if shape == nil {
+		shape = []int{len(data)}
+	}
+	if n, ok := elements(shape); !ok || n != len(data) {
+		return nil, ErrShape
+	}
+
+	var f T
+	size := int(unsafe.Sizeof(f))
+	b := make([]byte, 0, size*len(data))
+	for _, v := range data {
+		if size == 1 {
+			b = append(b, uint8(v))
+		} else {
+			b = binary.LittleEndian.AppendUint16(b, uint16(v))
+		}
+	}
+	return &Tensor{Dtype: dtypeOf[T](), Shape: append([]int{}, shape...), data: b}, nil
+}
+
+// Write writes tensors and the optional metadata to w as a safetensors file.
+// Like the reference implementation, the data is ordered by decreasing
+// element size and then by name, so every tensor is aligned for its dtype,
+// and the header is padded with spaces to a multiple of 8 bytes.
+func Write(w io.Writer, tensors map[string]*Tensor, metadata map[string]string) error {
+	names := make([]string, 0, len(tensors))
+	for name, t := range tensors {
+		if name == "__metadata__" {
+			return fmt.Errorf("%w: tensor name %q is reserved", ErrInvalidHeader, name)
+		}
+		if _, ok := dtypeSizes[t.Dtype]; !ok {
+			return fmt.Errorf("%w %q", ErrUnsupportedDtype, t.Dtype)
+		}
+		if n, ok := elements(t.Shape); !ok || n != t.Len() {
+			return fmt.Errorf("%w: tensor %q", ErrShape, name)
+		}
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		si, sj := dtypeSizes[tensors[names[i]].Dtype], dtypeSizes[tensors[names[j]].Dtype]
+		return si > sj || si == sj && names[i] < names[j]
+	})
+
+	var sb strings.Builder
+	sb.WriteByte('{')
+	if len(metadata) > 0 {
+		b, _ := json.Marshal(metadata)
+		sb.WriteString(`"__metadata__":`)
+		sb.Write(b)
+	}
+	offset := 0
+	for i, name := range names {
+		t := tensors[name]
+		if i > 0 || len(metadata) > 0 {
+			sb.WriteByte(',')
+		}
+		b, _ := json.Marshal(name)
+		sb.Write(b)
+		sb.WriteString(`:{"dtype":"`)
+		sb.WriteString(string(t.Dtype))
+		sb.WriteString(`","shape":[`)
+		for j, d := range t.Shape {
+			if j > 0 {
+				sb.WriteByte(',')
+			}
+			sb.WriteString(strconv.Itoa(d))
+		}
+		sb.WriteString(`],"data_offsets":[`)
+		sb.WriteString(strconv.Itoa(offset))
+		sb.WriteByte(',')
+		offset += len(t.data)
+		sb.WriteString(strconv.Itoa(offset))
+		sb.WriteString("]}")
+	}
+	sb.WriteByte('}')
+	for sb.Len()%8 != 0 {
+		sb.WriteByte(' ')
+	}
+
+	header := binary.LittleEndian.AppendUint64(nil, uint64(sb.Len()))
+	header = append(header, sb.String()...)
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	for _, name := range names {
+		if _, err := w.Write(tensors[name].data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package floatx
+
+import (
+	"database/sql/driver"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// database/sql support. Float16 and BFloat16 are stored in numeric columns
+// as float64 values. Float16Vector is stored as pgvector halfvec text and
+// also scans halfvec binary data and raw little-endian blobs.
+
+// Errors returned by Scan and Value.
+const (
+	// ErrScanType is returned by Scan for a source value of a type it can't
+	// convert.
+	ErrScanType = sqlError("floatx: unsupported Scan source type")
+
+	// ErrVectorFormat is returned by Float16Vector.Scan for data that isn't
+	// a valid halfvec or blob.
+	ErrVectorFormat = sqlError("floatx: invalid vector format")
+
+	// ErrVectorNotFinite is returned by Float16Vector.Value for a vector
+	// with NaN or infinite elements, which halfvec doesn't allow.
+	ErrVectorNotFinite = sqlError("floatx: vector element is NaN or infinite")
+)
+
+type sqlError string
+
+func (e sqlError) Error() string { return string(e) }
+
+// scanFloat64 returns src, a float or text value from a database driver,
+// as a float64.
+func scanFloat64(src any) (float64, error) {
+	switch v := src.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case []byte:
+		return strconv.ParseFloat(string(v), 64)
+	case string:
+		return strconv.ParseFloat(v, 64)
+	}
+	return 0, fmt.Errorf("%w %T", ErrScanType, src)
+}
+
+// Value satisfies the database/sql/driver.Valuer interface, returning f as
+// a float64.
+func (f Float16) Value() (driver.Value, error) {
+	return float64(f.Float32()), nil
+}
+
+// Scan satisfies the database/sql.Scanner interface. It converts a float,
+// integer or numeric text value with IEEE default rounding, rounding only
+// once.
+func (f *Float16) Scan(src any) error {
+	if v, ok := src.(int64); ok {
+		*f = FromInt64[Float16](v)
+		return nil
+	}
+	f64, err := scanFloat64(src)
+	if err != nil {
+		return err
+	}
+	*f = FromFloat64[Float16](f64)
+	return nil
+}
+
+// Value satisfies the database/sql/driver.Valuer interface, returning f as
+// a float64.
+func (f BFloat16) Value() (driver.Value, error) {
+	return float64(f.Float32()), nil
+}
+
+// Scan satisfies the database/sql.Scanner interface. It converts a float,
+// integer or numeric text value with IEEE default rounding, rounding only
+// once.
+func (f *BFloat16) Scan(src any) error {
+	if v, ok := src.(int64); ok {
+		*f = FromInt64[BFloat16](v)
+		return nil
+	}
+	f64, err := scanFloat64(src)
+	if err != nil {
+		return err
+	}
+	*f = FromFloat64[BFloat16](f64)
+	return nil
+}
+
+// Float16Vector is a []Float16 stored as a pgvector halfvec.
+type Float16Vector []Float16
+
+// Value satisfies the database/sql/driver.Valuer interface, returning v in
+// the halfvec text format, such as "[1,2.5,-3]". A halfvec has at least one
+// dimension, so an empty or nil v returns nil, which is stored as NULL and
+// scans back as a nil vector. It returns ErrVectorNotFinite for a vector
+// with NaN or infinite elements.
+func (v Float16Vector) Value() (driver.Value, error) {
+	if len(v) == 0 {
+		return nil, nil
+	}
+	b := make([]byte, 0, 2+8*len(v))
+	b = append(b, '[')
+	for i, f := range v {
+		if !f.IsFinite() {
+			return nil, ErrVectorNotFinite
+		}
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = strconv.AppendFloat(b, float64(f.Float32()), 'g', -1, 32)
+	}
+	return string(append(b, ']')), nil
+}
+
+// Blob returns v as raw little-endian binary16 values, for blob columns
+// such as SQLite's.
+func (v Float16Vector) Blob() []byte {
+	b := make([]byte, 2*len(v))
+	for i, f := range v {
+		binary.LittleEndian.PutUint16(b[2*i:], uint16(f))
+	}
+	return b
+}
+
+// Scan satisfies the database/sql.Scanner interface. It accepts the
+// halfvec text format as a string or []byte, and as a []byte also the
+// halfvec binary format, a big-endian int16 dimension and an unused int16
+// followed by big-endian binary16 values, or a raw little-endian blob as
+// returned by Blob. Since the formats overlap, a blob that starts with '['
+// or with a halfvec binary header for its length is read as a halfvec.
+// Text values are rounded to Float16 with IEEE default rounding. NULL sets
+// v to nil.
+func (v *Float16Vector) Scan(src any) error {
+	switch s := src.(type) {
+	case nil:
+		*v = nil
+		return nil
+	case string:
+		return v.scanText(s)
+	case []byte:
+		switch n := len(s); {
+		case n > 0 && s[0] == '[':
+			return v.scanText(string(s))
+		case n >= 4 && int(binary.BigEndian.Uint16(s)) == (n-4)/2 && n%2 == 0 && s[2] == 0 && s[3] == 0:
+			*v = make(Float16Vector, (n-4)/2)
+			for i := range *v {
+				(*v)[i] = Float16(binary.BigEndian.Uint16(s[4+2*i:]))
+			}
+			return nil
+		case n%2 == 0:
+			*v = make(Float16Vector, n/2)
+			for i := range *v {
+				(*v)[i] = Float16(binary.LittleEndian.Uint16(s[2*i:]))
+			}
+			return nil
+		}
+		return fmt.Errorf("%w: blob of %d bytes", ErrVectorFormat, len(s))
+	}
+	return fmt.Errorf("%w %T", ErrScanType, src)
+}
+
+// scanText sets v from the halfvec text s.
+func (v *Float16Vector) scanText(s string) error {
+	t := strings.TrimSpace(s)
+	if len(t) < 2 || t[0] != '[' || t[len(t)-1] != ']' {
+		return fmt.Errorf("%w: %q", ErrVectorFormat, s)
+	}
+	t = t[1 : len(t)-1]
+	if strings.TrimSpace(t) == "" {
+		*v = Float16Vector{}
+		return nil
+	}
+	vec := make(Float16Vector, 0, strings.Count(t, ",")+1)
+	for _, e := range strings.Split(t, ",") {
+		f64, err := strconv.ParseFloat(strings.TrimSpace(e), 64)
+		if err != nil {
+			return fmt.Errorf("%w: %q", ErrVectorFormat, s)
+		}
+		vec = append(vec, FromFloat64[Float16](f64))
+	}
+	*v = vec
+	return nil
+}
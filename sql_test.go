@@ -0,0 +1,147 @@
+package floatx_test
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+
+	floatx "github.com/chenxingqiang/go-floatx"
+)
+
+var (
+	_ sql.Scanner   = (*floatx.Float16)(nil)
+	_ sql.Scanner   = (*floatx.BFloat16)(nil)
+	_ sql.Scanner   = (*floatx.Float16Vector)(nil)
+	_ driver.Valuer = floatx.Float16(0)
+	_ driver.Valuer = floatx.BFloat16(0)
+	_ driver.Valuer = floatx.Float16Vector(nil)
+)
+
+func TestSQLScan(t *testing.T) {
+	testCases := []struct {
+		src  any
+		f16  uint16
+		bf16 uint16
+	}{
+		{1.5, 0x3e00, 0x3fc0},
+		{float32(-2), 0xc000, 0xc000},
+		{int64(65519), 0x7bff, 0x4780},
+		{int64(-3), 0xc200, 0xc040},
+		{"0.1", 0x2e66, 0x3dcd},
+		{[]byte("-Infinity"), 0xfc00, 0xff80},
+		{"65520", 0x7c00, 0x4780},
+		// just above a tie for both formats: rounding to float32 first
+		// would make it a tie and round down to 1
+		{1 + 0x1p-11 + 0x1p-40, 0x3c01, 0x3f80},
+		{1 + 0x1p-8 + 0x1p-40, 0x3c04, 0x3f81},
+		// just below a tie, where rounding to float32 rounds up
+		{1 + 0x1p-11 - 0x1p-40, 0x3c00, 0x3f80},
+		{1 + 0x1p-8 - 0x1p-40, 0x3c04, 0x3f80},
+		{1e300, 0x7c00, 0x7f80},
+		{5e-324, 0x0000, 0x0000},
+	}
+	for _, tc := range testCases {
+		var f floatx.Float16
+		if err := f.Scan(tc.src); err != nil || f.Bits() != tc.f16 {
+			t.Errorf("Float16.Scan(%v) = 0x%04x, %v, want 0x%04x", tc.src, f.Bits(), err, tc.f16)
+		}
+		var bf floatx.BFloat16
+		if err := bf.Scan(tc.src); err != nil || bf.Bits() != tc.bf16 {
+			t.Errorf("BFloat16.Scan(%v) = 0x%04x, %v, want 0x%04x", tc.src, bf.Bits(), err, tc.bf16)
+		}
+	}
+
+	var f floatx.Float16
+	if err := f.Scan("NaN"); err != nil || !f.IsNaN() {
+		t.Errorf("Float16.Scan(NaN) = %v, %v", f, err)
+	}
+	for _, src := range []any{nil, true, "x"} {
+		var f floatx.Float16
+		var bf floatx.BFloat16
+		if f.Scan(src) == nil || bf.Scan(src) == nil {
+			t.Errorf("Scan(%#v) returned nil", src)
+		}
+	}
+	if err := f.Scan(nil); !errors.Is(err, floatx.ErrScanType) {
+		t.Errorf("Scan(nil) returned %v, want ErrScanType", err)
+	}
+}
+
+func TestSQLValue(t *testing.T) {
+	if v, err := floatx.F16Fromfloat32(0.1).Value(); v != float64(float32(0.099975586)) || err != nil {
+		t.Errorf("Float16.Value() = %v, %v", v, err)
+	}
+	if v, err := floatx.BF16Fromfloat32(-3).Value(); v != -3.0 || err != nil {
+		t.Errorf("BFloat16.Value() = %v, %v", v, err)
+	}
+}
+
+func TestFloat16Vector(t *testing.T) {
+	vec := floatx.Float16Vector{
+		floatx.F16Fromfloat32(1),
+		floatx.F16Fromfloat32(2.5),
+		floatx.F16Fromfloat32(-3),
+		floatx.F16Fromfloat32(0.1),
+		floatx.F16Fromfloat32(65504),
+	}
+	v, err := vec.Value()
+	if want := "[1,2.5,-3,0.099975586,65504]"; v != want || err != nil {
+		t.Fatalf("Value() = %v, %v, want %s", v, err, want)
+	}
+	for _, empty := range []floatx.Float16Vector{{}, nil} {
+		if v, err := empty.Value(); v != nil || err != nil {
+			t.Errorf("Value() of %#v = %v, %v, want nil", empty, v, err)
+		}
+	}
+	if _, err := (floatx.Float16Vector{floatx.F16NaN()}).Value(); !errors.Is(err, floatx.ErrVectorNotFinite) {
+		t.Errorf("Value() of NaN returned %v, want ErrVectorNotFinite", err)
+	}
+	if _, err := (floatx.Float16Vector{0, floatx.F16Inf(-1)}).Value(); !errors.Is(err, floatx.ErrVectorNotFinite) {
+		t.Errorf("Value() of -Inf returned %v, want ErrVectorNotFinite", err)
+	}
+
+	blob := vec.Blob()
+	if want := []byte{0x00, 0x3c, 0x00, 0x41, 0x00, 0xc2, 0x66, 0x2e, 0xff, 0x7b}; !reflect.DeepEqual(blob, want) {
+		t.Errorf("Blob() = % x, want % x", blob, want)
+	}
+	binaryVec := []byte{0x00, 0x05, 0x00, 0x00, 0x3c, 0x00, 0x41, 0x00, 0xc2, 0x00, 0x2e, 0x66, 0x7b, 0xff}
+
+	for _, src := range []any{v, []byte(v.(string)), " [ 1, 2.5 ,-3,0.1, 65504 ] ", blob, binaryVec} {
+		var got floatx.Float16Vector
+		if err := got.Scan(src); err != nil || !reflect.DeepEqual(got, vec) {
+			t.Errorf("Scan(%v) = %v, %v, want %v", src, got, err, vec)
+		}
+	}
+	for _, src := range []any{"[]", "[ ]", []byte{}} {
+		got := floatx.Float16Vector{1}
+		if err := got.Scan(src); err != nil || len(got) != 0 {
+			t.Errorf("Scan(%q) = %v, %v, want an empty vector", src, got, err)
+		}
+	}
+
+	null := floatx.Float16Vector{1}
+	if err := null.Scan(nil); err != nil || null != nil {
+		t.Errorf("Scan(nil) = %v, %v, want a nil vector", null, err)
+	}
+
+	testCases := []struct {
+		src any
+		err error
+	}{
+		{"", floatx.ErrVectorFormat},
+		{"1,2", floatx.ErrVectorFormat},
+		{"[1,2", floatx.ErrVectorFormat},
+		{"[1,,2]", floatx.ErrVectorFormat},
+		{"[1,x]", floatx.ErrVectorFormat},
+		{[]byte{1, 2, 3}, floatx.ErrVectorFormat},
+		{1.5, floatx.ErrScanType},
+	}
+	for _, tc := range testCases {
+		var got floatx.Float16Vector
+		if err := got.Scan(tc.src); !errors.Is(err, tc.err) {
+			t.Errorf("Scan(%#v) returned %v, want %v", tc.src, err, tc.err)
+		}
+	}
+}
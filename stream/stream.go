@@ -0,0 +1,217 @@
+// Package stream converts between float32 data and binary streams of the
+// floating-point formats of github.com/chenxingqiang/go-floatx in constant
+// memory, for raw tensor files too large to load at once.
+//
+// An Encoder rounds float32 values to a format with IEEE default rounding,
+// writes them and counts the precision of each conversion. A Decoder reads
+// them back as float32 or as values of the format. Both reuse one buffer
+// and don't allocate after they are created.
+package stream
+
+import (
+	"encoding/binary"
+	"io"
+
+	floatx "github.com/chenxingqiang/go-floatx"
+	"github.com/chenxingqiang/go-floatx/metrics"
+)
+
+// bufSize is the size in bytes of the buffer of an Encoder or Decoder.
+const bufSize = 32 << 10
+
+// size returns the size in bytes of a T.
+func size[T floatx.AnyFloat]() int {
+	var f T
+	switch any(f).(type) {
+	case floatx.Float8, floatx.Float8E4M3FN:
+		return 1
+	}
+	return 2
+}
+
+// convert returns f32 rounded to a T and the precision of the conversion.
+// The precision is that of the actual result, so values that round to
+// infinity count as overflow.
+func convert[T floatx.AnyFloat](f32 float32) (T, floatx.F16Precision) {
+	var f T
+	switch p := any(&f).(type) {
+	case *floatx.Float16:
+		*p = floatx.F16Fromfloat32(f32)
+		return f, floatx.F16PrecisionExactFromfloat32(f32)
+	case *floatx.BFloat16:
+		*p = floatx.BF16Fromfloat32(f32)
+		return f, floatx.F16Precision(floatx.BF16PrecisionExactFromfloat32(f32))
+	case *floatx.Float8:
+		*p = floatx.F8Fromfloat32(f32)
+		return f, floatx.F16Precision(floatx.F8PrecisionExactFromfloat32(f32))
+	case *floatx.Float8E4M3FN:
+		*p = floatx.F8E4M3Fromfloat32(f32)
+		return f, floatx.F16Precision(floatx.F8E4M3PrecisionExactFromfloat32(f32))
+	}
+	return f, floatx.F16PrecisionExact
+}
+
+// Encoder writes float32 values to a stream as values of format T.
+type Encoder[T floatx.AnyFloat] struct {
+	w      io.Writer
+	order  binary.ByteOrder
+	size   int
+	buf    []byte
+	counts [5]int
+	err    error
+}
+
+// NewEncoder returns an Encoder that writes values of format T to w in
+// byte order order.
+func NewEncoder[T floatx.AnyFloat](w io.Writer, order binary.ByteOrder) *Encoder[T] {
+	return &Encoder[T]{w: w, order: order, size: size[T](), buf: make([]byte, bufSize)}
+}
+
+// NewFloat16Encoder returns an Encoder that writes binary16 values to w in
+// byte order order.
+func NewFloat16Encoder(w io.Writer, order binary.ByteOrder) *Encoder[floatx.Float16] {
+	return NewEncoder[floatx.Float16](w, order)
+}
+
+// put stores f in b.
+func (e *Encoder[T]) put(b []byte, f T) {
+	if e.size == 1 {
+		b[0] = uint8(f)
+	} else {
+		e.order.PutUint16(b, uint16(f))
+	}
+}
+
+// Write rounds src to T and writes it. Once a write to the underlying
+// writer fails, Write returns that error without writing anything more.
+func (e *Encoder[T]) Write(src []float32) error {
+	for len(src) > 0 && e.err == nil {
+		n := min(len(src), len(e.buf)/e.size)
+		var counts [5]int
+		for i, f32 := range src[:n] {
+			f, p := convert[T](f32)
+			counts[p]++
+			e.put(e.buf[i*e.size:], f)
+		}
+		if _, e.err = e.w.Write(e.buf[:n*e.size]); e.err == nil {
+			for p, c := range counts {
+				e.counts[p] += c
+			}
+		}
+		src = src[n:]
+	}
+	return e.err
+}
+
+// WriteValues writes src, which is counted as exact. Once a write to the
+// underlying writer fails, WriteValues returns that error without writing
+// anything more.
+func (e *Encoder[T]) WriteValues(src []T) error {
+	for len(src) > 0 && e.err == nil {
+		n := min(len(src), len(e.buf)/e.size)
+		for i, f := range src[:n] {
+			e.put(e.buf[i*e.size:], f)
+		}
+		if _, e.err = e.w.Write(e.buf[:n*e.size]); e.err == nil {
+			e.counts[floatx.F16PrecisionExact] += n
+		}
+		src = src[n:]
+	}
+	return e.err
+}
+
+// Precision returns the precision of the conversions of the values written
+// so far, as reported by the format's PrecisionExactFromfloat32 function.
+// Values that round to infinity, or to NaN for Float8E4M3FN, are counted as
+// overflows and nonzero values that round to zero as underflows. Unknown is
+// always 0.
+func (e *Encoder[T]) Precision() metrics.Histogram {
+	return metrics.Histogram{
+		Exact:     e.counts[floatx.F16PrecisionExact],
+		Unknown:   e.counts[floatx.F16PrecisionUnknown],
+		Inexact:   e.counts[floatx.F16PrecisionInexact],
+		Underflow: e.counts[floatx.F16PrecisionUnderflow],
+		Overflow:  e.counts[floatx.F16PrecisionOverflow],
+	}
+}
+
+// Decoder reads values of format T from a stream.
+type Decoder[T floatx.AnyFloat] struct {
+	r     io.Reader
+	order binary.ByteOrder
+	size  int
+	buf   []byte
+	n     int // bytes in buf, at most one partial value between reads
+	err   error
+}
+
+// NewDecoder returns a Decoder that reads values of format T from r in
+// byte order order.
+func NewDecoder[T floatx.AnyFloat](r io.Reader, order binary.ByteOrder) *Decoder[T] {
+	return &Decoder[T]{r: r, order: order, size: size[T](), buf: make([]byte, bufSize)}
+}
+
+// NewFloat16Decoder returns a Decoder that reads binary16 values from r in
+// byte order order.
+func NewFloat16Decoder(r io.Reader, order binary.ByteOrder) *Decoder[floatx.Float16] {
+	return NewDecoder[floatx.Float16](r, order)
+}
+
+// fill reads up to max values into d.buf and returns the number of whole
+// values in it.
+func (d *Decoder[T]) fill(max int) (int, error) {
+	if d.err != nil {
+		return 0, d.err
+	}
+	if max == 0 {
+		return 0, nil
+	}
+	want := min(max*d.size, len(d.buf))
+	m, err := io.ReadAtLeast(d.r, d.buf[d.n:want], d.size-d.n)
+	d.n += m
+	if err == io.EOF && d.n > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		d.err = err
+		return 0, err
+	}
+	return d.n / d.size, nil
+}
+
+// get returns value i of d.buf.
+func (d *Decoder[T]) get(i int) T {
+	if d.size == 1 {
+		return T(d.buf[i])
+	}
+	return T(d.order.Uint16(d.buf[2*i:]))
+}
+
+// consume removes the first n values from d.buf.
+func (d *Decoder[T]) consume(n int) {
+	d.n = copy(d.buf, d.buf[n*d.size:d.n])
+}
+
+// Read reads up to len(dst) values into dst, converted to float32 exactly,
+// and returns the number of values read. Like io.Reader, it can return
+// fewer values than len(dst) before the end of the stream. At the end it
+// returns 0 and io.EOF, or io.ErrUnexpectedEOF if the stream ends within a
+// value.
+func (d *Decoder[T]) Read(dst []float32) (int, error) {
+	n, err := d.fill(len(dst))
+	for i := range dst[:n] {
+		dst[i] = d.get(i).Float32()
+	}
+	d.consume(n)
+	return n, err
+}
+
+// ReadValues reads up to len(dst) values into dst, like Read.
+func (d *Decoder[T]) ReadValues(dst []T) (int, error) {
+	n, err := d.fill(len(dst))
+	for i := range dst[:n] {
+		dst[i] = d.get(i)
+	}
+	d.consume(n)
+	return n, err
+}
@@ -0,0 +1,241 @@
+package stream_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"testing"
+	"testing/iotest"
+
+	floatx "github.com/chenxingqiang/go-floatx"
+	"github.com/chenxingqiang/go-floatx/metrics"
+	"github.com/chenxingqiang/go-floatx/stream"
+)
+
+// testData returns n float32 values covering every precision class of the
+// formats, in a stream longer than the encoder buffer when n is large.
+func testData(n int) []float32 {
+	r := rand.New(rand.NewSource(1))
+	special := []float32{0, float32(math.Copysign(0, -1)), 1, -2.5, 0.1, 1e-30, 1e30, 1e-6, 65520, 500,
+		float32(math.Inf(1)), float32(math.Inf(-1)), float32(math.NaN()), 0x1p-24, 0x1p-17}
+	data := make([]float32, n)
+	for i := range data {
+		if i < len(special) {
+			data[i] = special[i]
+		} else {
+			data[i] = float32(r.NormFloat64() * math.Pow(2, float64(r.Intn(40)-20)))
+		}
+	}
+	return data
+}
+
+func testRoundTrip[T floatx.AnyFloat](t *testing.T, name string, fromFloat32 func(float32) T, order binary.ByteOrder, data []float32) {
+	t.Helper()
+	var buf bytes.Buffer
+	e := stream.NewEncoder[T](&buf, order)
+	// uneven chunks
+	for i := 0; i < len(data); i += 1000 {
+		if err := e.Write(data[i:min(i+1000, len(data))]); err != nil {
+			t.Fatalf("%s: Write: %v", name, err)
+		}
+	}
+	if got := e.Precision().Total(); got != len(data) {
+		t.Errorf("%s: Precision().Total() = %d, want %d", name, got, len(data))
+	}
+
+	// the stream holds the values in order
+	encoded := buf.Bytes()
+	want := make([]T, len(data))
+	for i, f32 := range data {
+		want[i] = fromFloat32(f32)
+		size := len(encoded) / len(data)
+		var u uint16
+		if size == 1 {
+			u = uint16(encoded[i])
+		} else {
+			u = order.Uint16(encoded[2*i:])
+		}
+		if T(u) != want[i] {
+			t.Fatalf("%s: value %d (%g) is 0x%x, want 0x%x", name, i, f32, u, uint16(want[i]))
+		}
+	}
+
+	// read back through a reader returning one byte at a time, so values
+	// are split across reads
+	d := stream.NewDecoder[T](iotest.OneByteReader(bytes.NewReader(encoded)), order)
+	var got []T
+	dst := make([]T, 7)
+	for {
+		n, err := d.ReadValues(dst)
+		got = append(got, dst[:n]...)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("%s: ReadValues: %v", name, err)
+		}
+	}
+	if len(got) != len(want) {
+		t.Fatalf("%s: read %d values, want %d", name, len(got), len(want))
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("%s: value %d = 0x%x, want 0x%x", name, i, uint16(got[i]), uint16(want[i]))
+		}
+	}
+
+	d = stream.NewDecoder[T](bytes.NewReader(encoded), order)
+	f32s := make([]float32, 0, len(data))
+	chunk := make([]float32, 5000)
+	for {
+		n, err := d.Read(chunk)
+		f32s = append(f32s, chunk[:n]...)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("%s: Read: %v", name, err)
+		}
+	}
+	for i := range want {
+		if w := want[i].Float32(); math.Float32bits(f32s[i]) != math.Float32bits(w) && !(w != w && f32s[i] != f32s[i]) {
+			t.Fatalf("%s: float32 value %d = %g, want %g", name, i, f32s[i], w)
+		}
+	}
+
+	// WriteValues writes the same stream
+	var buf2 bytes.Buffer
+	e = stream.NewEncoder[T](&buf2, order)
+	if err := e.WriteValues(want); err != nil || !bytes.Equal(buf2.Bytes(), encoded) {
+		t.Errorf("%s: WriteValues wrote a different stream, %v", name, err)
+	}
+	if h := e.Precision(); h.Exact != len(want) || h.Total() != len(want) {
+		t.Errorf("%s: Precision() after WriteValues = %+v", name, h)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	data := testData(50000)
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		testRoundTrip(t, "Float16", floatx.F16Fromfloat32, order, data)
+		testRoundTrip(t, "BFloat16", floatx.BF16Fromfloat32, order, data)
+		testRoundTrip(t, "Float8", floatx.F8Fromfloat32, order, data)
+		testRoundTrip(t, "Float8E4M3FN", floatx.F8E4M3Fromfloat32, order, data)
+	}
+}
+
+// histogram counts data by the precision of each conversion.
+func histogram[P ~int](data []float32, precision func(float32) P) metrics.Histogram {
+	var counts [5]int
+	for _, f32 := range data {
+		counts[precision(f32)]++
+	}
+	return metrics.Histogram{Exact: counts[0], Unknown: counts[1], Inexact: counts[2], Underflow: counts[3], Overflow: counts[4]}
+}
+
+func TestPrecision(t *testing.T) {
+	data := testData(1000)
+	check := func(name string, got, want metrics.Histogram) {
+		t.Helper()
+		if got != want {
+			t.Errorf("%s: Precision() = %+v, want %+v", name, got, want)
+		}
+	}
+
+	e16 := stream.NewFloat16Encoder(io.Discard, binary.LittleEndian)
+	e16.Write(data)
+	check("Float16", e16.Precision(), histogram(data, floatx.F16PrecisionExactFromfloat32))
+	eb := stream.NewEncoder[floatx.BFloat16](io.Discard, binary.LittleEndian)
+	eb.Write(data)
+	check("BFloat16", eb.Precision(), histogram(data, floatx.BF16PrecisionExactFromfloat32))
+	e8 := stream.NewEncoder[floatx.Float8](io.Discard, binary.LittleEndian)
+	e8.Write(data)
+	check("Float8", e8.Precision(), histogram(data, floatx.F8PrecisionExactFromfloat32))
+	e4 := stream.NewEncoder[floatx.Float8E4M3FN](io.Discard, binary.LittleEndian)
+	e4.Write(data)
+	check("Float8E4M3FN data", e4.Precision(), histogram(data, floatx.F8E4M3PrecisionExactFromfloat32))
+
+	// 65520 rounds to infinity and 0x1p-25 to zero
+	e16 = stream.NewFloat16Encoder(io.Discard, binary.LittleEndian)
+	e16.Write([]float32{65504, 65520, 0x1p-24, 0x1p-25})
+	check("Float16 limits", e16.Precision(), metrics.Histogram{Exact: 2, Underflow: 1, Overflow: 1})
+
+	e := stream.NewEncoder[floatx.Float8E4M3FN](io.Discard, binary.LittleEndian)
+	e.Write([]float32{1, 448, -0.5, 0, float32(math.NaN()), // exact
+		0.3, 460, 0x1p-9 * 1.25, // inexact
+		0x1p-11, -1e-30, // underflow
+		470, -1000, float32(math.Inf(1)), // overflow
+	})
+	check("Float8E4M3FN", e.Precision(), metrics.Histogram{Exact: 5, Inexact: 3, Underflow: 2, Overflow: 3})
+}
+
+func TestFloat16Decoder(t *testing.T) {
+	d := stream.NewFloat16Decoder(bytes.NewReader([]byte{0x3c, 0x00, 0xc0, 0x00, 0x7c}), binary.BigEndian)
+	dst := make([]float32, 4)
+	if n, err := d.Read(dst[:0]); n != 0 || err != nil {
+		t.Errorf("Read of no values = %d, %v", n, err)
+	}
+	if n, err := d.Read(dst); n != 2 || err != nil || dst[0] != 1 || dst[1] != -2 {
+		t.Errorf("Read = %d, %v, %v", n, err, dst[:n])
+	}
+	if n, err := d.Read(dst); n != 0 || err != io.ErrUnexpectedEOF {
+		t.Errorf("Read of a partial value = %d, %v, want io.ErrUnexpectedEOF", n, err)
+	}
+	if n, err := d.Read(dst); n != 0 || err != io.ErrUnexpectedEOF {
+		t.Errorf("Read after an error = %d, %v, want io.ErrUnexpectedEOF", n, err)
+	}
+
+	readErr := errors.New("read failed")
+	d = stream.NewFloat16Decoder(iotest.ErrReader(readErr), binary.LittleEndian)
+	if n, err := d.Read(dst); n != 0 || err != readErr {
+		t.Errorf("Read of a failing reader = %d, %v", n, err)
+	}
+}
+
+type errWriter struct{ n int }
+
+func (w *errWriter) Write(b []byte) (int, error) {
+	w.n++
+	return 0, errors.New("write failed")
+}
+
+func TestEncoderError(t *testing.T) {
+	w := &errWriter{}
+	e := stream.NewFloat16Encoder(w, binary.LittleEndian)
+	data := make([]float32, 100000)
+	if err := e.Write(data); err == nil {
+		t.Error("Write to a failing writer returned nil")
+	}
+	if err := e.WriteValues(make([]floatx.Float16, 10)); err == nil {
+		t.Error("WriteValues after an error returned nil")
+	}
+	if w.n != 1 {
+		t.Errorf("%d writes after an error, want 1", w.n)
+	}
+	if h := e.Precision(); h.Total() != 0 {
+		t.Errorf("Precision() after a failed write = %+v, want none", h)
+	}
+
+	e8 := stream.NewEncoder[floatx.Float8](&errWriter{}, binary.LittleEndian)
+	if err := e8.WriteValues(make([]floatx.Float8, 10)); err == nil {
+		t.Error("WriteValues to a failing writer returned nil")
+	}
+}
+
+func TestAllocs(t *testing.T) {
+	data := testData(100000)
+	e := stream.NewFloat16Encoder(io.Discard, binary.LittleEndian)
+	if n := testing.AllocsPerRun(10, func() { e.Write(data) }); n != 0 {
+		t.Errorf("Write allocates %v times", n)
+	}
+
+	var buf bytes.Buffer
+	stream.NewFloat16Encoder(&buf, binary.LittleEndian).Write(data)
+	r := bytes.NewReader(buf.Bytes())
+	d := stream.NewFloat16Decoder(r, binary.LittleEndian)
+	dst := make([]float32, 4096)
+	if n := testing.AllocsPerRun(10, func() { d.Read(dst) }); n != 0 {
+		t.Errorf("Read allocates %v times", n)
+	}
+}